@@ -0,0 +1,298 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/token"
+	"github.com/kratos69/movie-app/worker"
+)
+
+const exportFormatZIP = "zip"
+
+type accountProfile struct {
+	UserID              int64      `json:"user_id"`
+	Username            string     `json:"username"`
+	Name                string     `json:"name"`
+	Email               string     `json:"email"`
+	Role                string     `json:"role"`
+	TwoFactorEnabled    bool       `json:"two_factor_enabled"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+type accountIdentity struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// refresh tokens are credentials, not personal data, and stay out
+type accountSession struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	ClientIP  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	ExpiredAt time.Time `json:"expired_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type accountExport struct {
+	ExportedAt   time.Time                      `json:"exported_at"`
+	Profile      accountProfile                 `json:"profile"`
+	Identities   []accountIdentity              `json:"identities"`
+	Sessions     []accountSession               `json:"sessions"`
+	Reservations []db.ListReservationsByUserRow `json:"reservations"`
+	Reviews      []db.ListUserReviewsRow        `json:"reviews"`
+	Watchlist    []db.ListUserWatchlistRow      `json:"watchlist"`
+}
+
+type exportAccountRequest struct {
+	Format string `form:"format,default=json" binding:"oneof=json zip"`
+}
+
+// hands the user a copy of everything stored about them, as one json
+// document or as a zip with a file per section
+func (server *Server) exportAccount(ctx *gin.Context) {
+	var req exportAccountRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	export, err := server.collectAccountExport(ctx, authPayload.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	filename := fmt.Sprintf("account-%d-%s", export.Profile.UserID,
+		export.ExportedAt.Format("20060102"))
+
+	if req.Format == exportFormatZIP {
+		archive, err := zipAccountExport(export)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+
+		ctx.Header("Content-Disposition",
+			fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+		ctx.Data(http.StatusOK, "application/zip", archive)
+		return
+	}
+
+	ctx.Header("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+	ctx.JSON(http.StatusOK, export)
+}
+
+// users without a password of their own confirm with an emailed code
+type deleteAccountRequest struct {
+	Password         string `json:"password" binding:"required_without=ConfirmationCode"`
+	ConfirmationCode string `json:"confirmation_code" binding:"required_without=Password"`
+}
+
+type deleteAccountResponse struct {
+	Message         string    `json:"message"`
+	AnonymizedAfter time.Time `json:"anonymized_after"`
+}
+
+// schedules the account for anonymization and signs the user out, an
+// admin can still restore it by enabling the account during the grace
+// period. reservations are kept for accounting
+func (server *Server) deleteAccount(ctx *gin.Context) {
+	var req deleteAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	before, err := server.store.GetUserByID(ctx, authPayload.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	confirmed, err := server.confirmUser(ctx, before, req.Password,
+		req.ConfirmationCode, confirmDeleteAccount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if !confirmed {
+		ctx.JSON(http.StatusUnauthorized, errResponse(errInvalidConfirmation))
+		return
+	}
+
+	user, err := server.store.RequestUserDeletionTx(ctx, before.UserID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	err = server.revokeUserTokens(ctx, user.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionUserDelete, auditEntityUser,
+		user.UserID, newUserAuditSnapshot(before), newUserAuditSnapshot(user))
+
+	if server.config.CookieSessions {
+		server.clearSessionCookies(ctx)
+	}
+
+	ctx.JSON(http.StatusAccepted, deleteAccountResponse{
+		Message: "account scheduled for deletion",
+		AnonymizedAfter: user.DeletionRequestedAt.Time.Add(
+			server.deletionGracePeriod()),
+	})
+}
+
+// ------------------------------------------------------------------//
+// ------------------------------Helper Funcs------------------------//
+// ------------------------------------------------------------------//
+
+func (server *Server) deletionGracePeriod() time.Duration {
+	if server.config.DeletionGracePeriod > 0 {
+		return server.config.DeletionGracePeriod
+	}
+	return worker.DefaultDeletionGracePeriod
+}
+
+func (server *Server) collectAccountExport(ctx *gin.Context,
+	userID int64) (accountExport, error) {
+	user, err := server.store.GetUserByID(ctx, userID)
+	if err != nil {
+		return accountExport{}, err
+	}
+
+	identities, err := server.store.ListUserIdentities(ctx, user.UserID)
+	if err != nil {
+		return accountExport{}, err
+	}
+
+	sessions, err := server.store.ListSessionsByUsername(ctx, user.Username)
+	if err != nil {
+		return accountExport{}, err
+	}
+
+	reservations, err := server.store.ListReservationsByUser(ctx, user.UserID)
+	if err != nil {
+		return accountExport{}, err
+	}
+
+	reviews, err := server.store.ListUserReviews(ctx, user.UserID)
+	if err != nil {
+		return accountExport{}, err
+	}
+
+	watchlist, err := server.store.ListUserWatchlist(ctx, user.UserID)
+	if err != nil {
+		return accountExport{}, err
+	}
+
+	export := accountExport{
+		ExportedAt: time.Now().UTC(),
+		Profile: accountProfile{
+			UserID:              user.UserID,
+			Username:            user.Username,
+			Name:                user.Name,
+			Email:               user.Email,
+			Role:                user.Role,
+			TwoFactorEnabled:    user.TotpEnabled,
+			DisabledAt:          timePtr(user.DisabledAt),
+			DeletionRequestedAt: timePtr(user.DeletionRequestedAt),
+			CreatedAt:           user.CreatedAt,
+		},
+		Identities:   make([]accountIdentity, 0, len(identities)),
+		Sessions:     make([]accountSession, 0, len(sessions)),
+		Reservations: reservations,
+		Reviews:      reviews,
+		Watchlist:    watchlist,
+	}
+
+	for _, identity := range identities {
+		export.Identities = append(export.Identities, accountIdentity{
+			Issuer:    identity.Issuer,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, accountSession{
+			ID:        session.ID,
+			UserAgent: session.UserAgent,
+			ClientIP:  session.ClientIp,
+			IsBlocked: session.IsBlocked,
+			ExpiredAt: session.ExpiredAt,
+			CreatedAt: session.CreatedAt,
+		})
+	}
+
+	return export, nil
+}
+
+// writes each section of the export to its own json file in a zip
+func zipAccountExport(export accountExport) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", export.Profile},
+		{"identities.json", export.Identities},
+		{"sessions.json", export.Sessions},
+		{"reservations.json", export.Reservations},
+		{"reviews.json", export.Reviews},
+		{"watchlist.json", export.Watchlist},
+	}
+
+	for _, file := range files {
+		w, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
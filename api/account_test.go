@@ -0,0 +1,279 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+// accountStore adds the account data a user can export or delete
+type accountStore struct {
+	*userAdminStore
+	sessions      map[string][]db.Session
+	identities    map[int64][]db.UserIdentity
+	confirmations []db.AccountConfirmation
+}
+
+func newAccountStore(t *testing.T, password string) *accountStore {
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	store := &accountStore{
+		userAdminStore: newUserAdminStore(),
+		sessions: map[string][]db.Session{
+			"alice": {{
+				ID:           uuid.New(),
+				Username:     "alice",
+				RefreshToken: "refresh-token",
+				UserAgent:    "test-agent",
+				ClientIp:     "10.0.0.1",
+				ExpiredAt:    time.Now().Add(time.Hour),
+			}},
+		},
+	}
+
+	user := store.users[2]
+	user.HashedPassword = hashedPassword
+	store.users[2] = user
+
+	return store
+}
+
+func (store *accountStore) ListUserIdentities(ctx context.Context,
+	userID int64) ([]db.UserIdentity, error) {
+	return append([]db.UserIdentity{}, store.identities[userID]...), nil
+}
+
+func (store *accountStore) ListUserReviews(ctx context.Context,
+	userID int64) ([]db.ListUserReviewsRow, error) {
+	return []db.ListUserReviewsRow{
+		{ReviewID: 4, MovieID: 1, UserID: userID, Rating: 5, Title: "Dune"},
+	}, nil
+}
+
+func (store *accountStore) ListUserWatchlist(ctx context.Context,
+	userID int64) ([]db.ListUserWatchlistRow, error) {
+	return []db.ListUserWatchlistRow{{MovieID: 2, Title: "Alien"}}, nil
+}
+
+func (store *accountStore) CreateAccountConfirmation(ctx context.Context,
+	arg db.CreateAccountConfirmationParams) (db.AccountConfirmation, error) {
+	confirmation := db.AccountConfirmation{
+		ID:         int64(len(store.confirmations) + 1),
+		UserID:     arg.UserID,
+		Purpose:    arg.Purpose,
+		SecretCode: arg.SecretCode,
+		ExpiredAt:  time.Now().Add(15 * time.Minute),
+	}
+	store.confirmations = append(store.confirmations, confirmation)
+	return confirmation, nil
+}
+
+func (store *accountStore) UseAccountConfirmation(ctx context.Context,
+	arg db.UseAccountConfirmationParams) (db.AccountConfirmation, error) {
+	for i, confirmation := range store.confirmations {
+		if confirmation.UserID == arg.UserID &&
+			confirmation.Purpose == arg.Purpose &&
+			confirmation.SecretCode == arg.SecretCode &&
+			!confirmation.IsUsed {
+			store.confirmations[i].IsUsed = true
+			return store.confirmations[i], nil
+		}
+	}
+	return db.AccountConfirmation{}, db.ErrRecordNotFound
+}
+
+func (store *accountStore) ListSessionsByUsername(ctx context.Context,
+	username string) ([]db.Session, error) {
+	return store.sessions[username], nil
+}
+
+func (store *accountStore) RequestUserDeletionTx(ctx context.Context,
+	userID int64) (db.User, error) {
+	user := store.users[userID]
+	now := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	user.DeletionRequestedAt = now
+	user.DisabledAt = now
+	store.users[userID] = user
+	return user, nil
+}
+
+func TestExportAccount(t *testing.T) {
+	store := newAccountStore(t, "secret")
+	server := newTestServer(t, store)
+
+	accessToken, _, err := server.tokenMaker.CreateToken(
+		"alice", 2, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := serveWithToken(t, server, http.MethodGet,
+		"/users/me/export", accessToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Header().Get("Content-Disposition"),
+		".json")
+	require.NotContains(t, recorder.Body.String(), "refresh-token")
+
+	var export accountExport
+	err = json.Unmarshal(recorder.Body.Bytes(), &export)
+	require.NoError(t, err)
+	require.Equal(t, "alice@email.com", export.Profile.Email)
+	require.Len(t, export.Sessions, 1)
+	require.Equal(t, "10.0.0.1", export.Sessions[0].ClientIP)
+	require.Len(t, export.Reservations, 1)
+	require.Equal(t, int64(7), export.Reservations[0].ReservationID)
+	require.Len(t, export.Reviews, 1)
+	require.Equal(t, int32(5), export.Reviews[0].Rating)
+	require.Len(t, export.Watchlist, 1)
+	require.Equal(t, "Alien", export.Watchlist[0].Title)
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/users/me/export?format=zip", accessToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/zip", recorder.Header().Get("Content-Type"))
+
+	body := recorder.Body.Bytes()
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		r, err := file.Open()
+		require.NoError(t, err)
+		files[file.Name], err = io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+	}
+	require.Len(t, files, 6)
+	require.Contains(t, string(files["profile.json"]), "alice@email.com")
+	require.Contains(t, string(files["reservations.json"]), `"reservation_id": 7`)
+	require.Contains(t, string(files["watchlist.json"]), "Alien")
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/users/me/export?format=xml", accessToken, nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	store := newAccountStore(t, "secret")
+	server := newTestServer(t, store)
+
+	accessToken, _, err := server.tokenMaker.CreateToken(
+		"alice", 2, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := serveWithToken(t, server, http.MethodDelete, "/users/me",
+		accessToken, gin.H{"password": "wrong"})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.False(t, store.users[2].DeletionRequestedAt.Valid)
+
+	recorder = serveWithToken(t, server, http.MethodDelete, "/users/me",
+		accessToken, gin.H{"password": "secret"})
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	require.True(t, store.users[2].DeletionRequestedAt.Valid)
+	require.True(t, store.users[2].DisabledAt.Valid)
+
+	var resp deleteAccountResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(server.deletionGracePeriod()),
+		resp.AnonymizedAfter, time.Second)
+
+	// the user is signed out everywhere
+	recorder = serveWithToken(t, server, http.MethodGet, "/users/me/export",
+		accessToken, nil)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	require.Len(t, store.auditEvents, 1)
+	require.Equal(t, auditActionUserDelete, store.auditEvents[0].Action)
+	require.Equal(t, int64(2), store.auditEvents[0].ActorID.Int64)
+}
+
+// discards emails, they are sent in the background
+type nopMailer struct{}
+
+func (nopMailer) SendEmail(subject string, content string, to []string) error {
+	return nil
+}
+
+func TestDeleteAccountWithConfirmation(t *testing.T) {
+	store := newAccountStore(t, "secret")
+	server := newTestServer(t, store)
+
+	accessToken, _, err := server.tokenMaker.CreateToken(
+		"alice", 2, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	body := gin.H{"purpose": confirmDeleteAccount}
+
+	// users with only a password confirm with it
+	recorder := serveWithToken(t, server, http.MethodPost,
+		"/users/me/confirmations", accessToken, body)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	store.identities = map[int64][]db.UserIdentity{
+		2: {{UserID: 2, Issuer: "https://accounts.example.com", Subject: "alice"}},
+	}
+
+	recorder = serveWithToken(t, server, http.MethodPost,
+		"/users/me/confirmations", accessToken, body)
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	server.mailer = nopMailer{}
+
+	recorder = serveWithToken(t, server, http.MethodPost,
+		"/users/me/confirmations", accessToken, gin.H{"purpose": "anything"})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodPost,
+		"/users/me/confirmations", accessToken, body)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	require.Len(t, store.confirmations, 1)
+	code := store.confirmations[0].SecretCode
+
+	// a code is only good for what it was asked for
+	recorder = serveWithToken(t, server, http.MethodPut,
+		"/users/me/password", accessToken,
+		gin.H{"confirmation_code": code, "new_password": "n3w-Passw0rd!"})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodDelete, "/users/me",
+		accessToken, gin.H{"confirmation_code": "wrong"})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.False(t, store.users[2].DeletionRequestedAt.Valid)
+
+	recorder = serveWithToken(t, server, http.MethodDelete, "/users/me",
+		accessToken, gin.H{"confirmation_code": code})
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	require.True(t, store.users[2].DeletionRequestedAt.Valid)
+	require.True(t, store.confirmations[0].IsUsed)
+}
+
+func TestEnableAnonymizedUser(t *testing.T) {
+	store := newUserAdminStore()
+	server := newTestServer(t, store)
+
+	user := store.users[3]
+	user.AnonymizedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	store.users[3] = user
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := serveWithToken(t, server, http.MethodPost,
+		"/users/3/enable", adminToken, nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+	require.Empty(t, store.auditEvents)
+}
@@ -0,0 +1,458 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/token"
+	"github.com/kratos69/movie-app/util"
+)
+
+const (
+	authorizationTypeAPIKey = "apikey"
+	authorizationAPIKeyKey  = "authorization_api_key"
+)
+
+// keys look like mk_<prefix>_<secret>, the prefix is stored in the clear
+// to find the key and shown in listings, the secret only as a hash
+const (
+	apiKeyTag          = "mk"
+	apiKeyPrefixLength = 8
+	apiKeySecretLength = 32
+)
+
+var errInvalidAPIKey = errors.New("invalid api key")
+
+type apiKeyResponse struct {
+	ID          int64      `json:"id"`
+	UserID      int64      `json:"user_id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Permissions []string   `json:"permissions"`
+	AllowedIPs  []string   `json:"allowed_ips"`
+	CreatedBy   int64      `json:"created_by"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  string     `json:"last_used_ip"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func newAPIKeyResponse(key db.ApiKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:          key.ID,
+		UserID:      key.UserID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		Permissions: key.Permissions,
+		AllowedIPs:  key.AllowedIps,
+		CreatedBy:   key.CreatedBy,
+		ExpiresAt:   timePtr(key.ExpiredAt),
+		LastUsedAt:  timePtr(key.LastUsedAt),
+		LastUsedIP:  key.LastUsedIp,
+		RevokedAt:   timePtr(key.RevokedAt),
+		CreatedAt:   key.CreatedAt,
+	}
+}
+
+type createAPIKeyRequest struct {
+	UserID      int64      `json:"user_id" binding:"required,min=1"`
+	Name        string     `json:"name" binding:"required,max=100"`
+	Permissions []string   `json:"permissions" binding:"required,min=1"`
+	AllowedIPs  []string   `json:"allowed_ips"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+type createAPIKeyResponse struct {
+	Key    string         `json:"key"`
+	APIKey apiKeyResponse `json:"api_key"`
+}
+
+// issues a key acting as user_id, the key itself is only shown once
+func (server *Server) createAPIKey(ctx *gin.Context) {
+	var req createAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	if err := validatePermissions(req.Permissions); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	allowedIPs, err := normalizeIPRanges(req.AllowedIPs)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var expiredAt pgtype.Timestamptz
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			ctx.JSON(http.StatusBadRequest,
+				gin.H{"error": "expires_at must be in the future"})
+			return
+		}
+		expiredAt = pgtype.Timestamptz{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// nobody hands out more than they hold themselves, a key creating
+	// keys is also bound by its own permissions
+	granted, err := server.store.ListRolePermissions(ctx, authPayload.Role)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	allowed := hasPermissions(granted, req.Permissions)
+	if value, exists := ctx.Get(authorizationAPIKeyKey); exists {
+		allowed = allowed &&
+			hasPermissions(value.(db.ApiKey).Permissions, req.Permissions)
+	}
+	if !allowed {
+		ctx.JSON(http.StatusForbidden,
+			gin.H{"error": "cannot grant permissions you don't have"})
+		return
+	}
+
+	user, err := server.store.GetUserByID(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "user not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	// a key never does more than its user's role allows
+	userGranted, err := server.store.ListRolePermissions(ctx, user.Role)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if !hasPermissions(userGranted, req.Permissions) {
+		ctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "user's role doesn't grant these permissions"})
+		return
+	}
+
+	prefix, secret, err := generateAPIKey()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	key, err := server.store.CreateAPIKey(ctx, db.CreateAPIKeyParams{
+		UserID:      req.UserID,
+		Name:        req.Name,
+		Prefix:      prefix,
+		HashedKey:   hashSecret(secret),
+		Permissions: req.Permissions,
+		AllowedIps:  allowedIPs,
+		CreatedBy:   authPayload.UserID,
+		ExpiredAt:   expiredAt,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	resp := newAPIKeyResponse(key)
+	server.recordAudit(ctx, auditActionAPIKeyCreate, auditEntityAPIKey,
+		key.ID, nil, resp)
+
+	ctx.JSON(http.StatusOK, createAPIKeyResponse{
+		Key:    formatAPIKey(prefix, secret),
+		APIKey: resp,
+	})
+}
+
+// lists keys, newest first
+func (server *Server) listAPIKeys(ctx *gin.Context) {
+	var req pageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, ok, err := decodeCursor[struct{}](req.Cursor, "")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	keys, err := server.store.ListAPIKeys(ctx, db.ListAPIKeysParams{
+		BeforeID: pgtype.Int8{Int64: before.ID, Valid: ok},
+		RowLimit: req.Limit + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	page, err := newPageResponse(keys, req.Limit, "",
+		func(key db.ApiKey) (struct{}, int64) {
+			return struct{}{}, key.ID
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	resp := pageResponse[apiKeyResponse]{
+		Data:       make([]apiKeyResponse, 0, len(page.Data)),
+		NextCursor: page.NextCursor,
+	}
+	for _, key := range page.Data {
+		resp.Data = append(resp.Data, newAPIKeyResponse(key))
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+type apiKeyIDRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) revokeAPIKey(ctx *gin.Context) {
+	var uri apiKeyIDRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	key, err := server.store.RevokeAPIKey(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound,
+				gin.H{"error": "api key not found or already revoked"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	resp := newAPIKeyResponse(key)
+	before := resp
+	before.RevokedAt = nil
+	server.recordAudit(ctx, auditActionAPIKeyRevoke, auditEntityAPIKey,
+		key.ID, before, resp)
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// authenticates a request made with an api key, a permission has to be
+// granted by both the key and the current role of its user
+func (server *Server) authenticateAPIKey(ctx *gin.Context, rawKey string,
+	requiredPermissions []string) {
+	// account routes (password, 2fa, ...) stay for humans only
+	if len(requiredPermissions) == 0 {
+		err := errors.New("api keys can only access permission scoped routes")
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errResponse(err))
+		return
+	}
+
+	key, err := server.lookupAPIKey(ctx, rawKey)
+	if err != nil {
+		if errors.Is(err, errInvalidAPIKey) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(err))
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError,
+			errResponse(err))
+		return
+	}
+
+	// the address of the connection itself, a leaked key must not get
+	// past the allowlist with a forged X-Forwarded-For
+	clientIP := ctx.RemoteIP()
+	if !ipAllowed(key.AllowedIps, clientIP) {
+		err := fmt.Errorf("api key is not allowed from %s", clientIP)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errResponse(err))
+		return
+	}
+
+	if !hasPermissions(key.Permissions, requiredPermissions) {
+		err := fmt.Errorf("permission denied")
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserByID(ctx, key.UserID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError,
+			errResponse(err))
+		return
+	}
+
+	if user.DisabledAt.Valid {
+		ctx.AbortWithStatusJSON(http.StatusForbidden,
+			errResponse(errAccountDisabled))
+		return
+	}
+
+	// the user's current role still has to grant it, so downgrading the
+	// user also narrows their keys
+	granted, err := server.store.ListRolePermissions(ctx, user.Role)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError,
+			errResponse(err))
+		return
+	}
+
+	if !hasPermissions(granted, requiredPermissions) {
+		err := fmt.Errorf("permission denied")
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errResponse(err))
+		return
+	}
+
+	err = server.store.TouchAPIKey(ctx, db.TouchAPIKeyParams{
+		ID:         key.ID,
+		LastUsedIp: clientIP,
+	})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError,
+			errResponse(err))
+		return
+	}
+
+	// handlers only see the user the key acts as
+	payload := &token.Payload{
+		UserID:    user.UserID,
+		Username:  user.Username,
+		Role:      user.Role,
+		IssuedAt:  key.CreatedAt,
+		ExpiredAt: key.ExpiredAt.Time,
+	}
+	ctx.Set(authorizationPayloadKey, payload)
+	ctx.Set(authorizationAPIKeyKey, key)
+
+	ctx.Next()
+}
+
+// ------------------------------------------------------------------//
+// ------------------------------Helper Funcs------------------------//
+// ------------------------------------------------------------------//
+
+// finds the key and checks its secret, revocation and expiry
+func (server *Server) lookupAPIKey(ctx *gin.Context,
+	rawKey string) (db.ApiKey, error) {
+	prefix, secret, ok := parseAPIKey(rawKey)
+	if !ok {
+		return db.ApiKey{}, errInvalidAPIKey
+	}
+
+	key, err := server.store.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return db.ApiKey{}, errInvalidAPIKey
+		}
+		return db.ApiKey{}, err
+	}
+
+	hashed := hashSecret(secret)
+	if subtle.ConstantTimeCompare([]byte(hashed), []byte(key.HashedKey)) != 1 {
+		return db.ApiKey{}, errInvalidAPIKey
+	}
+
+	if key.RevokedAt.Valid {
+		return db.ApiKey{}, errInvalidAPIKey
+	}
+
+	if key.ExpiredAt.Valid && time.Now().After(key.ExpiredAt.Time) {
+		return db.ApiKey{}, errInvalidAPIKey
+	}
+
+	return key, nil
+}
+
+func generateAPIKey() (prefix string, secret string, err error) {
+	prefix, err = util.RandomSecureString(apiKeyPrefixLength)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err = util.RandomSecureString(apiKeySecretLength)
+	if err != nil {
+		return "", "", err
+	}
+
+	return prefix, secret, nil
+}
+
+func formatAPIKey(prefix string, secret string) string {
+	return apiKeyTag + "_" + prefix + "_" + secret
+}
+
+func parseAPIKey(rawKey string) (prefix string, secret string, ok bool) {
+	parts := strings.Split(rawKey, "_")
+	if len(parts) != 3 || parts[0] != apiKeyTag ||
+		len(parts[1]) != apiKeyPrefixLength ||
+		len(parts[2]) != apiKeySecretLength {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// parses ips and cidr ranges, storing single ips as ranges
+func normalizeIPRanges(values []string) ([]string, error) {
+	ranges := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			ranges = append(ranges, prefix.Masked().String())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ip range %s", value)
+		}
+		ranges = append(ranges,
+			netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()).String())
+	}
+	return ranges, nil
+}
+
+// reports whether ip falls in one of the ranges, no ranges allow any ip
+func ipAllowed(ranges []string, ip string) bool {
+	if len(ranges) == 0 {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, r := range ranges {
+		prefix, err := netip.ParsePrefix(r)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func timePtr(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
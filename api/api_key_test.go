@@ -0,0 +1,392 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+// apiKeyStore keeps api keys and users in memory
+type apiKeyStore struct {
+	db.Store
+	users       map[int64]db.User
+	roles       map[string][]string
+	keys        map[int64]db.ApiKey
+	auditEvents []db.CreateAuditEventParams
+}
+
+const kioskRole = "kiosk"
+
+func (store *apiKeyStore) ListRolePermissions(ctx context.Context,
+	role string) ([]string, error) {
+	if role == util.AdminRole {
+		return util.Permissions, nil
+	}
+	return store.roles[role], nil
+}
+
+func (store *apiKeyStore) GetUserByID(ctx context.Context,
+	userID int64) (db.User, error) {
+	user, ok := store.users[userID]
+	if !ok {
+		return db.User{}, db.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (store *apiKeyStore) CreateAPIKey(ctx context.Context,
+	arg db.CreateAPIKeyParams) (db.ApiKey, error) {
+	key := db.ApiKey{
+		ID:          int64(len(store.keys) + 1),
+		UserID:      arg.UserID,
+		Name:        arg.Name,
+		Prefix:      arg.Prefix,
+		HashedKey:   arg.HashedKey,
+		Permissions: arg.Permissions,
+		AllowedIps:  arg.AllowedIps,
+		CreatedBy:   arg.CreatedBy,
+		ExpiredAt:   arg.ExpiredAt,
+		CreatedAt:   time.Now(),
+	}
+	store.keys[key.ID] = key
+	return key, nil
+}
+
+func (store *apiKeyStore) GetAPIKeyByPrefix(ctx context.Context,
+	prefix string) (db.ApiKey, error) {
+	for _, key := range store.keys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return db.ApiKey{}, db.ErrRecordNotFound
+}
+
+func (store *apiKeyStore) RevokeAPIKey(ctx context.Context,
+	id int64) (db.ApiKey, error) {
+	key, ok := store.keys[id]
+	if !ok || key.RevokedAt.Valid {
+		return db.ApiKey{}, db.ErrRecordNotFound
+	}
+	key.RevokedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	store.keys[id] = key
+	return key, nil
+}
+
+func (store *apiKeyStore) TouchAPIKey(ctx context.Context,
+	arg db.TouchAPIKeyParams) error {
+	key := store.keys[arg.ID]
+	key.LastUsedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	key.LastUsedIp = arg.LastUsedIp
+	store.keys[arg.ID] = key
+	return nil
+}
+
+func (store *apiKeyStore) CreateAuditEvent(ctx context.Context,
+	arg db.CreateAuditEventParams) (db.AuditEvent, error) {
+	store.auditEvents = append(store.auditEvents, arg)
+	return db.AuditEvent{ID: int64(len(store.auditEvents))}, nil
+}
+
+func newAPIKeyTestServer(t *testing.T) (*Server, *apiKeyStore, string) {
+	store := &apiKeyStore{
+		users: map[int64]db.User{
+			1: {UserID: 1, Username: "admin", Role: util.AdminRole},
+			2: {UserID: 2, Username: "kiosk", Role: kioskRole},
+			3: {UserID: 3, Username: "keys", Role: "key_manager"},
+		},
+		roles: map[string][]string{
+			kioskRole: {util.CheckinScanPermission,
+				util.ReservationsWritePermission},
+			"key_manager": {util.APIKeysManagePermission,
+				util.CheckinScanPermission},
+		},
+		keys: map[int64]db.ApiKey{},
+	}
+
+	server := newTestServer(t, store)
+	server.router.GET("/scan", server.authMiddleware(util.CheckinScanPermission),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		})
+	server.router.GET("/any", server.authMiddleware(),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		})
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+
+	return server, store, adminToken
+}
+
+// creates a key through the admin endpoint and returns it in plain text
+func createTestAPIKey(t *testing.T, server *Server, adminToken string,
+	body gin.H) (string, apiKeyResponse) {
+	recorder := serveWithToken(t, server, http.MethodPost, "/api_keys",
+		adminToken, body)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var resp createAPIKeyResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Key)
+	require.Contains(t, resp.Key, resp.APIKey.Prefix)
+
+	return resp.Key, resp.APIKey
+}
+
+func serveWithAPIKey(t *testing.T, server *Server, path string,
+	key string, remoteAddr string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, path, nil)
+	require.NoError(t, err)
+	request.Header.Set(authorizationHeaderKey, "ApiKey "+key)
+	if remoteAddr != "" {
+		request.RemoteAddr = remoteAddr
+	}
+
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	server, store, adminToken := newAPIKeyTestServer(t)
+
+	key, created := createTestAPIKey(t, server, adminToken, gin.H{
+		"user_id":     2,
+		"name":        "lobby kiosk",
+		"permissions": []string{util.CheckinScanPermission},
+		"allowed_ips": []string{"10.0.0.0/24", "192.168.1.7"},
+	})
+	require.Equal(t, []string{"10.0.0.0/24", "192.168.1.7/32"},
+		created.AllowedIPs)
+	require.NotEqual(t, key, store.keys[created.ID].HashedKey)
+
+	recorder := serveWithAPIKey(t, server, "/scan", key, "10.0.0.12:1234")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "10.0.0.12", store.keys[created.ID].LastUsedIp)
+
+	recorder = serveWithAPIKey(t, server, "/scan", key, "192.168.1.7:1234")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// outside the allowed ranges
+	recorder = serveWithAPIKey(t, server, "/scan", key, "10.0.1.12:1234")
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// claiming to forward for an allowed ip doesn't help
+	recorder = httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/scan", nil)
+	require.NoError(t, err)
+	request.Header.Set(authorizationHeaderKey, "ApiKey "+key)
+	request.Header.Set("X-Forwarded-For", "10.0.0.12")
+	request.RemoteAddr = "10.0.1.12:1234"
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// routes without a permission scope are for humans only
+	recorder = serveWithAPIKey(t, server, "/any", key, "10.0.0.12:1234")
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// wrong secret for a known prefix
+	forged := key[:len(key)-1] + "q"
+	if forged == key {
+		forged = key[:len(key)-1] + "r"
+	}
+	recorder = serveWithAPIKey(t, server, "/scan", forged, "10.0.0.12:1234")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodDelete,
+		"/api_keys/1", adminToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serveWithAPIKey(t, server, "/scan", key, "10.0.0.12:1234")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodDelete,
+		"/api_keys/1", adminToken, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// the secret never ends up in the audit log
+	require.Len(t, store.auditEvents, 2)
+	require.Equal(t, auditActionAPIKeyCreate, store.auditEvents[0].Action)
+	require.Equal(t, auditActionAPIKeyRevoke, store.auditEvents[1].Action)
+	for _, event := range store.auditEvents {
+		require.NotContains(t, string(event.After), key)
+		require.NotContains(t, string(event.After), "hashed_key")
+	}
+}
+
+func TestAPIKeyPermissions(t *testing.T) {
+	server, _, adminToken := newAPIKeyTestServer(t)
+
+	key, _ := createTestAPIKey(t, server, adminToken, gin.H{
+		"user_id":     2,
+		"name":        "partner",
+		"permissions": []string{util.ReservationsWritePermission},
+	})
+
+	recorder := serveWithAPIKey(t, server, "/scan", key, "")
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// a key can't manage keys unless granted to
+	recorder = serveWithAPIKey(t, server, "/api_keys", key, "")
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestAPIKeyOwnerRole(t *testing.T) {
+	server, store, adminToken := newAPIKeyTestServer(t)
+
+	key, _ := createTestAPIKey(t, server, adminToken, gin.H{
+		"user_id":     2,
+		"name":        "kiosk",
+		"permissions": []string{util.CheckinScanPermission},
+	})
+
+	recorder := serveWithAPIKey(t, server, "/scan", key, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// the key stops working once its user's role loses the permission
+	store.roles[kioskRole] = []string{util.ReservationsWritePermission}
+	recorder = serveWithAPIKey(t, server, "/scan", key, "")
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// keys can't be given permissions their user doesn't have
+	recorder = serveWithToken(t, server, http.MethodPost, "/api_keys",
+		adminToken, gin.H{"user_id": 2, "name": "k",
+			"permissions": []string{util.UsersManagePermission}})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestCreateAPIKeyEscalation(t *testing.T) {
+	server, _, adminToken := newAPIKeyTestServer(t)
+
+	managerToken, _, err := server.tokenMaker.CreateToken(
+		"keys", 3, "key_manager", time.Minute)
+	require.NoError(t, err)
+
+	// a key manager can't hand out permissions they don't hold
+	for _, permission := range []string{
+		util.RolesManagePermission,
+		util.UsersManagePermission,
+	} {
+		recorder := serveWithToken(t, server, http.MethodPost, "/api_keys",
+			managerToken, gin.H{"user_id": 1, "name": "k",
+				"permissions": []string{permission}})
+		require.Equal(t, http.StatusForbidden, recorder.Code, permission)
+	}
+
+	recorder := serveWithToken(t, server, http.MethodPost, "/api_keys",
+		managerToken, gin.H{"user_id": 2, "name": "k",
+			"permissions": []string{util.CheckinScanPermission}})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// neither can a key that manages keys
+	managerKey, _ := createTestAPIKey(t, server, adminToken, gin.H{
+		"user_id":     3,
+		"name":        "provisioning",
+		"permissions": []string{util.APIKeysManagePermission},
+	})
+
+	body, err := json.Marshal(gin.H{"user_id": 2, "name": "k",
+		"permissions": []string{util.CheckinScanPermission}})
+	require.NoError(t, err)
+
+	recorder = httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api_keys",
+		bytes.NewReader(body))
+	require.NoError(t, err)
+	request.Header.Set(authorizationHeaderKey, "ApiKey "+managerKey)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestAPIKeyExpired(t *testing.T) {
+	server, store, adminToken := newAPIKeyTestServer(t)
+
+	key, created := createTestAPIKey(t, server, adminToken, gin.H{
+		"user_id":     2,
+		"name":        "kiosk",
+		"permissions": []string{util.CheckinScanPermission},
+		"expires_at":  time.Now().Add(time.Hour),
+	})
+
+	recorder := serveWithAPIKey(t, server, "/scan", key, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	stored := store.keys[created.ID]
+	stored.ExpiredAt.Time = time.Now().Add(-time.Minute)
+	store.keys[created.ID] = stored
+
+	recorder = serveWithAPIKey(t, server, "/scan", key, "")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestCreateAPIKeyValidation(t *testing.T) {
+	server, _, adminToken := newAPIKeyTestServer(t)
+
+	testCases := []struct {
+		name string
+		body gin.H
+	}{
+		{
+			name: "UnknownPermission",
+			body: gin.H{"user_id": 2, "name": "k", "permissions": []string{"nope"}},
+		},
+		{
+			name: "NoPermissions",
+			body: gin.H{"user_id": 2, "name": "k", "permissions": []string{}},
+		},
+		{
+			name: "InvalidIPRange",
+			body: gin.H{"user_id": 2, "name": "k",
+				"permissions": []string{util.CheckinScanPermission},
+				"allowed_ips": []string{"10.0.0.0/33"}},
+		},
+		{
+			name: "ExpiryInThePast",
+			body: gin.H{"user_id": 2, "name": "k",
+				"permissions": []string{util.CheckinScanPermission},
+				"expires_at":  time.Now().Add(-time.Hour)},
+		},
+		{
+			name: "UnknownUser",
+			body: gin.H{"user_id": 99, "name": "k",
+				"permissions": []string{util.CheckinScanPermission}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := serveWithToken(t, server, http.MethodPost, "/api_keys",
+				adminToken, tc.body)
+			require.Equal(t, http.StatusBadRequest, recorder.Code)
+		})
+	}
+}
+
+func TestIPAllowed(t *testing.T) {
+	ranges, err := normalizeIPRanges([]string{"10.0.0.1/8", "2001:db8::1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.0/8", "2001:db8::1/128"}, ranges)
+
+	require.True(t, ipAllowed(ranges, "10.200.3.4"))
+	require.True(t, ipAllowed(ranges, "::ffff:10.1.1.1"))
+	require.True(t, ipAllowed(ranges, "2001:db8::1"))
+	require.False(t, ipAllowed(ranges, "11.0.0.1"))
+	require.False(t, ipAllowed(ranges, "not-an-ip"))
+	require.True(t, ipAllowed(nil, "11.0.0.1"))
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/token"
+)
+
+// entities privileged actions are recorded against
+const (
+	auditEntityUser     = "user"
+	auditEntityMovie    = "movie"
+	auditEntityShowtime = "showtime"
+	auditEntityRole     = "role"
+	auditEntityAPIKey   = "api_key"
+	auditEntityGenre    = "genre"
+	auditEntityPerson   = "person"
+	auditEntityCredit   = "credit"
+	auditEntityReview   = "review"
+)
+
+// privileged actions, named <entity>.<verb>
+const (
+	auditActionUserRole    = "user.role"
+	auditActionUserUnlock  = "user.unlock"
+	auditActionUserDisable = "user.disable"
+	auditActionUserEnable  = "user.enable"
+	auditActionUserDelete  = "user.delete"
+
+	auditActionMovieCreate    = "movie.create"
+	auditActionMovieUpdate    = "movie.update"
+	auditActionMovieDelete    = "movie.delete"
+	auditActionShowtimeCreate = "showtime.create"
+	auditActionShowtimeDelete = "showtime.delete"
+	auditActionRoleCreate     = "role.create"
+	auditActionRoleUpdate     = "role.update"
+	auditActionRoleDelete     = "role.delete"
+	auditActionAPIKeyCreate   = "api_key.create"
+	auditActionAPIKeyRevoke   = "api_key.revoke"
+	auditActionGenreCreate    = "genre.create"
+	auditActionGenreUpdate    = "genre.update"
+	auditActionGenreDelete    = "genre.delete"
+	auditActionPersonCreate   = "person.create"
+	auditActionPersonUpdate   = "person.update"
+	auditActionPersonDelete   = "person.delete"
+	auditActionCreditCreate   = "credit.create"
+	auditActionCreditUpdate   = "credit.update"
+	auditActionCreditDelete   = "credit.delete"
+	auditActionReviewModerate = "review.moderate"
+)
+
+// audit events are append only and outlive anonymization, so users are
+// recorded without their name or email
+type userAuditSnapshot struct {
+	UserID              int64      `json:"user_id"`
+	Role                string     `json:"role"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty"`
+}
+
+func newUserAuditSnapshot(user db.User) userAuditSnapshot {
+	return userAuditSnapshot{
+		UserID:              user.UserID,
+		Role:                user.Role,
+		DisabledAt:          timePtr(user.DisabledAt),
+		DeletionRequestedAt: timePtr(user.DeletionRequestedAt),
+	}
+}
+
+// appends an audit event for the caller of the request, before and after
+// are snapshots of the entity (nil when it didn't or doesn't exist).
+// the action already happened, so a failure is logged rather than
+// turned into an error response
+func (server *Server) recordAudit(ctx *gin.Context, action string,
+	entityType string, entityID any, before any, after any) {
+	arg := db.CreateAuditEventParams{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   fmt.Sprint(entityID),
+		ClientIp:   ctx.ClientIP(),
+		UserAgent:  ctx.Request.UserAgent(),
+	}
+
+	if value, exists := ctx.Get(authorizationPayloadKey); exists {
+		payload := value.(*token.Payload)
+		arg.ActorID = pgtype.Int8{Int64: payload.UserID, Valid: true}
+	}
+
+	var err error
+	if arg.Before, err = auditSnapshot(before); err == nil {
+		arg.After, err = auditSnapshot(after)
+	}
+	if err == nil {
+		_, err = server.store.CreateAuditEvent(ctx, arg)
+	}
+
+	if err != nil {
+		log.Printf("cannot record audit event %s %s/%s: %v\n",
+			action, entityType, arg.EntityID, err)
+	}
+}
+
+type auditEventResponse struct {
+	ID         int64           `json:"id"`
+	ActorID    *int64          `json:"actor_id"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	ClientIP   string          `json:"client_ip"`
+	UserAgent  string          `json:"user_agent"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+func newAuditEventResponse(event db.AuditEvent) auditEventResponse {
+	resp := auditEventResponse{
+		ID:         event.ID,
+		Action:     event.Action,
+		EntityType: event.EntityType,
+		EntityID:   event.EntityID,
+		Before:     event.Before,
+		After:      event.After,
+		ClientIP:   event.ClientIp,
+		UserAgent:  event.UserAgent,
+		CreatedAt:  event.CreatedAt,
+	}
+
+	if event.ActorID.Valid {
+		resp.ActorID = &event.ActorID.Int64
+	}
+	return resp
+}
+
+type listAuditEventsRequest struct {
+	ActorID    int64     `form:"actor_id" binding:"min=0"`
+	Action     string    `form:"action"`
+	EntityType string    `form:"entity_type"`
+	EntityID   string    `form:"entity_id"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	pageRequest
+}
+
+// lists audit events, newest first, every filter is optional
+func (server *Server) listAuditEvents(ctx *gin.Context) {
+	var req listAuditEventsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, ok, err := decodeCursor[struct{}](req.Cursor, "")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	events, err := server.store.ListAuditEvents(ctx, db.ListAuditEventsParams{
+		ActorID:     pgtype.Int8{Int64: req.ActorID, Valid: req.ActorID > 0},
+		Action:      pgtype.Text{String: req.Action, Valid: req.Action != ""},
+		EntityType:  pgtype.Text{String: req.EntityType, Valid: req.EntityType != ""},
+		EntityID:    pgtype.Text{String: req.EntityID, Valid: req.EntityID != ""},
+		CreatedFrom: pgtype.Timestamptz{Time: req.From, Valid: !req.From.IsZero()},
+		CreatedTo:   pgtype.Timestamptz{Time: req.To, Valid: !req.To.IsZero()},
+		BeforeID:    pgtype.Int8{Int64: before.ID, Valid: ok},
+		RowLimit:    req.Limit + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	page, err := newPageResponse(events, req.Limit, "",
+		func(event db.AuditEvent) (struct{}, int64) {
+			return struct{}{}, event.ID
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	resp := pageResponse[auditEventResponse]{
+		Data:       make([]auditEventResponse, 0, len(page.Data)),
+		NextCursor: page.NextCursor,
+	}
+	for _, event := range page.Data {
+		resp.Data = append(resp.Data, newAuditEventResponse(event))
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func auditSnapshot(entity any) ([]byte, error) {
+	if entity == nil {
+		return nil, nil
+	}
+	return json.Marshal(entity)
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+// auditStore adds showtimes, roles and stored audit events to the
+// user admin fake
+type auditStore struct {
+	*userAdminStore
+	showtimes       map[int32]db.Showtime
+	roles           map[string]db.Role
+	rolePermissions map[string][]string
+	listArg         db.ListAuditEventsParams
+}
+
+func newAuditStore() *auditStore {
+	return &auditStore{
+		userAdminStore: newUserAdminStore(),
+		showtimes: map[int32]db.Showtime{
+			4: {ShowtimeID: 4, MovieID: 2},
+		},
+		roles:           map[string]db.Role{},
+		rolePermissions: map[string][]string{},
+	}
+}
+
+func (store *auditStore) GetShowtime(ctx context.Context,
+	id int32) (db.Showtime, error) {
+	showtime, ok := store.showtimes[id]
+	if !ok {
+		return db.Showtime{}, db.ErrRecordNotFound
+	}
+	return showtime, nil
+}
+
+func (store *auditStore) DeleteShowtime(ctx context.Context, id int32) error {
+	delete(store.showtimes, id)
+	return nil
+}
+
+func (store *auditStore) ListRolePermissions(ctx context.Context,
+	role string) ([]string, error) {
+	if role == util.AdminRole {
+		return util.Permissions, nil
+	}
+	return store.rolePermissions[role], nil
+}
+
+func (store *auditStore) GetRole(ctx context.Context,
+	name string) (db.Role, error) {
+	role, ok := store.roles[name]
+	if !ok {
+		return db.Role{}, db.ErrRecordNotFound
+	}
+	return role, nil
+}
+
+func (store *auditStore) CreateRoleTx(ctx context.Context,
+	arg db.CreateRoleTxParams) (db.RoleTxResult, error) {
+	role := db.Role{Name: arg.Name, Description: arg.Description}
+	store.roles[arg.Name] = role
+	store.rolePermissions[arg.Name] = arg.Permissions
+	return db.RoleTxResult{Role: role, Permissions: arg.Permissions}, nil
+}
+
+func (store *auditStore) UpdateRoleTx(ctx context.Context,
+	arg db.UpdateRoleTxParams) (db.RoleTxResult, error) {
+	return store.CreateRoleTx(ctx, db.CreateRoleTxParams(arg))
+}
+
+func (store *auditStore) CountUsersWithRole(ctx context.Context,
+	role string) (int64, error) {
+	return 0, nil
+}
+
+func (store *auditStore) DeleteRole(ctx context.Context, name string) error {
+	delete(store.roles, name)
+	return nil
+}
+
+func (store *auditStore) ListAuditEvents(ctx context.Context,
+	arg db.ListAuditEventsParams) ([]db.AuditEvent, error) {
+	store.listArg = arg
+
+	events := []db.AuditEvent{}
+	for i := len(store.auditEvents) - 1; i >= 0; i-- {
+		event := store.auditEvents[i]
+		events = append(events, db.AuditEvent{
+			ID:         int64(i + 1),
+			ActorID:    event.ActorID,
+			Action:     event.Action,
+			EntityType: event.EntityType,
+			EntityID:   event.EntityID,
+			Before:     event.Before,
+			After:      event.After,
+			ClientIp:   event.ClientIp,
+			UserAgent:  event.UserAgent,
+		})
+	}
+	return events, nil
+}
+
+func TestAuditDeleteShowtime(t *testing.T) {
+	store := newAuditStore()
+	server := newTestServer(t, store)
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := serveWithToken(t, server, http.MethodDelete,
+		"/showtimes/9", adminToken, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	require.Empty(t, store.auditEvents)
+
+	recorder = serveWithToken(t, server, http.MethodDelete,
+		"/showtimes/4", adminToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	require.Len(t, store.auditEvents, 1)
+	event := store.auditEvents[0]
+	require.Equal(t, auditActionShowtimeDelete, event.Action)
+	require.Equal(t, auditEntityShowtime, event.EntityType)
+	require.Equal(t, "4", event.EntityID)
+	require.Equal(t, pgtype.Int8{Int64: 1, Valid: true}, event.ActorID)
+	require.Contains(t, string(event.Before), `"movie_id":2`)
+	require.Nil(t, event.After)
+}
+
+func TestAuditRoles(t *testing.T) {
+	store := newAuditStore()
+	server := newTestServer(t, store)
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := serveWithToken(t, server, http.MethodPost, "/roles",
+		adminToken, map[string]any{
+			"name":        "usher",
+			"permissions": []string{util.CheckinScanPermission},
+		})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodPut, "/roles/usher",
+		adminToken, map[string]any{
+			"permissions": []string{util.ReservationsReadAllPermission},
+		})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodDelete, "/roles/usher",
+		adminToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	require.Len(t, store.auditEvents, 3)
+	require.Equal(t, auditActionRoleCreate, store.auditEvents[0].Action)
+	require.Equal(t, auditActionRoleUpdate, store.auditEvents[1].Action)
+	require.Equal(t, auditActionRoleDelete, store.auditEvents[2].Action)
+
+	update := store.auditEvents[1]
+	require.Equal(t, "usher", update.EntityID)
+	require.Contains(t, string(update.Before), util.CheckinScanPermission)
+	require.Contains(t, string(update.After), util.ReservationsReadAllPermission)
+}
+
+func TestListAuditEvents(t *testing.T) {
+	store := newAuditStore()
+	server := newTestServer(t, store)
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := serveWithToken(t, server, http.MethodDelete,
+		"/showtimes/4", adminToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	cursor, err := encodeCursor("", struct{}{}, 20)
+	require.NoError(t, err)
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/audit_events?actor_id=1&entity_type=showtime&entity_id=4"+
+			"&from=2025-05-01T00:00:00Z&limit=10&cursor="+cursor,
+		adminToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	arg := store.listArg
+	require.Equal(t, pgtype.Int8{Int64: 1, Valid: true}, arg.ActorID)
+	require.False(t, arg.Action.Valid)
+	require.Equal(t, "showtime", arg.EntityType.String)
+	require.Equal(t, "4", arg.EntityID.String)
+	require.True(t, arg.CreatedFrom.Valid)
+	require.False(t, arg.CreatedTo.Valid)
+	require.Equal(t, pgtype.Int8{Int64: 20, Valid: true}, arg.BeforeID)
+	require.Equal(t, int32(11), arg.RowLimit)
+
+	var page pageResponse[auditEventResponse]
+	err = json.Unmarshal(recorder.Body.Bytes(), &page)
+	require.NoError(t, err)
+	require.Nil(t, page.NextCursor)
+	events := page.Data
+	require.Len(t, events, 1)
+	require.Equal(t, auditActionShowtimeDelete, events[0].Action)
+	require.Equal(t, int64(1), *events[0].ActorID)
+	require.JSONEq(t, string(store.auditEvents[0].Before),
+		string(events[0].Before))
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/audit_events?from=yesterday", adminToken, nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/audit_events?cursor=bogus", adminToken, nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	customerToken, _, err := server.tokenMaker.CreateToken(
+		"alice", 2, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder = serveWithToken(t, server, http.MethodGet, "/audit_events",
+		customerToken, nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/token"
+	"github.com/kratos69/movie-app/util"
+)
+
+// what an emailed confirmation code can be used for
+const (
+	confirmDeleteAccount    = "delete_account"
+	confirmChangePassword   = "change_password"
+	confirmDisableTwoFactor = "disable_2fa"
+)
+
+var errInvalidConfirmation = errors.New(
+	"invalid password or confirmation code")
+
+type requestConfirmationRequest struct {
+	Purpose string `json:"purpose" binding:"required,oneof=delete_account change_password disable_2fa"`
+}
+
+// emails a one time code that stands in for the password on sensitive
+// changes. only for users that signed up through an identity provider,
+// their password is a random one nobody knows
+func (server *Server) requestConfirmation(ctx *gin.Context) {
+	var req requestConfirmationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUserByID(ctx, authPayload.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	identities, err := server.store.ListUserIdentities(ctx, user.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if len(identities) == 0 {
+		ctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "confirm with your password instead"})
+		return
+	}
+
+	if server.mailer == nil {
+		ctx.JSON(http.StatusServiceUnavailable,
+			gin.H{"error": "email is not configured"})
+		return
+	}
+
+	err = server.sendConfirmationEmail(ctx, user, req.Purpose)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "confirmation code sent"})
+}
+
+// ------------------------------------------------------------------//
+// ------------------------------Helper Funcs------------------------//
+// ------------------------------------------------------------------//
+
+// emails the user a code that confirms one change of the given purpose
+func (server *Server) sendConfirmationEmail(ctx context.Context,
+	user db.User, purpose string) error {
+	secretCode, err := util.RandomSecureString(32)
+	if err != nil {
+		return err
+	}
+
+	_, err = server.store.CreateAccountConfirmation(ctx,
+		db.CreateAccountConfirmationParams{
+			UserID:     user.UserID,
+			Purpose:    purpose,
+			SecretCode: secretCode,
+		})
+	if err != nil {
+		return err
+	}
+
+	subject := "Your confirmation code"
+	content := fmt.Sprintf(`Hello %s,<br/>
+	Your confirmation code is <b>%s</b>, it is valid for 15 minutes.<br/>
+	If you didn't ask for it, you can ignore this email.<br/>
+	`, user.Name, secretCode)
+
+	go func() {
+		err := server.mailer.SendEmail(subject, content,
+			[]string{user.Email})
+		if err != nil {
+			log.Printf("failed to send confirmation email to user %d: %v\n",
+				user.UserID, err)
+		}
+	}()
+
+	return nil
+}
+
+// checks the user's password when given, otherwise burns an emailed
+// confirmation code for the purpose
+func (server *Server) confirmUser(ctx *gin.Context, user db.User,
+	password, code, purpose string) (bool, error) {
+	if password != "" {
+		return util.CheckPassword(password, user.HashedPassword) == nil, nil
+	}
+
+	_, err := server.store.UseAccountConfirmation(ctx,
+		db.UseAccountConfirmationParams{
+			UserID:     user.UserID,
+			Purpose:    purpose,
+			SecretCode: strings.TrimSpace(code),
+		})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
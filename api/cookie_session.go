@@ -0,0 +1,119 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kratos69/movie-app/util"
+)
+
+// cookies set in cookie session mode, the csrf cookie is readable by the
+// frontend so it can echo the token back in csrfHeaderKey
+const (
+	accessTokenCookie  = "access_token"
+	refreshTokenCookie = "refresh_token"
+	csrfTokenCookie    = "csrf_token"
+	csrfHeaderKey      = "X-CSRF-Token"
+	csrfTokenLength    = 32
+)
+
+var errInvalidCSRFToken = errors.New("missing or invalid csrf token")
+
+// parses the SameSite attribute of the session cookies
+func parseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("unsupported cookie same site mode %s", value)
+	}
+}
+
+// moves the tokens of a new login into cookies, so they never reach
+// javascript, and issues the csrf token for the session
+func (server *Server) setSessionCookies(ctx *gin.Context,
+	resp *loginUserResponse) error {
+	csrfToken, err := util.RandomSecureString(csrfTokenLength)
+	if err != nil {
+		return err
+	}
+
+	server.setCookie(ctx, accessTokenCookie, resp.AccessToken,
+		resp.AccessTokenExpiresAt, true)
+	server.setCookie(ctx, refreshTokenCookie, resp.RefreshToken,
+		resp.RefreshTokenExpiresAt, true)
+	server.setCookie(ctx, csrfTokenCookie, csrfToken,
+		resp.RefreshTokenExpiresAt, false)
+
+	resp.AccessToken = ""
+	resp.RefreshToken = ""
+	resp.CSRFToken = csrfToken
+	return nil
+}
+
+// expires every session cookie
+func (server *Server) clearSessionCookies(ctx *gin.Context) {
+	for _, name := range []string{
+		accessTokenCookie, refreshTokenCookie, csrfTokenCookie,
+	} {
+		server.setCookie(ctx, name, "", time.Unix(0, 0), name != csrfTokenCookie)
+	}
+}
+
+func (server *Server) setCookie(ctx *gin.Context, name string, value string,
+	expiresAt time.Time, httpOnly bool) {
+	maxAge := int(time.Until(expiresAt).Seconds())
+	if maxAge <= 0 {
+		maxAge = -1
+	}
+
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   server.config.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: server.cookieSameSite,
+	})
+}
+
+// returns the token of a session cookie, empty outside cookie mode
+func (server *Server) sessionCookie(ctx *gin.Context, name string) string {
+	if !server.config.CookieSessions {
+		return ""
+	}
+
+	value, err := ctx.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// double submit check: state changing requests authenticated by cookie
+// must repeat the csrf cookie in a header, which other sites can't read
+func checkCSRF(ctx *gin.Context) bool {
+	switch ctx.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	cookie, err := ctx.Cookie(csrfTokenCookie)
+	if err != nil || cookie == "" {
+		return false
+	}
+
+	header := ctx.GetHeader(csrfHeaderKey)
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+// cookieSessionStore keeps sessions in memory
+type cookieSessionStore struct {
+	db.Store
+	sessions map[uuid.UUID]db.Session
+}
+
+func (store *cookieSessionStore) CreateSession(ctx context.Context,
+	arg db.CreateSessionParams) (db.Session, error) {
+	session := db.Session{
+		ID:           arg.ID,
+		Username:     arg.Username,
+		RefreshToken: arg.RefreshToken,
+		ExpiredAt:    arg.ExpiredAt,
+	}
+	store.sessions[arg.ID] = session
+	return session, nil
+}
+
+func (store *cookieSessionStore) GetSessionByID(ctx context.Context,
+	id uuid.UUID) (db.Session, error) {
+	session, ok := store.sessions[id]
+	if !ok {
+		return db.Session{}, db.ErrRecordNotFound
+	}
+	return session, nil
+}
+
+func (store *cookieSessionStore) BlockSession(ctx context.Context,
+	arg db.BlockSessionParams) (int64, error) {
+	session, ok := store.sessions[arg.ID]
+	if !ok || session.Username != arg.Username {
+		return 0, nil
+	}
+	session.IsBlocked = true
+	store.sessions[arg.ID] = session
+	return 1, nil
+}
+
+func newCookieTestServer(t *testing.T) *Server {
+	config := util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		CookieSessions:       true,
+		CookieSameSite:       "strict",
+	}
+
+	server, err := NewServer(config, &cookieSessionStore{
+		sessions: map[uuid.UUID]db.Session{},
+	})
+	require.NoError(t, err)
+
+	server.router.Any("/auth", server.authMiddleware(),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		})
+	return server
+}
+
+// logs a user in and returns the cookies and csrf token it was given
+func cookieLogin(t *testing.T, server *Server) ([]*http.Cookie, string) {
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/users/login", nil)
+
+	resp, err := server.createLoginSession(ctx, db.User{
+		UserID:   1,
+		Username: "user",
+		Role:     util.CustomerRole,
+	})
+	require.NoError(t, err)
+
+	// tokens stay out of reach of javascript
+	require.Empty(t, resp.AccessToken)
+	require.Empty(t, resp.RefreshToken)
+	require.Len(t, resp.CSRFToken, csrfTokenLength)
+
+	cookies := recorder.Result().Cookies()
+	require.Len(t, cookies, 3)
+	for _, cookie := range cookies {
+		require.True(t, cookie.Secure)
+		require.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+		require.Equal(t, cookie.Name != csrfTokenCookie, cookie.HttpOnly)
+		require.NotEmpty(t, cookie.Value)
+	}
+
+	return cookies, resp.CSRFToken
+}
+
+func serveWithCookies(t *testing.T, server *Server, method string, path string,
+	cookies []*http.Cookie, csrfToken string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(method, path, nil)
+	require.NoError(t, err)
+
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	if csrfToken != "" {
+		request.Header.Set(csrfHeaderKey, csrfToken)
+	}
+
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestAuthMiddlewareCookie(t *testing.T) {
+	server := newCookieTestServer(t)
+	cookies, csrfToken := cookieLogin(t, server)
+
+	recorder := serveWithCookies(t, server, http.MethodGet, "/auth",
+		cookies, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// state changing requests need the csrf header
+	recorder = serveWithCookies(t, server, http.MethodPost, "/auth",
+		cookies, "")
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = serveWithCookies(t, server, http.MethodPost, "/auth",
+		cookies, "forged")
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = serveWithCookies(t, server, http.MethodPost, "/auth",
+		cookies, csrfToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// cookies are ignored unless cookie sessions are turned on
+	server.config.CookieSessions = false
+	recorder = serveWithCookies(t, server, http.MethodGet, "/auth",
+		cookies, "")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestRenewAccessTokenCookie(t *testing.T) {
+	server := newCookieTestServer(t)
+	cookies, csrfToken := cookieLogin(t, server)
+
+	recorder := serveWithCookies(t, server, http.MethodPost,
+		"/tokens/renew_access", cookies, "")
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = serveWithCookies(t, server, http.MethodPost,
+		"/tokens/renew_access", cookies, csrfToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp renewAccessTokenResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Empty(t, resp.AccessToken)
+
+	renewed := recorder.Result().Cookies()
+	require.Len(t, renewed, 1)
+	require.Equal(t, accessTokenCookie, renewed[0].Name)
+	require.True(t, renewed[0].HttpOnly)
+}
+
+func TestLogoutClearsCookies(t *testing.T) {
+	server := newCookieTestServer(t)
+	cookies, csrfToken := cookieLogin(t, server)
+
+	recorder := serveWithCookies(t, server, http.MethodPost, "/users/logout",
+		cookies, csrfToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// the refresh cookie identifies the session to block
+	store := server.store.(*cookieSessionStore)
+	require.Len(t, store.sessions, 1)
+	for _, session := range store.sessions {
+		require.True(t, session.IsBlocked)
+	}
+
+	cleared := recorder.Result().Cookies()
+	require.Len(t, cleared, 3)
+	for _, cookie := range cleared {
+		require.Empty(t, cookie.Value)
+		require.Negative(t, cookie.MaxAge)
+	}
+
+	recorder = serveWithCookies(t, server, http.MethodGet, "/auth",
+		cookies, "")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestParseSameSite(t *testing.T) {
+	sameSite, err := parseSameSite("")
+	require.NoError(t, err)
+	require.Equal(t, http.SameSiteLaxMode, sameSite)
+
+	sameSite, err = parseSameSite("None")
+	require.NoError(t, err)
+	require.Equal(t, http.SameSiteNoneMode, sameSite)
+
+	_, err = parseSameSite("sometimes")
+	require.Error(t, err)
+}
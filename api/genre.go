@@ -0,0 +1,180 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kratos69/movie-app/db/sqlc"
+)
+
+var errGenreNotFound = errors.New("genre not found")
+
+func (server *Server) listGenres(ctx *gin.Context) {
+	genres, err := server.store.ListGenres(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, genres)
+}
+
+type genreRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+}
+
+func (server *Server) createGenre(ctx *gin.Context) {
+	var req genreRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	genre, err := server.store.CreateGenre(ctx, req.Name)
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusConflict,
+				gin.H{"error": "genre already exists"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionGenreCreate, auditEntityGenre,
+		genre.GenreID, nil, genre)
+
+	ctx.JSON(http.StatusOK, genre)
+}
+
+type genreIDUri struct {
+	GenreID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// renames a genre
+func (server *Server) updateGenre(ctx *gin.Context) {
+	var uri genreIDUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var req genreRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, err := server.store.GetGenre(ctx, uri.GenreID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "genre not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	genre, err := server.store.UpdateGenre(ctx, db.UpdateGenreParams{
+		GenreID: uri.GenreID,
+		Name:    req.Name,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusConflict,
+				gin.H{"error": "genre already exists"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionGenreUpdate, auditEntityGenre,
+		genre.GenreID, before, genre)
+
+	ctx.JSON(http.StatusOK, genre)
+}
+
+// deletes a genre no movie uses
+func (server *Server) deleteGenre(ctx *gin.Context) {
+	var uri genreIDUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, err := server.store.GetGenre(ctx, uri.GenreID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "genre not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	count, err := server.store.CountMoviesWithGenre(ctx, uri.GenreID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if count > 0 {
+		ctx.JSON(http.StatusConflict,
+			gin.H{"error": "genre is still used by movies"})
+		return
+	}
+
+	err = server.store.DeleteGenre(ctx, uri.GenreID)
+	if err != nil {
+		// a movie picked the genre up in the meantime
+		if db.ErrorCode(err) == db.ForeignKeyViolation {
+			ctx.JSON(http.StatusConflict,
+				gin.H{"error": "genre is still used by movies"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionGenreDelete, auditEntityGenre,
+		before.GenreID, before, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "genre deleted"})
+}
+
+// ------------------------------------------------------------------//
+// ------------------------------Helper Funcs------------------------//
+// ------------------------------------------------------------------//
+
+// checks every genre exists, returns them with duplicates dropped
+func (server *Server) validateGenres(ctx *gin.Context,
+	genreIDs []int32) ([]int32, error) {
+	unique := slices.Clone(genreIDs)
+	slices.Sort(unique)
+	unique = slices.Compact(unique)
+
+	genres, err := server.store.ListGenresByIDs(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(genres) != len(unique) {
+		for _, genreID := range unique {
+			if !slices.ContainsFunc(genres, func(genre db.Genre) bool {
+				return genre.GenreID == genreID
+			}) {
+				return nil, fmt.Errorf("%w: %d", errGenreNotFound, genreID)
+			}
+		}
+	}
+
+	return unique, nil
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func (store *movieStore) ListGenres(ctx context.Context) ([]db.Genre, error) {
+	genres := []db.Genre{}
+	for _, genre := range store.genres {
+		genres = append(genres, genre)
+	}
+	slices.SortFunc(genres, func(a, b db.Genre) int {
+		return int(a.GenreID - b.GenreID)
+	})
+	return genres, nil
+}
+
+func (store *movieStore) GetGenre(ctx context.Context,
+	genreID int32) (db.Genre, error) {
+	genre, ok := store.genres[genreID]
+	if !ok {
+		return db.Genre{}, db.ErrRecordNotFound
+	}
+	return genre, nil
+}
+
+func (store *movieStore) CreateGenre(ctx context.Context,
+	name string) (db.Genre, error) {
+	genre := db.Genre{GenreID: int32(len(store.genres) + 1), Name: name}
+	store.genres[genre.GenreID] = genre
+	return genre, nil
+}
+
+func (store *movieStore) UpdateGenre(ctx context.Context,
+	arg db.UpdateGenreParams) (db.Genre, error) {
+	genre := db.Genre{GenreID: arg.GenreID, Name: arg.Name}
+	store.genres[genre.GenreID] = genre
+	return genre, nil
+}
+
+func (store *movieStore) CountMoviesWithGenre(ctx context.Context,
+	genreID int32) (int64, error) {
+	var count int64
+	for _, genreIDs := range store.movieGenres {
+		if slices.Contains(genreIDs, genreID) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (store *movieStore) DeleteGenre(ctx context.Context,
+	genreID int32) error {
+	delete(store.genres, genreID)
+	return nil
+}
+
+func TestManageGenres(t *testing.T) {
+	store := newMovieStore()
+	server := newTestServer(t, store)
+
+	store.movies[1] = db.Movie{MovieID: 1}
+	store.movieGenres[1] = []int32{1}
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+	customerToken, _, err := server.tokenMaker.CreateToken(
+		"alice", 2, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := serveWithToken(t, server, http.MethodPost, "/genres",
+		customerToken, map[string]any{"name": "Western"})
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodPost, "/genres",
+		adminToken, map[string]any{"name": "Western"})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "Western", store.genres[4].Name)
+
+	recorder = serveWithToken(t, server, http.MethodPut, "/genres/4",
+		adminToken, map[string]any{"name": "Spaghetti Western"})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "Spaghetti Western", store.genres[4].Name)
+
+	recorder = serveWithToken(t, server, http.MethodPut, "/genres/9",
+		adminToken, map[string]any{"name": "Musical"})
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// a movie still uses the genre
+	recorder = serveWithToken(t, server, http.MethodDelete, "/genres/1",
+		adminToken, nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+	require.Contains(t, store.genres, int32(1))
+
+	recorder = serveWithToken(t, server, http.MethodDelete, "/genres/4",
+		adminToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, store.genres, int32(4))
+
+	recorder = serveWithToken(t, server, http.MethodGet, "/genres", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var genres []db.Genre
+	err = json.Unmarshal(recorder.Body.Bytes(), &genres)
+	require.NoError(t, err)
+	require.Len(t, genres, 3)
+
+	require.Len(t, store.auditEvents, 3)
+	require.Equal(t, auditActionGenreCreate, store.auditEvents[0].Action)
+	require.Equal(t, auditActionGenreUpdate, store.auditEvents[1].Action)
+	require.Equal(t, auditActionGenreDelete, store.auditEvents[2].Action)
+}
+
+func TestListMoviesByGenre(t *testing.T) {
+	store := newMovieStore()
+	server := newTestServer(t, store)
+
+	store.movies[1] = db.Movie{MovieID: 1, Title: "Action"}
+	store.movies[2] = db.Movie{MovieID: 2, Title: "Drama"}
+	store.movies[3] = db.Movie{MovieID: 3, Title: "Action comedy"}
+	store.movieGenres[1] = []int32{1}
+	store.movieGenres[2] = []int32{2}
+	store.movieGenres[3] = []int32{1, 3}
+
+	testCases := []struct {
+		name   string
+		query  string
+		titles []string
+	}{
+		{name: "All", query: "", titles: []string{"Action", "Drama", "Action comedy"}},
+		{name: "OneGenre", query: "?genre_id=3", titles: []string{"Action comedy"}},
+		{name: "AnyGenre", query: "?genre_id=2&genre_id=3", titles: []string{"Drama", "Action comedy"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := serveWithToken(t, server, http.MethodGet,
+				"/movies"+tc.query, "", nil)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			var page pageResponse[movieResponse]
+			err := json.Unmarshal(recorder.Body.Bytes(), &page)
+			require.NoError(t, err)
+
+			titles := []string{}
+			for _, movie := range page.Data {
+				titles = append(titles, movie.Title)
+				require.NotEmpty(t, movie.Genres)
+			}
+			require.Equal(t, tc.titles, titles)
+		})
+	}
+
+	recorder := serveWithToken(t, server, http.MethodGet,
+		"/movies?genre_id=0", "", nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+)
+
+const (
+	guestReferenceLength = 8
+	guestTokenLength     = 32
+)
+
+// same answer for unknown references and wrong tokens
+var errGuestBookingNotFound = errors.New("booking not found")
+
+type reserveGuestSeatsRequest struct {
+	Name       string  `json:"name" binding:"required,max=100"`
+	Email      string  `json:"email" binding:"required,email"`
+	ShowtimeID int32   `json:"showtime_id" binding:"required"`
+	SeatIDs    []int32 `json:"seat_ids" binding:"required,min=1"`
+}
+
+// the lookup token is only ever shown here and in the confirmation email
+type reserveGuestSeatsResponse struct {
+	Reference    string           `json:"reference"`
+	LookupToken  string           `json:"lookup_token"`
+	Reservations []db.Reservation `json:"reservations"`
+}
+
+// books seats without an account, the guest gets a booking reference
+// and a secret token to look the booking up or cancel it later
+func (server *Server) reserveGuestSeats(ctx *gin.Context) {
+	var req reserveGuestSeatsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	reference, err := util.RandomSecureString(guestReferenceLength)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	reference = strings.ToUpper(reference)
+
+	lookupToken, err := util.RandomSecureString(guestTokenLength)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	result, err := server.store.ReserveGuestSeatsTx(ctx,
+		db.ReserveGuestSeatsTxParams{
+			CreateGuestBookingParams: db.CreateGuestBookingParams{
+				Reference:   reference,
+				HashedToken: hashSecret(lookupToken),
+				Email:       req.Email,
+				Name:        req.Name,
+			},
+			ShowtimeID: req.ShowtimeID,
+			SeatIDs:    req.SeatIDs,
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	server.sendGuestBookingEmail(result.GuestBooking, lookupToken)
+
+	ctx.JSON(http.StatusOK, reserveGuestSeatsResponse{
+		Reference:    result.GuestBooking.Reference,
+		LookupToken:  lookupToken,
+		Reservations: result.Reservations,
+	})
+}
+
+type guestBookingURI struct {
+	Reference string `uri:"reference" binding:"required,len=8"`
+}
+
+type guestBookingQuery struct {
+	Token string `form:"token" binding:"required,len=32"`
+}
+
+type guestBookingResponse struct {
+	Reference    string                                 `json:"reference"`
+	Name         string                                 `json:"name"`
+	Email        string                                 `json:"email"`
+	Claimed      bool                                   `json:"claimed"`
+	CreatedAt    time.Time                              `json:"created_at"`
+	Reservations []db.ListReservationsByGuestBookingRow `json:"reservations"`
+}
+
+func (server *Server) getGuestBooking(ctx *gin.Context) {
+	booking, ok := server.bindGuestBooking(ctx)
+	if !ok {
+		return
+	}
+
+	reservations, err := server.store.ListReservationsByGuestBooking(ctx,
+		booking.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError,
+			gin.H{"error": "could not fetch reservations"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, guestBookingResponse{
+		Reference:    booking.Reference,
+		Name:         booking.Name,
+		Email:        booking.Email,
+		Claimed:      booking.ClaimedBy.Valid,
+		CreatedAt:    booking.CreatedAt,
+		Reservations: reservations,
+	})
+}
+
+// cancels every seat of a guest booking
+func (server *Server) cancelGuestBooking(ctx *gin.Context) {
+	booking, ok := server.bindGuestBooking(ctx)
+	if !ok {
+		return
+	}
+
+	cancelled, err := server.store.CancelGuestBooking(ctx, booking.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if cancelled == 0 {
+		ctx.JSON(http.StatusNotFound,
+			gin.H{"error": "booking has no reservations left"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "reservation cancelled"})
+}
+
+// ------------------------------------------------------------------//
+// ------------------------------Helper Funcs------------------------//
+// ------------------------------------------------------------------//
+
+// loads the booking named in the url and checks its lookup token,
+// writing the error response itself when it returns false
+func (server *Server) bindGuestBooking(ctx *gin.Context) (db.GuestBooking,
+	bool) {
+	var uri guestBookingURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return db.GuestBooking{}, false
+	}
+
+	var req guestBookingQuery
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return db.GuestBooking{}, false
+	}
+
+	booking, err := server.store.GetGuestBookingByReference(ctx,
+		strings.ToUpper(uri.Reference))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errResponse(errGuestBookingNotFound))
+			return db.GuestBooking{}, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return db.GuestBooking{}, false
+	}
+
+	hashed := hashSecret(req.Token)
+	if subtle.ConstantTimeCompare([]byte(hashed),
+		[]byte(booking.HashedToken)) != 1 {
+		ctx.JSON(http.StatusNotFound, errResponse(errGuestBookingNotFound))
+		return db.GuestBooking{}, false
+	}
+
+	return booking, true
+}
+
+// emails the guest their booking reference and the link to manage it
+func (server *Server) sendGuestBookingEmail(booking db.GuestBooking,
+	lookupToken string) {
+	if server.mailer == nil {
+		return
+	}
+
+	bookingURL := fmt.Sprintf("%s/guest/reservations/%s?token=%s",
+		server.config.AppBaseURL, booking.Reference, lookupToken)
+	subject := fmt.Sprintf("Your booking %s", booking.Reference)
+	content := fmt.Sprintf(`Hello %s,<br/>
+	Thank you for your booking, your reference is <b>%s</b>.<br/>
+	You can <a href="%s">view or cancel it here</a>. Create an account
+	with this email address to keep all your bookings in one place.<br/>
+	`, html.EscapeString(booking.Name), booking.Reference, bookingURL)
+
+	// don't hold the booking response while talking to the mail server
+	go func() {
+		err := server.mailer.SendEmail(subject, content,
+			[]string{booking.Email})
+		if err != nil {
+			log.Printf("failed to send booking email for %s: %v\n",
+				booking.Reference, err)
+		}
+	}()
+}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+// guestStore keeps guest bookings and their reservations in memory
+type guestStore struct {
+	*userAdminStore
+	bookings     map[string]db.GuestBooking
+	reservations map[int64][]db.Reservation
+	verifyEmails map[int64]db.VerifyEmail
+}
+
+func newGuestStore() *guestStore {
+	return &guestStore{
+		userAdminStore: newUserAdminStore(),
+		bookings:       map[string]db.GuestBooking{},
+		reservations:   map[int64][]db.Reservation{},
+		verifyEmails:   map[int64]db.VerifyEmail{},
+	}
+}
+
+func (store *guestStore) ReserveGuestSeatsTx(ctx context.Context,
+	arg db.ReserveGuestSeatsTxParams) (db.ReserveGuestSeatsTxResult, error) {
+	booking := db.GuestBooking{
+		ID:          int64(len(store.bookings) + 1),
+		Reference:   arg.Reference,
+		HashedToken: arg.HashedToken,
+		Email:       arg.Email,
+		Name:        arg.Name,
+		CreatedAt:   time.Now(),
+	}
+	store.bookings[booking.Reference] = booking
+
+	for _, seatID := range arg.SeatIDs {
+		store.reservations[booking.ID] = append(store.reservations[booking.ID],
+			db.Reservation{
+				ReservationID:  int64(seatID),
+				GuestBookingID: pgtype.Int8{Int64: booking.ID, Valid: true},
+				ShowtimeID:     arg.ShowtimeID,
+				SeatID:         seatID,
+			})
+	}
+
+	return db.ReserveGuestSeatsTxResult{
+		GuestBooking: booking,
+		Reservations: store.reservations[booking.ID],
+	}, nil
+}
+
+func (store *guestStore) GetGuestBookingByReference(ctx context.Context,
+	reference string) (db.GuestBooking, error) {
+	booking, ok := store.bookings[reference]
+	if !ok {
+		return db.GuestBooking{}, db.ErrRecordNotFound
+	}
+	return booking, nil
+}
+
+func (store *guestStore) ListReservationsByGuestBooking(ctx context.Context,
+	guestBookingID int64) ([]db.ListReservationsByGuestBookingRow, error) {
+	rows := []db.ListReservationsByGuestBookingRow{}
+	for _, res := range store.reservations[guestBookingID] {
+		rows = append(rows, db.ListReservationsByGuestBookingRow{
+			ReservationID: res.ReservationID,
+			ShowtimeID:    res.ShowtimeID,
+			SeatID:        res.SeatID,
+		})
+	}
+	return rows, nil
+}
+
+func (store *guestStore) CancelGuestBooking(ctx context.Context,
+	guestBookingID int64) (int64, error) {
+	cancelled := len(store.reservations[guestBookingID])
+	delete(store.reservations, guestBookingID)
+	return int64(cancelled), nil
+}
+
+func (store *guestStore) ClaimGuestBookingsTx(ctx context.Context,
+	arg db.ClaimGuestBookingsParams) ([]db.GuestBooking, error) {
+	claimed := []db.GuestBooking{}
+	for reference, booking := range store.bookings {
+		if booking.Email != arg.Email || booking.ClaimedBy.Valid {
+			continue
+		}
+		booking.ClaimedBy = pgtype.Int8{Int64: arg.UserID, Valid: true}
+		store.bookings[reference] = booking
+		claimed = append(claimed, booking)
+	}
+	return claimed, nil
+}
+
+func (store *guestStore) VerifyEmailTx(ctx context.Context,
+	arg db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error) {
+	verifyEmail, ok := store.verifyEmails[arg.EmailID]
+	if !ok || verifyEmail.IsUsed || verifyEmail.SecretCode != arg.SecretCode {
+		return db.VerifyEmailTxResult{}, db.ErrRecordNotFound
+	}
+	verifyEmail.IsUsed = true
+	store.verifyEmails[arg.EmailID] = verifyEmail
+
+	user := store.users[verifyEmail.UserID]
+	user.IsEmailVerified = true
+	store.users[user.UserID] = user
+
+	claimed, err := store.ClaimGuestBookingsTx(ctx, db.ClaimGuestBookingsParams{
+		UserID: user.UserID,
+		Email:  user.Email,
+	})
+	return db.VerifyEmailTxResult{
+		User:            user,
+		VerifyEmail:     verifyEmail,
+		ClaimedBookings: claimed,
+	}, err
+}
+
+func serveGuestRequest(t *testing.T, server *Server, method string,
+	path string, body any) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(method, path, bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestGuestBooking(t *testing.T) {
+	store := newGuestStore()
+	server := newTestServer(t, store)
+
+	recorder := serveGuestRequest(t, server, http.MethodPost,
+		"/guest/reservations", gin.H{
+			"name":        "Guest",
+			"email":       "not-an-email",
+			"showtime_id": 1,
+			"seat_ids":    []int32{4, 5},
+		})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveGuestRequest(t, server, http.MethodPost,
+		"/guest/reservations", gin.H{
+			"name":        "Guest",
+			"email":       "guest@email.com",
+			"showtime_id": 1,
+			"seat_ids":    []int32{4, 5},
+		})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var created reserveGuestSeatsResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &created)
+	require.NoError(t, err)
+	require.Len(t, created.Reference, guestReferenceLength)
+	require.Len(t, created.LookupToken, guestTokenLength)
+	require.Len(t, created.Reservations, 2)
+
+	// only the hash of the token is stored
+	booking := store.bookings[created.Reference]
+	require.NotEqual(t, created.LookupToken, booking.HashedToken)
+
+	bookingPath := "/guest/reservations/" + created.Reference
+
+	recorder = serveGuestRequest(t, server, http.MethodGet,
+		bookingPath+"?token="+created.LookupToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var got guestBookingResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &got)
+	require.NoError(t, err)
+	require.Equal(t, "guest@email.com", got.Email)
+	require.Len(t, got.Reservations, 2)
+
+	recorder = serveGuestRequest(t, server, http.MethodGet,
+		bookingPath+"?token="+util.RandomString(guestTokenLength), nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveGuestRequest(t, server, http.MethodGet,
+		"/guest/reservations/AAAAAAAA?token="+created.LookupToken, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveGuestRequest(t, server, http.MethodGet, bookingPath, nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveGuestRequest(t, server, http.MethodDelete,
+		bookingPath+"?token="+created.LookupToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Empty(t, store.reservations[booking.ID])
+
+	recorder = serveGuestRequest(t, server, http.MethodDelete,
+		bookingPath+"?token="+created.LookupToken, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestVerifyEmailClaimsGuestBookings(t *testing.T) {
+	store := newGuestStore()
+	server := newTestServer(t, store)
+
+	store.bookings["ABCDEFGH"] = db.GuestBooking{
+		ID: 1, Reference: "ABCDEFGH", Email: "alice@email.com",
+	}
+	store.bookings["HGFEDCBA"] = db.GuestBooking{
+		ID: 2, Reference: "HGFEDCBA", Email: "bob@email.com",
+	}
+	secretCode := util.RandomString(32)
+	store.verifyEmails[1] = db.VerifyEmail{
+		ID: 1, UserID: 2, Email: "alice@email.com", SecretCode: secretCode,
+	}
+
+	recorder := serveGuestRequest(t, server, http.MethodGet,
+		"/users/verify_email?email_id=1&secret_code="+util.RandomString(32),
+		nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveGuestRequest(t, server, http.MethodGet,
+		"/users/verify_email?email_id=1&secret_code="+secretCode, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp verifyEmailResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.True(t, resp.User.IsEmailVerified)
+	require.Equal(t, []string{"ABCDEFGH"}, resp.ClaimedBookings)
+	require.Equal(t, int64(2), store.bookings["ABCDEFGH"].ClaimedBy.Int64)
+	require.False(t, store.bookings["HGFEDCBA"].ClaimedBy.Valid)
+}
+
+func TestClaimBookings(t *testing.T) {
+	store := newGuestStore()
+	server := newTestServer(t, store)
+
+	store.bookings["HGFEDCBA"] = db.GuestBooking{
+		ID: 1, Reference: "HGFEDCBA", Email: "bob@email.com",
+	}
+
+	accessToken, _, err := server.tokenMaker.CreateToken(
+		"bob", 3, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	// an unverified email proves nothing about the guest bookings
+	recorder := serveWithToken(t, server, http.MethodPost,
+		"/users/me/claim_bookings", accessToken, nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.False(t, store.bookings["HGFEDCBA"].ClaimedBy.Valid)
+
+	user := store.users[3]
+	user.IsEmailVerified = true
+	store.users[3] = user
+
+	recorder = serveWithToken(t, server, http.MethodPost,
+		"/users/me/claim_bookings", accessToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "HGFEDCBA")
+	require.Equal(t, int64(3), store.bookings["HGFEDCBA"].ClaimedBy.Int64)
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+)
+
+const (
+	loginScopeAccount = "account"
+	loginScopeIP      = "ip"
+
+	// failures allowed before backoff kicks in
+	loginFreeAttempts = 3
+	loginBackoffBase  = time.Second
+
+	defaultLoginMaxAttempts     = 10
+	defaultLoginMaxIPAttempts   = 50
+	defaultLoginLockoutDuration = 15 * time.Minute
+)
+
+var (
+	// same error for unknown emails and wrong passwords, so the
+	// api doesn't reveal which emails have an account
+	errInvalidCredentials   = errors.New("invalid email or password")
+	errTooManyLoginAttempts = errors.New(
+		"too many failed login attempts, try again later")
+)
+
+var (
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
+)
+
+// compares the password against a throwaway hash so requests for
+// unknown emails take as long as the ones for existing accounts
+func (server *Server) checkDummyPassword(password string) {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = server.passwords.Hash(util.RandomString(16))
+	})
+	_ = util.CheckPassword(password, dummyPasswordHash)
+}
+
+// returns how long a key must wait after its n-th consecutive failure,
+// growing exponentially until maxAttempts locks it for lockout
+func loginDelay(failures int, maxAttempts int,
+	lockout time.Duration) time.Duration {
+	if failures >= maxAttempts {
+		return lockout
+	}
+	if failures < loginFreeAttempts {
+		return 0
+	}
+
+	delay := loginBackoffBase << (failures - loginFreeAttempts)
+	if delay > lockout {
+		delay = lockout
+	}
+	return delay
+}
+
+func (server *Server) loginMaxAttempts() int {
+	if server.config.LoginMaxAttempts > 0 {
+		return server.config.LoginMaxAttempts
+	}
+	return defaultLoginMaxAttempts
+}
+
+func (server *Server) loginMaxIPAttempts() int {
+	if server.config.LoginMaxIPAttempts > 0 {
+		return server.config.LoginMaxIPAttempts
+	}
+	return defaultLoginMaxIPAttempts
+}
+
+func (server *Server) loginLockoutDuration() time.Duration {
+	if server.config.LoginLockoutDuration > 0 {
+		return server.config.LoginLockoutDuration
+	}
+	return defaultLoginLockoutDuration
+}
+
+func loginAccountKey(email string) string {
+	return strings.ToLower(email)
+}
+
+// returns how long the caller has to wait before the next attempt
+// for this email from this ip, zero if they may try right away
+func (server *Server) loginRetryAfter(ctx context.Context, email string,
+	clientIP string) (time.Duration, error) {
+	var wait time.Duration
+
+	keys := map[string]string{
+		loginScopeAccount: loginAccountKey(email),
+		loginScopeIP:      clientIP,
+	}
+	for scope, key := range keys {
+		throttle, err := server.store.GetLoginThrottle(ctx,
+			db.GetLoginThrottleParams{
+				Scope:       scope,
+				ThrottleKey: key,
+			})
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				continue
+			}
+			return 0, err
+		}
+
+		if remaining := time.Until(throttle.LockedUntil); remaining > wait {
+			wait = remaining
+		}
+	}
+
+	return wait, nil
+}
+
+// counts a failed login against the email and the ip, user is nil
+// when no account exists for the email
+func (server *Server) recordLoginFailure(ctx context.Context, email string,
+	clientIP string, user *db.User) error {
+	lockout := server.loginLockoutDuration()
+
+	limits := []struct {
+		scope       string
+		key         string
+		maxAttempts int
+	}{
+		{loginScopeAccount, loginAccountKey(email), server.loginMaxAttempts()},
+		{loginScopeIP, clientIP, server.loginMaxIPAttempts()},
+	}
+
+	for _, limit := range limits {
+		throttle, err := server.store.RecordLoginFailure(ctx,
+			db.RecordLoginFailureParams{
+				Scope:       limit.scope,
+				ThrottleKey: limit.key,
+				ResetBefore: time.Now().Add(-lockout),
+			})
+		if err != nil {
+			return err
+		}
+
+		failures := int(throttle.FailedAttempts)
+		delay := loginDelay(failures, limit.maxAttempts, lockout)
+		if delay == 0 {
+			continue
+		}
+
+		err = server.store.LockLoginThrottle(ctx, db.LockLoginThrottleParams{
+			Scope:       limit.scope,
+			ThrottleKey: limit.key,
+			LockedUntil: time.Now().Add(delay),
+		})
+		if err != nil {
+			return err
+		}
+
+		if limit.scope == loginScopeAccount && user != nil &&
+			failures == limit.maxAttempts {
+			err = server.sendUnlockEmail(ctx, *user)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// answers a login attempt made while the caller is locked out
+func rejectThrottledLogin(ctx *gin.Context, retryAfter time.Duration) {
+	ctx.Header("Retry-After",
+		strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	ctx.JSON(http.StatusTooManyRequests, errResponse(errTooManyLoginAttempts))
+}
+
+// clears the failed attempts of an account
+func (server *Server) unlockLogin(ctx context.Context, email string) error {
+	return server.store.DeleteLoginThrottle(ctx, db.DeleteLoginThrottleParams{
+		Scope:       loginScopeAccount,
+		ThrottleKey: loginAccountKey(email),
+	})
+}
+
+// emails the user a link that lifts the lockout of their account
+func (server *Server) sendUnlockEmail(ctx context.Context, user db.User) error {
+	if server.mailer == nil {
+		return nil
+	}
+
+	secretCode, err := util.RandomSecureString(32)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := server.store.CreateAccountUnlock(ctx,
+		db.CreateAccountUnlockParams{
+			UserID:     user.UserID,
+			Email:      user.Email,
+			SecretCode: secretCode,
+		})
+	if err != nil {
+		return err
+	}
+
+	unlockURL := fmt.Sprintf("%s/users/unlock?id=%d&secret_code=%s",
+		server.config.AppBaseURL, unlock.ID, unlock.SecretCode)
+	subject := "Your account has been locked"
+	content := fmt.Sprintf(`Hello %s,<br/>
+	We noticed several failed sign in attempts on your account, so it has
+	been locked for a while.<br/>
+	If this was you, please <a href="%s">click here</a> to unlock it.<br/>
+	`, user.Name, unlockURL)
+
+	// don't hold the login response while talking to the mail server
+	go func() {
+		err := server.mailer.SendEmail(subject, content,
+			[]string{user.Email})
+		if err != nil {
+			log.Printf("failed to send unlock email to user %d: %v\n",
+				user.UserID, err)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,319 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+// unlockStore holds the unlock codes sent by email
+type unlockStore struct {
+	db.Store
+	unlocks  map[int64]db.AccountUnlock
+	unlocked []string
+}
+
+func (store *unlockStore) UseAccountUnlock(ctx context.Context,
+	arg db.UseAccountUnlockParams) (db.AccountUnlock, error) {
+	unlock, ok := store.unlocks[arg.ID]
+	if !ok || unlock.IsUsed || unlock.SecretCode != arg.SecretCode {
+		return db.AccountUnlock{}, db.ErrRecordNotFound
+	}
+
+	unlock.IsUsed = true
+	store.unlocks[arg.ID] = unlock
+	return unlock, nil
+}
+
+func (store *unlockStore) DeleteLoginThrottle(ctx context.Context,
+	arg db.DeleteLoginThrottleParams) error {
+	store.unlocked = append(store.unlocked, arg.ThrottleKey)
+	return nil
+}
+
+// throttleStore keeps login throttles in memory
+type throttleStore struct {
+	db.Store
+	users     map[int64]db.User
+	throttles map[string]db.LoginThrottle
+}
+
+func newThrottleStore() *throttleStore {
+	return &throttleStore{
+		users:     map[int64]db.User{},
+		throttles: map[string]db.LoginThrottle{},
+	}
+}
+
+func (store *throttleStore) GetUserByEmail(ctx context.Context,
+	email string) (db.User, error) {
+	for _, user := range store.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return db.User{}, db.ErrRecordNotFound
+}
+
+func (store *throttleStore) GetLoginThrottle(ctx context.Context,
+	arg db.GetLoginThrottleParams) (db.LoginThrottle, error) {
+	throttle, ok := store.throttles[arg.Scope+"/"+arg.ThrottleKey]
+	if !ok {
+		return db.LoginThrottle{}, db.ErrRecordNotFound
+	}
+	return throttle, nil
+}
+
+func (store *throttleStore) RecordLoginFailure(ctx context.Context,
+	arg db.RecordLoginFailureParams) (db.LoginThrottle, error) {
+	key := arg.Scope + "/" + arg.ThrottleKey
+	throttle := store.throttles[key]
+	throttle.Scope = arg.Scope
+	throttle.ThrottleKey = arg.ThrottleKey
+	throttle.FailedAttempts++
+	throttle.LastFailedAt = time.Now()
+	store.throttles[key] = throttle
+	return throttle, nil
+}
+
+func (store *throttleStore) LockLoginThrottle(ctx context.Context,
+	arg db.LockLoginThrottleParams) error {
+	key := arg.Scope + "/" + arg.ThrottleKey
+	throttle := store.throttles[key]
+	throttle.LockedUntil = arg.LockedUntil
+	store.throttles[key] = throttle
+	return nil
+}
+
+func (store *throttleStore) DeleteLoginThrottle(ctx context.Context,
+	arg db.DeleteLoginThrottleParams) error {
+	delete(store.throttles, arg.Scope+"/"+arg.ThrottleKey)
+	return nil
+}
+
+// logs in from 10.0.0.1, claiming to forward for forwardedFor
+func loginFrom(t *testing.T, server *Server, forwardedFor string,
+	body gin.H) *httptest.ResponseRecorder {
+	return postFrom(t, server, "/users/login", forwardedFor, body)
+}
+
+// posts body to path from 10.0.0.1, claiming to forward for forwardedFor
+func postFrom(t *testing.T, server *Server, path string,
+	forwardedFor string, body gin.H) *httptest.ResponseRecorder {
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, path,
+		bytes.NewReader(data))
+	require.NoError(t, err)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("X-Forwarded-For", forwardedFor)
+
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestLoginThrottleIgnoresForwardedFor(t *testing.T) {
+	store := newThrottleStore()
+	server := newTestServer(t, store)
+
+	// a new email and a new forged ip every time
+	for i := 0; i < loginFreeAttempts; i++ {
+		recorder := loginFrom(t, server, fmt.Sprintf("203.0.113.%d", i),
+			gin.H{"email": fmt.Sprintf("user%d@email.com", i), "password": "x"})
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	}
+
+	recorder := loginFrom(t, server, "198.51.100.1",
+		gin.H{"email": "someone@email.com", "password": "x"})
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Contains(t, store.throttles, loginScopeIP+"/10.0.0.1")
+}
+
+func TestLoginDelay(t *testing.T) {
+	lockout := 15 * time.Minute
+
+	testCases := []struct {
+		name     string
+		failures int
+		delay    time.Duration
+	}{
+		{name: "FirstFailure", failures: 1, delay: 0},
+		{name: "LastFreeFailure", failures: loginFreeAttempts - 1, delay: 0},
+		{name: "FirstBackoff", failures: loginFreeAttempts, delay: time.Second},
+		{name: "Doubling", failures: loginFreeAttempts + 2, delay: 4 * time.Second},
+		{name: "Lockout", failures: 10, delay: lockout},
+		{name: "PastLockout", failures: 25, delay: lockout},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.delay, loginDelay(tc.failures, 10, lockout))
+		})
+	}
+}
+
+func TestLoginDelayCapped(t *testing.T) {
+	lockout := 5 * time.Second
+	require.Equal(t, lockout, loginDelay(40, 100, lockout))
+}
+
+func TestUnlockAccount(t *testing.T) {
+	secretCode := util.RandomString(32)
+	store := &unlockStore{
+		unlocks: map[int64]db.AccountUnlock{
+			1: {ID: 1, UserID: 2, Email: "alice@email.com", SecretCode: secretCode},
+		},
+	}
+	server := newTestServer(t, store)
+
+	form := url.Values{}
+	form.Set("id", "1")
+	form.Set("secret_code", secretCode)
+
+	// opening the link only shows a page that submits the code
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet,
+		"/users/unlock?"+form.Encode(), nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Header().Get("Content-Type"), "text/html")
+	require.Contains(t, recorder.Body.String(), `method="post"`)
+	require.Contains(t, recorder.Body.String(), secretCode)
+	require.False(t, store.unlocks[1].IsUsed)
+	require.Empty(t, store.unlocked)
+
+	submit := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, "/users/unlock",
+			strings.NewReader(form.Encode()))
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder = submit()
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.True(t, store.unlocks[1].IsUsed)
+	require.Equal(t, []string{loginAccountKey("alice@email.com")},
+		store.unlocked)
+
+	// the code only works once
+	recorder = submit()
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+// twoFactorStore adds login challenges to throttleStore
+type twoFactorStore struct {
+	*throttleStore
+	challenges map[uuid.UUID]db.LoginChallenge
+}
+
+func (store *twoFactorStore) GetUserByID(ctx context.Context,
+	userID int64) (db.User, error) {
+	user, ok := store.users[userID]
+	if !ok {
+		return db.User{}, db.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (store *twoFactorStore) CreateLoginChallenge(ctx context.Context,
+	arg db.CreateLoginChallengeParams) (db.LoginChallenge, error) {
+	challenge := db.LoginChallenge{
+		ID:        arg.ID,
+		UserID:    arg.UserID,
+		ExpiredAt: arg.ExpiredAt,
+	}
+	store.challenges[arg.ID] = challenge
+	return challenge, nil
+}
+
+func (store *twoFactorStore) IncrementLoginChallengeAttempts(
+	ctx context.Context, id uuid.UUID) (db.LoginChallenge, error) {
+	challenge, ok := store.challenges[id]
+	if !ok {
+		return db.LoginChallenge{}, db.ErrRecordNotFound
+	}
+	challenge.Attempts++
+	store.challenges[id] = challenge
+	return challenge, nil
+}
+
+func (store *twoFactorStore) DeleteLoginChallenge(ctx context.Context,
+	id uuid.UUID) error {
+	delete(store.challenges, id)
+	return nil
+}
+
+func (store *twoFactorStore) UseRecoveryCode(ctx context.Context,
+	arg db.UseRecoveryCodeParams) (db.RecoveryCode, error) {
+	return db.RecoveryCode{}, db.ErrRecordNotFound
+}
+
+func TestVerifyLoginCountsFailures(t *testing.T) {
+	store := &twoFactorStore{
+		throttleStore: newThrottleStore(),
+		challenges:    map[uuid.UUID]db.LoginChallenge{},
+	}
+	server := newTestServer(t, store)
+
+	password := util.RandomString(8)
+	hashedPassword, err := server.passwords.Hash(password)
+	require.NoError(t, err)
+	secret, err := util.GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	store.users[1] = db.User{
+		UserID:         1,
+		Email:          "alice@email.com",
+		HashedPassword: hashedPassword,
+		TotpEnabled:    true,
+		TotpSecret:     secret,
+	}
+	login := gin.H{"email": "alice@email.com", "password": password}
+
+	// the right password hands out a new challenge each time, that must
+	// not give a fresh set of guesses at the code
+	for i := 0; i < loginFreeAttempts; i++ {
+		recorder := loginFrom(t, server, "", login)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var challenge loginChallengeResponse
+		err = json.Unmarshal(recorder.Body.Bytes(), &challenge)
+		require.NoError(t, err)
+		require.True(t, challenge.TwoFactorRequired)
+
+		recorder = postFrom(t, server, "/users/login/verify", "", gin.H{
+			"challenge_token": challenge.ChallengeToken,
+			"code":            "not-a-code",
+		})
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	}
+
+	throttle := store.throttles[loginScopeAccount+"/alice@email.com"]
+	require.EqualValues(t, loginFreeAttempts, throttle.FailedAttempts)
+
+	recorder := loginFrom(t, server, "", login)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+}
@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/kratos69/movie-app/token"
 )
 
 const (
@@ -16,11 +15,24 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
-// returns auth middlware function
-func authMiddleware(tokenMaker token.Maker,
-	accessibleRoles []string) gin.HandlerFunc {
+// returns auth middlware function, the caller's role must grant
+// every one of requiredPermissions (none means any logged in user)
+func (server *Server) authMiddleware(
+	requiredPermissions ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
+
+		// browsers in cookie session mode send the token as a cookie
+		cookieToken := server.sessionCookie(ctx, accessTokenCookie)
+		if len(authorizationHeader) == 0 && cookieToken != "" {
+			if !checkCSRF(ctx) {
+				ctx.AbortWithStatusJSON(http.StatusForbidden,
+					errResponse(errInvalidCSRFToken))
+				return
+			}
+			authorizationHeader = authorizationTypeBearer + " " + cookieToken
+		}
+
 		if len(authorizationHeader) == 0 {
 			err := errors.New("authorization header is not provided")
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, 
@@ -38,6 +50,11 @@ func authMiddleware(tokenMaker token.Maker,
 
 		// first value of header is auth-type
 		authorizationType := strings.ToLower(fields[0])
+		if authorizationType == authorizationTypeAPIKey {
+			server.authenticateAPIKey(ctx, fields[1], requiredPermissions)
+			return
+		}
+
 		if authorizationType != authorizationTypeBearer {
 			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(err))
@@ -46,19 +63,60 @@ func authMiddleware(tokenMaker token.Maker,
 
 		// second value of header is token
 		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		payload, err := server.tokenMaker.VerifyToken(accessToken)
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(err))
 			return
 		}
 
+		// logged out, password changed or role changed since
+		revoked, err := server.isTokenRevoked(ctx, payload)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError,
+				errResponse(err))
+			return
+		}
+		if revoked {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized,
+				errResponse(errTokenRevoked))
+			return
+		}
+
 		// store payload in key
 		ctx.Set(authorizationPayloadKey, payload)
 
-		if !hasPermissions(payload.Role, accessibleRoles) {
-			err := fmt.Errorf("permission denied")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(err))
-			return
+		if len(requiredPermissions) > 0 {
+			// permissions are looked up on every request so role
+			// changes apply without waiting for tokens to expire
+			granted, err := server.store.ListRolePermissions(ctx, payload.Role)
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError,
+					errResponse(err))
+				return
+			}
+
+			if !hasPermissions(granted, requiredPermissions) {
+				err := fmt.Errorf("permission denied")
+				ctx.AbortWithStatusJSON(http.StatusForbidden, errResponse(err))
+				return
+			}
+
+			// privileged routes stay closed to enforced roles
+			// until two factor authentication is turned on
+			if server.requiresTwoFactor(payload.Role) {
+				user, err := server.store.GetUserByID(ctx, payload.UserID)
+				if err != nil {
+					ctx.AbortWithStatusJSON(http.StatusInternalServerError,
+						errResponse(err))
+					return
+				}
+
+				if !user.TotpEnabled {
+					ctx.AbortWithStatusJSON(http.StatusForbidden,
+						errResponse(errTwoFactorRequired))
+					return
+				}
+			}
 		}
 
 		// forward the request to the next handler
@@ -66,11 +124,20 @@ func authMiddleware(tokenMaker token.Maker,
 	}
 }
 
-func hasPermissions(userRole string, accessibleRoles []string) bool {
-	for _, role := range accessibleRoles {
-		if userRole == role {
-			return true
+// checks that every required permission is among the granted ones
+func hasPermissions(granted []string, required []string) bool {
+	for _, permission := range required {
+		found := false
+		for _, g := range granted {
+			if g == permission {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
 		}
 	}
-	return false
+	return true
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	db "github.com/kratos69/movie-app/db/sqlc"
 	"github.com/kratos69/movie-app/token"
 	"github.com/kratos69/movie-app/util"
 	"github.com/stretchr/testify/require"
@@ -96,7 +98,7 @@ func TestAuthMiddleware(t *testing.T) {
 			authPath := "/auth"
 			server.router.GET(
 				authPath,
-				authMiddleware(server.tokenMaker, []string{util.AdminRole, util.CustomerRole}),
+				server.authMiddleware(),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -112,3 +114,164 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+// permissionStore only answers role permission and user lookups
+type permissionStore struct {
+	db.Store
+	rolePermissions map[string][]string
+	users           map[int64]db.User
+}
+
+func (store *permissionStore) ListRolePermissions(ctx context.Context,
+	role string) ([]string, error) {
+	return store.rolePermissions[role], nil
+}
+
+func (store *permissionStore) GetUserByID(ctx context.Context,
+	userID int64) (db.User, error) {
+	user, ok := store.users[userID]
+	if !ok {
+		return db.User{}, db.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func TestAuthMiddlewarePermissions(t *testing.T) {
+	store := &permissionStore{
+		rolePermissions: map[string][]string{
+			util.CustomerRole: {util.ReservationsWritePermission},
+			util.AdminRole: {
+				util.ReservationsWritePermission,
+				util.MoviesWritePermission,
+			},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		role          string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Granted",
+			role: util.AdminRole,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Denied",
+			role: util.CustomerRole,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownRole",
+			role: "ghost",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, store)
+
+			authPath := "/auth"
+			server.router.GET(
+				authPath,
+				server.authMiddleware(util.MoviesWritePermission),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker,
+				authorizationTypeBearer, "user", 100, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestAuthMiddlewareTwoFactor(t *testing.T) {
+	store := &permissionStore{
+		rolePermissions: map[string][]string{
+			util.AdminRole: {util.MoviesWritePermission},
+		},
+		users: map[int64]db.User{
+			1: {UserID: 1, Role: util.AdminRole, TotpEnabled: true},
+			2: {UserID: 2, Role: util.AdminRole, TotpEnabled: false},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		userID        int64
+		enforce       bool
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "Enabled",
+			userID:  1,
+			enforce: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "NotEnabled",
+			userID:  2,
+			enforce: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:    "NotEnforced",
+			userID:  2,
+			enforce: false,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, store)
+			if tc.enforce {
+				server.config.TwoFactorRoles = []string{util.AdminRole}
+			}
+
+			authPath := "/auth"
+			server.router.GET(
+				authPath,
+				server.authMiddleware(util.MoviesWritePermission),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker,
+				authorizationTypeBearer, "user", tc.userID, util.AdminRole,
+				time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
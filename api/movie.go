@@ -1,44 +1,63 @@
 package api
 
 import (
-	"database/sql"
-	"log"
+	"errors"
 	"net/http"
-	"path/filepath"
-	"strconv"
-	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/kratos69/movie-app/db/sqlc"
 	"github.com/kratos69/movie-app/util"
 )
 
+// a movie as the API returns it, with its genres
+type movieResponse struct {
+	db.Movie
+	Genres []db.Genre `json:"genres"`
+}
+
+func newMovieResponse(movie db.Movie, genres []db.Genre) movieResponse {
+	return movieResponse{Movie: movie, Genres: genres}
+}
+
+// a single movie also lists its cast and crew
+type movieDetailResponse struct {
+	movieResponse
+	Credits []db.ListMovieCreditsRow `json:"credits"`
+}
+
+type createMovieRequest struct {
+	Title            string    `form:"title" binding:"required"`
+	Description      string    `form:"description" binding:"required"`
+	GenreIDs         []int32   `form:"genre_ids" binding:"required,min=1,dive,min=1"`
+	RuntimeMinutes   int32     `form:"runtime_minutes" binding:"min=0,max=1440"`
+	Certification    string    `form:"certification" binding:"max=16"`
+	ReleaseDate      time.Time `form:"release_date" time_format:"2006-01-02"`
+	OriginalLanguage string    `form:"original_language" binding:"omitempty,bcp47_language_tag"`
+	TrailerURL       string    `form:"trailer_url" binding:"omitempty,http_url"`
+	Status           string    `form:"status" binding:"omitempty,oneof=draft coming_soon now_showing archived"`
+}
+
 // to create a movie in database
 func (server *Server) createMovie(ctx *gin.Context) {
-	title := ctx.PostForm("title")
-	description := ctx.PostForm("description")
-	strGenreID := ctx.PostForm("genre_id")
-
-	if title == "" || description == "" || strGenreID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"message": "title, description & genre_id are required",
-		})
+	var req createMovieRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
 		return
 	}
 
-	genreID, err := strconv.Atoi(strGenreID)
+	genreIDs, err := server.validateGenres(ctx, req.GenreIDs)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "genre_id must be an integer"})
+		if errors.Is(err, errGenreNotFound) {
+			ctx.JSON(http.StatusBadRequest, errResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
 		return
 	}
 
-	// (Optional) Check if genre exists
-	// _, err = server.store.gen(ctx, int32(genreID))
-	// if err != nil {
-	// 	ctx.JSON(http.StatusBadRequest, gin.H{"error": "genre not found"})
-	// 	return
-	// }
-
 	posterURL := ""
 	fileHeader, _ := ctx.FormFile("poster_url")
 	if fileHeader != nil {
@@ -49,68 +68,120 @@ func (server *Server) createMovie(ctx *gin.Context) {
 		}
 	}
 
-	arg := db.CreateMovieParams{
-		Title:       title,
-		Description: description,
-		PosterUrl:   posterURL,
-		GenreID:     int32(genreID),
+	releaseDate := pgtype.Date{
+		Time:  req.ReleaseDate,
+		Valid: !req.ReleaseDate.IsZero(),
 	}
 
-	movie, err := server.store.CreateMovie(ctx, arg)
+	status := req.Status
+	if status == "" {
+		status = defaultMovieStatus(releaseDate)
+	}
+
+	arg := db.CreateMovieTxParams{
+		CreateMovieParams: db.CreateMovieParams{
+			Title:            req.Title,
+			Description:      req.Description,
+			PosterUrl:        posterURL,
+			RuntimeMinutes:   req.RuntimeMinutes,
+			Certification:    req.Certification,
+			ReleaseDate:      releaseDate,
+			OriginalLanguage: req.OriginalLanguage,
+			TrailerUrl:       req.TrailerURL,
+			Status:           status,
+		},
+		GenreIDs: genreIDs,
+	}
+
+	result, err := server.store.CreateMovieTx(ctx, arg)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
 		return
 	}
 
+	movie := newMovieResponse(result.Movie, result.Genres)
+	server.recordAudit(ctx, auditActionMovieCreate, auditEntityMovie,
+		movie.MovieID, nil, movie)
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "movie created successfully",
 		"data":    movie,
 	})
 }
 
+// orders the movie list can be sorted in
+const (
+	movieSortNewest      = "newest"
+	movieSortTitle       = "title"
+	movieSortReleaseDate = "release_date"
+	movieSortPopularity  = "popularity"
+)
 
-// Page 1 (first 50 movies): LIMIT 50 OFFSET 0
-// Page 2 (next 50):→ LIMIT 50 OFFSET 50
-// Page 3 (next 50):→ LIMIT 50 OFFSET 100
-// Offset = limit * (page - 1)
-func (server *Server) listAllMovies(ctx *gin.Context) {
-	// get query params (e.g., ?page=1&limit=50)
-	pageStr := ctx.DefaultQuery("page", "1")
-	limitStr := ctx.DefaultQuery("limit", "50")
+// every filter is optional, genre_id can be repeated to match any of the
+// genres and the date ranges include both ends
+type listMoviesFilter struct {
+	GenreIDs      []int32   `form:"genre_id" binding:"omitempty,dive,min=1"`
+	Certification string    `form:"certification" binding:"max=16"`
+	Language      string    `form:"language" binding:"omitempty,bcp47_language_tag"`
+	NowShowing    bool      `form:"now_showing"`
+	ComingSoon    bool      `form:"coming_soon"`
+	ReleasedFrom  time.Time `form:"released_from" time_format:"2006-01-02"`
+	ReleasedTo    time.Time `form:"released_to" time_format:"2006-01-02"`
+	ShowingFrom   time.Time `form:"showing_from" time_format:"2006-01-02"`
+	ShowingTo     time.Time `form:"showing_to" time_format:"2006-01-02"`
+	Sort          string    `form:"sort" binding:"omitempty,oneof=newest title release_date popularity"`
+}
 
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page < 1 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid page number"})
+type listMoviesRequest struct {
+	pageRequest
+	listMoviesFilter
+}
+
+// lists movies a page at a time, pass next_cursor back as cursor for the
+// next page, with the same filters and sort
+func (server *Server) listAllMovies(ctx *gin.Context) {
+	var req listMoviesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
 		return
 	}
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 || limit > 100 {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+	arg, err := newListMoviesParams(req.listMoviesFilter, time.Now().UTC())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
 		return
 	}
 
-	offset := (page - 1) * limit
+	page, err := server.listMoviesPage(ctx, req.Sort, arg, req.pageRequest)
+	if err != nil {
+		if errors.Is(err, errInvalidCursor) {
+			ctx.JSON(http.StatusBadRequest, errResponse(err))
+			return
+		}
 
-	arg := db.ListMoviesParams{
-		Limit:  int32(limit),
-		Offset: int32(offset),
+		ctx.JSON(http.StatusInternalServerError,
+			gin.H{"error": "could not fetch movies"})
+		return
 	}
 
-	movies, err := server.store.ListMovies(ctx, arg)
+	movies, err := server.withGenres(ctx, page.Data)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError,
 			gin.H{"error": "could not fetch movies"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, movies)
+	ctx.JSON(http.StatusOK, pageResponse[movieResponse]{
+		Data:       movies,
+		NextCursor: page.NextCursor,
+	})
 }
 
 type movieIDStruct struct {
 	MovieID int32 `uri:"id" binding:"required,min=1"`
 }
 
+// drafts and archived movies are not found, like in the movie list
 func (server *Server) getMovieByID(ctx *gin.Context) {
 	var req movieIDStruct
 
@@ -119,19 +190,42 @@ func (server *Server) getMovieByID(ctx *gin.Context) {
 		return
 	}
 
-	movie, err := server.store.GetMovie(ctx, req.MovieID)
+	movie, ok := server.getPublicMovie(ctx, req.MovieID)
+	if !ok {
+		return
+	}
+
+	genres, err := server.store.ListMovieGenres(ctx, movie.MovieID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound,
-				gin.H{"error": "movie not found"})
-			return
-		}
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
 
+	credits, err := server.store.ListMovieCredits(ctx, movie.MovieID)
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
 		return
 	}
+	if credits == nil {
+		credits = []db.ListMovieCreditsRow{}
+	}
+
+	ctx.JSON(http.StatusOK, movieDetailResponse{
+		movieResponse: newMovieResponse(movie, genres),
+		Credits:       credits,
+	})
+}
 
-	ctx.JSON(http.StatusOK, movie)
+type updateMovieRequest struct {
+	Title            string     `form:"title"`
+	Description      string     `form:"description"`
+	GenreIDs         []int32    `form:"genre_ids" binding:"omitempty,min=1,dive,min=1"`
+	RuntimeMinutes   *int32     `form:"runtime_minutes" binding:"omitempty,min=0,max=1440"`
+	Certification    *string    `form:"certification" binding:"omitempty,max=16"`
+	ReleaseDate      *time.Time `form:"release_date" time_format:"2006-01-02"`
+	OriginalLanguage *string    `form:"original_language" binding:"omitempty,bcp47_language_tag"`
+	TrailerURL       *string    `form:"trailer_url" binding:"omitempty,http_url"`
+	Status           *string    `form:"status" binding:"omitempty,oneof=draft coming_soon now_showing archived"`
 }
 
 // update a movie
@@ -145,7 +239,7 @@ func (server *Server) updateMovie(ctx *gin.Context) {
 
 	movie, err := server.store.GetMovie(ctx, req.MovieID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound,
 				gin.H{"error": "movie not found"})
 			return
@@ -155,65 +249,106 @@ func (server *Server) updateMovie(ctx *gin.Context) {
 		return
 	}
 
-	title := ctx.PostForm("title")
-	description := ctx.PostForm("description")
-	strGenreID := ctx.PostForm("genre_id")
+	// fields left out of the form keep their current value
+	var form updateMovieRequest
+	if err := ctx.ShouldBind(&form); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	// genre_ids replaces the whole set when given
+	var genreIDs []int32
+	if form.GenreIDs != nil {
+		genreIDs, err = server.validateGenres(ctx, form.GenreIDs)
+		if err != nil {
+			if errors.Is(err, errGenreNotFound) {
+				ctx.JSON(http.StatusBadRequest, errResponse(err))
+				return
+			}
 
-	if title == "" {
-		title = movie.Title
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
 	}
 
-	if description == "" {
-		description = movie.Description
+	before, err := server.store.ListMovieGenres(ctx, movie.MovieID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
 	}
 
-	var genreID int32
-	if strGenreID == "" {
-		genreID = movie.GenreID // fallback to existing
-	} else {
-		// convert to int
-		parsedID, err := strconv.Atoi(strGenreID)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": "invalid genre id",
-			})
-			return
+	arg := db.UpdateMovieParams{
+		MovieID:          movie.MovieID,
+		Title:            movie.Title,
+		Description:      movie.Description,
+		RuntimeMinutes:   movie.RuntimeMinutes,
+		Certification:    movie.Certification,
+		ReleaseDate:      movie.ReleaseDate,
+		OriginalLanguage: movie.OriginalLanguage,
+		TrailerUrl:       movie.TrailerUrl,
+		Status:           movie.Status,
+	}
+
+	if form.Title != "" {
+		arg.Title = form.Title
+	}
+	if form.Description != "" {
+		arg.Description = form.Description
+	}
+	if form.RuntimeMinutes != nil {
+		arg.RuntimeMinutes = *form.RuntimeMinutes
+	}
+	if form.Certification != nil {
+		arg.Certification = *form.Certification
+	}
+	if form.ReleaseDate != nil {
+		// an empty release_date clears it
+		arg.ReleaseDate = pgtype.Date{
+			Time:  *form.ReleaseDate,
+			Valid: !form.ReleaseDate.IsZero(),
 		}
-		genreID = int32(parsedID)
+	}
+	if form.OriginalLanguage != nil {
+		arg.OriginalLanguage = *form.OriginalLanguage
+	}
+	if form.TrailerURL != nil {
+		arg.TrailerUrl = *form.TrailerURL
+	}
+	if form.Status != nil {
+		arg.Status = *form.Status
 	}
 
 	// Optional poster upload
 	_, err = ctx.FormFile("poster_url")
-	var posterURL string
 	if err == nil {
-		posterURL, err = uploadToCloud(ctx)
+		arg.PosterUrl, err = uploadToCloud(ctx)
 		if err != nil {
 			// uploadToCloud already handles JSON error response
 			return
 		}
 	} else {
 		// No file uploaded → use old URL
-		posterURL = movie.PosterUrl
-	}
-
-	arg := db.UpdateMovieParams{
-		MovieID:     movie.MovieID,
-		Title:       title,
-		Description: description,
-		PosterUrl:   posterURL,
-		GenreID:     int32(genreID),
+		arg.PosterUrl = movie.PosterUrl
 	}
 
-	updatedMovie, err := server.store.UpdateMovie(ctx, arg)
+	result, err := server.store.UpdateMovieTx(ctx, db.UpdateMovieTxParams{
+		UpdateMovieParams: arg,
+		GenreIDs:          genreIDs,
+	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
 		return
 	}
 
+	updatedMovie := newMovieResponse(result.Movie, result.Genres)
+	server.recordAudit(ctx, auditActionMovieUpdate, auditEntityMovie,
+		movie.MovieID, newMovieResponse(movie, before), updatedMovie)
+
 	ctx.JSON(http.StatusOK, updatedMovie)
 }
 
-// delete a movie
+// deletes a movie, it's only hidden so past bookings keep their movie.
+// refused while customers hold seats for an upcoming showtime
 func (server *Server) deleteMovie(ctx *gin.Context) {
 	var req movieIDStruct
 
@@ -224,7 +359,7 @@ func (server *Server) deleteMovie(ctx *gin.Context) {
 
 	movie, err := server.store.GetMovie(ctx, req.MovieID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound,
 				gin.H{"error": "movie not found"})
 			return
@@ -234,31 +369,25 @@ func (server *Server) deleteMovie(ctx *gin.Context) {
 		return
 	}
 
-	cloudService, err := util.NewCloudinaryService()
+	deleted, err := server.store.DeleteMovieTx(ctx, movie.MovieID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
-		return
-	}
-
-	// Delete image from Cloudinary
-	// extract cloud public ID from URL
-	if movie.PosterUrl != "" {
-		publicID := extractPublicID(movie.PosterUrl)
-		// fmt.Printf("Extracted Public ID: %s\n", publicID)
-
-		if err := cloudService.DeleteImage(ctx, publicID); err != nil {
-			log.Printf("Failed to delete image from Cloudinary: %v\n", err)
-			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		if errors.Is(err, db.ErrMovieHasBookings) {
+			ctx.JSON(http.StatusConflict, errResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound,
+				gin.H{"error": "movie not found"})
 			return
 		}
-	}
 
-	err = server.store.DeleteMovie(ctx, movie.MovieID)
-	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
 		return
 	}
 
+	server.recordAudit(ctx, auditActionMovieDelete, auditEntityMovie,
+		movie.MovieID, movie, deleted)
+
 	ctx.JSON(http.StatusOK,
 		gin.H{"message": "movie deleted"})
 }
@@ -308,6 +437,198 @@ func uploadToCloud(ctx *gin.Context) (string, error) {
 	return imageUrl, nil
 }
 
+// turns the query filters into query params, now is when "now showing"
+// starts
+func newListMoviesParams(filter listMoviesFilter,
+	now time.Time) (db.ListMoviesByNewestParams, error) {
+	arg := db.ListMoviesByNewestParams{
+		GenreIds:   filter.GenreIDs,
+		ComingSoon: filter.ComingSoon,
+	}
+	if arg.GenreIds == nil {
+		arg.GenreIds = []int32{}
+	}
+
+	if filter.Certification != "" {
+		arg.Certification = pgtype.Text{String: filter.Certification, Valid: true}
+	}
+	if filter.Language != "" {
+		arg.OriginalLanguage = pgtype.Text{String: filter.Language, Valid: true}
+	}
+
+	if !filter.ReleasedFrom.IsZero() {
+		arg.ReleasedFrom = pgtype.Date{Time: filter.ReleasedFrom, Valid: true}
+	}
+	if !filter.ReleasedTo.IsZero() {
+		arg.ReleasedTo = pgtype.Date{Time: filter.ReleasedTo, Valid: true}
+	}
+	if arg.ReleasedFrom.Valid && arg.ReleasedTo.Valid &&
+		filter.ReleasedFrom.After(filter.ReleasedTo) {
+		return arg, errors.New("released_from must not be after released_to")
+	}
+
+	showingFrom := filter.ShowingFrom
+	if filter.NowShowing && showingFrom.Before(now) {
+		showingFrom = now
+	}
+	if !showingFrom.IsZero() {
+		arg.ShowingFrom = pgtype.Timestamp{Time: showingFrom, Valid: true}
+	}
+	if !filter.ShowingTo.IsZero() {
+		// showtimes on the last day count too
+		arg.ShowingTo = pgtype.Timestamp{
+			Time:  filter.ShowingTo.AddDate(0, 0, 1),
+			Valid: true,
+		}
+	}
+	if arg.ShowingFrom.Valid && arg.ShowingTo.Valid &&
+		!arg.ShowingFrom.Time.Before(arg.ShowingTo.Time) {
+		return arg, errors.New("showing_from must not be after showing_to")
+	}
+
+	return arg, nil
+}
+
+// runs the query for the sort order, newest first by default. filter
+// holds the filters every sort order shares
+func (server *Server) listMoviesPage(ctx *gin.Context, sort string,
+	filter db.ListMoviesByNewestParams,
+	req pageRequest) (pageResponse[db.Movie], error) {
+	if sort == "" {
+		sort = movieSortNewest
+	}
+	limit := req.Limit
+
+	switch sort {
+	case movieSortTitle:
+		after, ok, err := decodeCursor[string](req.Cursor, sort)
+		if err != nil {
+			return pageResponse[db.Movie]{}, err
+		}
+
+		arg := db.ListMoviesByTitleParams{
+			GenreIds:         filter.GenreIds,
+			Certification:    filter.Certification,
+			OriginalLanguage: filter.OriginalLanguage,
+			ComingSoon:       filter.ComingSoon,
+			ReleasedFrom:     filter.ReleasedFrom,
+			ReleasedTo:       filter.ReleasedTo,
+			ShowingFrom:      filter.ShowingFrom,
+			ShowingTo:        filter.ShowingTo,
+			AfterID:          pgtype.Int4{Int32: int32(after.ID), Valid: ok},
+			AfterTitle:       pgtype.Text{String: after.Key, Valid: ok},
+			RowLimit:         limit + 1,
+		}
+
+		movies, err := server.store.ListMoviesByTitle(ctx, arg)
+		if err != nil {
+			return pageResponse[db.Movie]{}, err
+		}
+
+		return newPageResponse(movies, limit, sort,
+			func(movie db.Movie) (string, int64) {
+				return movie.Title, int64(movie.MovieID)
+			})
+
+	case movieSortReleaseDate:
+		after, ok, err := decodeCursor[pgtype.Date](req.Cursor, sort)
+		if err != nil {
+			return pageResponse[db.Movie]{}, err
+		}
+
+		arg := db.ListMoviesByReleaseDateParams{
+			GenreIds:         filter.GenreIds,
+			Certification:    filter.Certification,
+			OriginalLanguage: filter.OriginalLanguage,
+			ComingSoon:       filter.ComingSoon,
+			ReleasedFrom:     filter.ReleasedFrom,
+			ReleasedTo:       filter.ReleasedTo,
+			ShowingFrom:      filter.ShowingFrom,
+			ShowingTo:        filter.ShowingTo,
+			AfterID:          pgtype.Int4{Int32: int32(after.ID), Valid: ok},
+			AfterReleaseDate: after.Key,
+			RowLimit:         limit + 1,
+		}
+
+		movies, err := server.store.ListMoviesByReleaseDate(ctx, arg)
+		if err != nil {
+			return pageResponse[db.Movie]{}, err
+		}
+
+		return newPageResponse(movies, limit, sort,
+			func(movie db.Movie) (pgtype.Date, int64) {
+				// the query sorts movies without a release date as
+				// -infinity
+				if !movie.ReleaseDate.Valid {
+					return pgtype.Date{
+						InfinityModifier: pgtype.NegativeInfinity,
+						Valid:            true,
+					}, int64(movie.MovieID)
+				}
+				return movie.ReleaseDate, int64(movie.MovieID)
+			})
+
+	case movieSortPopularity:
+		after, ok, err := decodeCursor[int64](req.Cursor, sort)
+		if err != nil {
+			return pageResponse[db.Movie]{}, err
+		}
+
+		arg := db.ListMoviesByPopularityParams{
+			GenreIds:         filter.GenreIds,
+			Certification:    filter.Certification,
+			OriginalLanguage: filter.OriginalLanguage,
+			ComingSoon:       filter.ComingSoon,
+			ReleasedFrom:     filter.ReleasedFrom,
+			ReleasedTo:       filter.ReleasedTo,
+			ShowingFrom:      filter.ShowingFrom,
+			ShowingTo:        filter.ShowingTo,
+			AfterID:          pgtype.Int4{Int32: int32(after.ID), Valid: ok},
+			AfterBookings:    pgtype.Int8{Int64: after.Key, Valid: ok},
+			RowLimit:         limit + 1,
+		}
+
+		movies, err := server.store.ListMoviesByPopularity(ctx, arg)
+		if err != nil {
+			return pageResponse[db.Movie]{}, err
+		}
+
+		return newPageResponse(movies, limit, sort,
+			func(movie db.Movie) (int64, int64) {
+				return movie.BookingsCount, int64(movie.MovieID)
+			})
+
+	default:
+		after, ok, err := decodeCursor[time.Time](req.Cursor, sort)
+		if err != nil {
+			return pageResponse[db.Movie]{}, err
+		}
+
+		arg := filter
+		arg.AfterID = pgtype.Int4{Int32: int32(after.ID), Valid: ok}
+		arg.AfterCreatedAt = pgtype.Timestamptz{Time: after.Key, Valid: ok}
+		arg.RowLimit = limit + 1
+
+		movies, err := server.store.ListMoviesByNewest(ctx, arg)
+		if err != nil {
+			return pageResponse[db.Movie]{}, err
+		}
+
+		return newPageResponse(movies, limit, sort,
+			func(movie db.Movie) (time.Time, int64) {
+				return movie.CreatedAt, int64(movie.MovieID)
+			})
+	}
+}
+
+// movies without a status are coming soon until their release date
+func defaultMovieStatus(releaseDate pgtype.Date) string {
+	if releaseDate.Valid && releaseDate.Time.After(time.Now()) {
+		return db.MovieStatusComingSoon
+	}
+	return db.MovieStatusNowShowing
+}
+
 // Helper function to check if a string exists in a slice
 func containsValidFormat(item string) bool {
 	slice := []string{"image/png", "image/jpeg", "image/jpg", "image/gif"}
@@ -320,17 +641,59 @@ func containsValidFormat(item string) bool {
 	return false
 }
 
-// Helper function to extract public ID from a Cloudinary URL
-func extractPublicID(url string) string {
-	parts := strings.Split(url, "/")
-	lastPart := parts[len(parts)-1]
-	publicID := strings.TrimSuffix(lastPart, filepath.Ext(lastPart)) // Remove file extension
+// drafts, archived and deleted movies are reported as not found like the
+// catalog leaves them out. ok is false once the error response has been
+// written
+func (server *Server) getPublicMovie(ctx *gin.Context,
+	movieID int32) (db.Movie, bool) {
+	movie, err := server.store.GetMovie(ctx, movieID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound,
+				gin.H{"error": "movie not found"})
+			return movie, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return movie, false
+	}
+
+	if !db.IsPublicMovieStatus(movie.Status) {
+		ctx.JSON(http.StatusNotFound,
+			gin.H{"error": "movie not found"})
+		return movie, false
+	}
+
+	return movie, true
+}
+
+// looks up the genres of all movies in one query
+func (server *Server) withGenres(ctx *gin.Context,
+	movies []db.Movie) ([]movieResponse, error) {
+	movieIDs := make([]int32, 0, len(movies))
+	for _, movie := range movies {
+		movieIDs = append(movieIDs, movie.MovieID)
+	}
 
-	// Extract folder path if it exists
-	if len(parts) > 7 { // Cloudinary path structure
-		folderPath := strings.Join(parts[7:len(parts)-1], "/") // Preserve folder structure
-		publicID = folderPath + "/" + publicID
+	rows, err := server.store.ListGenresForMovies(ctx, movieIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	genres := map[int32][]db.Genre{}
+	for _, row := range rows {
+		genres[row.MovieID] = append(genres[row.MovieID],
+			db.Genre{GenreID: row.GenreID, Name: row.Name})
+	}
+
+	resp := make([]movieResponse, 0, len(movies))
+	for _, movie := range movies {
+		movieGenres := genres[movie.MovieID]
+		if movieGenres == nil {
+			movieGenres = []db.Genre{}
+		}
+		resp = append(resp, newMovieResponse(movie, movieGenres))
 	}
 
-	return publicID
+	return resp, nil
 }
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+)
+
+var errCharacterNotActor = errors.New("only actors play a character")
+
+type movieCreditUri struct {
+	MovieID  int32 `uri:"id" binding:"required,min=1"`
+	CreditID int32 `uri:"credit_id" binding:"required,min=1"`
+}
+
+type createMovieCreditRequest struct {
+	PersonID      int32  `json:"person_id" binding:"required,min=1"`
+	Role          string `json:"role" binding:"required,oneof=actor director writer"`
+	CharacterName string `json:"character_name" binding:"max=200"`
+	BillingOrder  int32  `json:"billing_order" binding:"min=0"`
+}
+
+// credits a person on a movie
+func (server *Server) createMovieCredit(ctx *gin.Context) {
+	var uri movieIDStruct
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var req createMovieCreditRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	if req.Role != util.CreditRoleActor && req.CharacterName != "" {
+		ctx.JSON(http.StatusBadRequest, errResponse(errCharacterNotActor))
+		return
+	}
+
+	_, err := server.store.GetMovie(ctx, uri.MovieID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "movie not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	_, err = server.store.GetPerson(ctx, req.PersonID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, errResponse(errPersonNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	credit, err := server.store.CreateMovieCredit(ctx, db.CreateMovieCreditParams{
+		MovieID:       uri.MovieID,
+		PersonID:      req.PersonID,
+		Role:          req.Role,
+		CharacterName: req.CharacterName,
+		BillingOrder:  req.BillingOrder,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusConflict,
+				gin.H{"error": "person already has this credit"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionCreditCreate, auditEntityCredit,
+		credit.CreditID, nil, credit)
+
+	ctx.JSON(http.StatusOK, credit)
+}
+
+// the person of a credit can't change, delete it and add another instead
+type updateMovieCreditRequest struct {
+	Role          string `json:"role" binding:"required,oneof=actor director writer"`
+	CharacterName string `json:"character_name" binding:"max=200"`
+	BillingOrder  int32  `json:"billing_order" binding:"min=0"`
+}
+
+func (server *Server) updateMovieCredit(ctx *gin.Context) {
+	var uri movieCreditUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var req updateMovieCreditRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	if req.Role != util.CreditRoleActor && req.CharacterName != "" {
+		ctx.JSON(http.StatusBadRequest, errResponse(errCharacterNotActor))
+		return
+	}
+
+	before, err := server.store.GetMovieCredit(ctx, db.GetMovieCreditParams{
+		CreditID: uri.CreditID,
+		MovieID:  uri.MovieID,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "credit not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	credit, err := server.store.UpdateMovieCredit(ctx, db.UpdateMovieCreditParams{
+		CreditID:      uri.CreditID,
+		MovieID:       uri.MovieID,
+		Role:          req.Role,
+		CharacterName: req.CharacterName,
+		BillingOrder:  req.BillingOrder,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusConflict,
+				gin.H{"error": "person already has this credit"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionCreditUpdate, auditEntityCredit,
+		credit.CreditID, before, credit)
+
+	ctx.JSON(http.StatusOK, credit)
+}
+
+func (server *Server) deleteMovieCredit(ctx *gin.Context) {
+	var uri movieCreditUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, err := server.store.GetMovieCredit(ctx, db.GetMovieCreditParams{
+		CreditID: uri.CreditID,
+		MovieID:  uri.MovieID,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "credit not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	err = server.store.DeleteMovieCredit(ctx, db.DeleteMovieCreditParams{
+		CreditID: uri.CreditID,
+		MovieID:  uri.MovieID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionCreditDelete, auditEntityCredit,
+		before.CreditID, before, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "credit deleted"})
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"errors"
+	"html"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+)
+
+// how a search result was found
+const (
+	searchMatchFullText = "full_text"
+	searchMatchSimilar  = "similar"
+)
+
+// longer queries are cut to this many words
+const maxSearchTerms = 10
+
+var errEmptySearch = errors.New("q must contain letters or digits")
+
+type searchMoviesRequest struct {
+	Query string `form:"q" binding:"required,max=200"`
+	Page  int32  `form:"page,default=1" binding:"min=1"`
+	Limit int32  `form:"limit,default=20" binding:"min=1,max=50"`
+}
+
+type movieSearchResult struct {
+	MovieID          int32       `json:"movie_id"`
+	Title            string      `json:"title"`
+	HighlightedTitle string      `json:"highlighted_title"`
+	Snippet          string      `json:"snippet"`
+	PosterUrl        string      `json:"poster_url"`
+	ReleaseDate      pgtype.Date `json:"release_date"`
+	Status           string      `json:"status"`
+	Rank             float32     `json:"rank"`
+}
+
+type searchMoviesResponse struct {
+	Query   string              `json:"query"`
+	Match   string              `json:"match"`
+	Results []movieSearchResult `json:"results"`
+}
+
+// searches titles and descriptions, the last word matches as a prefix so
+// it works while the user types. when no word matches, titles that look
+// like the query are returned instead to cover typos
+func (server *Server) searchMovies(ctx *gin.Context) {
+	var req searchMoviesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	tsQuery := searchTSQuery(req.Query)
+	if tsQuery == "" {
+		ctx.JSON(http.StatusBadRequest, errResponse(errEmptySearch))
+		return
+	}
+
+	resp := searchMoviesResponse{
+		Query:   req.Query,
+		Match:   searchMatchFullText,
+		Results: []movieSearchResult{},
+	}
+
+	rows, err := server.store.SearchMovies(ctx, db.SearchMoviesParams{
+		Query:     tsQuery,
+		RowLimit:  req.Limit,
+		RowOffset: (req.Page - 1) * req.Limit,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	for _, row := range rows {
+		resp.Results = append(resp.Results, newMovieSearchResult(row))
+	}
+
+	// only fall back on the first page, later pages of a full text
+	// search are just empty
+	if len(rows) == 0 && req.Page == 1 {
+		similar, err := server.store.SearchMoviesByTitleSimilarity(ctx,
+			db.SearchMoviesByTitleSimilarityParams{
+				Query:    strings.TrimSpace(req.Query),
+				RowLimit: req.Limit,
+			})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+
+		resp.Match = searchMatchSimilar
+		for _, row := range similar {
+			resp.Results = append(resp.Results,
+				newMovieSearchResult(db.SearchMoviesRow(row)))
+		}
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ------------------------------------------------------------------//
+// ------------------------------Helper Funcs------------------------//
+// ------------------------------------------------------------------//
+
+func newMovieSearchResult(row db.SearchMoviesRow) movieSearchResult {
+	return movieSearchResult{
+		MovieID:          row.MovieID,
+		Title:            row.Title,
+		HighlightedTitle: escapeHighlight(row.HighlightedTitle),
+		Snippet:          escapeHighlight(row.Snippet),
+		PosterUrl:        row.PosterUrl,
+		ReleaseDate:      row.ReleaseDate,
+		Status:           row.Status,
+		Rank:             row.Rank,
+	}
+}
+
+// turns free text into a to_tsquery expression. only letters and digits
+// are kept so user input can't break the tsquery syntax, every word must
+// match and the last one may be incomplete, e.g. "star wa" becomes
+// "star & wa:*"
+func searchTSQuery(query string) string {
+	terms := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) == 0 {
+		return ""
+	}
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+
+	terms[len(terms)-1] += ":*"
+	return strings.Join(terms, " & ")
+}
+
+// escapes the text around the <mark> tags postgres puts around matches,
+// so a snippet is safe to render as html
+func escapeHighlight(text string) string {
+	escaped := html.EscapeString(text)
+	escaped = strings.ReplaceAll(escaped, "&lt;mark&gt;", "<mark>")
+	return strings.ReplaceAll(escaped, "&lt;/mark&gt;", "</mark>")
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// searchStore returns canned search rows and remembers the arguments
+type searchStore struct {
+	db.Store
+	fullText   []db.SearchMoviesRow
+	similar    []db.SearchMoviesByTitleSimilarityRow
+	searchArg  db.SearchMoviesParams
+	similarArg *db.SearchMoviesByTitleSimilarityParams
+}
+
+func (store *searchStore) SearchMovies(ctx context.Context,
+	arg db.SearchMoviesParams) ([]db.SearchMoviesRow, error) {
+	store.searchArg = arg
+	return store.fullText, nil
+}
+
+func (store *searchStore) SearchMoviesByTitleSimilarity(ctx context.Context,
+	arg db.SearchMoviesByTitleSimilarityParams) (
+	[]db.SearchMoviesByTitleSimilarityRow, error) {
+	store.similarArg = &arg
+	return store.similar, nil
+}
+
+func TestSearchTSQuery(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{query: "star", expected: "star:*"},
+		{query: "Star Wa", expected: "star & wa:*"},
+		{query: "  it's  a trap!! ", expected: "it & s & a & trap:*"},
+		{query: "amélie 2001", expected: "amélie & 2001:*"},
+		{query: "a:* | !b & (c)", expected: "a & b & c:*"},
+		{query: "1 2 3 4 5 6 7 8 9 10 11 12", expected: "1 & 2 & 3 & 4 & 5 & 6 & 7 & 8 & 9 & 10:*"},
+		{query: "&|!", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, searchTSQuery(tc.query), tc.query)
+	}
+}
+
+func TestEscapeHighlight(t *testing.T) {
+	require.Equal(t,
+		`A <mark>star</mark> &lt;script&gt;alert(1)&lt;/script&gt; &amp; more`,
+		escapeHighlight(`A <mark>star</mark> <script>alert(1)</script> & more`))
+}
+
+func TestSearchMovies(t *testing.T) {
+	store := &searchStore{
+		fullText: []db.SearchMoviesRow{{
+			MovieID:          1,
+			Title:            "Star Wars",
+			HighlightedTitle: "<mark>Star</mark> <mark>Wars</mark>",
+			Snippet:          "A <mark>star</mark> & a <b>war</b>",
+			Rank:             0.9,
+		}},
+	}
+	server := newTestServer(t, store)
+
+	recorder := serveWithToken(t, server, http.MethodGet,
+		"/movies/search?q=star+wa&page=2&limit=5", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "star & wa:*", store.searchArg.Query)
+	require.Equal(t, int32(5), store.searchArg.RowLimit)
+	require.Equal(t, int32(5), store.searchArg.RowOffset)
+
+	var resp searchMoviesResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, searchMatchFullText, resp.Match)
+	require.Len(t, resp.Results, 1)
+	require.Equal(t, "<mark>Star</mark> <mark>Wars</mark>",
+		resp.Results[0].HighlightedTitle)
+	require.Equal(t, "A <mark>star</mark> &amp; a &lt;b&gt;war&lt;/b&gt;",
+		resp.Results[0].Snippet)
+	require.Nil(t, store.similarArg)
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/movies/search", "", nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/movies/search?q=%21%21", "", nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestSearchMoviesTypoFallback(t *testing.T) {
+	store := &searchStore{
+		similar: []db.SearchMoviesByTitleSimilarityRow{{
+			MovieID:          1,
+			Title:            "Interstellar",
+			HighlightedTitle: "Interstellar",
+			Rank:             0.5,
+		}},
+	}
+	server := newTestServer(t, store)
+
+	recorder := serveWithToken(t, server, http.MethodGet,
+		"/movies/search?q=+intersteller+", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotNil(t, store.similarArg)
+	require.Equal(t, "intersteller", store.similarArg.Query)
+
+	var resp searchMoviesResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, searchMatchSimilar, resp.Match)
+	require.Len(t, resp.Results, 1)
+	require.Equal(t, "Interstellar", resp.Results[0].Title)
+
+	// later pages never fall back
+	store.similarArg = nil
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/movies/search?q=intersteller&page=2", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Nil(t, store.similarArg)
+}
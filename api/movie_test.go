@@ -0,0 +1,658 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+// movieStore keeps movies, their genres and showtimes in memory
+type movieStore struct {
+	*userAdminStore
+	movies      map[int32]db.Movie
+	genres      map[int32]db.Genre
+	movieGenres map[int32][]int32
+	showtimes   map[int32]db.Showtime
+	// reservations for upcoming showtimes of each movie
+	bookings map[int32]int64
+	people   map[int32]db.Person
+	credits  map[int32]db.MovieCredit
+	listArg  db.ListMoviesByNewestParams
+	listSort string
+}
+
+func newMovieStore() *movieStore {
+	return &movieStore{
+		userAdminStore: newUserAdminStore(),
+		movies:         map[int32]db.Movie{},
+		genres: map[int32]db.Genre{
+			1: {GenreID: 1, Name: "Action"},
+			2: {GenreID: 2, Name: "Drama"},
+			3: {GenreID: 3, Name: "Comedy"},
+		},
+		movieGenres: map[int32][]int32{},
+		showtimes:   map[int32]db.Showtime{},
+		bookings:    map[int32]int64{},
+		people:      map[int32]db.Person{},
+		credits:     map[int32]db.MovieCredit{},
+	}
+}
+
+func (store *movieStore) CreateMovieTx(ctx context.Context,
+	arg db.CreateMovieTxParams) (db.MovieTxResult, error) {
+	movie := db.Movie{
+		MovieID:          int32(len(store.movies) + 1),
+		Title:            arg.Title,
+		Description:      arg.Description,
+		PosterUrl:        arg.PosterUrl,
+		RuntimeMinutes:   arg.RuntimeMinutes,
+		Certification:    arg.Certification,
+		ReleaseDate:      arg.ReleaseDate,
+		OriginalLanguage: arg.OriginalLanguage,
+		TrailerUrl:       arg.TrailerUrl,
+		Status:           arg.Status,
+		CreatedAt:        time.Now(),
+	}
+	store.movies[movie.MovieID] = movie
+	store.movieGenres[movie.MovieID] = arg.GenreIDs
+
+	genres, err := store.ListMovieGenres(ctx, movie.MovieID)
+	return db.MovieTxResult{Movie: movie, Genres: genres}, err
+}
+
+func (store *movieStore) GetMovie(ctx context.Context,
+	movieID int32) (db.Movie, error) {
+	movie, ok := store.movies[movieID]
+	if !ok {
+		return db.Movie{}, db.ErrRecordNotFound
+	}
+	return movie, nil
+}
+
+func (store *movieStore) DeleteMovieTx(ctx context.Context,
+	movieID int32) (db.Movie, error) {
+	movie, ok := store.movies[movieID]
+	if !ok {
+		return db.Movie{}, db.ErrRecordNotFound
+	}
+	if store.bookings[movieID] > 0 {
+		return db.Movie{}, db.ErrMovieHasBookings
+	}
+
+	delete(store.movies, movieID)
+	movie.Status = db.MovieStatusArchived
+	movie.DeletedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	return movie, nil
+}
+
+func (store *movieStore) UpdateMovieTx(ctx context.Context,
+	arg db.UpdateMovieTxParams) (db.MovieTxResult, error) {
+	movie := store.movies[arg.MovieID]
+	movie.Title = arg.Title
+	movie.Description = arg.Description
+	movie.PosterUrl = arg.PosterUrl
+	movie.RuntimeMinutes = arg.RuntimeMinutes
+	movie.Certification = arg.Certification
+	movie.ReleaseDate = arg.ReleaseDate
+	movie.OriginalLanguage = arg.OriginalLanguage
+	movie.TrailerUrl = arg.TrailerUrl
+	movie.Status = arg.Status
+	store.movies[arg.MovieID] = movie
+	if arg.GenreIDs != nil {
+		store.movieGenres[arg.MovieID] = arg.GenreIDs
+	}
+
+	genres, err := store.ListMovieGenres(ctx, movie.MovieID)
+	return db.MovieTxResult{Movie: movie, Genres: genres}, err
+}
+
+// only the genre filter is applied, the other filters are checked
+// through listArg
+func (store *movieStore) ListMoviesByNewest(ctx context.Context,
+	arg db.ListMoviesByNewestParams) ([]db.Movie, error) {
+	store.listArg = arg
+	store.listSort = movieSortNewest
+
+	movies := []db.Movie{}
+	for id := int32(1); id <= int32(len(store.movies)); id++ {
+		movie, ok := store.movies[id]
+		if !ok {
+			continue
+		}
+		if len(arg.GenreIds) > 0 && !slices.ContainsFunc(arg.GenreIds,
+			func(genreID int32) bool {
+				return slices.Contains(store.movieGenres[id], genreID)
+			}) {
+			continue
+		}
+		movies = append(movies, movie)
+	}
+	return movies, nil
+}
+
+func (store *movieStore) ListMoviesByTitle(ctx context.Context,
+	arg db.ListMoviesByTitleParams) ([]db.Movie, error) {
+	movies, err := store.ListMoviesByNewest(ctx, db.ListMoviesByNewestParams{
+		GenreIds:         arg.GenreIds,
+		Certification:    arg.Certification,
+		OriginalLanguage: arg.OriginalLanguage,
+		ComingSoon:       arg.ComingSoon,
+		ReleasedFrom:     arg.ReleasedFrom,
+		ReleasedTo:       arg.ReleasedTo,
+		ShowingFrom:      arg.ShowingFrom,
+		ShowingTo:        arg.ShowingTo,
+		AfterID:          arg.AfterID,
+		RowLimit:         arg.RowLimit,
+	})
+	store.listSort = movieSortTitle
+	return movies, err
+}
+
+func (store *movieStore) ListMoviesByReleaseDate(ctx context.Context,
+	arg db.ListMoviesByReleaseDateParams) ([]db.Movie, error) {
+	movies, err := store.ListMoviesByNewest(ctx, db.ListMoviesByNewestParams{
+		GenreIds:         arg.GenreIds,
+		Certification:    arg.Certification,
+		OriginalLanguage: arg.OriginalLanguage,
+		ComingSoon:       arg.ComingSoon,
+		ReleasedFrom:     arg.ReleasedFrom,
+		ReleasedTo:       arg.ReleasedTo,
+		ShowingFrom:      arg.ShowingFrom,
+		ShowingTo:        arg.ShowingTo,
+		AfterID:          arg.AfterID,
+		RowLimit:         arg.RowLimit,
+	})
+	store.listSort = movieSortReleaseDate
+	return movies, err
+}
+
+func (store *movieStore) ListMoviesByPopularity(ctx context.Context,
+	arg db.ListMoviesByPopularityParams) ([]db.Movie, error) {
+	movies, err := store.ListMoviesByNewest(ctx, db.ListMoviesByNewestParams{
+		GenreIds:         arg.GenreIds,
+		Certification:    arg.Certification,
+		OriginalLanguage: arg.OriginalLanguage,
+		ComingSoon:       arg.ComingSoon,
+		ReleasedFrom:     arg.ReleasedFrom,
+		ReleasedTo:       arg.ReleasedTo,
+		ShowingFrom:      arg.ShowingFrom,
+		ShowingTo:        arg.ShowingTo,
+		AfterID:          arg.AfterID,
+		RowLimit:         arg.RowLimit,
+	})
+	store.listSort = movieSortPopularity
+	return movies, err
+}
+
+func (store *movieStore) ListGenresForMovies(ctx context.Context,
+	movieIDs []int32) ([]db.ListGenresForMoviesRow, error) {
+	rows := []db.ListGenresForMoviesRow{}
+	for _, movieID := range movieIDs {
+		for _, genreID := range store.movieGenres[movieID] {
+			rows = append(rows, db.ListGenresForMoviesRow{
+				MovieID: movieID,
+				GenreID: genreID,
+				Name:    store.genres[genreID].Name,
+			})
+		}
+	}
+	return rows, nil
+}
+
+func (store *movieStore) ListMovieGenres(ctx context.Context,
+	movieID int32) ([]db.Genre, error) {
+	return store.ListGenresByIDs(ctx, store.movieGenres[movieID])
+}
+
+func (store *movieStore) ListGenresByIDs(ctx context.Context,
+	genreIDs []int32) ([]db.Genre, error) {
+	genres := []db.Genre{}
+	for _, genreID := range genreIDs {
+		if genre, ok := store.genres[genreID]; ok {
+			genres = append(genres, genre)
+		}
+	}
+	return genres, nil
+}
+
+func (store *movieStore) CreateShowtimeTx(ctx context.Context,
+	arg db.CreateShowtimeParams) (db.CreateShowtimeTxResult, error) {
+	showtime := db.Showtime{
+		ShowtimeID: int32(len(store.showtimes) + 1),
+		MovieID:    arg.MovieID,
+		StartTime:  arg.StartTime,
+		EndTime:    arg.EndTime,
+		Price:      arg.Price,
+		CreatedAt:  time.Now(),
+	}
+	store.showtimes[showtime.ShowtimeID] = showtime
+	return db.CreateShowtimeTxResult{Showtime: showtime}, nil
+}
+
+// sends fields as a multipart form like the admin dashboard does
+func serveFormWithToken(t *testing.T, server *Server, method string,
+	path string, accessToken string,
+	fields map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, value := range fields {
+		require.NoError(t, writer.WriteField(key, value))
+	}
+	require.NoError(t, writer.Close())
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(method, path, body)
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.Header.Set(authorizationHeaderKey, "Bearer "+accessToken)
+
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestCreateMovieMetadata(t *testing.T) {
+	store := newMovieStore()
+	server := newTestServer(t, store)
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+
+	fields := map[string]string{
+		"title":             "Movie",
+		"description":       "A movie",
+		"genre_ids":         "2",
+		"runtime_minutes":   "128",
+		"certification":     "PG-13",
+		"release_date":      time.Now().AddDate(0, 1, 0).Format("2006-01-02"),
+		"original_language": "en",
+		"trailer_url":       "https://videos.example.com/trailer",
+	}
+
+	testCases := []struct {
+		name  string
+		field string
+		value string
+	}{
+		{name: "NegativeRuntime", field: "runtime_minutes", value: "-1"},
+		{name: "BadReleaseDate", field: "release_date", value: "01/05/2025"},
+		{name: "BadLanguage", field: "original_language", value: "not a language"},
+		{name: "BadTrailerURL", field: "trailer_url", value: "trailer"},
+		{name: "BadStatus", field: "status", value: "released"},
+		{name: "UnknownGenre", field: "genre_ids", value: "9"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			invalid := map[string]string{tc.field: tc.value}
+			for key, value := range fields {
+				if key != tc.field {
+					invalid[key] = value
+				}
+			}
+
+			recorder := serveFormWithToken(t, server, http.MethodPost,
+				"/movies", adminToken, invalid)
+			require.Equal(t, http.StatusBadRequest, recorder.Code)
+		})
+	}
+	require.Empty(t, store.movies)
+
+	recorder := serveFormWithToken(t, server, http.MethodPost, "/movies",
+		adminToken, fields)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	recorder = serveWithToken(t, server, http.MethodGet, "/movies/1", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var movie movieResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &movie)
+	require.NoError(t, err)
+	require.Equal(t, int32(128), movie.RuntimeMinutes)
+	require.Equal(t, "PG-13", movie.Certification)
+	require.Equal(t, fields["release_date"],
+		movie.ReleaseDate.Time.Format("2006-01-02"))
+	require.Equal(t, "en", movie.OriginalLanguage)
+	require.Equal(t, fields["trailer_url"], movie.TrailerUrl)
+	// released next month
+	require.Equal(t, db.MovieStatusComingSoon, movie.Status)
+	require.Equal(t, []db.Genre{store.genres[2]}, movie.Genres)
+
+	recorder = serveWithToken(t, server, http.MethodGet, "/movies/9", "", nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestUpdateMovieMetadata(t *testing.T) {
+	store := newMovieStore()
+	server := newTestServer(t, store)
+
+	store.movies[1] = db.Movie{
+		MovieID:          1,
+		Title:            "Movie",
+		Description:      "A movie",
+		RuntimeMinutes:   128,
+		Certification:    "PG-13",
+		ReleaseDate:      pgtype.Date{Time: time.Now(), Valid: true},
+		OriginalLanguage: "en",
+		Status:           db.MovieStatusNowShowing,
+	}
+	store.movieGenres[1] = []int32{2}
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := serveFormWithToken(t, server, http.MethodPut, "/movies/1",
+		adminToken, map[string]string{"status": "released"})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveFormWithToken(t, server, http.MethodPut, "/movies/1",
+		adminToken, map[string]string{
+			"runtime_minutes": "131",
+			"release_date":    "",
+			"status":          db.MovieStatusArchived,
+		})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	movie := store.movies[1]
+	require.Equal(t, int32(131), movie.RuntimeMinutes)
+	require.False(t, movie.ReleaseDate.Valid)
+	require.Equal(t, db.MovieStatusArchived, movie.Status)
+
+	// fields left out keep their value
+	require.Equal(t, "Movie", movie.Title)
+	require.Equal(t, []int32{2}, store.movieGenres[1])
+	require.Equal(t, "PG-13", movie.Certification)
+	require.Equal(t, "en", movie.OriginalLanguage)
+}
+
+func TestCreateShowtimeEndTime(t *testing.T) {
+	store := newMovieStore()
+	server := newTestServer(t, store)
+
+	store.movies[1] = db.Movie{MovieID: 1, RuntimeMinutes: 95,
+		Status: db.MovieStatusNowShowing}
+	store.movies[2] = db.Movie{MovieID: 2, Status: db.MovieStatusComingSoon}
+	store.movies[3] = db.Movie{MovieID: 3, Status: db.MovieStatusDraft}
+	store.movies[4] = db.Movie{MovieID: 4, Status: db.MovieStatusArchived}
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Minute).UTC()
+
+	testCases := []struct {
+		name    string
+		movieID int32
+		code    int
+		check   func(showtime db.Showtime)
+	}{
+		{
+			name:    "WithRuntime",
+			movieID: 1,
+			code:    http.StatusOK,
+			check: func(showtime db.Showtime) {
+				require.True(t, showtime.EndTime.Valid)
+				require.Equal(t, start.Add(95*time.Minute),
+					showtime.EndTime.Time)
+			},
+		},
+		{
+			name:    "UnknownRuntime",
+			movieID: 2,
+			code:    http.StatusOK,
+			check: func(showtime db.Showtime) {
+				require.False(t, showtime.EndTime.Valid)
+			},
+		},
+		{
+			name:    "Draft",
+			movieID: 3,
+			code:    http.StatusConflict,
+		},
+		{
+			name:    "Archived",
+			movieID: 4,
+			code:    http.StatusConflict,
+		},
+		{
+			name:    "MovieNotFound",
+			movieID: 9,
+			code:    http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := serveWithToken(t, server, http.MethodPost,
+				"/showtimes", adminToken, map[string]any{
+					"movie_id":   tc.movieID,
+					"start_time": start.Format("2006-01-02T15:04"),
+					"price":      "9.99",
+				})
+			require.Equal(t, tc.code, recorder.Code, recorder.Body.String())
+
+			if tc.check != nil {
+				var showtime db.Showtime
+				err := json.Unmarshal(recorder.Body.Bytes(), &showtime)
+				require.NoError(t, err)
+				tc.check(showtime)
+			}
+		})
+	}
+}
+
+func TestListMoviesFilters(t *testing.T) {
+	store := newMovieStore()
+	server := newTestServer(t, store)
+
+	testCases := []struct {
+		name  string
+		query string
+		code  int
+		check func(t *testing.T, arg db.ListMoviesByNewestParams, sort string)
+	}{
+		{
+			name:  "NoFilters",
+			query: "",
+			code:  http.StatusOK,
+			check: func(t *testing.T, arg db.ListMoviesByNewestParams, sort string) {
+				require.Equal(t, movieSortNewest, sort)
+				require.Equal(t, []int32{}, arg.GenreIds)
+				require.False(t, arg.Certification.Valid)
+				require.False(t, arg.OriginalLanguage.Valid)
+				require.False(t, arg.ComingSoon)
+				require.False(t, arg.ShowingFrom.Valid)
+				require.False(t, arg.AfterID.Valid)
+				// one more than the limit to tell if there's a next page
+				require.Equal(t, int32(51), arg.RowLimit)
+			},
+		},
+		{
+			name:  "Metadata",
+			query: "?certification=PG-13&language=en&coming_soon=true&sort=title",
+			code:  http.StatusOK,
+			check: func(t *testing.T, arg db.ListMoviesByNewestParams, sort string) {
+				require.Equal(t, movieSortTitle, sort)
+				require.Equal(t, "PG-13", arg.Certification.String)
+				require.Equal(t, "en", arg.OriginalLanguage.String)
+				require.True(t, arg.ComingSoon)
+			},
+		},
+		{
+			name:  "NowShowing",
+			query: "?now_showing=true&sort=popularity&limit=10",
+			code:  http.StatusOK,
+			check: func(t *testing.T, arg db.ListMoviesByNewestParams, sort string) {
+				require.Equal(t, movieSortPopularity, sort)
+				require.True(t, arg.ShowingFrom.Valid)
+				require.WithinDuration(t, time.Now().UTC(),
+					arg.ShowingFrom.Time, time.Minute)
+				require.False(t, arg.ShowingTo.Valid)
+				require.Equal(t, int32(11), arg.RowLimit)
+			},
+		},
+		{
+			name:  "DateRanges",
+			query: "?released_from=2025-01-01&released_to=2025-12-31&showing_from=2025-05-01&showing_to=2025-05-03&sort=release_date",
+			code:  http.StatusOK,
+			check: func(t *testing.T, arg db.ListMoviesByNewestParams, sort string) {
+				require.Equal(t, movieSortReleaseDate, sort)
+				require.Equal(t, "2025-01-01",
+					arg.ReleasedFrom.Time.Format("2006-01-02"))
+				require.Equal(t, "2025-12-31",
+					arg.ReleasedTo.Time.Format("2006-01-02"))
+				require.Equal(t, "2025-05-01",
+					arg.ShowingFrom.Time.Format("2006-01-02"))
+				// the whole last day is included
+				require.Equal(t, "2025-05-04",
+					arg.ShowingTo.Time.Format("2006-01-02"))
+			},
+		},
+		{
+			name:  "ReversedReleaseRange",
+			query: "?released_from=2025-12-31&released_to=2025-01-01",
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:  "ReversedShowingRange",
+			query: "?showing_from=2025-05-03&showing_to=2025-05-01",
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:  "BadSort",
+			query: "?sort=rating",
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:  "BadDate",
+			query: "?released_from=May",
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:  "BadCursor",
+			query: "?cursor=bogus",
+			code:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store.listSort = ""
+
+			recorder := serveWithToken(t, server, http.MethodGet,
+				"/movies"+tc.query, "", nil)
+			require.Equal(t, tc.code, recorder.Code, recorder.Body.String())
+
+			if tc.check != nil {
+				tc.check(t, store.listArg, store.listSort)
+			} else {
+				require.Empty(t, store.listSort)
+			}
+		})
+	}
+}
+
+func TestListMoviesCursor(t *testing.T) {
+	store := newMovieStore()
+	server := newTestServer(t, store)
+
+	for id := int32(1); id <= 3; id++ {
+		store.movies[id] = db.Movie{
+			MovieID:   id,
+			Title:     fmt.Sprintf("Movie %d", id),
+			CreatedAt: time.Date(2025, 5, int(id), 0, 0, 0, 0, time.UTC),
+		}
+		store.movieGenres[id] = []int32{1}
+	}
+
+	recorder := serveWithToken(t, server, http.MethodGet,
+		"/movies?limit=2", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var page pageResponse[movieResponse]
+	err := json.Unmarshal(recorder.Body.Bytes(), &page)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 2)
+	require.NotNil(t, page.NextCursor)
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/movies?limit=2&cursor="+*page.NextCursor, "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// the page continues after the last movie of the first one
+	require.Equal(t, pgtype.Int4{Int32: 2, Valid: true}, store.listArg.AfterID)
+	require.Equal(t, pgtype.Timestamptz{
+		Time:  time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC),
+		Valid: true,
+	}, store.listArg.AfterCreatedAt)
+
+	// a cursor only works for the sort order it was made for
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/movies?sort=title&cursor="+*page.NextCursor, "", nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestGetMovieHidesUnpublished(t *testing.T) {
+	store := newMovieStore()
+	server := newTestServer(t, store)
+
+	store.movies[1] = db.Movie{MovieID: 1, Status: db.MovieStatusDraft}
+	store.movies[2] = db.Movie{MovieID: 2, Status: db.MovieStatusArchived}
+	store.movies[3] = db.Movie{MovieID: 3, Status: db.MovieStatusComingSoon}
+
+	for id, code := range map[int32]int{
+		1: http.StatusNotFound,
+		2: http.StatusNotFound,
+		3: http.StatusOK,
+	} {
+		recorder := serveWithToken(t, server, http.MethodGet,
+			fmt.Sprintf("/movies/%d", id), "", nil)
+		require.Equal(t, code, recorder.Code, id)
+	}
+}
+
+func TestDeleteMovie(t *testing.T) {
+	store := newMovieStore()
+	server := newTestServer(t, store)
+
+	store.movies[1] = db.Movie{MovieID: 1, Status: db.MovieStatusNowShowing}
+	store.movies[2] = db.Movie{MovieID: 2, Status: db.MovieStatusNowShowing}
+	store.bookings[2] = 3
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+
+	// customers hold seats for an upcoming showtime
+	recorder := serveWithToken(t, server, http.MethodDelete, "/movies/2",
+		adminToken, nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+	require.Contains(t, store.movies, int32(2))
+
+	recorder = serveWithToken(t, server, http.MethodDelete, "/movies/1",
+		adminToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, store.movies, int32(1))
+
+	require.Len(t, store.auditEvents, 1)
+	require.Equal(t, auditActionMovieDelete, store.auditEvents[0].Action)
+	require.Contains(t, string(store.auditEvents[0].After), `"deleted_at"`)
+
+	recorder = serveWithToken(t, server, http.MethodDelete, "/movies/1",
+		adminToken, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
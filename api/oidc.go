@@ -0,0 +1,351 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcStateDuration = 10 * time.Minute
+	// ties the callback to the browser that started the login, so
+	// nobody can sign a victim in with their own authorization code
+	oidcStateCookie = "oidc_state"
+)
+
+var (
+	errInvalidOIDCState = errors.New("invalid or expired login state")
+	errUnverifiedEmail  = errors.New(
+		"identity provider did not return a verified email")
+	nonAlphanumeric = regexp.MustCompile(`[^a-zA-Z0-9]`)
+)
+
+// talks to the configured OpenID Connect provider, discovery happens on
+// first use so the api starts even while the provider is unreachable
+type oidcClient struct {
+	mu           sync.Mutex
+	issuerURL    string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	provider     *oidc.Provider
+	verifier     *oidc.IDTokenVerifier
+	oauth2       oauth2.Config
+}
+
+func newOIDCClient(config util.Config) *oidcClient {
+	return &oidcClient{
+		issuerURL:    config.OIDCIssuerURL,
+		clientID:     config.OIDCClientID,
+		clientSecret: config.OIDCClientSecret,
+		redirectURL:  config.OIDCRedirectURL,
+	}
+}
+
+// fetches the provider's discovery document once
+func (client *oidcClient) discover(ctx context.Context) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if client.provider != nil {
+		return nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, client.issuerURL)
+	if err != nil {
+		return fmt.Errorf("failed to discover identity provider: %w", err)
+	}
+
+	client.provider = provider
+	client.verifier = provider.Verifier(&oidc.Config{ClientID: client.clientID})
+	client.oauth2 = oauth2.Config{
+		ClientID:     client.clientID,
+		ClientSecret: client.clientSecret,
+		RedirectURL:  client.redirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+	}
+
+	return nil
+}
+
+// redirects the browser to the identity provider
+func (server *Server) oidcLogin(ctx *gin.Context) {
+	if err := server.oidc.discover(ctx); err != nil {
+		ctx.JSON(http.StatusBadGateway, errResponse(err))
+		return
+	}
+
+	state, err := util.RandomSecureString(32)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	nonce, err := util.RandomSecureString(32)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	verifier := oauth2.GenerateVerifier()
+
+	_, err = server.store.CreateOIDCState(ctx, db.CreateOIDCStateParams{
+		State:        state,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiredAt:    time.Now().Add(oidcStateDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	// lax, the provider redirects back with a top level navigation
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/auth/oidc",
+		Domain:   server.config.CookieDomain,
+		MaxAge:   int(oidcStateDuration.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	authURL := server.oidc.oauth2.AuthCodeURL(state, oidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(verifier))
+
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+type oidcCallbackRequest struct {
+	Code  string `form:"code" binding:"required"`
+	State string `form:"state" binding:"required"`
+}
+
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+// exchanges the authorization code and signs the user in
+func (server *Server) oidcCallback(ctx *gin.Context) {
+	if providerErr := ctx.Query("error"); providerErr != "" {
+		err := fmt.Errorf("identity provider returned %s: %s",
+			providerErr, ctx.Query("error_description"))
+		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		return
+	}
+
+	var req oidcCallbackRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	stateCookie, err := ctx.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare(
+		[]byte(stateCookie), []byte(req.State)) != 1 {
+		ctx.JSON(http.StatusBadRequest, errResponse(errInvalidOIDCState))
+		return
+	}
+
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     "/auth/oidc",
+		Domain:   server.config.CookieDomain,
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if err := server.oidc.discover(ctx); err != nil {
+		ctx.JSON(http.StatusBadGateway, errResponse(err))
+		return
+	}
+
+	// states are deleted on first use so a callback can't be replayed
+	state, err := server.store.DeleteOIDCState(ctx, req.State)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, errResponse(errInvalidOIDCState))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if time.Now().After(state.ExpiredAt) {
+		ctx.JSON(http.StatusBadRequest, errResponse(errInvalidOIDCState))
+		return
+	}
+
+	oauth2Token, err := server.oidc.oauth2.Exchange(ctx, req.Code,
+		oauth2.VerifierOption(state.CodeVerifier))
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		return
+	}
+
+	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
+	if !ok {
+		err := errors.New("identity provider did not return an id token")
+		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		return
+	}
+
+	idToken, err := server.oidc.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		return
+	}
+	if idToken.Nonce != state.Nonce {
+		err := errors.New("id token nonce does not match")
+		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		return
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		return
+	}
+
+	user, err := server.findOrCreateOIDCUser(ctx, idToken.Issuer,
+		idToken.Subject, claims)
+	if err != nil {
+		if errors.Is(err, errUnverifiedEmail) {
+			ctx.JSON(http.StatusForbidden, errResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if user.TotpEnabled {
+		challenge, err := server.createLoginChallenge(ctx, user)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	resp, err := server.createLoginSession(ctx, user)
+	if err != nil {
+		if errors.Is(err, errAccountDisabled) {
+			ctx.JSON(http.StatusForbidden, errResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// returns the user linked to the external identity, linking an account
+// with the same verified email or creating a new one the first time
+func (server *Server) findOrCreateOIDCUser(ctx *gin.Context, issuer string,
+	subject string, claims oidcClaims) (db.User, error) {
+	identity, err := server.store.GetUserIdentity(ctx,
+		db.GetUserIdentityParams{
+			Issuer:  issuer,
+			Subject: subject,
+		})
+	if err == nil {
+		return server.store.GetUserByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, db.ErrRecordNotFound) {
+		return db.User{}, err
+	}
+
+	// unverified emails could be used to take over someone's account
+	if claims.Email == "" || !claims.EmailVerified {
+		return db.User{}, errUnverifiedEmail
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, claims.Email)
+	if err == nil {
+		_, err = server.store.CreateUserIdentity(ctx,
+			db.CreateUserIdentityParams{
+				UserID:  user.UserID,
+				Issuer:  issuer,
+				Subject: subject,
+				Email:   claims.Email,
+			})
+		return user, err
+	}
+	if !errors.Is(err, db.ErrRecordNotFound) {
+		return db.User{}, err
+	}
+
+	username, err := oidcUsername(claims.Email)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = username
+	}
+
+	// the account can only sign in through the provider until the
+	// user sets a password of their own
+	password, err := util.RandomSecureString(32)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	hashedPassword, err := server.passwords.Hash(password)
+	if err != nil {
+		return db.User{}, err
+	}
+
+	return server.store.CreateUserWithIdentityTx(ctx,
+		db.CreateUserWithIdentityTxParams{
+			CreateUserParams: db.CreateUserParams{
+				Name:           name,
+				Username:       username,
+				Email:          claims.Email,
+				HashedPassword: hashedPassword,
+			},
+			Issuer:  issuer,
+			Subject: subject,
+		})
+}
+
+// derives a unique alphanumeric username from the email's local part
+func oidcUsername(email string) (string, error) {
+	local := strings.SplitN(email, "@", 2)[0]
+	local = strings.ToLower(nonAlphanumeric.ReplaceAllString(local, ""))
+	if len(local) > 20 {
+		local = local[:20]
+	}
+
+	suffix, err := util.RandomSecureString(6)
+	if err != nil {
+		return "", err
+	}
+
+	return local + suffix, nil
+}
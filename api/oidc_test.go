@@ -0,0 +1,413 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/google/uuid"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+const stubClientID = "movie-app"
+
+// stubProvider is a minimal OpenID Connect provider for one login
+type stubProvider struct {
+	*httptest.Server
+	key           *rsa.PrivateKey
+	codeChallenge string
+	nonce         string
+	claims        map[string]any
+}
+
+func newStubProvider(t *testing.T) *stubProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	provider := &stubProvider{key: key}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration",
+		func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(map[string]any{
+				"issuer":                                provider.URL,
+				"authorization_endpoint":                provider.URL + "/authorize",
+				"token_endpoint":                        provider.URL + "/token",
+				"jwks_uri":                              provider.URL + "/jwks",
+				"id_token_signing_alg_values_supported": []string{"RS256"},
+			})
+		})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+			Key:       &key.PublicKey,
+			KeyID:     "stub",
+			Algorithm: string(jose.RS256),
+			Use:       "sig",
+		}}})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		// PKCE: the verifier must hash to the challenge sent earlier
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != provider.codeChallenge ||
+			r.FormValue("code") != "stub-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "stub-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     provider.idToken(t),
+		})
+	})
+
+	provider.Server = httptest.NewServer(mux)
+	t.Cleanup(provider.Close)
+
+	return provider
+}
+
+func (provider *stubProvider) idToken(t *testing.T) string {
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.RS256,
+		Key:       jose.JSONWebKey{Key: provider.key, KeyID: "stub"},
+	}, nil)
+	require.NoError(t, err)
+
+	claims := map[string]any{
+		"iss":   provider.URL,
+		"aud":   stubClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": provider.nonce,
+	}
+	for k, v := range provider.claims {
+		claims[k] = v
+	}
+
+	raw, err := jwt.Signed(signer).Claims(claims).Serialize()
+	require.NoError(t, err)
+
+	return raw
+}
+
+// oidcStore keeps the records touched by an OIDC login in memory
+type oidcStore struct {
+	db.Store
+	states     map[string]db.OidcState
+	users      map[int64]db.User
+	identities map[string]db.UserIdentity
+}
+
+func newOIDCStore() *oidcStore {
+	return &oidcStore{
+		states:     make(map[string]db.OidcState),
+		users:      make(map[int64]db.User),
+		identities: make(map[string]db.UserIdentity),
+	}
+}
+
+func (store *oidcStore) CreateOIDCState(ctx context.Context,
+	arg db.CreateOIDCStateParams) (db.OidcState, error) {
+	state := db.OidcState{
+		State:        arg.State,
+		CodeVerifier: arg.CodeVerifier,
+		Nonce:        arg.Nonce,
+		ExpiredAt:    arg.ExpiredAt,
+	}
+	store.states[arg.State] = state
+	return state, nil
+}
+
+func (store *oidcStore) DeleteOIDCState(ctx context.Context,
+	state string) (db.OidcState, error) {
+	s, ok := store.states[state]
+	if !ok {
+		return db.OidcState{}, db.ErrRecordNotFound
+	}
+	delete(store.states, state)
+	return s, nil
+}
+
+func (store *oidcStore) GetUserIdentity(ctx context.Context,
+	arg db.GetUserIdentityParams) (db.UserIdentity, error) {
+	identity, ok := store.identities[arg.Issuer+"|"+arg.Subject]
+	if !ok {
+		return db.UserIdentity{}, db.ErrRecordNotFound
+	}
+	return identity, nil
+}
+
+func (store *oidcStore) CreateUserIdentity(ctx context.Context,
+	arg db.CreateUserIdentityParams) (db.UserIdentity, error) {
+	identity := db.UserIdentity{
+		UserID:  arg.UserID,
+		Issuer:  arg.Issuer,
+		Subject: arg.Subject,
+		Email:   arg.Email,
+	}
+	store.identities[arg.Issuer+"|"+arg.Subject] = identity
+	return identity, nil
+}
+
+func (store *oidcStore) GetUserByID(ctx context.Context,
+	userID int64) (db.User, error) {
+	user, ok := store.users[userID]
+	if !ok {
+		return db.User{}, db.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (store *oidcStore) GetUserByEmail(ctx context.Context,
+	email string) (db.User, error) {
+	for _, user := range store.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return db.User{}, db.ErrRecordNotFound
+}
+
+func (store *oidcStore) CreateUserWithIdentityTx(ctx context.Context,
+	arg db.CreateUserWithIdentityTxParams) (db.User, error) {
+	user := db.User{
+		UserID:         int64(len(store.users) + 1),
+		Username:       arg.Username,
+		Name:           arg.Name,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		Role:           util.CustomerRole,
+	}
+	store.users[user.UserID] = user
+
+	_, err := store.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+		UserID:  user.UserID,
+		Issuer:  arg.Issuer,
+		Subject: arg.Subject,
+		Email:   arg.Email,
+	})
+	return user, err
+}
+
+func (store *oidcStore) CreateSession(ctx context.Context,
+	arg db.CreateSessionParams) (db.Session, error) {
+	return db.Session{ID: arg.ID, Username: arg.Username}, nil
+}
+
+func newOIDCTestServer(t *testing.T, provider *stubProvider,
+	store db.Store) *Server {
+	config := util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		OIDCIssuerURL:        provider.URL,
+		OIDCClientID:         stubClientID,
+		OIDCClientSecret:     "secret",
+		OIDCRedirectURL:      "http://localhost:8080/auth/oidc/callback",
+	}
+
+	server, err := NewServer(config, store)
+	require.NoError(t, err)
+
+	return server
+}
+
+// starts a login and returns the state the provider would send back
+func startOIDCLogin(t *testing.T, server *Server,
+	provider *stubProvider) string {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/auth/oidc/login", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusFound, recorder.Code)
+
+	location, err := url.Parse(recorder.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, provider.URL+"/authorize",
+		location.Scheme+"://"+location.Host+location.Path)
+
+	query := location.Query()
+	require.Equal(t, stubClientID, query.Get("client_id"))
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+	require.NotEmpty(t, query.Get("state"))
+
+	cookies := recorder.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, oidcStateCookie, cookies[0].Name)
+	require.Equal(t, query.Get("state"), cookies[0].Value)
+	require.True(t, cookies[0].HttpOnly)
+
+	provider.codeChallenge = query.Get("code_challenge")
+	provider.nonce = query.Get("nonce")
+
+	return query.Get("state")
+}
+
+// calls back from the browser that started the login
+func oidcCallback(t *testing.T, server *Server,
+	state string) *httptest.ResponseRecorder {
+	return oidcCallbackWithCookie(t, server, state, state)
+}
+
+func oidcCallbackWithCookie(t *testing.T, server *Server,
+	state string, stateCookie string) *httptest.ResponseRecorder {
+	query := url.Values{}
+	query.Set("code", "stub-code")
+	query.Set("state", state)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet,
+		"/auth/oidc/callback?"+query.Encode(), nil)
+	require.NoError(t, err)
+	if stateCookie != "" {
+		request.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: stateCookie})
+	}
+
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestOIDCLoginCreatesUser(t *testing.T) {
+	provider := newStubProvider(t)
+	provider.claims = map[string]any{
+		"sub":            uuid.NewString(),
+		"email":          "jane.doe@email.com",
+		"email_verified": true,
+		"name":           "Jane Doe",
+	}
+
+	store := newOIDCStore()
+	server := newOIDCTestServer(t, provider, store)
+
+	state := startOIDCLogin(t, server, provider)
+	recorder := oidcCallback(t, server, state)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var resp loginUserResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.AccessToken)
+	require.NotEmpty(t, resp.RefreshToken)
+	require.Equal(t, "jane.doe@email.com", resp.User.Email)
+
+	payload, err := server.tokenMaker.VerifyToken(resp.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, util.CustomerRole, payload.Role)
+
+	require.Len(t, store.users, 1)
+	require.Len(t, store.identities, 1)
+
+	// the state was used up
+	recorder = oidcCallback(t, server, state)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// signing in again reuses the linked account
+	state = startOIDCLogin(t, server, provider)
+	recorder = oidcCallback(t, server, state)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Len(t, store.users, 1)
+}
+
+func TestOIDCLoginLinksExistingUser(t *testing.T) {
+	provider := newStubProvider(t)
+	provider.claims = map[string]any{
+		"sub":            uuid.NewString(),
+		"email":          "existing@email.com",
+		"email_verified": true,
+	}
+
+	store := newOIDCStore()
+	store.users[7] = db.User{
+		UserID:   7,
+		Username: "existing",
+		Email:    "existing@email.com",
+		Role:     util.AdminRole,
+	}
+	server := newOIDCTestServer(t, provider, store)
+
+	state := startOIDCLogin(t, server, provider)
+	recorder := oidcCallback(t, server, state)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var resp loginUserResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	require.Equal(t, int64(7), resp.User.UserID)
+	require.Len(t, store.users, 1)
+	require.Len(t, store.identities, 1)
+}
+
+func TestOIDCLoginUnverifiedEmail(t *testing.T) {
+	provider := newStubProvider(t)
+	provider.claims = map[string]any{
+		"sub":            uuid.NewString(),
+		"email":          "existing@email.com",
+		"email_verified": false,
+	}
+
+	store := newOIDCStore()
+	server := newOIDCTestServer(t, provider, store)
+
+	state := startOIDCLogin(t, server, provider)
+	recorder := oidcCallback(t, server, state)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.Empty(t, store.users)
+}
+
+func TestOIDCLoginWrongNonce(t *testing.T) {
+	provider := newStubProvider(t)
+	provider.claims = map[string]any{
+		"sub":            uuid.NewString(),
+		"email":          "jane@email.com",
+		"email_verified": true,
+		"nonce":          "replayed",
+	}
+
+	server := newOIDCTestServer(t, provider, newOIDCStore())
+
+	state := startOIDCLogin(t, server, provider)
+	recorder := oidcCallback(t, server, state)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestOIDCCallbackFromAnotherBrowser(t *testing.T) {
+	provider := newStubProvider(t)
+	provider.claims = map[string]any{
+		"sub":            uuid.NewString(),
+		"email":          "jane@email.com",
+		"email_verified": true,
+	}
+
+	store := newOIDCStore()
+	server := newOIDCTestServer(t, provider, store)
+
+	// an attacker's state can't be planted in the victim's browser
+	state := startOIDCLogin(t, server, provider)
+	recorder := oidcCallbackWithCookie(t, server, state, "")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = oidcCallbackWithCookie(t, server, state, "another-state")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Empty(t, store.users)
+
+	// and the state is still good for the browser that owns it
+	recorder = oidcCallback(t, server, state)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// list endpoints take the next_cursor of the previous page, an empty
+// cursor starts from the first page
+type pageRequest struct {
+	Cursor string `form:"cursor" binding:"max=512"`
+	Limit  int32  `form:"limit,default=50" binding:"min=1,max=100"`
+}
+
+// every paginated list is returned in this envelope, next_cursor is
+// null on the last page
+type pageResponse[T any] struct {
+	Data       []T     `json:"data"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// a cursor holds the sort key and id of the last item of a page, sort
+// names the order it was made for so it can't be replayed on another one
+type pageCursor[K any] struct {
+	Sort string `json:"s,omitempty"`
+	Key  K      `json:"k"`
+	ID   int64  `json:"id"`
+}
+
+// opaque to clients, it's base64 so they don't start building their own
+func encodeCursor[K any](sort string, key K, id int64) (string, error) {
+	data, err := json.Marshal(pageCursor[K]{Sort: sort, Key: key, ID: id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodes a cursor made for sort, ok is false for an empty cursor
+func decodeCursor[K any](cursor string,
+	sort string) (pageCursor[K], bool, error) {
+	var decoded pageCursor[K]
+	if cursor == "" {
+		return decoded, false, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return decoded, false, errInvalidCursor
+	}
+
+	err = json.Unmarshal(data, &decoded)
+	if err != nil || decoded.Sort != sort || decoded.ID <= 0 {
+		return decoded, false, errInvalidCursor
+	}
+
+	return decoded, true, nil
+}
+
+// items are fetched with limit+1 rows, the extra row only tells there is
+// a next page. keyOf returns the sort key and id a page ends with
+func newPageResponse[T any, K any](items []T, limit int32, sort string,
+	keyOf func(item T) (K, int64)) (pageResponse[T], error) {
+	resp := pageResponse[T]{Data: items}
+	if resp.Data == nil {
+		resp.Data = []T{}
+	}
+
+	if len(items) <= int(limit) {
+		return resp, nil
+	}
+
+	resp.Data = items[:limit]
+	key, id := keyOf(resp.Data[limit-1])
+	cursor, err := encodeCursor(sort, key, id)
+	if err != nil {
+		return resp, err
+	}
+	resp.NextCursor = &cursor
+
+	return resp, nil
+}
+
+// the search filter of list queries, its LIKE wildcards are escaped so
+// "50%" matches literally instead of everything starting with 50
+func searchText(search string) pgtype.Text {
+	return pgtype.Text{String: likeEscaper.Replace(search), Valid: search != ""}
+}
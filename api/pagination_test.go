@@ -0,0 +1,95 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 5, 1, 18, 30, 0, 0, time.UTC)
+	cursor, err := encodeCursor(movieSortNewest, createdAt, 42)
+	require.NoError(t, err)
+
+	decoded, ok, err := decodeCursor[time.Time](cursor, movieSortNewest)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, createdAt, decoded.Key)
+	require.Equal(t, int64(42), decoded.ID)
+
+	// movies without a release date sort as -infinity
+	cursor, err = encodeCursor(movieSortReleaseDate, pgtype.Date{
+		InfinityModifier: pgtype.NegativeInfinity,
+		Valid:            true,
+	}, 7)
+	require.NoError(t, err)
+
+	date, ok, err := decodeCursor[pgtype.Date](cursor, movieSortReleaseDate)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, pgtype.NegativeInfinity, date.Key.InfinityModifier)
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	_, ok, err := decodeCursor[string]("", movieSortTitle)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	cursor, err := encodeCursor(movieSortTitle, "Alien", 1)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		cursor string
+		sort   string
+	}{
+		{name: "NotBase64", cursor: "not a cursor!", sort: movieSortTitle},
+		{name: "NotJSON", cursor: "bm90IGpzb24", sort: movieSortTitle},
+		{name: "OtherSort", cursor: cursor, sort: movieSortNewest},
+		{name: "NoID", cursor: "eyJzIjoidGl0bGUiLCJrIjoiQWxpZW4ifQ", sort: movieSortTitle},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, ok, err := decodeCursor[string](tc.cursor, tc.sort)
+			require.ErrorIs(t, err, errInvalidCursor)
+			require.False(t, ok)
+		})
+	}
+}
+
+func TestNewPageResponse(t *testing.T) {
+	keyOf := func(id int64) (struct{}, int64) {
+		return struct{}{}, id
+	}
+
+	page, err := newPageResponse([]int64{1, 2}, 2, "", keyOf)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2}, page.Data)
+	require.Nil(t, page.NextCursor)
+
+	// the extra row is dropped and the page ends at the last kept one
+	page, err = newPageResponse([]int64{1, 2, 3}, 2, "", keyOf)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2}, page.Data)
+	require.NotNil(t, page.NextCursor)
+
+	next, ok, err := decodeCursor[struct{}](*page.NextCursor, "")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(2), next.ID)
+
+	page, err = newPageResponse[int64](nil, 2, "", keyOf)
+	require.NoError(t, err)
+	require.NotNil(t, page.Data)
+}
+
+func TestSearchText(t *testing.T) {
+	require.False(t, searchText("").Valid)
+
+	search := searchText(`50%_off\`)
+	require.True(t, search.Valid)
+	require.Equal(t, `50\%\_off\\`, search.String)
+}
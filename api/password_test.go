@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+// loginStore adds what a password login and a sign up need
+type loginStore struct {
+	*sessionStore
+}
+
+func (store *loginStore) GetUserByEmail(ctx context.Context,
+	email string) (db.User, error) {
+	for _, user := range store.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return db.User{}, db.ErrRecordNotFound
+}
+
+func (store *loginStore) GetLoginThrottle(ctx context.Context,
+	arg db.GetLoginThrottleParams) (db.LoginThrottle, error) {
+	return db.LoginThrottle{}, db.ErrRecordNotFound
+}
+
+func (store *loginStore) RecordLoginFailure(ctx context.Context,
+	arg db.RecordLoginFailureParams) (db.LoginThrottle, error) {
+	return db.LoginThrottle{
+		Scope:          arg.Scope,
+		ThrottleKey:    arg.ThrottleKey,
+		FailedAttempts: 1,
+	}, nil
+}
+
+func (store *loginStore) DeleteLoginThrottle(ctx context.Context,
+	arg db.DeleteLoginThrottleParams) error {
+	return nil
+}
+
+func (store *loginStore) CreateSession(ctx context.Context,
+	arg db.CreateSessionParams) (db.Session, error) {
+	session := db.Session{ID: arg.ID, Username: arg.Username}
+	store.sessions[arg.ID] = session
+	return session, nil
+}
+
+func (store *loginStore) CreateUser(ctx context.Context,
+	arg db.CreateUserParams) (db.User, error) {
+	user := db.User{
+		UserID:         int64(len(store.users) + 1),
+		Username:       arg.Username,
+		Name:           arg.Name,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+	}
+	store.users[user.UserID] = user
+	return user, nil
+}
+
+// a server with cheap argon2id parameters
+func newPasswordTestServer(t *testing.T, store db.Store,
+	breachedFile string) *Server {
+	config := util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		Argon2Memory:         1024,
+		Argon2Iterations:     1,
+		Argon2Threads:        1,
+		BreachedPasswords:    breachedFile,
+	}
+
+	server, err := NewServer(config, store)
+	require.NoError(t, err)
+
+	return server
+}
+
+func TestLoginRehashesPassword(t *testing.T) {
+	password := util.RandomString(10)
+	bcryptHash, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	store := &loginStore{&sessionStore{
+		users: map[int64]db.User{
+			1: {UserID: 1, Username: "user", Email: "user@email.com",
+				Role: util.CustomerRole, HashedPassword: bcryptHash},
+		},
+		sessions: map[uuid.UUID]db.Session{},
+	}}
+	server := newPasswordTestServer(t, store, "")
+
+	login := gin.H{"email": "user@email.com", "password": password}
+
+	recorder := serveWithToken(t, server, http.MethodPost, "/users/login",
+		"", login)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	upgraded := store.users[1].HashedPassword
+	require.True(t, strings.HasPrefix(upgraded, "$argon2id$"))
+	require.NoError(t, util.CheckPassword(password, upgraded))
+
+	// an up to date hash is left alone
+	recorder = serveWithToken(t, server, http.MethodPost, "/users/login",
+		"", login)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, upgraded, store.users[1].HashedPassword)
+
+	// a wrong password never touches the hash
+	login["password"] = util.RandomString(10)
+	recorder = serveWithToken(t, server, http.MethodPost, "/users/login",
+		"", login)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Equal(t, upgraded, store.users[1].HashedPassword)
+}
+
+func TestCreateUserPasswordPolicy(t *testing.T) {
+	breachedFile := filepath.Join(t.TempDir(), "breached.txt")
+	err := os.WriteFile(breachedFile, []byte("letmein123\n"), 0o600)
+	require.NoError(t, err)
+
+	store := &loginStore{&sessionStore{users: map[int64]db.User{}}}
+	server := newPasswordTestServer(t, store, breachedFile)
+
+	testCases := []struct {
+		name     string
+		password string
+		code     int
+	}{
+		{name: "TooShort", password: "abc123", code: http.StatusBadRequest},
+		{name: "Breached", password: "LetMeIn123", code: http.StatusBadRequest},
+		{name: "Username", password: "newcustomer", code: http.StatusBadRequest},
+		{name: "OK", password: "correct horse battery", code: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := serveWithToken(t, server, http.MethodPost, "/users",
+				"", gin.H{
+					"name":     "Customer",
+					"username": "newcustomer",
+					"email":    "customer@email.com",
+					"password": tc.password,
+				})
+			require.Equal(t, tc.code, recorder.Code, recorder.Body.String())
+		})
+	}
+
+	require.Len(t, store.users, 1)
+	require.True(t, strings.HasPrefix(store.users[1].HashedPassword,
+		"$argon2id$v=19$m=1024,t=1,p=1$"))
+}
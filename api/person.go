@@ -0,0 +1,271 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+)
+
+var errPersonNotFound = errors.New("person not found")
+
+type listPeopleRequest struct {
+	Search string `form:"search" binding:"max=100"`
+	pageRequest
+}
+
+// lists people by name, optionally matching search
+func (server *Server) listPeople(ctx *gin.Context) {
+	var req listPeopleRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	after, ok, err := decodeCursor[string](req.Cursor, "")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	people, err := server.store.ListPeople(ctx, db.ListPeopleParams{
+		Search:    searchText(req.Search),
+		AfterID:   pgtype.Int4{Int32: int32(after.ID), Valid: ok},
+		AfterName: pgtype.Text{String: after.Key, Valid: ok},
+		RowLimit:  req.Limit + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	page, err := newPageResponse(people, req.Limit, "",
+		func(person db.Person) (string, int64) {
+			return person.Name, int64(person.PersonID)
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+type personIDUri struct {
+	PersonID int32 `uri:"id" binding:"required,min=1"`
+}
+
+// what a person did on one movie
+type personCredit struct {
+	CreditID      int32  `json:"credit_id"`
+	Role          string `json:"role"`
+	CharacterName string `json:"character_name"`
+	BillingOrder  int32  `json:"billing_order"`
+}
+
+type personMovie struct {
+	MovieID           int32          `json:"movie_id"`
+	Title             string         `json:"title"`
+	PosterUrl         string         `json:"poster_url"`
+	ReleaseDate       pgtype.Date    `json:"release_date"`
+	Status            string         `json:"status"`
+	Credits           []personCredit `json:"credits"`
+	UpcomingShowtimes []db.Showtime  `json:"upcoming_showtimes"`
+}
+
+type personResponse struct {
+	db.Person
+	Movies []personMovie `json:"movies"`
+}
+
+// a person with the movies they are credited in, so users can find other
+// films with them and book one
+func (server *Server) getPerson(ctx *gin.Context) {
+	var uri personIDUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	person, err := server.store.GetPerson(ctx, uri.PersonID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errResponse(errPersonNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	credits, err := server.store.ListPersonCredits(ctx, person.PersonID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	// credits come ordered by movie, so each movie's credits are together
+	resp := personResponse{Person: person, Movies: []personMovie{}}
+	movieIDs := []int32{}
+	for _, credit := range credits {
+		if len(resp.Movies) == 0 ||
+			resp.Movies[len(resp.Movies)-1].MovieID != credit.MovieID {
+			resp.Movies = append(resp.Movies, personMovie{
+				MovieID:           credit.MovieID,
+				Title:             credit.Title,
+				PosterUrl:         credit.PosterUrl,
+				ReleaseDate:       credit.ReleaseDate,
+				Status:            credit.Status,
+				UpcomingShowtimes: []db.Showtime{},
+			})
+			movieIDs = append(movieIDs, credit.MovieID)
+		}
+
+		movie := &resp.Movies[len(resp.Movies)-1]
+		movie.Credits = append(movie.Credits, personCredit{
+			CreditID:      credit.CreditID,
+			Role:          credit.Role,
+			CharacterName: credit.CharacterName,
+			BillingOrder:  credit.BillingOrder,
+		})
+	}
+
+	showtimes, err := server.store.ListUpcomingShowtimesForMovies(ctx, movieIDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	for _, showtime := range showtimes {
+		for i := range resp.Movies {
+			if resp.Movies[i].MovieID == showtime.MovieID {
+				resp.Movies[i].UpcomingShowtimes = append(
+					resp.Movies[i].UpcomingShowtimes, showtime)
+				break
+			}
+		}
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+type personRequest struct {
+	Name      string `json:"name" binding:"required,max=200"`
+	Biography string `json:"biography" binding:"max=5000"`
+	PhotoURL  string `json:"photo_url" binding:"omitempty,http_url"`
+}
+
+func (server *Server) createPerson(ctx *gin.Context) {
+	var req personRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	person, err := server.store.CreatePerson(ctx, db.CreatePersonParams{
+		Name:      req.Name,
+		Biography: req.Biography,
+		PhotoUrl:  req.PhotoURL,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionPersonCreate, auditEntityPerson,
+		person.PersonID, nil, person)
+
+	ctx.JSON(http.StatusOK, person)
+}
+
+func (server *Server) updatePerson(ctx *gin.Context) {
+	var uri personIDUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var req personRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, err := server.store.GetPerson(ctx, uri.PersonID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errResponse(errPersonNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	person, err := server.store.UpdatePerson(ctx, db.UpdatePersonParams{
+		PersonID:  uri.PersonID,
+		Name:      req.Name,
+		Biography: req.Biography,
+		PhotoUrl:  req.PhotoURL,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionPersonUpdate, auditEntityPerson,
+		person.PersonID, before, person)
+
+	ctx.JSON(http.StatusOK, person)
+}
+
+// deletes a person without movie credits
+func (server *Server) deletePerson(ctx *gin.Context) {
+	var uri personIDUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, err := server.store.GetPerson(ctx, uri.PersonID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errResponse(errPersonNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	count, err := server.store.CountCreditsForPerson(ctx, uri.PersonID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if count > 0 {
+		ctx.JSON(http.StatusConflict,
+			gin.H{"error": "person is still credited in movies"})
+		return
+	}
+
+	err = server.store.DeletePerson(ctx, uri.PersonID)
+	if err != nil {
+		// a credit was added in the meantime
+		if db.ErrorCode(err) == db.ForeignKeyViolation {
+			ctx.JSON(http.StatusConflict,
+				gin.H{"error": "person is still credited in movies"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionPersonDelete, auditEntityPerson,
+		before.PersonID, before, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "person deleted"})
+}
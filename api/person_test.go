@@ -0,0 +1,369 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func (store *movieStore) CreatePerson(ctx context.Context,
+	arg db.CreatePersonParams) (db.Person, error) {
+	person := db.Person{
+		PersonID:  int32(len(store.people) + 1),
+		Name:      arg.Name,
+		Biography: arg.Biography,
+		PhotoUrl:  arg.PhotoUrl,
+	}
+	store.people[person.PersonID] = person
+	return person, nil
+}
+
+func (store *movieStore) GetPerson(ctx context.Context,
+	personID int32) (db.Person, error) {
+	person, ok := store.people[personID]
+	if !ok {
+		return db.Person{}, db.ErrRecordNotFound
+	}
+	return person, nil
+}
+
+func (store *movieStore) UpdatePerson(ctx context.Context,
+	arg db.UpdatePersonParams) (db.Person, error) {
+	person := store.people[arg.PersonID]
+	person.Name = arg.Name
+	person.Biography = arg.Biography
+	person.PhotoUrl = arg.PhotoUrl
+	store.people[arg.PersonID] = person
+	return person, nil
+}
+
+func (store *movieStore) CountCreditsForPerson(ctx context.Context,
+	personID int32) (int64, error) {
+	var count int64
+	for _, credit := range store.credits {
+		if credit.PersonID == personID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (store *movieStore) DeletePerson(ctx context.Context,
+	personID int32) error {
+	delete(store.people, personID)
+	return nil
+}
+
+func (store *movieStore) CreateMovieCredit(ctx context.Context,
+	arg db.CreateMovieCreditParams) (db.MovieCredit, error) {
+	credit := db.MovieCredit{
+		CreditID:      int32(len(store.credits) + 1),
+		MovieID:       arg.MovieID,
+		PersonID:      arg.PersonID,
+		Role:          arg.Role,
+		CharacterName: arg.CharacterName,
+		BillingOrder:  arg.BillingOrder,
+	}
+	store.credits[credit.CreditID] = credit
+	return credit, nil
+}
+
+func (store *movieStore) GetMovieCredit(ctx context.Context,
+	arg db.GetMovieCreditParams) (db.MovieCredit, error) {
+	credit, ok := store.credits[arg.CreditID]
+	if !ok || credit.MovieID != arg.MovieID {
+		return db.MovieCredit{}, db.ErrRecordNotFound
+	}
+	return credit, nil
+}
+
+func (store *movieStore) UpdateMovieCredit(ctx context.Context,
+	arg db.UpdateMovieCreditParams) (db.MovieCredit, error) {
+	credit := store.credits[arg.CreditID]
+	credit.Role = arg.Role
+	credit.CharacterName = arg.CharacterName
+	credit.BillingOrder = arg.BillingOrder
+	store.credits[arg.CreditID] = credit
+	return credit, nil
+}
+
+func (store *movieStore) DeleteMovieCredit(ctx context.Context,
+	arg db.DeleteMovieCreditParams) error {
+	delete(store.credits, arg.CreditID)
+	return nil
+}
+
+func (store *movieStore) ListMovieCredits(ctx context.Context,
+	movieID int32) ([]db.ListMovieCreditsRow, error) {
+	rows := []db.ListMovieCreditsRow{}
+	for id := int32(1); id <= int32(len(store.credits)); id++ {
+		credit, ok := store.credits[id]
+		if !ok || credit.MovieID != movieID {
+			continue
+		}
+		rows = append(rows, db.ListMovieCreditsRow{
+			CreditID:      credit.CreditID,
+			PersonID:      credit.PersonID,
+			Name:          store.people[credit.PersonID].Name,
+			Role:          credit.Role,
+			CharacterName: credit.CharacterName,
+			BillingOrder:  credit.BillingOrder,
+		})
+	}
+	return rows, nil
+}
+
+// ordered by movie and limited to public movies like the query
+func (store *movieStore) ListPersonCredits(ctx context.Context,
+	personID int32) ([]db.ListPersonCreditsRow, error) {
+	rows := []db.ListPersonCreditsRow{}
+	for id := int32(1); id <= int32(len(store.credits)); id++ {
+		credit, ok := store.credits[id]
+		if !ok || credit.PersonID != personID {
+			continue
+		}
+		movie := store.movies[credit.MovieID]
+		if movie.DeletedAt.Valid || !db.IsPublicMovieStatus(movie.Status) {
+			continue
+		}
+		rows = append(rows, db.ListPersonCreditsRow{
+			CreditID:      credit.CreditID,
+			Role:          credit.Role,
+			CharacterName: credit.CharacterName,
+			BillingOrder:  credit.BillingOrder,
+			MovieID:       movie.MovieID,
+			Title:         movie.Title,
+			Status:        movie.Status,
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b db.ListPersonCreditsRow) int {
+		return int(a.MovieID - b.MovieID)
+	})
+	return rows, nil
+}
+
+func (store *movieStore) ListUpcomingShowtimesForMovies(ctx context.Context,
+	movieIDs []int32) ([]db.Showtime, error) {
+	showtimes := []db.Showtime{}
+	for id := int32(1); id <= int32(len(store.showtimes)); id++ {
+		showtime := store.showtimes[id]
+		if slices.Contains(movieIDs, showtime.MovieID) &&
+			showtime.StartTime.Time.After(time.Now().UTC()) {
+			showtimes = append(showtimes, showtime)
+		}
+	}
+	return showtimes, nil
+}
+
+func TestManagePeople(t *testing.T) {
+	store := newMovieStore()
+	server := newTestServer(t, store)
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+	customerToken, _, err := server.tokenMaker.CreateToken(
+		"alice", 2, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	person := map[string]any{
+		"name":      "Greta Gerwig",
+		"biography": "Writer and director",
+		"photo_url": "https://images.example.com/greta.jpg",
+	}
+
+	recorder := serveWithToken(t, server, http.MethodPost, "/people",
+		customerToken, person)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodPost, "/people",
+		adminToken, map[string]any{"name": "No Photo", "photo_url": "photo"})
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodPost, "/people",
+		adminToken, person)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "Greta Gerwig", store.people[1].Name)
+
+	person["name"] = "Greta Celeste Gerwig"
+	recorder = serveWithToken(t, server, http.MethodPut, "/people/1",
+		adminToken, person)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "Greta Celeste Gerwig", store.people[1].Name)
+
+	recorder = serveWithToken(t, server, http.MethodPut, "/people/9",
+		adminToken, person)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	// credited people can't be deleted
+	store.movies[1] = db.Movie{MovieID: 1, Status: db.MovieStatusNowShowing}
+	store.credits[1] = db.MovieCredit{CreditID: 1, MovieID: 1, PersonID: 1,
+		Role: util.CreditRoleDirector}
+
+	recorder = serveWithToken(t, server, http.MethodDelete, "/people/1",
+		adminToken, nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+
+	delete(store.credits, 1)
+	recorder = serveWithToken(t, server, http.MethodDelete, "/people/1",
+		adminToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Empty(t, store.people)
+
+	require.Len(t, store.auditEvents, 3)
+	require.Equal(t, auditActionPersonCreate, store.auditEvents[0].Action)
+	require.Equal(t, auditActionPersonUpdate, store.auditEvents[1].Action)
+	require.Equal(t, auditActionPersonDelete, store.auditEvents[2].Action)
+}
+
+func TestManageMovieCredits(t *testing.T) {
+	store := newMovieStore()
+	server := newTestServer(t, store)
+
+	store.movies[1] = db.Movie{MovieID: 1, Status: db.MovieStatusNowShowing}
+	store.people[1] = db.Person{PersonID: 1, Name: "Margot Robbie"}
+	store.people[2] = db.Person{PersonID: 2, Name: "Greta Gerwig"}
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		path   string
+		credit map[string]any
+		code   int
+	}{
+		{
+			name: "Actor",
+			path: "/movies/1/credits",
+			credit: map[string]any{"person_id": 1, "role": "actor",
+				"character_name": "Barbie", "billing_order": 1},
+			code: http.StatusOK,
+		},
+		{
+			name:   "Director",
+			path:   "/movies/1/credits",
+			credit: map[string]any{"person_id": 2, "role": "director"},
+			code:   http.StatusOK,
+		},
+		{
+			name: "DirectorWithCharacter",
+			path: "/movies/1/credits",
+			credit: map[string]any{"person_id": 2, "role": "director",
+				"character_name": "Narrator"},
+			code: http.StatusBadRequest,
+		},
+		{
+			name:   "BadRole",
+			path:   "/movies/1/credits",
+			credit: map[string]any{"person_id": 2, "role": "producer"},
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "UnknownPerson",
+			path:   "/movies/1/credits",
+			credit: map[string]any{"person_id": 9, "role": "writer"},
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "UnknownMovie",
+			path:   "/movies/9/credits",
+			credit: map[string]any{"person_id": 2, "role": "writer"},
+			code:   http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := serveWithToken(t, server, http.MethodPost, tc.path,
+				adminToken, tc.credit)
+			require.Equal(t, tc.code, recorder.Code, recorder.Body.String())
+		})
+	}
+	require.Len(t, store.credits, 2)
+
+	recorder := serveWithToken(t, server, http.MethodPut,
+		"/movies/1/credits/1", adminToken, map[string]any{
+			"role": "actor", "character_name": "Stereotypical Barbie",
+			"billing_order": 1,
+		})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "Stereotypical Barbie", store.credits[1].CharacterName)
+
+	// the credit belongs to another movie
+	store.movies[2] = db.Movie{MovieID: 2, Status: db.MovieStatusNowShowing}
+	recorder = serveWithToken(t, server, http.MethodDelete,
+		"/movies/2/credits/1", adminToken, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodGet, "/movies/1", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var movie movieDetailResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &movie)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), movie.MovieID)
+	require.Len(t, movie.Credits, 2)
+	require.Equal(t, "Margot Robbie", movie.Credits[0].Name)
+	require.Equal(t, "Stereotypical Barbie", movie.Credits[0].CharacterName)
+
+	recorder = serveWithToken(t, server, http.MethodDelete,
+		"/movies/1/credits/2", adminToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Len(t, store.credits, 1)
+}
+
+func TestGetPerson(t *testing.T) {
+	store := newMovieStore()
+	server := newTestServer(t, store)
+
+	store.people[1] = db.Person{PersonID: 1, Name: "Greta Gerwig"}
+	store.movies[1] = db.Movie{MovieID: 1, Title: "Barbie",
+		Status: db.MovieStatusNowShowing}
+	store.movies[2] = db.Movie{MovieID: 2, Title: "Lady Bird",
+		Status: db.MovieStatusNowShowing}
+	store.credits[1] = db.MovieCredit{CreditID: 1, MovieID: 1, PersonID: 1,
+		Role: util.CreditRoleDirector}
+	store.credits[2] = db.MovieCredit{CreditID: 2, MovieID: 2, PersonID: 1,
+		Role: util.CreditRoleDirector}
+	store.credits[3] = db.MovieCredit{CreditID: 3, MovieID: 1, PersonID: 1,
+		Role: util.CreditRoleWriter}
+
+	now := time.Now().UTC()
+	store.showtimes[1] = db.Showtime{ShowtimeID: 1, MovieID: 1,
+		StartTime: pgtype.Timestamp{Time: now.Add(-time.Hour), Valid: true}}
+	store.showtimes[2] = db.Showtime{ShowtimeID: 2, MovieID: 1,
+		StartTime: pgtype.Timestamp{Time: now.Add(time.Hour), Valid: true}}
+
+	recorder := serveWithToken(t, server, http.MethodGet, "/people/1", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var person personResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &person)
+	require.NoError(t, err)
+	require.Equal(t, "Greta Gerwig", person.Name)
+	require.Len(t, person.Movies, 2)
+
+	barbie := person.Movies[0]
+	require.Equal(t, "Barbie", barbie.Title)
+	require.Len(t, barbie.Credits, 2)
+	require.Len(t, barbie.UpcomingShowtimes, 1)
+	require.Equal(t, int32(2), barbie.UpcomingShowtimes[0].ShowtimeID)
+
+	ladyBird := person.Movies[1]
+	require.Equal(t, "Lady Bird", ladyBird.Title)
+	require.NotNil(t, ladyBird.UpcomingShowtimes)
+	require.Empty(t, ladyBird.UpcomingShowtimes)
+
+	recorder = serveWithToken(t, server, http.MethodGet, "/people/9", "", nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
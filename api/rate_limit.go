@@ -0,0 +1,144 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kratos69/movie-app/limiter"
+	"github.com/kratos69/movie-app/token"
+	"github.com/redis/go-redis/v9"
+)
+
+// route groups with their own rate limit
+const (
+	rateLimitPublic  = "public"
+	rateLimitAuth    = "auth"
+	rateLimitBooking = "booking"
+)
+
+const rateLimitDisabled = "off"
+
+var errRateLimitExceeded = errors.New("rate limit exceeded, slow down")
+
+// picks the key requests are counted against
+type rateLimitKeyFunc func(ctx *gin.Context) string
+
+func keyByIP(ctx *gin.Context) string {
+	return "ip:" + ctx.ClientIP()
+}
+
+// counts per user on authenticated routes, per ip otherwise
+func keyByUser(ctx *gin.Context) string {
+	value, exists := ctx.Get(authorizationPayloadKey)
+	if !exists {
+		return keyByIP(ctx)
+	}
+
+	payload := value.(*token.Payload)
+	return fmt.Sprintf("user:%d", payload.UserID)
+}
+
+// creates the limiter backend named in config
+func newLimiter(backend string, redisAddress string) (limiter.Limiter, error) {
+	switch backend {
+	case "", "memory":
+		return limiter.NewMemoryLimiter(), nil
+	case "redis":
+		client := redis.NewClient(&redis.Options{Addr: redisAddress})
+		return limiter.NewRedisLimiter(client), nil
+	default:
+		return nil, fmt.Errorf("unsupported rate limit backend %s", backend)
+	}
+}
+
+// parses the limit of every route group, falling back to the defaults;
+// a group set to "off" gets no limit
+func parseRateLimits(public string, auth string,
+	booking string) (map[string]*limiter.Limit, error) {
+	values := map[string]string{
+		rateLimitPublic:  valueOrDefault(public, "300/1m"),
+		rateLimitAuth:    valueOrDefault(auth, "20/1m"),
+		rateLimitBooking: valueOrDefault(booking, "30/1m"),
+	}
+
+	limits := make(map[string]*limiter.Limit, len(values))
+	for group, value := range values {
+		if value == rateLimitDisabled {
+			limits[group] = nil
+			continue
+		}
+
+		limit, err := limiter.ParseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s rate limit: %w", group, err)
+		}
+		limits[group] = &limit
+	}
+
+	return limits, nil
+}
+
+func valueOrDefault(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// returns the middleware enforcing the limit of a route group
+func (server *Server) rateLimitMiddleware(group string,
+	keyFunc rateLimitKeyFunc) gin.HandlerFunc {
+	limit := server.rateLimits[group]
+	if limit == nil {
+		return func(ctx *gin.Context) {
+			ctx.Next()
+		}
+	}
+
+	return rateLimitMiddleware(server.limiter, group, *limit, keyFunc)
+}
+
+// returns rate limit middleware function, counting requests for the
+// key returned by keyFunc against limit
+func rateLimitMiddleware(l limiter.Limiter, group string, limit limiter.Limit,
+	keyFunc rateLimitKeyFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, err := l.Allow(ctx, group+":"+keyFunc(ctx), limit)
+		if err != nil {
+			// an unreachable backend shouldn't take the api down with it
+			log.Printf("rate limiter unavailable: %v\n", err)
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.Header("RateLimit-Reset", headerSeconds(result.ResetAfter))
+		ctx.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%s",
+			limit.Requests, headerSeconds(limit.Window)))
+
+		if !result.Allowed {
+			ctx.Header("Retry-After", headerSeconds(result.RetryAfter))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests,
+				errResponse(errRateLimitExceeded))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// formats a duration as whole seconds, rounded up and at least 1
+func headerSeconds(d time.Duration) string {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.Itoa(seconds)
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kratos69/movie-app/limiter"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	limitPath := "/limited"
+	server.router.GET(
+		limitPath,
+		rateLimitMiddleware(limiter.NewMemoryLimiter(), "test",
+			limiter.Limit{Requests: 2, Window: time.Minute}, keyByIP),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	for i := 0; i < 2; i++ {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, limitPath, nil)
+		require.NoError(t, err)
+
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "2", recorder.Header().Get("RateLimit-Limit"))
+		require.NotEmpty(t, recorder.Header().Get("RateLimit-Remaining"))
+		require.NotEmpty(t, recorder.Header().Get("RateLimit-Reset"))
+		require.Empty(t, recorder.Header().Get("Retry-After"))
+	}
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, limitPath, nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "0", recorder.Header().Get("RateLimit-Remaining"))
+	require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+
+	// requests from another address are counted separately
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, limitPath, nil)
+	require.NoError(t, err)
+	request.RemoteAddr = "10.0.0.2:1234"
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestKeyByIPTrustedProxies(t *testing.T) {
+	keyOf := func(server *Server, forwardedFor string) string {
+		server.router.GET("/key", func(ctx *gin.Context) {
+			ctx.String(http.StatusOK, keyByIP(ctx))
+		})
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/key", nil)
+		require.NoError(t, err)
+		request.RemoteAddr = "10.0.0.1:1234"
+		request.Header.Set("X-Forwarded-For", forwardedFor)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder.Body.String()
+	}
+
+	// nobody is trusted by default, so a forged header changes nothing
+	server := newTestServer(t, nil)
+	require.Equal(t, "ip:10.0.0.1", keyOf(server, "203.0.113.7"))
+
+	config := util.Config{
+		TokenSymmetricKey: util.RandomString(32),
+		TrustedProxies:    []string{"10.0.0.0/8"},
+	}
+	server, err := NewServer(config, nil)
+	require.NoError(t, err)
+	require.Equal(t, "ip:203.0.113.7", keyOf(server, "203.0.113.7"))
+
+	config.TrustedProxies = []string{"not-an-ip"}
+	_, err = NewServer(config, nil)
+	require.Error(t, err)
+}
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := parseRateLimits("", "off", "5/1s")
+	require.NoError(t, err)
+
+	require.Equal(t, 300, limits[rateLimitPublic].Requests)
+	require.Nil(t, limits[rateLimitAuth])
+	require.Equal(t, limiter.Limit{Requests: 5, Window: time.Second},
+		*limits[rateLimitBooking])
+
+	_, err = parseRateLimits("lots", "", "")
+	require.Error(t, err)
+}
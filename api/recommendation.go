@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/token"
+)
+
+type listRecommendationsRequest struct {
+	Limit int32 `form:"limit,default=20" binding:"min=1,max=50"`
+}
+
+// the parts of the score are between 0 and 1, see the recommendation
+// queries for how they are weighted
+type recommendedMovie struct {
+	db.Movie
+	Score           float64 `json:"score"`
+	GenreScore      float64 `json:"genre_score"`
+	CoBookingScore  float64 `json:"co_booking_score"`
+	PopularityScore float64 `json:"popularity_score"`
+}
+
+// personalized is false when the caller has no recommendations yet, the
+// movies are then simply the most booked ones
+type listRecommendationsResponse struct {
+	Personalized bool               `json:"personalized"`
+	Movies       []recommendedMovie `json:"movies"`
+}
+
+// upcoming movies picked for the caller from their booking history, the
+// recommendation job keeps them up to date
+func (server *Server) listRecommendations(ctx *gin.Context) {
+	var req listRecommendationsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	rows, err := server.store.ListUserRecommendations(ctx,
+		db.ListUserRecommendationsParams{
+			UserID:   authPayload.UserID,
+			RowLimit: req.Limit,
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if len(rows) > 0 {
+		resp := listRecommendationsResponse{
+			Personalized: true,
+			Movies:       make([]recommendedMovie, 0, len(rows)),
+		}
+		for _, row := range rows {
+			resp.Movies = append(resp.Movies, recommendedMovie{
+				Movie:           row.Movie,
+				Score:           row.Score,
+				GenreScore:      row.GenreScore,
+				CoBookingScore:  row.CoBookingScore,
+				PopularityScore: row.PopularityScore,
+			})
+		}
+
+		ctx.JSON(http.StatusOK, resp)
+		return
+	}
+
+	popular, err := server.store.ListPopularUpcomingMovies(ctx, req.Limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	resp := listRecommendationsResponse{
+		Movies: make([]recommendedMovie, 0, len(popular)),
+	}
+	for _, row := range popular {
+		resp.Movies = append(resp.Movies, recommendedMovie{Movie: row.Movie})
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
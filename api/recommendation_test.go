@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+// recommendationStore returns the canned recommendations of each user
+type recommendationStore struct {
+	*movieStore
+	recommendations map[int64][]db.ListUserRecommendationsRow
+	popular         []db.ListPopularUpcomingMoviesRow
+	limit           int32
+}
+
+func (store *recommendationStore) ListUserRecommendations(
+	ctx context.Context, arg db.ListUserRecommendationsParams) (
+	[]db.ListUserRecommendationsRow, error) {
+	store.limit = arg.RowLimit
+	return store.recommendations[arg.UserID], nil
+}
+
+func (store *recommendationStore) ListPopularUpcomingMovies(
+	ctx context.Context,
+	rowLimit int32) ([]db.ListPopularUpcomingMoviesRow, error) {
+	return store.popular, nil
+}
+
+func TestListRecommendations(t *testing.T) {
+	store := &recommendationStore{
+		movieStore: newMovieStore(),
+		recommendations: map[int64][]db.ListUserRecommendationsRow{
+			2: {{
+				Movie:          db.Movie{MovieID: 1, Title: "Dune"},
+				Score:          0.8,
+				GenreScore:     1,
+				CoBookingScore: 1,
+			}},
+		},
+		popular: []db.ListPopularUpcomingMoviesRow{
+			{Movie: db.Movie{MovieID: 2, Title: "Alien"}, Bookings: 10},
+		},
+	}
+	server := newTestServer(t, store)
+
+	aliceToken, _, err := server.tokenMaker.CreateToken(
+		"alice", 2, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+	bobToken, _, err := server.tokenMaker.CreateToken(
+		"bob", 3, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := serveWithToken(t, server, http.MethodGet,
+		"/users/me/recommendations", "", nil)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/users/me/recommendations?limit=51", aliceToken, nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/users/me/recommendations", aliceToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, int32(20), store.limit)
+
+	var resp listRecommendationsResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.True(t, resp.Personalized)
+	require.Len(t, resp.Movies, 1)
+	require.Equal(t, "Dune", resp.Movies[0].Title)
+	require.Equal(t, 0.8, resp.Movies[0].Score)
+
+	// nothing booked yet, so the most popular movies instead
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/users/me/recommendations", bobToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	resp = listRecommendationsResponse{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.False(t, resp.Personalized)
+	require.Len(t, resp.Movies, 1)
+	require.Equal(t, "Alien", resp.Movies[0].Title)
+}
@@ -2,9 +2,12 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/kratos69/movie-app/db/sqlc"
 	"github.com/kratos69/movie-app/token"
 )
@@ -71,15 +74,99 @@ func (server *Server) cancelReservation(ctx *gin.Context) {
 }
 
 func (server *Server) listReservationsByUser(ctx *gin.Context) {
+	var req pageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	reservations, err := server.store.ListReservationsByUser(
-		ctx, authPayload.UserID)
+	page, err := server.listUserReservationsPage(
+		ctx, authPayload.UserID, req)
 	if err != nil {
+		if errors.Is(err, errInvalidCursor) {
+			ctx.JSON(http.StatusBadRequest, errResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError,
 			gin.H{"error": "could not fetch reservations"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, reservations)
+	ctx.JSON(http.StatusOK, page)
+}
+
+// lists every booked seat of a showtime, for box office staff
+func (server *Server) listReservationsByShowtime(ctx *gin.Context) {
+	var uri struct {
+		ID int32 `uri:"id" binding:"required,min=1"`
+	}
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "invalid showtime ID"})
+		return
+	}
+
+	var req pageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	after, ok, err := decodeCursor[struct{}](req.Cursor, "")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	reservations, err := server.store.ListReservationsByShowtime(ctx,
+		db.ListReservationsByShowtimeParams{
+			ShowtimeID: uri.ID,
+			AfterID:    pgtype.Int8{Int64: after.ID, Valid: ok},
+			RowLimit:   req.Limit + 1,
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError,
+			gin.H{"error": "could not fetch reservations"})
+		return
+	}
+
+	page, err := newPageResponse(reservations, req.Limit, "",
+		func(reservation db.ListReservationsByShowtimeRow) (struct{}, int64) {
+			return struct{}{}, reservation.ReservationID
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// a page of a user's reservations ordered by showtime, shared by the
+// customer and the support staff listing
+func (server *Server) listUserReservationsPage(ctx *gin.Context, userID int64,
+	req pageRequest) (pageResponse[db.ListReservationsByUserPageRow], error) {
+	after, ok, err := decodeCursor[time.Time](req.Cursor, "")
+	if err != nil {
+		return pageResponse[db.ListReservationsByUserPageRow]{}, err
+	}
+
+	reservations, err := server.store.ListReservationsByUserPage(ctx,
+		db.ListReservationsByUserPageParams{
+			UserID:         userID,
+			AfterID:        pgtype.Int8{Int64: after.ID, Valid: ok},
+			AfterStartTime: pgtype.Timestamp{Time: after.Key, Valid: ok},
+			RowLimit:       req.Limit + 1,
+		})
+	if err != nil {
+		return pageResponse[db.ListReservationsByUserPageRow]{}, err
+	}
+
+	return newPageResponse(reservations, req.Limit, "",
+		func(reservation db.ListReservationsByUserPageRow) (time.Time, int64) {
+			return reservation.StartTime.Time, reservation.ReservationID
+		})
 }
@@ -0,0 +1,324 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/token"
+)
+
+var (
+	errReviewNotFound = errors.New("review not found")
+	errNotWatched     = errors.New("you can review a movie once you've seen it")
+)
+
+type listMovieReviewsRequest struct {
+	pageRequest
+}
+
+// visible reviews of a movie, newest first
+func (server *Server) listMovieReviews(ctx *gin.Context) {
+	var uri movieIDStruct
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var req listMovieReviewsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, ok, err := decodeCursor[struct{}](req.Cursor, "")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	movie, found := server.getPublicMovie(ctx, uri.MovieID)
+	if !found {
+		return
+	}
+
+	reviews, err := server.store.ListMovieReviews(ctx, db.ListMovieReviewsParams{
+		MovieID:  movie.MovieID,
+		BeforeID: pgtype.Int8{Int64: before.ID, Valid: ok},
+		RowLimit: req.Limit + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	page, err := newPageResponse(reviews, req.Limit, "",
+		func(review db.ListMovieReviewsRow) (struct{}, int64) {
+			return struct{}{}, review.ReviewID
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+type reviewRequest struct {
+	Rating int32  `json:"rating" binding:"required,min=1,max=5"`
+	Body   string `json:"body" binding:"max=5000"`
+}
+
+// rates a movie the caller has seen, each user reviews a movie once
+func (server *Server) createReview(ctx *gin.Context) {
+	var uri movieIDStruct
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var req reviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	movie, ok := server.getPublicMovie(ctx, uri.MovieID)
+	if !ok {
+		return
+	}
+
+	watched, err := server.store.HasWatchedMovie(ctx, db.HasWatchedMovieParams{
+		UserID:  authPayload.UserID,
+		MovieID: movie.MovieID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if !watched {
+		ctx.JSON(http.StatusForbidden, errResponse(errNotWatched))
+		return
+	}
+
+	review, err := server.store.CreateReviewTx(ctx, db.CreateReviewParams{
+		MovieID: movie.MovieID,
+		UserID:  authPayload.UserID,
+		Rating:  req.Rating,
+		Body:    req.Body,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusConflict,
+				gin.H{"error": "you already reviewed this movie"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, review)
+}
+
+type reviewIDUri struct {
+	ReviewID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// changes the rating or text of the caller's own review
+func (server *Server) updateReview(ctx *gin.Context) {
+	var uri reviewIDUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var req reviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	if _, ok := server.getOwnReview(ctx, uri.ReviewID); !ok {
+		return
+	}
+
+	review, err := server.store.UpdateReviewTx(ctx, db.UpdateReviewParams{
+		ReviewID: uri.ReviewID,
+		Rating:   req.Rating,
+		Body:     req.Body,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errResponse(errReviewNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, review)
+}
+
+func (server *Server) deleteReview(ctx *gin.Context) {
+	var uri reviewIDUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	if _, ok := server.getOwnReview(ctx, uri.ReviewID); !ok {
+		return
+	}
+
+	err := server.store.DeleteReviewTx(ctx, uri.ReviewID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errResponse(errReviewNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "review deleted"})
+}
+
+// both filters are optional, without them every flagged or hidden review
+// is listed
+type listModeratedReviewsRequest struct {
+	Flagged *bool `form:"flagged"`
+	Hidden  *bool `form:"hidden"`
+	pageRequest
+}
+
+func (server *Server) listModeratedReviews(ctx *gin.Context) {
+	var req listModeratedReviewsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, ok, err := decodeCursor[struct{}](req.Cursor, "")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	arg := db.ListModeratedReviewsParams{
+		BeforeID: pgtype.Int8{Int64: before.ID, Valid: ok},
+		RowLimit: req.Limit + 1,
+	}
+	if req.Flagged != nil {
+		arg.Flagged = pgtype.Bool{Bool: *req.Flagged, Valid: true}
+	}
+	if req.Hidden != nil {
+		arg.Hidden = pgtype.Bool{Bool: *req.Hidden, Valid: true}
+	}
+
+	reviews, err := server.store.ListModeratedReviews(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	page, err := newPageResponse(reviews, req.Limit, "",
+		func(review db.Review) (struct{}, int64) {
+			return struct{}{}, review.ReviewID
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// hidden reviews are left out of listings and the movie's rating, flagged
+// ones stay visible until someone decides on them
+type moderateReviewRequest struct {
+	Hidden  bool   `json:"hidden"`
+	Flagged bool   `json:"flagged"`
+	Note    string `json:"note" binding:"max=1000"`
+}
+
+func (server *Server) moderateReview(ctx *gin.Context) {
+	var uri reviewIDUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var req moderateReviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, err := server.store.GetReview(ctx, uri.ReviewID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errResponse(errReviewNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	review, err := server.store.ModerateReviewTx(ctx, db.ModerateReviewParams{
+		ReviewID:       uri.ReviewID,
+		Hidden:         req.Hidden,
+		Flagged:        req.Flagged,
+		ModerationNote: req.Note,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errResponse(errReviewNotFound))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionReviewModerate, auditEntityReview,
+		review.ReviewID, before, review)
+
+	ctx.JSON(http.StatusOK, review)
+}
+
+// ------------------------------------------------------------------//
+// ------------------------------Helper Funcs------------------------//
+// ------------------------------------------------------------------//
+
+// reviews of other users are reported as not found, so their ids can't
+// be probed. ok is false once the error response has been written
+func (server *Server) getOwnReview(ctx *gin.Context,
+	reviewID int64) (db.Review, bool) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	review, err := server.store.GetReview(ctx, reviewID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errResponse(errReviewNotFound))
+			return review, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return review, false
+	}
+
+	if review.UserID != authPayload.UserID {
+		ctx.JSON(http.StatusNotFound, errResponse(errReviewNotFound))
+		return review, false
+	}
+
+	return review, true
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+// reviewStore keeps reviews in memory, watched holds the users that have
+// seen a showtime of any movie
+type reviewStore struct {
+	*movieStore
+	reviews map[int64]db.Review
+	watched map[int64]bool
+}
+
+func newReviewStore() *reviewStore {
+	return &reviewStore{
+		movieStore: newMovieStore(),
+		reviews:    map[int64]db.Review{},
+		watched:    map[int64]bool{},
+	}
+}
+
+func (store *reviewStore) HasWatchedMovie(ctx context.Context,
+	arg db.HasWatchedMovieParams) (bool, error) {
+	return store.watched[arg.UserID], nil
+}
+
+func (store *reviewStore) CreateReviewTx(ctx context.Context,
+	arg db.CreateReviewParams) (db.Review, error) {
+	for _, review := range store.reviews {
+		if review.MovieID == arg.MovieID && review.UserID == arg.UserID {
+			return db.Review{}, &pgconn.PgError{Code: db.UniqueViolation}
+		}
+	}
+
+	review := db.Review{
+		ReviewID: int64(len(store.reviews) + 1),
+		MovieID:  arg.MovieID,
+		UserID:   arg.UserID,
+		Rating:   arg.Rating,
+		Body:     arg.Body,
+	}
+	store.reviews[review.ReviewID] = review
+	return review, nil
+}
+
+func (store *reviewStore) GetReview(ctx context.Context,
+	reviewID int64) (db.Review, error) {
+	review, ok := store.reviews[reviewID]
+	if !ok {
+		return db.Review{}, db.ErrRecordNotFound
+	}
+	return review, nil
+}
+
+func (store *reviewStore) UpdateReviewTx(ctx context.Context,
+	arg db.UpdateReviewParams) (db.Review, error) {
+	review := store.reviews[arg.ReviewID]
+	review.Rating = arg.Rating
+	review.Body = arg.Body
+	store.reviews[arg.ReviewID] = review
+	return review, nil
+}
+
+func (store *reviewStore) DeleteReviewTx(ctx context.Context,
+	reviewID int64) error {
+	delete(store.reviews, reviewID)
+	return nil
+}
+
+func (store *reviewStore) ModerateReviewTx(ctx context.Context,
+	arg db.ModerateReviewParams) (db.Review, error) {
+	review := store.reviews[arg.ReviewID]
+	review.HiddenAt = pgtype.Timestamptz{Time: time.Now(), Valid: arg.Hidden}
+	review.FlaggedAt = pgtype.Timestamptz{Time: time.Now(), Valid: arg.Flagged}
+	review.ModerationNote = arg.ModerationNote
+	store.reviews[arg.ReviewID] = review
+	return review, nil
+}
+
+// newest first, hidden reviews left out
+func (store *reviewStore) ListMovieReviews(ctx context.Context,
+	arg db.ListMovieReviewsParams) ([]db.ListMovieReviewsRow, error) {
+	rows := []db.ListMovieReviewsRow{}
+	for id := int64(len(store.reviews)); id > 0; id-- {
+		review, ok := store.reviews[id]
+		if !ok || review.MovieID != arg.MovieID || review.HiddenAt.Valid ||
+			(arg.BeforeID.Valid && id >= arg.BeforeID.Int64) ||
+			len(rows) == int(arg.RowLimit) {
+			continue
+		}
+		rows = append(rows, db.ListMovieReviewsRow{
+			ReviewID: review.ReviewID,
+			MovieID:  review.MovieID,
+			Rating:   review.Rating,
+			Body:     review.Body,
+			Username: store.users[review.UserID].Username,
+		})
+	}
+	return rows, nil
+}
+
+func TestReviewMovie(t *testing.T) {
+	store := newReviewStore()
+	server := newTestServer(t, store)
+
+	store.movies[1] = db.Movie{MovieID: 1, Status: db.MovieStatusNowShowing}
+	store.movies[2] = db.Movie{MovieID: 2, Status: db.MovieStatusDraft}
+	store.movies[3] = db.Movie{MovieID: 3, Status: db.MovieStatusArchived}
+	store.watched[2] = true
+
+	aliceToken, _, err := server.tokenMaker.CreateToken(
+		"alice", 2, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+	bobToken, _, err := server.tokenMaker.CreateToken(
+		"bob", 3, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		path   string
+		token  string
+		review map[string]any
+		code   int
+	}{
+		{
+			name:   "NoToken",
+			path:   "/movies/1/reviews",
+			review: map[string]any{"rating": 4},
+			code:   http.StatusUnauthorized,
+		},
+		{
+			name:   "NotWatched",
+			path:   "/movies/1/reviews",
+			token:  bobToken,
+			review: map[string]any{"rating": 4},
+			code:   http.StatusForbidden,
+		},
+		{
+			name:   "BadRating",
+			path:   "/movies/1/reviews",
+			token:  aliceToken,
+			review: map[string]any{"rating": 6},
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "UnknownMovie",
+			path:   "/movies/9/reviews",
+			token:  aliceToken,
+			review: map[string]any{"rating": 4},
+			code:   http.StatusNotFound,
+		},
+		{
+			name:   "DraftMovie",
+			path:   "/movies/2/reviews",
+			token:  aliceToken,
+			review: map[string]any{"rating": 4},
+			code:   http.StatusNotFound,
+		},
+		{
+			name:   "ArchivedMovie",
+			path:   "/movies/3/reviews",
+			token:  aliceToken,
+			review: map[string]any{"rating": 4},
+			code:   http.StatusNotFound,
+		},
+		{
+			name:   "OK",
+			path:   "/movies/1/reviews",
+			token:  aliceToken,
+			review: map[string]any{"rating": 4, "body": "Loved it"},
+			code:   http.StatusOK,
+		},
+		{
+			name:   "Twice",
+			path:   "/movies/1/reviews",
+			token:  aliceToken,
+			review: map[string]any{"rating": 5},
+			code:   http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := serveWithToken(t, server, http.MethodPost, tc.path,
+				tc.token, tc.review)
+			require.Equal(t, tc.code, recorder.Code, recorder.Body.String())
+		})
+	}
+	require.Len(t, store.reviews, 1)
+	require.Equal(t, "Loved it", store.reviews[1].Body)
+
+	// other users can't tell the review exists
+	recorder := serveWithToken(t, server, http.MethodPut, "/reviews/1",
+		bobToken, map[string]any{"rating": 1})
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodPut, "/reviews/1",
+		aliceToken, map[string]any{"rating": 3, "body": "Good, not great"})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, int32(3), store.reviews[1].Rating)
+
+	recorder = serveWithToken(t, server, http.MethodDelete, "/reviews/1",
+		bobToken, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodDelete, "/reviews/1",
+		aliceToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Empty(t, store.reviews)
+}
+
+func TestListMovieReviews(t *testing.T) {
+	store := newReviewStore()
+	server := newTestServer(t, store)
+
+	store.movies[1] = db.Movie{MovieID: 1, Status: db.MovieStatusNowShowing}
+	store.movies[2] = db.Movie{MovieID: 2, Status: db.MovieStatusDraft}
+	store.reviews[1] = db.Review{ReviewID: 1, MovieID: 1, UserID: 2, Rating: 5}
+	store.reviews[2] = db.Review{ReviewID: 2, MovieID: 1, UserID: 3, Rating: 1,
+		HiddenAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}}
+	store.reviews[3] = db.Review{ReviewID: 3, MovieID: 1, UserID: 1, Rating: 4}
+
+	recorder := serveWithToken(t, server, http.MethodGet,
+		"/movies/1/reviews?limit=1", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var page pageResponse[db.ListMovieReviewsRow]
+	err := json.Unmarshal(recorder.Body.Bytes(), &page)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	require.Equal(t, "admin", page.Data[0].Username)
+	require.NotNil(t, page.NextCursor)
+
+	// the hidden review is skipped
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/movies/1/reviews?limit=1&cursor="+*page.NextCursor, "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	page = pageResponse[db.ListMovieReviewsRow]{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &page)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	require.Equal(t, int64(1), page.Data[0].ReviewID)
+	require.Nil(t, page.NextCursor)
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/movies/2/reviews", "", nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestModerateReview(t *testing.T) {
+	store := newReviewStore()
+	server := newTestServer(t, store)
+
+	store.reviews[1] = db.Review{ReviewID: 1, MovieID: 1, UserID: 2, Rating: 1}
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+	aliceToken, _, err := server.tokenMaker.CreateToken(
+		"alice", 2, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	moderation := map[string]any{"hidden": true, "note": "spoilers"}
+
+	recorder := serveWithToken(t, server, http.MethodPut,
+		"/reviews/1/moderation", aliceToken, moderation)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodPut,
+		"/reviews/9/moderation", adminToken, moderation)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodPut,
+		"/reviews/1/moderation", adminToken, moderation)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.True(t, store.reviews[1].HiddenAt.Valid)
+	require.False(t, store.reviews[1].FlaggedAt.Valid)
+	require.Equal(t, "spoilers", store.reviews[1].ModerationNote)
+
+	require.Len(t, store.auditEvents, 1)
+	require.Equal(t, auditActionReviewModerate, store.auditEvents[0].Action)
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kratos69/movie-app/revocation"
+	"github.com/kratos69/movie-app/token"
+	"github.com/redis/go-redis/v9"
+)
+
+var errTokenRevoked = errors.New("token has been revoked")
+
+// creates the revocation store named in config, redis is backed by an
+// in-memory store that keeps working while redis is unreachable
+func newRevocationStore(backend string,
+	redisAddress string) (revocation.Store, error) {
+	switch backend {
+	case "", "memory":
+		return revocation.NewMemoryStore(), nil
+	case "redis":
+		client := redis.NewClient(&redis.Options{Addr: redisAddress})
+		return revocation.NewFallbackStore(revocation.NewRedisStore(client),
+			revocation.NewMemoryStore()), nil
+	default:
+		return nil, fmt.Errorf("unsupported revocation backend %s", backend)
+	}
+}
+
+// checks the token against the revocation list and the user's watermark
+func (server *Server) isTokenRevoked(ctx *gin.Context,
+	payload *token.Payload) (bool, error) {
+	return server.revocations.IsRevoked(ctx, payload.ID, payload.UserID,
+		payload.IssuedAt)
+}
+
+// revokes every token issued to the user so far, refresh tokens included
+func (server *Server) revokeUserTokens(ctx *gin.Context, userID int64) error {
+	ttl := max(server.config.AccessTokenDuration,
+		server.config.RefreshTokenDuration)
+	return server.revocations.RevokeUserTokens(ctx, userID, time.Now(), ttl)
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+// sessionStore keeps users and sessions in memory
+type sessionStore struct {
+	db.Store
+	users    map[int64]db.User
+	sessions map[uuid.UUID]db.Session
+}
+
+func (store *sessionStore) GetUserByID(ctx context.Context,
+	userID int64) (db.User, error) {
+	user, ok := store.users[userID]
+	if !ok {
+		return db.User{}, db.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (store *sessionStore) UpdateUserPassword(ctx context.Context,
+	arg db.UpdateUserPasswordParams) (db.User, error) {
+	user := store.users[arg.UserID]
+	user.HashedPassword = arg.HashedPassword
+	store.users[arg.UserID] = user
+	return user, nil
+}
+
+func (store *sessionStore) BlockSession(ctx context.Context,
+	arg db.BlockSessionParams) (int64, error) {
+	session, ok := store.sessions[arg.ID]
+	if !ok || session.Username != arg.Username {
+		return 0, nil
+	}
+	session.IsBlocked = true
+	store.sessions[arg.ID] = session
+	return 1, nil
+}
+
+// sends a request authorized with accessToken
+func serveWithToken(t *testing.T, server *Server, method string,
+	path string, accessToken string, body any) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(method, path, bytes.NewReader(data))
+	require.NoError(t, err)
+	request.Header.Set(authorizationHeaderKey, "Bearer "+accessToken)
+
+	server.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestAuthMiddlewareRevocation(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.router.GET("/auth", server.authMiddleware(),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		})
+
+	revokedToken, revokedPayload, err := server.tokenMaker.CreateToken(
+		"user", 1, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+	otherToken, _, err := server.tokenMaker.CreateToken(
+		"user", 1, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	err = server.revocations.RevokeToken(context.Background(),
+		revokedPayload.ID, revokedPayload.ExpiredAt)
+	require.NoError(t, err)
+
+	recorder := serveWithToken(t, server, http.MethodGet, "/auth",
+		revokedToken, nil)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodGet, "/auth",
+		otherToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// the watermark takes every earlier token of the user
+	err = server.revocations.RevokeUserTokens(context.Background(), 1,
+		time.Now(), time.Minute)
+	require.NoError(t, err)
+
+	recorder = serveWithToken(t, server, http.MethodGet, "/auth",
+		otherToken, nil)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	newToken, _, err := server.tokenMaker.CreateToken(
+		"user", 1, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder = serveWithToken(t, server, http.MethodGet, "/auth",
+		newToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestLogoutUser(t *testing.T) {
+	session := db.Session{ID: uuid.New(), Username: "user"}
+	otherSession := db.Session{ID: uuid.New(), Username: "other"}
+	store := &sessionStore{
+		sessions: map[uuid.UUID]db.Session{
+			session.ID:      session,
+			otherSession.ID: otherSession,
+		},
+	}
+
+	server := newTestServer(t, store)
+
+	accessToken, _, err := server.tokenMaker.CreateToken(
+		"user", 1, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	// the refresh token would stay usable without the session
+	recorder := serveWithToken(t, server, http.MethodPost, "/users/logout",
+		accessToken, nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// someone else's session is left alone
+	recorder = serveWithToken(t, server, http.MethodPost, "/users/logout",
+		accessToken, gin.H{"session_id": otherSession.ID})
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	require.False(t, store.sessions[otherSession.ID].IsBlocked)
+
+	recorder = serveWithToken(t, server, http.MethodPost, "/users/logout",
+		accessToken, gin.H{"session_id": session.ID})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.True(t, store.sessions[session.ID].IsBlocked)
+
+	recorder = serveWithToken(t, server, http.MethodPost, "/users/logout",
+		accessToken, gin.H{"session_id": session.ID})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestChangePassword(t *testing.T) {
+	password := util.RandomString(8)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	store := &sessionStore{
+		users: map[int64]db.User{
+			1: {UserID: 1, Username: "user", HashedPassword: hashedPassword},
+		},
+	}
+
+	server := newTestServer(t, store)
+
+	accessToken, _, err := server.tokenMaker.CreateToken(
+		"user", 1, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := serveWithToken(t, server, http.MethodPut, "/users/me/password",
+		accessToken, gin.H{
+			"current_password": "wrong-password",
+			"new_password":     util.RandomString(8),
+		})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	newPassword := util.RandomString(8)
+	recorder = serveWithToken(t, server, http.MethodPut, "/users/me/password",
+		accessToken, gin.H{
+			"current_password": password,
+			"new_password":     newPassword,
+		})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, util.CheckPassword(newPassword,
+		store.users[1].HashedPassword))
+
+	// the token used for the change is signed out as well
+	recorder = serveWithToken(t, server, http.MethodPut, "/users/me/password",
+		accessToken, gin.H{
+			"current_password": newPassword,
+			"new_password":     util.RandomString(8),
+		})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestNewRevocationStoreUnsupported(t *testing.T) {
+	_, err := newRevocationStore("memcached", "")
+	require.Error(t, err)
+}
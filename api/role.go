@@ -0,0 +1,317 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+)
+
+type roleResponse struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newRoleResponse(role db.Role, permissions []string) roleResponse {
+	return roleResponse{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+		CreatedAt:   role.CreatedAt,
+	}
+}
+
+// list every permission that can be granted to a role
+func (server *Server) listPermissions(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, util.Permissions)
+}
+
+func (server *Server) listRoles(ctx *gin.Context) {
+	roles, err := server.store.ListRoles(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	resp := make([]roleResponse, 0, len(roles))
+	for _, role := range roles {
+		permissions, err := server.store.ListRolePermissions(ctx, role.Name)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+		resp = append(resp, newRoleResponse(role, permissions))
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+type createRoleRequest struct {
+	Name        string   `json:"name" binding:"required,alphanum,max=32"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+func (server *Server) createRole(ctx *gin.Context) {
+	var req createRoleRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	if err := validatePermissions(req.Permissions); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	result, err := server.store.CreateRoleTx(ctx, db.CreateRoleTxParams{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusConflict,
+				gin.H{"error": "role already exists"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	resp := newRoleResponse(result.Role, result.Permissions)
+	server.recordAudit(ctx, auditActionRoleCreate, auditEntityRole,
+		resp.Name, nil, resp)
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+type roleNameUri struct {
+	Name string `uri:"name" binding:"required"`
+}
+
+type updateRoleRequest struct {
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// replaces the description and the whole permission set of a role
+func (server *Server) updateRole(ctx *gin.Context) {
+	var uri roleNameUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var req updateRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	if err := validatePermissions(req.Permissions); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	// admins must always be able to manage roles, otherwise
+	// nobody could give the permission back
+	if uri.Name == util.AdminRole &&
+		!hasPermissions(req.Permissions,
+			[]string{util.RolesManagePermission}) {
+		err := fmt.Errorf("%s role must keep %s permission",
+			util.AdminRole, util.RolesManagePermission)
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, err := server.roleSnapshot(ctx, uri.Name)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	result, err := server.store.UpdateRoleTx(ctx, db.UpdateRoleTxParams{
+		Name:        uri.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	resp := newRoleResponse(result.Role, result.Permissions)
+	server.recordAudit(ctx, auditActionRoleUpdate, auditEntityRole,
+		resp.Name, before, resp)
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// deletes a role that is neither built-in nor assigned to any user
+func (server *Server) deleteRole(ctx *gin.Context) {
+	var uri roleNameUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	if uri.Name == util.AdminRole || uri.Name == util.CustomerRole {
+		ctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "built-in roles cannot be deleted"})
+		return
+	}
+
+	before, err := server.roleSnapshot(ctx, uri.Name)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	count, err := server.store.CountUsersWithRole(ctx, uri.Name)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if count > 0 {
+		ctx.JSON(http.StatusConflict,
+			gin.H{"error": "role is still assigned to users"})
+		return
+	}
+
+	err = server.store.DeleteRole(ctx, uri.Name)
+	if err != nil {
+		if db.ErrorCode(err) == db.ForeignKeyViolation {
+			ctx.JSON(http.StatusConflict,
+				gin.H{"error": "role is still assigned to users"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionRoleDelete, auditEntityRole,
+		uri.Name, before, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "role deleted"})
+}
+
+type updateUserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// assigns a role to a user
+func (server *Server) updateUserRole(ctx *gin.Context) {
+	var uri inputUserID
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var req updateUserRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, err := server.store.GetUserByID(ctx, uri.UserID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	_, err = server.store.GetRole(ctx, req.Role)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "role not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	user, err := server.store.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+		UserID: uri.UserID,
+		Role:   req.Role,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	// tokens carry the role, so the old ones must go
+	err = server.revokeUserTokens(ctx, user.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionUserRole, auditEntityUser,
+		user.UserID, newUserAuditSnapshot(before), newUserAuditSnapshot(user))
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// ------------------------------------------------------------------//
+// ------------------------------Helper Funcs------------------------//
+// ------------------------------------------------------------------//
+
+func validatePermissions(permissions []string) error {
+	for _, permission := range permissions {
+		if !util.IsSupportedPermission(permission) {
+			return fmt.Errorf("unsupported permission %s", permission)
+		}
+	}
+	return nil
+}
+
+// returns a role with its permissions, as stored before a change
+func (server *Server) roleSnapshot(ctx *gin.Context,
+	name string) (roleResponse, error) {
+	role, err := server.store.GetRole(ctx, name)
+	if err != nil {
+		return roleResponse{}, err
+	}
+
+	permissions, err := server.store.ListRolePermissions(ctx, name)
+	if err != nil {
+		return roleResponse{}, err
+	}
+
+	return newRoleResponse(role, permissions), nil
+}
@@ -0,0 +1,14 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// hashes a long random secret, like an api key or a guest lookup token,
+// for storage. guessing such a secret is hopeless, so a fast hash is
+// enough, unlike for passwords
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
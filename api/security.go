@@ -0,0 +1,106 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/kratos69/movie-app/util"
+)
+
+// defaults for a JSON api that is never framed or rendered as a page
+const (
+	defaultHSTSMaxAge   = 180 * 24 * time.Hour
+	defaultCSP          = "default-src 'none'; frame-ancestors 'none'"
+	defaultFrameOptions = "DENY"
+	defaultCORSMaxAge   = 12 * time.Hour
+)
+
+const developmentEnvironment = "development"
+
+// builds the cors config from util.Config, nil when no origin is allowed
+func newCORSConfig(config util.Config) (*cors.Config, error) {
+	if len(config.CORSAllowedOrigins) == 0 {
+		return nil, nil
+	}
+
+	corsConfig := cors.Config{
+		AllowOrigins: config.CORSAllowedOrigins,
+		AllowMethods: config.CORSAllowedMethods,
+		AllowHeaders: config.CORSAllowedHeaders,
+		ExposeHeaders: []string{
+			"Content-Length", "Retry-After",
+			"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset",
+			"RateLimit-Policy",
+		},
+		AllowCredentials: config.CORSAllowCredentials,
+		MaxAge:           config.CORSMaxAge,
+	}
+
+	if len(corsConfig.AllowMethods) == 0 {
+		corsConfig.AllowMethods = []string{
+			"GET", "POST", "PUT", "DELETE", "OPTIONS",
+		}
+	}
+
+	if len(corsConfig.AllowHeaders) == 0 {
+		corsConfig.AllowHeaders = []string{
+			"Origin", "Content-Type", "Accept", "Authorization", csrfHeaderKey,
+		}
+	}
+
+	if corsConfig.MaxAge == 0 {
+		corsConfig.MaxAge = defaultCORSMaxAge
+	}
+
+	if len(corsConfig.AllowOrigins) == 1 && corsConfig.AllowOrigins[0] == "*" {
+		// browsers refuse credentials for a wildcard origin
+		if corsConfig.AllowCredentials {
+			return nil, errors.New("cors credentials can't be allowed " +
+				"for every origin")
+		}
+		corsConfig.AllowOrigins = nil
+		corsConfig.AllowAllOrigins = true
+	}
+
+	if err := corsConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid cors config: %w", err)
+	}
+
+	return &corsConfig, nil
+}
+
+// returns the middleware setting security headers on every response
+func securityHeadersMiddleware(config util.Config) gin.HandlerFunc {
+	hstsMaxAge := config.HSTSMaxAge
+	if hstsMaxAge == 0 {
+		hstsMaxAge = defaultHSTSMaxAge
+	}
+
+	// plain http in development must not pin the host to https
+	var hsts string
+	if config.Environment != developmentEnvironment && hstsMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d; includeSubDomains",
+			int(hstsMaxAge.Seconds()))
+	}
+
+	csp := valueOrDefault(config.CSP, defaultCSP)
+	frameOptions := strings.ToUpper(
+		valueOrDefault(config.FrameOptions, defaultFrameOptions))
+
+	return func(ctx *gin.Context) {
+		header := ctx.Writer.Header()
+		if hsts != "" {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+		header.Set("Content-Security-Policy", csp)
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("X-Frame-Options", frameOptions)
+		header.Set("Referrer-Policy", "no-referrer")
+
+		ctx.Next()
+	}
+}
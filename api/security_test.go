@@ -0,0 +1,199 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newSecurityTestServer(t *testing.T, config util.Config) *Server {
+	config.TokenSymmetricKey = util.RandomString(32)
+	config.AccessTokenDuration = time.Minute
+
+	server, err := NewServer(config, nil)
+	require.NoError(t, err)
+
+	server.router.GET("/ping", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+	return server
+}
+
+func TestCORSPreflight(t *testing.T) {
+	server := newSecurityTestServer(t, util.Config{
+		CORSAllowedOrigins:   []string{"https://movies.example.com"},
+		CORSAllowCredentials: true,
+	})
+
+	testCases := []struct {
+		name          string
+		origin        string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "AllowedOrigin",
+			origin: "https://movies.example.com",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+				header := recorder.Header()
+				require.Equal(t, "https://movies.example.com",
+					header.Get("Access-Control-Allow-Origin"))
+				require.Equal(t, "true",
+					header.Get("Access-Control-Allow-Credentials"))
+				require.Contains(t, header.Get("Access-Control-Allow-Methods"),
+					http.MethodDelete)
+				require.Contains(t, header.Get("Access-Control-Allow-Headers"),
+					http.CanonicalHeaderKey(csrfHeaderKey))
+				require.Equal(t, "43200", header.Get("Access-Control-Max-Age"))
+			},
+		},
+		{
+			name:   "UnknownOrigin",
+			origin: "https://evil.example.com",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Empty(t,
+					recorder.Header().Get("Access-Control-Allow-Origin"))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodOptions, "/movies", nil)
+			require.NoError(t, err)
+			request.Header.Set("Origin", tc.origin)
+			request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			request.Header.Set("Access-Control-Request-Headers",
+				"Content-Type, X-CSRF-Token")
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCORSSimpleRequest(t *testing.T) {
+	server := newSecurityTestServer(t, util.Config{
+		CORSAllowedOrigins: []string{"https://movies.example.com"},
+	})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/ping", nil)
+	require.NoError(t, err)
+	request.Header.Set("Origin", "https://movies.example.com")
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "https://movies.example.com",
+		recorder.Header().Get("Access-Control-Allow-Origin"))
+	require.Empty(t, recorder.Header().Get("Access-Control-Allow-Credentials"))
+	exposed := recorder.Header().Get("Access-Control-Expose-Headers")
+	for _, header := range []string{
+		"Retry-After", "RateLimit-Limit", "RateLimit-Remaining",
+		"RateLimit-Reset", "RateLimit-Policy",
+	} {
+		require.Contains(t, exposed, http.CanonicalHeaderKey(header))
+	}
+}
+
+func TestCORSDisabled(t *testing.T) {
+	server := newSecurityTestServer(t, util.Config{})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/ping", nil)
+	require.NoError(t, err)
+	request.Header.Set("Origin", "https://movies.example.com")
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestNewCORSConfig(t *testing.T) {
+	corsConfig, err := newCORSConfig(util.Config{
+		CORSAllowedOrigins: []string{"*"},
+	})
+	require.NoError(t, err)
+	require.True(t, corsConfig.AllowAllOrigins)
+
+	_, err = newCORSConfig(util.Config{
+		CORSAllowedOrigins:   []string{"*"},
+		CORSAllowCredentials: true,
+	})
+	require.Error(t, err)
+
+	_, err = newCORSConfig(util.Config{
+		CORSAllowedOrigins: []string{"movies.example.com"},
+	})
+	require.Error(t, err)
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	testCases := []struct {
+		name          string
+		config        util.Config
+		checkResponse func(t *testing.T, header http.Header)
+	}{
+		{
+			name:   "Defaults",
+			config: util.Config{},
+			checkResponse: func(t *testing.T, header http.Header) {
+				require.Equal(t, "max-age=15552000; includeSubDomains",
+					header.Get("Strict-Transport-Security"))
+				require.Equal(t, defaultCSP,
+					header.Get("Content-Security-Policy"))
+				require.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+				require.Equal(t, "DENY", header.Get("X-Frame-Options"))
+				require.Equal(t, "no-referrer", header.Get("Referrer-Policy"))
+			},
+		},
+		{
+			name: "Configured",
+			config: util.Config{
+				HSTSMaxAge:   time.Hour,
+				CSP:          "default-src 'self'",
+				FrameOptions: "sameorigin",
+			},
+			checkResponse: func(t *testing.T, header http.Header) {
+				require.Equal(t, "max-age=3600; includeSubDomains",
+					header.Get("Strict-Transport-Security"))
+				require.Equal(t, "default-src 'self'",
+					header.Get("Content-Security-Policy"))
+				require.Equal(t, "SAMEORIGIN", header.Get("X-Frame-Options"))
+			},
+		},
+		{
+			name:   "Development",
+			config: util.Config{Environment: developmentEnvironment},
+			checkResponse: func(t *testing.T, header http.Header) {
+				require.Empty(t, header.Get("Strict-Transport-Security"))
+				require.Equal(t, "nosniff", header.Get("X-Content-Type-Options"))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newSecurityTestServer(t, tc.config)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/ping", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+			tc.checkResponse(t, recorder.Header())
+		})
+	}
+}
@@ -2,87 +2,265 @@ package api
 
 import (
 	"fmt"
+	"net/http"
 
+	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/limiter"
+	"github.com/kratos69/movie-app/mail"
+	"github.com/kratos69/movie-app/revocation"
 	"github.com/kratos69/movie-app/token"
 	"github.com/kratos69/movie-app/util"
 )
 
 // servers HTTP requests for the insta-app
 type Server struct {
-	config     util.Config
-	store      db.Store
-	tokenMaker token.Maker
-	router     *gin.Engine
+	config         util.Config
+	store          db.Store
+	tokenMaker     token.Maker
+	passwords      *util.PasswordHasher
+	passwordPolicy *util.PasswordPolicy
+	revocations    revocation.Store
+	mailer         mail.EmailSender
+	limiter        limiter.Limiter
+	rateLimits     map[string]*limiter.Limit
+	cookieSameSite http.SameSite
+	cors           *cors.Config
+	oidc           *oidcClient
+	router         *gin.Engine
 }
 
 // Creates HTTP server and Setup Routing
 func NewServer(config util.Config, store db.Store) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	tokenMaker, err := newTokenMaker(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	passwords, err := util.NewPasswordHasher(config.PasswordHashAlgo,
+		util.Argon2Params{
+			Memory:     config.Argon2Memory,
+			Iterations: config.Argon2Iterations,
+			Threads:    config.Argon2Threads,
+		}, config.BcryptCost)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create password hasher: %w", err)
+	}
+
+	passwordPolicy, err := util.NewPasswordPolicy(config.PasswordMinLength,
+		config.BreachedPasswords)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create password policy: %w", err)
+	}
+
+	revocations, err := newRevocationStore(config.RevocationBackend,
+		config.RedisAddress)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create revocation store: %w", err)
+	}
+
+	rateLimiter, err := newLimiter(config.RateLimitBackend, config.RedisAddress)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create rate limiter: %w", err)
+	}
+
+	rateLimits, err := parseRateLimits(config.RateLimitPublic,
+		config.RateLimitAuth, config.RateLimitBooking)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse rate limits: %w", err)
+	}
+
+	cookieSameSite, err := parseSameSite(config.CookieSameSite)
+	if err != nil {
+		return nil, err
+	}
+
+	corsConfig, err := newCORSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:         config,
+		store:          store,
+		tokenMaker:     tokenMaker,
+		passwords:      passwords,
+		passwordPolicy: passwordPolicy,
+		revocations:    revocations,
+		limiter:        rateLimiter,
+		rateLimits:     rateLimits,
+		cookieSameSite: cookieSameSite,
+		cors:           corsConfig,
+	}
+
+	if config.EmailSenderAddress != "" {
+		server.mailer = mail.NewGmailSender(config.EmailSenderName,
+			config.EmailSenderAddress, config.EmailSenderPassword)
+	}
+
+	if config.OIDCIssuerURL != "" {
+		server.oidc = newOIDCClient(config)
 	}
 
 	// Routes
-	server.setupRoutes()
+	if err := server.setupRoutes(); err != nil {
+		return nil, err
+	}
 
 	return server, nil
 }
 
-func (server *Server) setupRoutes() {
+func (server *Server) setupRoutes() error {
 	router := gin.Default()
 
-	// cors
-	// router.Use(cors.New(cors.Config{
-	// 	AllowOrigins:     []string{"http://127.0.0.1:5173", "http://localhost:5173"}, // frontend origin
-	// 	AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-	// 	AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-	// 	AllowCredentials: true,
-	// 	ExposeHeaders:    []string{"Content-Length"},
-	// 	MaxAge:           12 * time.Hour,
-	// }))
+	// only the proxies in TRUSTED_PROXIES may name the client ip with
+	// X-Forwarded-For, otherwise clients pick the ip they're limited by
+	err := router.SetTrustedProxies(server.config.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	router.Use(securityHeadersMiddleware(server.config))
+
+	// cors, e.g. CORS_ALLOWED_ORIGINS=http://localhost:5173 for the frontend
+	if server.cors != nil {
+		router.Use(cors.New(*server.cors))
+	}
 
 	// routes
-	router.POST("/users", server.createUser)
-	router.POST("/users/login", server.loginUser)
-	router.POST("/tokens/renew_access", server.renewAccessToken)
+	authLimit := server.rateLimitMiddleware(rateLimitAuth, keyByIP)
+	router.POST("/users", authLimit, server.createUser)
+	router.POST("/users/login", authLimit, server.loginUser)
+	router.POST("/users/login/verify", authLimit, server.verifyLogin)
+	router.GET("/users/unlock", authLimit, server.showUnlockAccount)
+	router.POST("/users/unlock", authLimit, server.unlockAccount)
+	router.GET("/users/verify_email", authLimit, server.verifyEmail)
+	router.POST("/tokens/renew_access", authLimit, server.renewAccessToken)
 
-	router.GET("/movies", server.listAllMovies)
-	router.GET("/movies/:id", server.getMovieByID)
+	if _, ok := server.tokenMaker.(token.PublicKeyProvider); ok {
+		router.GET("/.well-known/jwks.json", server.listTokenKeys)
+	}
 
-	router.GET("/showtimes/:id", server.getShowtime)
-	router.GET("/showtimes", server.listShowtimes)
+	if server.oidc != nil {
+		router.GET("/auth/oidc/login", authLimit, server.oidcLogin)
+		router.GET("/auth/oidc/callback", authLimit, server.oidcCallback)
+	}
 
-	router.GET("/showtimes/:id/seats", server.listSeatsForShowtime)
+	publicRoutes := router.Group("/").Use(
+		server.rateLimitMiddleware(rateLimitPublic, keyByIP))
+	publicRoutes.GET("/movies", server.listAllMovies)
+	publicRoutes.GET("/movies/search", server.searchMovies)
+	publicRoutes.GET("/movies/:id", server.getMovieByID)
+	publicRoutes.GET("/movies/:id/reviews", server.listMovieReviews)
+	publicRoutes.GET("/genres", server.listGenres)
+	publicRoutes.GET("/people", server.listPeople)
+	publicRoutes.GET("/people/:id", server.getPerson)
 
-	// for both users and admins
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker,
-		[]string{util.AdminRole, util.CustomerRole}))
+	publicRoutes.GET("/showtimes/:id", server.getShowtime)
+	publicRoutes.GET("/showtimes", server.listShowtimes)
+
+	publicRoutes.GET("/showtimes/:id/seats", server.listSeatsForShowtime)
+
+	// bookings without an account, managed with the emailed lookup token
+	guestRoutes := router.Group("/guest").Use(
+		server.rateLimitMiddleware(rateLimitBooking, keyByIP))
+	guestRoutes.POST("/reservations", server.reserveGuestSeats)
+	guestRoutes.GET("/reservations/:reference", server.getGuestBooking)
+	guestRoutes.DELETE("/reservations/:reference", server.cancelGuestBooking)
+
+	// for any logged in user
+	authRoutes := router.Group("/").Use(server.authMiddleware())
 	authRoutes.GET("/users/:user_id", server.getUserByID)
+	authRoutes.POST("/users/logout", server.logoutUser)
+	authRoutes.PUT("/users/me/password", server.changePassword)
+	authRoutes.GET("/users/me/export", server.exportAccount)
+	authRoutes.DELETE("/users/me", server.deleteAccount)
+	authRoutes.POST("/users/me/confirmations",
+		server.rateLimitMiddleware(rateLimitAuth, keyByUser),
+		server.requestConfirmation)
+	authRoutes.POST("/users/me/verify_email", server.resendVerifyEmail)
+	authRoutes.POST("/users/me/claim_bookings", server.claimBookings)
+	authRoutes.POST("/users/me/2fa/enroll", server.enrollTwoFactor)
+	authRoutes.POST("/users/me/2fa/confirm", server.confirmTwoFactor)
+	authRoutes.POST("/users/me/2fa/disable", server.disableTwoFactor)
+	authRoutes.GET("/users/me/recommendations", server.listRecommendations)
+	authRoutes.GET("/users/me/watchlist", server.listWatchlist)
+	authRoutes.POST("/users/me/watchlist", server.addToWatchlist)
+	authRoutes.DELETE("/users/me/watchlist/:id", server.removeFromWatchlist)
+	authRoutes.POST("/movies/:id/reviews", server.createReview)
+	authRoutes.PUT("/reviews/:id", server.updateReview)
+	authRoutes.DELETE("/reviews/:id", server.deleteReview)
+
+	reservationRoutes := router.Group("/").Use(
+		server.authMiddleware(util.ReservationsWritePermission),
+		server.rateLimitMiddleware(rateLimitBooking, keyByUser))
+	reservationRoutes.POST("/reservations", server.reserveSeats)
+	reservationRoutes.GET("/reservations", server.listReservationsByUser)
+	reservationRoutes.DELETE("/reservations/:id", server.cancelReservation)
+
+	reservationAdminRoutes := router.Group("/").Use(
+		server.authMiddleware(util.ReservationsReadAllPermission))
+	reservationAdminRoutes.GET("/showtimes/:id/reservations",
+		server.listReservationsByShowtime)
+
+	movieRoutes := router.Group("/").Use(
+		server.authMiddleware(util.MoviesWritePermission))
+	movieRoutes.POST("/movies", server.createMovie)
+	movieRoutes.PUT("/movies/:id", server.updateMovie)
+	movieRoutes.DELETE("/movies/:id", server.deleteMovie)
+	movieRoutes.POST("/genres", server.createGenre)
+	movieRoutes.PUT("/genres/:id", server.updateGenre)
+	movieRoutes.DELETE("/genres/:id", server.deleteGenre)
+	movieRoutes.POST("/people", server.createPerson)
+	movieRoutes.PUT("/people/:id", server.updatePerson)
+	movieRoutes.DELETE("/people/:id", server.deletePerson)
+	movieRoutes.POST("/movies/:id/credits", server.createMovieCredit)
+	movieRoutes.PUT("/movies/:id/credits/:credit_id", server.updateMovieCredit)
+	movieRoutes.DELETE("/movies/:id/credits/:credit_id",
+		server.deleteMovieCredit)
+
+	showtimeRoutes := router.Group("/").Use(
+		server.authMiddleware(util.ShowtimesWritePermission))
+	showtimeRoutes.POST("/showtimes", server.createShowtime)
+	showtimeRoutes.DELETE("/showtimes/:id", server.deleteShowtime)
+
+	roleRoutes := router.Group("/").Use(
+		server.authMiddleware(util.RolesManagePermission))
+	roleRoutes.GET("/permissions", server.listPermissions)
+	roleRoutes.GET("/roles", server.listRoles)
+	roleRoutes.POST("/roles", server.createRole)
+	roleRoutes.PUT("/roles/:name", server.updateRole)
+	roleRoutes.DELETE("/roles/:name", server.deleteRole)
+	roleRoutes.PUT("/users/:user_id/role", server.updateUserRole)
+
+	userAdminRoutes := router.Group("/").Use(
+		server.authMiddleware(util.UsersManagePermission))
+	userAdminRoutes.GET("/users", server.listUsers)
+	userAdminRoutes.POST("/users/:user_id/unlock", server.adminUnlockUser)
+	userAdminRoutes.POST("/users/:user_id/disable", server.disableUser)
+	userAdminRoutes.POST("/users/:user_id/enable", server.enableUser)
+	userAdminRoutes.GET("/users/:user_id/reservations",
+		server.listUserReservations)
+
+	apiKeyRoutes := router.Group("/").Use(
+		server.authMiddleware(util.APIKeysManagePermission))
+	apiKeyRoutes.POST("/api_keys", server.createAPIKey)
+	apiKeyRoutes.GET("/api_keys", server.listAPIKeys)
+	apiKeyRoutes.DELETE("/api_keys/:id", server.revokeAPIKey)
 
-	authRoutes.POST("/reservations", server.reserveSeats)
-	authRoutes.GET("/reservations", server.listReservationsByUser)
-	authRoutes.DELETE("/reservations/:id", server.cancelReservation)
+	auditRoutes := router.Group("/").Use(
+		server.authMiddleware(util.AuditReadPermission))
+	auditRoutes.GET("/audit_events", server.listAuditEvents)
 
-	// for only admins
-	adminRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker,
-		[]string{util.AdminRole}))
-	adminRoutes.POST("/movies", server.createMovie)
-	adminRoutes.PUT("/movies/:id", server.updateMovie)
-	adminRoutes.DELETE("/movies/:id", server.deleteMovie)
+	reviewRoutes := router.Group("/").Use(
+		server.authMiddleware(util.ReviewsModeratePermission))
+	reviewRoutes.GET("/reviews/moderation", server.listModeratedReviews)
+	reviewRoutes.PUT("/reviews/:id/moderation", server.moderateReview)
 
-	adminRoutes.POST("/showtimes", server.createShowtime)
-	adminRoutes.DELETE("/showtimes/:id", server.deleteShowtime)
-	
 	server.router = router
 
+	return nil
 }
 
 // Starts and runs HTTP server on a specific address
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -58,19 +59,51 @@ func (server *Server) createShowtime(ctx *gin.Context) {
 		return
 	}
 
+	movie, err := server.store.GetMovie(ctx, showtimeReq.MovieID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound,
+				gin.H{"error": "movie not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if !db.IsPublicMovieStatus(movie.Status) {
+		ctx.JSON(http.StatusConflict, errResponse(db.ErrMovieNotPublic))
+		return
+	}
+
 	arg := db.CreateShowtimeParams{
 		MovieID:   showtimeReq.MovieID,
 		StartTime: startTime,
+		EndTime:   showtimeEndTime(t, movie.RuntimeMinutes),
 		Price:     price,
 	}
 
-	showtime, err := server.store.CreateShowtime(ctx, arg)
+	// the first upcoming showtime also alerts the movie's watchers
+	result, err := server.store.CreateShowtimeTx(ctx, arg)
 	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound,
+				gin.H{"error": "movie not found"})
+			return
+		}
+		if errors.Is(err, db.ErrMovieNotPublic) {
+			ctx.JSON(http.StatusConflict, errResponse(err))
+			return
+		}
+
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, showtime)
+	server.recordAudit(ctx, auditActionShowtimeCreate, auditEntityShowtime,
+		result.Showtime.ShowtimeID, nil, result.Showtime)
+
+	ctx.JSON(http.StatusOK, result.Showtime)
 }
 
 func (server *Server) getShowtime(ctx *gin.Context) {
@@ -93,21 +126,31 @@ func (server *Server) getShowtime(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, showtime)
 }
 
+type listShowtimesRequest struct {
+	pageRequest
+	Date string `form:"date"`
+}
+
 // /showtimes
 // /showtimes?date=2025-05-01
+// /showtimes?date=2025-05-01&cursor=...
 func (server *Server) listShowtimes(ctx *gin.Context) {
-	dateQuery := ctx.Query("date")
+	var req listShowtimesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
 
 	var start, end time.Time
 	var err error
 
-	if dateQuery == "" {
+	if req.Date == "" {
 		// No date param = return upcoming showtimes
 		start = time.Now().UTC()
 		end = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC) // Far future to fetch all
 	} else {
 		// Parse date format: "2006-01-02"
-		start, err = time.Parse("2006-01-02", dateQuery)
+		start, err = time.Parse("2006-01-02", req.Date)
 		if err != nil {
 			ctx.JSON(http.StatusBadRequest,
 				gin.H{"error": "invalid date format, use YYYY-MM-DD"})
@@ -117,22 +160,35 @@ func (server *Server) listShowtimes(ctx *gin.Context) {
 		end = start.Add(24 * time.Hour)
 	}
 
-	// Convert to pgtype.Timestamp
-	startPg := pgtype.Timestamp{Time: start, Valid: true}
-	endPg := pgtype.Timestamp{Time: end, Valid: true}
+	after, ok, err := decodeCursor[time.Time](req.Cursor, "")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
 
-	// Call modified DB function
 	showtimes, err := server.store.ListShowtimesBetween(ctx,
 		db.ListShowtimesBetweenParams{
-			StartTime:   startPg,
-			StartTime_2: endPg,
+			StartsFrom:     pgtype.Timestamp{Time: start, Valid: true},
+			StartsBefore:   pgtype.Timestamp{Time: end, Valid: true},
+			AfterID:        pgtype.Int4{Int32: int32(after.ID), Valid: ok},
+			AfterStartTime: pgtype.Timestamp{Time: after.Key, Valid: ok},
+			RowLimit:       req.Limit + 1,
 		})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, showtimes)
+	page, err := newPageResponse(showtimes, req.Limit, "",
+		func(showtime db.ListShowtimesBetweenRow) (time.Time, int64) {
+			return showtime.StartTime.Time, int64(showtime.ShowtimeID)
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
 }
 
 // delete a showTime
@@ -147,14 +203,53 @@ func (server *Server) deleteShowtime(ctx *gin.Context) {
 		return
 	}
 
-	err := server.store.DeleteShowtime(ctx, uri.ID)
+	showtime, err := server.store.GetShowtime(ctx, uri.ID)
 	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound,
+				gin.H{"error": "showtime not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	err = server.store.DeleteShowtime(ctx, uri.ID)
+	if err != nil {
+		// bookings are never removed along with their showtime
+		if db.ErrorCode(err) == db.ForeignKeyViolation {
+			ctx.JSON(http.StatusConflict,
+				gin.H{"error": "showtime has reservations"})
+			return
+		}
+
 		ctx.JSON(http.StatusInternalServerError,
 			gin.H{"error": "unable to delete a showtime"})
 		return
 	}
 
+	server.recordAudit(ctx, auditActionShowtimeDelete, auditEntityShowtime,
+		showtime.ShowtimeID, showtime, nil)
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "showtime deleted",
 	})
 }
+
+// ------------------------------------------------------------------//
+// ------------------------------Helper Funcs------------------------//
+// ------------------------------------------------------------------//
+
+// a showtime ends once the movie has run, the end time stays null while
+// the runtime isn't known
+func showtimeEndTime(start time.Time, runtimeMinutes int32) pgtype.Timestamp {
+	if runtimeMinutes <= 0 {
+		return pgtype.Timestamp{}
+	}
+
+	return pgtype.Timestamp{
+		Time:  start.Add(time.Duration(runtimeMinutes) * time.Minute),
+		Valid: true,
+	}
+}
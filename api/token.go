@@ -14,14 +14,22 @@ type renewAccessTokenRequest struct {
 }
 
 type renewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
+	AccessToken          string    `json:"access_token,omitempty"`
 	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
 }
 
 func (server *Server) renewAccessToken(ctx *gin.Context) {
 	var input renewAccessTokenRequest
 
-	if err := ctx.ShouldBindJSON(&input); err != nil {
+	// browsers in cookie session mode renew with their refresh cookie
+	cookieToken := server.sessionCookie(ctx, refreshTokenCookie)
+	if cookieToken != "" && ctx.Request.ContentLength <= 0 {
+		if !checkCSRF(ctx) {
+			ctx.JSON(http.StatusForbidden, errResponse(errInvalidCSRFToken))
+			return
+		}
+		input.RefreshToken = cookieToken
+	} else if err := ctx.ShouldBindJSON(&input); err != nil {
 		ctx.JSON(http.StatusBadRequest, errResponse(err))
 		return
 	}
@@ -33,6 +41,16 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
+	revoked, err := server.isTokenRevoked(ctx, refreshPayload)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if revoked {
+		ctx.JSON(http.StatusUnauthorized, errResponse(errTokenRevoked))
+		return
+	}
+
 	session, err := server.store.GetSessionByID(ctx, refreshPayload.ID)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -85,5 +103,11 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 		AccessTokenExpiresAt: accessPayload.ExpiredAt,
 	}
 
+	if cookieToken != "" && input.RefreshToken == cookieToken {
+		server.setCookie(ctx, accessTokenCookie, accessToken,
+			accessPayload.ExpiredAt, true)
+		resp.AccessToken = ""
+	}
+
 	ctx.JSON(http.StatusOK, resp)
 }
\ No newline at end of file
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kratos69/movie-app/token"
+	"github.com/kratos69/movie-app/util"
+)
+
+// token makers selectable with TOKEN_MAKER
+const (
+	tokenMakerLocal  = "paseto-local"
+	tokenMakerPublic = "paseto-public"
+)
+
+// creates the token maker named in config
+func newTokenMaker(config util.Config) (token.Maker, error) {
+	switch config.TokenMaker {
+	case "", tokenMakerLocal:
+		return token.NewPasetoMaker(config.TokenSymmetricKey)
+	case tokenMakerPublic:
+		return token.NewPublicPasetoMaker(config.TokenSigningKey,
+			config.TokenVerifyKeys)
+	default:
+		return nil, fmt.Errorf("unsupported token maker %s", config.TokenMaker)
+	}
+}
+
+// Ed25519 key in JWK format (RFC 8037)
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// lists the public keys other services verify access tokens with
+func (server *Server) listTokenKeys(ctx *gin.Context) {
+	provider, ok := server.tokenMaker.(token.PublicKeyProvider)
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "token keys are not public"})
+		return
+	}
+
+	keySet := jsonWebKeySet{Keys: []jsonWebKey{}}
+	for _, key := range provider.PublicKeys() {
+		keySet.Keys = append(keySet.Keys, jsonWebKey{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key.Key),
+			KeyID:     key.KeyID,
+			Use:       "sig",
+			Algorithm: "EdDSA",
+		})
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, keySet)
+}
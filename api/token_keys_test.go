@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kratos69/movie-app/token"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTokenKeys(t *testing.T) {
+	signingKey, _, err := token.GenerateKeyPair("current")
+	require.NoError(t, err)
+	_, oldVerifyKey, err := token.GenerateKeyPair("previous")
+	require.NoError(t, err)
+
+	config := util.Config{
+		TokenMaker:          tokenMakerPublic,
+		TokenSigningKey:     signingKey,
+		TokenVerifyKeys:     []string{oldVerifyKey},
+		AccessTokenDuration: time.Minute,
+	}
+
+	server, err := NewServer(config, nil)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var keySet jsonWebKeySet
+	err = json.Unmarshal(recorder.Body.Bytes(), &keySet)
+	require.NoError(t, err)
+	require.Len(t, keySet.Keys, 2)
+
+	keys := server.tokenMaker.(token.PublicKeyProvider).PublicKeys()
+	for i, key := range keySet.Keys {
+		require.Equal(t, "OKP", key.KeyType)
+		require.Equal(t, "Ed25519", key.Curve)
+		require.Equal(t, "EdDSA", key.Algorithm)
+		require.Equal(t, keys[i].KeyID, key.KeyID)
+		require.Equal(t, base64.RawURLEncoding.EncodeToString(keys[i].Key), key.X)
+	}
+}
+
+func TestListTokenKeysSymmetric(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestNewTokenMakerUnsupported(t *testing.T) {
+	_, err := newTokenMaker(util.Config{TokenMaker: "jwt-hs256"})
+	require.Error(t, err)
+}
@@ -0,0 +1,411 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/token"
+	"github.com/kratos69/movie-app/util"
+)
+
+const (
+	totpIssuer = "Movie App"
+
+	loginChallengeDuration    = 5 * time.Minute
+	loginChallengeMaxAttempts = 5
+	recoveryCodeCount         = 10
+)
+
+var (
+	errTwoFactorRequired = errors.New(
+		"two factor authentication must be enabled for this role")
+	errInvalidTwoFactorCode = errors.New("invalid two factor code")
+	errInvalidChallenge     = errors.New("invalid or expired login challenge")
+)
+
+// returns true if users with role must use two factor authentication
+func (server *Server) requiresTwoFactor(role string) bool {
+	for _, r := range server.config.TwoFactorRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type loginChallengeResponse struct {
+	TwoFactorRequired  bool      `json:"two_factor_required"`
+	ChallengeToken     string    `json:"challenge_token"`
+	ChallengeExpiresAt time.Time `json:"challenge_expires_at"`
+}
+
+// starts the second login step of a user with two factor enabled
+func (server *Server) createLoginChallenge(ctx *gin.Context,
+	user db.User) (loginChallengeResponse, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return loginChallengeResponse{}, err
+	}
+
+	challenge, err := server.store.CreateLoginChallenge(ctx,
+		db.CreateLoginChallengeParams{
+			ID:        id,
+			UserID:    user.UserID,
+			ExpiredAt: time.Now().Add(loginChallengeDuration),
+		})
+	if err != nil {
+		return loginChallengeResponse{}, err
+	}
+
+	resp := loginChallengeResponse{
+		TwoFactorRequired:  true,
+		ChallengeToken:     challenge.ID.String(),
+		ChallengeExpiresAt: challenge.ExpiredAt,
+	}
+
+	return resp, nil
+}
+
+type verifyLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required,uuid"`
+	Code           string `json:"code" binding:"required"`
+}
+
+// completes a login with a TOTP or recovery code
+func (server *Server) verifyLogin(ctx *gin.Context) {
+	var req verifyLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	challengeID, err := uuid.Parse(req.ChallengeToken)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	challenge, err := server.store.IncrementLoginChallengeAttempts(ctx,
+		challengeID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusUnauthorized, errResponse(errInvalidChallenge))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if time.Now().After(challenge.ExpiredAt) ||
+		challenge.Attempts > loginChallengeMaxAttempts {
+		err = server.store.DeleteLoginChallenge(ctx, challenge.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusUnauthorized, errResponse(errInvalidChallenge))
+		return
+	}
+
+	user, err := server.store.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	// wrong codes count against the account like wrong passwords, new
+	// challenges don't give a fresh set of guesses
+	clientIP := ctx.ClientIP()
+	retryAfter, err := server.loginRetryAfter(ctx, user.Email, clientIP)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if retryAfter > 0 {
+		rejectThrottledLogin(ctx, retryAfter)
+		return
+	}
+
+	valid, err := server.checkTwoFactorCode(ctx, user, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if !valid {
+		err = server.recordLoginFailure(ctx, user.Email, clientIP, &user)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusUnauthorized, errResponse(errInvalidTwoFactorCode))
+		return
+	}
+
+	err = server.store.DeleteLoginChallenge(ctx, challenge.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	err = server.unlockLogin(ctx, user.Email)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	resp, err := server.createLoginSession(ctx, user)
+	if err != nil {
+		if errors.Is(err, errAccountDisabled) {
+			ctx.JSON(http.StatusForbidden, errResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+type enrollTwoFactorResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// generates a new TOTP secret, two factor stays off until it's confirmed
+func (server *Server) enrollTwoFactor(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUserByID(ctx, authPayload.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if user.TotpEnabled {
+		ctx.JSON(http.StatusConflict,
+			gin.H{"error": "two factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	_, err = server.store.SetUserTOTPSecret(ctx, db.SetUserTOTPSecretParams{
+		UserID:     user.UserID,
+		TotpSecret: secret,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollTwoFactorResponse{
+		Secret:          secret,
+		ProvisioningURI: util.TOTPProvisioningURI(totpIssuer, user.Email, secret),
+	})
+}
+
+type confirmTwoFactorRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type confirmTwoFactorResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// enables two factor once the user proves their app generates codes,
+// the recovery codes are only ever shown in this response
+func (server *Server) confirmTwoFactor(ctx *gin.Context) {
+	var req confirmTwoFactorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUserByID(ctx, authPayload.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if user.TotpEnabled {
+		ctx.JSON(http.StatusConflict,
+			gin.H{"error": "two factor authentication is already enabled"})
+		return
+	}
+	if user.TotpSecret == "" {
+		ctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "enroll before confirming two factor authentication"})
+		return
+	}
+
+	step, valid := util.TOTPStep(req.Code, user.TotpSecret, time.Now())
+	if valid {
+		valid, err = server.useTOTPStep(ctx, user.UserID, step)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+	}
+	if !valid {
+		ctx.JSON(http.StatusBadRequest, errResponse(errInvalidTwoFactorCode))
+		return
+	}
+
+	codes, hashedCodes, err := generateRecoveryCodes()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	_, err = server.store.EnableTOTPTx(ctx, db.EnableTOTPTxParams{
+		UserID:              user.UserID,
+		HashedRecoveryCodes: hashedCodes,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, confirmTwoFactorResponse{RecoveryCodes: codes})
+}
+
+// users without a password of their own confirm with an emailed code
+type disableTwoFactorRequest struct {
+	Password         string `json:"password" binding:"required_without=ConfirmationCode"`
+	ConfirmationCode string `json:"confirmation_code" binding:"required_without=Password"`
+	Code             string `json:"code" binding:"required"`
+}
+
+// turns two factor off, asking for both factors once more
+func (server *Server) disableTwoFactor(ctx *gin.Context) {
+	var req disableTwoFactorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUserByID(ctx, authPayload.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if !user.TotpEnabled {
+		ctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "two factor authentication is not enabled"})
+		return
+	}
+
+	confirmed, err := server.confirmUser(ctx, user, req.Password,
+		req.ConfirmationCode, confirmDisableTwoFactor)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if !confirmed {
+		ctx.JSON(http.StatusUnauthorized, errResponse(errInvalidConfirmation))
+		return
+	}
+
+	valid, err := server.checkTwoFactorCode(ctx, user, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if !valid {
+		ctx.JSON(http.StatusUnauthorized, errResponse(errInvalidTwoFactorCode))
+		return
+	}
+
+	_, err = server.store.DisableTOTPTx(ctx, user.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK,
+		gin.H{"message": "two factor authentication disabled"})
+}
+
+// ------------------------------------------------------------------//
+// ------------------------------Helper Funcs------------------------//
+// ------------------------------------------------------------------//
+
+// accepts a current TOTP code or burns one of the user's recovery codes
+func (server *Server) checkTwoFactorCode(ctx *gin.Context, user db.User,
+	code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if step, ok := util.TOTPStep(code, user.TotpSecret, time.Now()); ok {
+		return server.useTOTPStep(ctx, user.UserID, step)
+	}
+
+	_, err := server.store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+		UserID:     user.UserID,
+		HashedCode: hashRecoveryCode(code),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// returns the plain recovery codes for the user and their hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashedCodes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		random, err := util.RandomSecureString(10)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		code := random[:5] + "-" + random[5:]
+		codes = append(codes, code)
+		hashedCodes = append(hashedCodes, hashRecoveryCode(code))
+	}
+
+	return codes, hashedCodes, nil
+}
+
+// recovery codes are random enough that a plain sha256 is safe to store
+func hashRecoveryCode(code string) string {
+	return hashSecret(strings.ToLower(strings.ReplaceAll(code, " ", "")))
+}
+
+// burns the time step of an accepted TOTP code, false when a code of the
+// same or a later step was already used
+func (server *Server) useTOTPStep(ctx *gin.Context, userID int64,
+	step int64) (bool, error) {
+	rows, err := server.store.UseTOTPStep(ctx, db.UseTOTPStepParams{
+		UserID: userID,
+		Step:   step,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
@@ -1,36 +1,47 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
 	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/token"
 	"github.com/kratos69/movie-app/util"
 )
 
 type createUserRequest struct {
 	Name     string `json:"name" binding:"required,alphanum"`
 	Username string `json:"username" binding:"required,alphanum"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 }
 
 type userResponse struct {
-	UserID    int64     `json:"user_id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	UserID          int64      `json:"user_id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	IsEmailVerified bool       `json:"is_email_verified"`
+	DisabledAt      *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
 }
 
 func newUserResponse(user db.User) userResponse {
 	return userResponse{
-		UserID:    user.UserID,
-		Username:  user.Name,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
+		UserID:          user.UserID,
+		Username:        user.Name,
+		Email:           user.Email,
+		Role:            user.Role,
+		IsEmailVerified: user.IsEmailVerified,
+		DisabledAt:      timePtr(user.DisabledAt),
+		CreatedAt:       user.CreatedAt,
 	}
 }
 
@@ -42,7 +53,14 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := util.HashPassword(req.Password)
+	err := server.passwordPolicy.Validate(req.Password, req.Name,
+		req.Username, req.Email)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	hashedPassword, err := server.passwords.Hash(req.Password)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errResponse(err))
 		return
@@ -61,6 +79,13 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
+	// the account works without it, the user can ask for a new link
+	err = server.sendVerifyEmail(ctx, user)
+	if err != nil {
+		log.Printf("cannot send verification email to user %d: %v\n",
+			user.UserID, err)
+	}
+
 	resp := newUserResponse(user)
 
 	ctx.JSON(http.StatusOK, resp)
@@ -91,13 +116,14 @@ func (server *Server) getUserByID(ctx *gin.Context) {
 
 type loginUserRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
 type loginUserResponse struct {
 	SessionID             uuid.UUID    `json:"session_id"`
-	AccessToken           string       `json:"access_token"`
-	RefreshToken          string       `json:"refresh_token"`
+	AccessToken           string       `json:"access_token,omitempty"`
+	RefreshToken          string       `json:"refresh_token,omitempty"`
+	CSRFToken             string       `json:"csrf_token,omitempty"`
 	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
 	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
 	User                  userResponse `json:"user"`
@@ -111,10 +137,22 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	clientIP := ctx.ClientIP()
+	retryAfter, err := server.loginRetryAfter(ctx, input.Email, clientIP)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if retryAfter > 0 {
+		rejectThrottledLogin(ctx, retryAfter)
+		return
+	}
+
 	user, err := server.store.GetUserByEmail(ctx, input.Email)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errResponse(err))
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.checkDummyPassword(input.Password)
+			server.rejectLogin(ctx, input.Email, clientIP, nil)
 			return
 		}
 
@@ -124,10 +162,56 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	err = util.CheckPassword(input.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		server.rejectLogin(ctx, input.Email, clientIP, &user)
+		return
+	}
+
+	// hashes made with an older algorithm or weaker parameters are
+	// upgraded now that we have the plain password
+	server.rehashPassword(ctx, user, input.Password)
+
+	// with two factor the failures are only cleared once the code went
+	// through as well, see verifyLogin
+	if !user.TotpEnabled {
+		err = server.unlockLogin(ctx, user.Email)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+	}
+
+	if user.DisabledAt.Valid {
+		ctx.JSON(http.StatusForbidden, errResponse(errAccountDisabled))
 		return
 	}
 
+	if user.TotpEnabled {
+		challenge, err := server.createLoginChallenge(ctx, user)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	resp, err := server.createLoginSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// issues the access and refresh tokens of a fully authenticated user
+func (server *Server) createLoginSession(ctx *gin.Context,
+	user db.User) (loginUserResponse, error) {
+	if user.DisabledAt.Valid {
+		return loginUserResponse{}, errAccountDisabled
+	}
+
 	// creating access token
 	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
@@ -136,8 +220,7 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
-		return
+		return loginUserResponse{}, err
 	}
 
 	// creating refresh token
@@ -148,8 +231,7 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
-		return
+		return loginUserResponse{}, err
 	}
 
 	// create session
@@ -163,8 +245,7 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		ExpiredAt:    refreshPayload.ExpiredAt,
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
-		return
+		return loginUserResponse{}, err
 	}
 
 	resp := loginUserResponse{
@@ -176,5 +257,279 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		User:                  newUserResponse(user),
 	}
 
-	ctx.JSON(http.StatusOK, resp)
+	if server.config.CookieSessions {
+		err = server.setSessionCookies(ctx, &resp)
+		if err != nil {
+			return loginUserResponse{}, err
+		}
+	}
+
+	return resp, nil
+}
+
+// records the failed attempt and answers with the uniform error
+func (server *Server) rejectLogin(ctx *gin.Context, email string,
+	clientIP string, user *db.User) {
+	err := server.recordLoginFailure(ctx, email, clientIP, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusUnauthorized, errResponse(errInvalidCredentials))
+}
+
+type unlockAccountRequest struct {
+	ID         int64  `form:"id" json:"id" binding:"required,min=1"`
+	SecretCode string `form:"secret_code" json:"secret_code" binding:"required,len=32"`
+}
+
+// the emailed link only shows this page, unlocking happens when it is
+// submitted so link scanners and prefetchers can't use up the code
+var unlockAccountPage = template.Must(template.New("unlock").Parse(
+	`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unlock your account</title></head>
+<body>
+<form method="post" action="unlock">
+<input type="hidden" name="id" value="{{.ID}}">
+<input type="hidden" name="secret_code" value="{{.SecretCode}}">
+<button type="submit">Unlock my account</button>
+</form>
+</body>
+</html>
+`))
+
+// shows the page the unlock link in the email opens
+func (server *Server) showUnlockAccount(ctx *gin.Context) {
+	var req unlockAccountRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var page bytes.Buffer
+	if err := unlockAccountPage.Execute(&page, req); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// unlocks an account with the code sent by email, from the unlock page
+// or as json
+func (server *Server) unlockAccount(ctx *gin.Context) {
+	var req unlockAccountRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	unlock, err := server.store.UseAccountUnlock(ctx,
+		db.UseAccountUnlockParams{
+			ID:         req.ID,
+			SecretCode: req.SecretCode,
+		})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest,
+				gin.H{"error": "invalid or expired unlock link"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	err = server.unlockLogin(ctx, unlock.Email)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "account unlocked"})
+}
+
+// lets an admin lift the lockout of an account
+func (server *Server) adminUnlockUser(ctx *gin.Context) {
+	var uri inputUserID
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserByID(ctx, uri.UserID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	err = server.unlockLogin(ctx, user.Email)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionUserUnlock, auditEntityUser,
+		user.UserID, nil, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "account unlocked"})
+}
+
+type logoutUserRequest struct {
+	SessionID uuid.UUID `json:"session_id"`
+}
+
+// revokes the access token of the request and blocks the session so its
+// refresh token can't be renewed either. session_id is required unless a
+// refresh cookie names the session
+func (server *Server) logoutUser(ctx *gin.Context) {
+	var req logoutUserRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, errResponse(err))
+			return
+		}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// browsers don't know the session id, their refresh cookie does
+	refreshToken := server.sessionCookie(ctx, refreshTokenCookie)
+	if req.SessionID == uuid.Nil && refreshToken != "" {
+		refreshPayload, err := server.tokenMaker.VerifyToken(refreshToken)
+		if err == nil {
+			req.SessionID = refreshPayload.ID
+		}
+	}
+
+	// without it the refresh token would outlive the logout
+	if req.SessionID == uuid.Nil && refreshToken == "" {
+		ctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "session_id is required"})
+		return
+	}
+
+	if req.SessionID != uuid.Nil {
+		rows, err := server.store.BlockSession(ctx, db.BlockSessionParams{
+			ID:       req.SessionID,
+			Username: authPayload.Username,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errResponse(err))
+			return
+		}
+		if rows == 0 {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+			return
+		}
+	}
+
+	err := server.revocations.RevokeToken(ctx, authPayload.ID,
+		authPayload.ExpiredAt)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if server.config.CookieSessions {
+		server.clearSessionCookies(ctx)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// users without a password of their own confirm with an emailed code
+type changePasswordRequest struct {
+	CurrentPassword  string `json:"current_password" binding:"required_without=ConfirmationCode"`
+	ConfirmationCode string `json:"confirmation_code" binding:"required_without=CurrentPassword"`
+	NewPassword      string `json:"new_password" binding:"required"`
+}
+
+// changes the password and signs the user out everywhere
+func (server *Server) changePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUserByID(ctx, authPayload.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	confirmed, err := server.confirmUser(ctx, user, req.CurrentPassword,
+		req.ConfirmationCode, confirmChangePassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+	if !confirmed {
+		ctx.JSON(http.StatusUnauthorized, errResponse(errInvalidConfirmation))
+		return
+	}
+
+	err = server.passwordPolicy.Validate(req.NewPassword, user.Name,
+		user.Username, user.Email)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	hashedPassword, err := server.passwords.Hash(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	_, err = server.store.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		UserID:         user.UserID,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	err = server.revokeUserTokens(ctx, user.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK,
+		gin.H{"message": "password changed, please log in again"})
+}
+
+// stores a new hash of the password when the current one was made with
+// other settings than the hasher uses now. the login already succeeded,
+// so a failure is only logged
+func (server *Server) rehashPassword(ctx context.Context, user db.User,
+	password string) {
+	if !server.passwords.NeedsRehash(user.HashedPassword) {
+		return
+	}
+
+	hashedPassword, err := server.passwords.Hash(password)
+	if err == nil {
+		_, err = server.store.UpdateUserPassword(ctx,
+			db.UpdateUserPasswordParams{
+				UserID:         user.UserID,
+				HashedPassword: hashedPassword,
+			})
+	}
+	if err != nil {
+		log.Printf("cannot rehash password of user %d: %v\n",
+			user.UserID, err)
+	}
 }
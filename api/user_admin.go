@@ -0,0 +1,186 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/token"
+)
+
+var errAccountDisabled = errors.New("account is disabled")
+
+type listUsersRequest struct {
+	Search string `form:"search" binding:"max=100"`
+	Role   string `form:"role"`
+	pageRequest
+}
+
+// lists users, optionally matching search against username, name and
+// email and filtered by role
+func (server *Server) listUsers(ctx *gin.Context) {
+	var req listUsersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	after, ok, err := decodeCursor[struct{}](req.Cursor, "")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	users, err := server.store.ListUsers(ctx, db.ListUsersParams{
+		Search:   searchText(req.Search),
+		Role:     pgtype.Text{String: req.Role, Valid: req.Role != ""},
+		AfterID:  pgtype.Int8{Int64: after.ID, Valid: ok},
+		RowLimit: req.Limit + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	page, err := newPageResponse(users, req.Limit, "",
+		func(user db.User) (struct{}, int64) {
+			return struct{}{}, user.UserID
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	resp := pageResponse[userResponse]{
+		Data:       make([]userResponse, 0, len(page.Data)),
+		NextCursor: page.NextCursor,
+	}
+	for _, user := range page.Data {
+		resp.Data = append(resp.Data, newUserResponse(user))
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// disables an account, blocking login and ending every session
+func (server *Server) disableUser(ctx *gin.Context) {
+	var uri inputUserID
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.UserID == uri.UserID {
+		ctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "you can't disable your own account"})
+		return
+	}
+
+	before, err := server.store.GetUserByID(ctx, uri.UserID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	user, err := server.store.DisableUserTx(ctx, uri.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	err = server.revokeUserTokens(ctx, user.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionUserDisable, auditEntityUser,
+		user.UserID, newUserAuditSnapshot(before), newUserAuditSnapshot(user))
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// enables an account again, which also cancels a pending deletion
+func (server *Server) enableUser(ctx *gin.Context) {
+	var uri inputUserID
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, err := server.store.GetUserByID(ctx, uri.UserID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if before.AnonymizedAt.Valid {
+		ctx.JSON(http.StatusConflict,
+			gin.H{"error": "account has been deleted and can't be restored"})
+		return
+	}
+
+	user, err := server.store.EnableUser(ctx, uri.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	server.recordAudit(ctx, auditActionUserEnable, auditEntityUser,
+		user.UserID, newUserAuditSnapshot(before), newUserAuditSnapshot(user))
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// lists the reservations of any user, for support staff
+func (server *Server) listUserReservations(ctx *gin.Context) {
+	var uri inputUserID
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	var req pageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	_, err := server.store.GetUserByID(ctx, uri.UserID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	page, err := server.listUserReservationsPage(ctx, uri.UserID, req)
+	if err != nil {
+		if errors.Is(err, errInvalidCursor) {
+			ctx.JSON(http.StatusBadRequest, errResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError,
+			gin.H{"error": "could not fetch reservations"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
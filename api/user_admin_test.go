@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+// userAdminStore keeps users and audit events in memory
+type userAdminStore struct {
+	db.Store
+	users        map[int64]db.User
+	reservations map[int64][]db.ListReservationsByUserRow
+	auditEvents  []db.CreateAuditEventParams
+}
+
+func newUserAdminStore() *userAdminStore {
+	return &userAdminStore{
+		users: map[int64]db.User{
+			1: {UserID: 1, Username: "admin", Name: "admin",
+				Email: "admin@email.com", Role: util.AdminRole},
+			2: {UserID: 2, Username: "alice", Name: "alice",
+				Email: "alice@email.com", Role: util.CustomerRole},
+			3: {UserID: 3, Username: "bob", Name: "bob",
+				Email: "bob@email.com", Role: util.CustomerRole},
+		},
+		reservations: map[int64][]db.ListReservationsByUserRow{
+			2: {{ReservationID: 7, UserID: pgtype.Int8{Int64: 2, Valid: true},
+				Title: "Movie"}},
+		},
+	}
+}
+
+func (store *userAdminStore) ListRolePermissions(ctx context.Context,
+	role string) ([]string, error) {
+	if role == util.AdminRole {
+		return util.Permissions, nil
+	}
+	return nil, nil
+}
+
+func (store *userAdminStore) GetUserByID(ctx context.Context,
+	userID int64) (db.User, error) {
+	user, ok := store.users[userID]
+	if !ok {
+		return db.User{}, db.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (store *userAdminStore) ListUsers(ctx context.Context,
+	arg db.ListUsersParams) ([]db.User, error) {
+	users := []db.User{}
+	for id := int64(1); id <= int64(len(store.users)); id++ {
+		user := store.users[id]
+		if arg.Search.Valid && !strings.Contains(user.Username, arg.Search.String) {
+			continue
+		}
+		if arg.Role.Valid && user.Role != arg.Role.String {
+			continue
+		}
+		if arg.AfterID.Valid && user.UserID <= arg.AfterID.Int64 {
+			continue
+		}
+		if len(users) == int(arg.RowLimit) {
+			break
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (store *userAdminStore) DisableUserTx(ctx context.Context,
+	userID int64) (db.User, error) {
+	user := store.users[userID]
+	user.DisabledAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	store.users[userID] = user
+	return user, nil
+}
+
+func (store *userAdminStore) EnableUser(ctx context.Context,
+	userID int64) (db.User, error) {
+	user := store.users[userID]
+	user.DisabledAt = pgtype.Timestamptz{}
+	store.users[userID] = user
+	return user, nil
+}
+
+func (store *userAdminStore) ListReservationsByUser(ctx context.Context,
+	userID int64) ([]db.ListReservationsByUserRow, error) {
+	return store.reservations[userID], nil
+}
+
+func (store *userAdminStore) ListReservationsByUserPage(ctx context.Context,
+	arg db.ListReservationsByUserPageParams) (
+	[]db.ListReservationsByUserPageRow, error) {
+	rows := []db.ListReservationsByUserPageRow{}
+	for _, reservation := range store.reservations[arg.UserID] {
+		rows = append(rows, db.ListReservationsByUserPageRow(reservation))
+	}
+	return rows, nil
+}
+
+func (store *userAdminStore) CreateAuditEvent(ctx context.Context,
+	arg db.CreateAuditEventParams) (db.AuditEvent, error) {
+	store.auditEvents = append(store.auditEvents, arg)
+	return db.AuditEvent{ID: int64(len(store.auditEvents))}, nil
+}
+
+func TestListUsers(t *testing.T) {
+	store := newUserAdminStore()
+	server := newTestServer(t, store)
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := serveWithToken(t, server, http.MethodGet,
+		"/users?role=customer&search=ali", adminToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var page pageResponse[userResponse]
+	err = json.Unmarshal(recorder.Body.Bytes(), &page)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	require.Equal(t, int64(2), page.Data[0].UserID)
+	require.Nil(t, page.NextCursor)
+
+	// walk every user two at a time
+	ids := []int64{}
+	cursor := ""
+	for {
+		recorder = serveWithToken(t, server, http.MethodGet,
+			"/users?limit=2&cursor="+cursor, adminToken, nil)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		page = pageResponse[userResponse]{}
+		err = json.Unmarshal(recorder.Body.Bytes(), &page)
+		require.NoError(t, err)
+		for _, user := range page.Data {
+			ids = append(ids, user.UserID)
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		cursor = *page.NextCursor
+	}
+	require.Equal(t, []int64{1, 2, 3}, ids)
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/users?limit=500", adminToken, nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	// customers can't list users
+	customerToken, _, err := server.tokenMaker.CreateToken(
+		"alice", 2, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder = serveWithToken(t, server, http.MethodGet, "/users",
+		customerToken, nil)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestDisableUser(t *testing.T) {
+	store := newUserAdminStore()
+	server := newTestServer(t, store)
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+	customerToken, _, err := server.tokenMaker.CreateToken(
+		"alice", 2, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := serveWithToken(t, server, http.MethodPost,
+		"/users/1/disable", adminToken, nil)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodPost,
+		"/users/99/disable", adminToken, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serveWithToken(t, server, http.MethodPost,
+		"/users/2/disable", adminToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.True(t, store.users[2].DisabledAt.Valid)
+
+	// every token the user holds stops working
+	recorder = serveWithToken(t, server, http.MethodGet, "/users/2",
+		customerToken, nil)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// and no new session can be created
+	_, err = server.createLoginSession(nil, store.users[2])
+	require.ErrorIs(t, err, errAccountDisabled)
+
+	require.Len(t, store.auditEvents, 1)
+	event := store.auditEvents[0]
+	require.Equal(t, auditActionUserDisable, event.Action)
+	require.Equal(t, auditEntityUser, event.EntityType)
+	require.Equal(t, "2", event.EntityID)
+	require.Equal(t, int64(1), event.ActorID.Int64)
+	require.NotContains(t, string(event.Before), "disabled_at")
+	require.Contains(t, string(event.After), "disabled_at")
+	// audit events outlive anonymization, so they hold no personal data
+	require.NotContains(t, string(event.After), "alice")
+
+	recorder = serveWithToken(t, server, http.MethodPost,
+		"/users/2/enable", adminToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.False(t, store.users[2].DisabledAt.Valid)
+	require.Len(t, store.auditEvents, 2)
+	require.Equal(t, auditActionUserEnable, store.auditEvents[1].Action)
+}
+
+func TestListUserReservations(t *testing.T) {
+	store := newUserAdminStore()
+	server := newTestServer(t, store)
+
+	adminToken, _, err := server.tokenMaker.CreateToken(
+		"admin", 1, util.AdminRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := serveWithToken(t, server, http.MethodGet,
+		"/users/2/reservations", adminToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var page pageResponse[db.ListReservationsByUserPageRow]
+	err = json.Unmarshal(recorder.Body.Bytes(), &page)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	require.Equal(t, int64(7), page.Data[0].ReservationID)
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/users/99/reservations", adminToken, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/token"
+	"github.com/kratos69/movie-app/util"
+)
+
+var errEmailNotVerified = errors.New("email address is not verified")
+
+type verifyEmailRequest struct {
+	EmailID    int64  `form:"email_id" binding:"required,min=1"`
+	SecretCode string `form:"secret_code" binding:"required,len=32"`
+}
+
+type verifyEmailResponse struct {
+	User            userResponse `json:"user"`
+	ClaimedBookings []string     `json:"claimed_bookings"`
+}
+
+// verifies an email from the link sent on registration, guest bookings
+// made with that email move to the account at the same time
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	result, err := server.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
+		EmailID:    req.EmailID,
+		SecretCode: req.SecretCode,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest,
+				gin.H{"error": "invalid or expired verification link"})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, verifyEmailResponse{
+		User:            newUserResponse(result.User),
+		ClaimedBookings: bookingReferences(result.ClaimedBookings),
+	})
+}
+
+// sends a new verification link to the logged in user
+func (server *Server) resendVerifyEmail(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUserByID(ctx, authPayload.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if user.IsEmailVerified {
+		ctx.JSON(http.StatusBadRequest,
+			gin.H{"error": "email address is already verified"})
+		return
+	}
+
+	if server.mailer == nil {
+		ctx.JSON(http.StatusServiceUnavailable,
+			gin.H{"error": "email is not configured"})
+		return
+	}
+
+	err = server.sendVerifyEmail(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "verification email sent"})
+}
+
+// claims guest bookings made with the user's email after it was
+// verified, e.g. when they booked as a guest while logged out
+func (server *Server) claimBookings(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUserByID(ctx, authPayload.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if !user.IsEmailVerified {
+		ctx.JSON(http.StatusForbidden, errResponse(errEmailNotVerified))
+		return
+	}
+
+	bookings, err := server.store.ClaimGuestBookingsTx(ctx,
+		db.ClaimGuestBookingsParams{
+			UserID: user.UserID,
+			Email:  user.Email,
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK,
+		gin.H{"claimed_bookings": bookingReferences(bookings)})
+}
+
+// ------------------------------------------------------------------//
+// ------------------------------Helper Funcs------------------------//
+// ------------------------------------------------------------------//
+
+// emails the user a link that verifies their email address
+func (server *Server) sendVerifyEmail(ctx context.Context, user db.User) error {
+	if server.mailer == nil {
+		return nil
+	}
+
+	secretCode, err := util.RandomSecureString(32)
+	if err != nil {
+		return err
+	}
+
+	verifyEmail, err := server.store.CreateVerifyEmail(ctx,
+		db.CreateVerifyEmailParams{
+			UserID:     user.UserID,
+			Email:      user.Email,
+			SecretCode: secretCode,
+		})
+	if err != nil {
+		return err
+	}
+
+	verifyURL := fmt.Sprintf("%s/users/verify_email?email_id=%d&secret_code=%s",
+		server.config.AppBaseURL, verifyEmail.ID, verifyEmail.SecretCode)
+	subject := "Please verify your email address"
+	content := fmt.Sprintf(`Hello %s,<br/>
+	Thank you for registering with us!<br/>
+	Please <a href="%s">click here</a> to verify your email address.
+	Bookings you made as a guest with this address will be added to your
+	account.<br/>
+	`, user.Name, verifyURL)
+
+	// don't hold the response while talking to the mail server
+	go func() {
+		err := server.mailer.SendEmail(subject, content,
+			[]string{user.Email})
+		if err != nil {
+			log.Printf("failed to send verification email to user %d: %v\n",
+				user.UserID, err)
+		}
+	}()
+
+	return nil
+}
+
+func bookingReferences(bookings []db.GuestBooking) []string {
+	references := make([]string, 0, len(bookings))
+	for _, booking := range bookings {
+		references = append(references, booking.Reference)
+	}
+	return references
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/token"
+)
+
+type watchlistMovie struct {
+	db.Movie
+	AddedAt time.Time `json:"added_at"`
+}
+
+// movies the caller watches, most recently added first
+func (server *Server) listWatchlist(ctx *gin.Context) {
+	var req pageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	before, ok, err := decodeCursor[time.Time](req.Cursor, "")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	rows, err := server.store.ListWatchlist(ctx, db.ListWatchlistParams{
+		UserID:        authPayload.UserID,
+		BeforeMovieID: pgtype.Int4{Int32: int32(before.ID), Valid: ok},
+		BeforeAddedAt: pgtype.Timestamptz{Time: before.Key, Valid: ok},
+		RowLimit:      req.Limit + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	movies := make([]watchlistMovie, 0, len(rows))
+	for _, row := range rows {
+		movies = append(movies, watchlistMovie{
+			Movie:   row.Movie,
+			AddedAt: row.AddedAt,
+		})
+	}
+
+	page, err := newPageResponse(movies, req.Limit, "",
+		func(movie watchlistMovie) (time.Time, int64) {
+			return movie.AddedAt, int64(movie.MovieID)
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+type addToWatchlistRequest struct {
+	MovieID int32 `json:"movie_id" binding:"required,min=1"`
+}
+
+// follows a movie, its watchers are emailed when tickets go on sale
+func (server *Server) addToWatchlist(ctx *gin.Context) {
+	var req addToWatchlistRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	movie, ok := server.getPublicMovie(ctx, req.MovieID)
+	if !ok {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	err := server.store.AddToWatchlist(ctx, db.AddToWatchlistParams{
+		UserID:  authPayload.UserID,
+		MovieID: movie.MovieID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "movie added to watchlist"})
+}
+
+func (server *Server) removeFromWatchlist(ctx *gin.Context) {
+	var uri movieIDStruct
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	removed, err := server.store.RemoveFromWatchlist(ctx,
+		db.RemoveFromWatchlistParams{
+			UserID:  authPayload.UserID,
+			MovieID: uri.MovieID,
+		})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		return
+	}
+
+	if removed == 0 {
+		ctx.JSON(http.StatusNotFound,
+			gin.H{"error": "movie is not on your watchlist"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "movie removed from watchlist"})
+}
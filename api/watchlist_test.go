@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+// watchlistStore keeps each user's watched movie ids in the order added
+type watchlistStore struct {
+	*movieStore
+	watchlist map[int64][]int32
+}
+
+func newWatchlistStore() *watchlistStore {
+	return &watchlistStore{
+		movieStore: newMovieStore(),
+		watchlist:  map[int64][]int32{},
+	}
+}
+
+func (store *watchlistStore) AddToWatchlist(ctx context.Context,
+	arg db.AddToWatchlistParams) error {
+	for _, movieID := range store.watchlist[arg.UserID] {
+		if movieID == arg.MovieID {
+			return nil
+		}
+	}
+	store.watchlist[arg.UserID] = append(store.watchlist[arg.UserID],
+		arg.MovieID)
+	return nil
+}
+
+func (store *watchlistStore) RemoveFromWatchlist(ctx context.Context,
+	arg db.RemoveFromWatchlistParams) (int64, error) {
+	movieIDs := store.watchlist[arg.UserID]
+	for i, movieID := range movieIDs {
+		if movieID == arg.MovieID {
+			store.watchlist[arg.UserID] = append(movieIDs[:i], movieIDs[i+1:]...)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// newest first, the position in the list stands in for the time added
+func (store *watchlistStore) ListWatchlist(ctx context.Context,
+	arg db.ListWatchlistParams) ([]db.ListWatchlistRow, error) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	movieIDs := store.watchlist[arg.UserID]
+
+	rows := []db.ListWatchlistRow{}
+	for i := len(movieIDs) - 1; i >= 0; i-- {
+		addedAt := base.Add(time.Duration(i) * time.Hour)
+		if arg.BeforeAddedAt.Valid && !addedAt.Before(arg.BeforeAddedAt.Time) {
+			continue
+		}
+		if len(rows) == int(arg.RowLimit) {
+			break
+		}
+		rows = append(rows, db.ListWatchlistRow{
+			Movie:   store.movies[movieIDs[i]],
+			AddedAt: addedAt,
+		})
+	}
+	return rows, nil
+}
+
+func TestWatchlist(t *testing.T) {
+	store := newWatchlistStore()
+	server := newTestServer(t, store)
+
+	store.movies[1] = db.Movie{MovieID: 1, Title: "Dune",
+		Status: db.MovieStatusComingSoon}
+	store.movies[2] = db.Movie{MovieID: 2, Title: "Alien",
+		Status: db.MovieStatusNowShowing}
+	store.movies[3] = db.Movie{MovieID: 3, Title: "Secret",
+		Status: db.MovieStatusDraft}
+
+	aliceToken, _, err := server.tokenMaker.CreateToken(
+		"alice", 2, util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		token   string
+		movieID int32
+		code    int
+	}{
+		{name: "NoToken", movieID: 1, code: http.StatusUnauthorized},
+		{name: "First", token: aliceToken, movieID: 1, code: http.StatusOK},
+		{name: "Second", token: aliceToken, movieID: 2, code: http.StatusOK},
+		{name: "Again", token: aliceToken, movieID: 1, code: http.StatusOK},
+		{name: "Draft", token: aliceToken, movieID: 3, code: http.StatusNotFound},
+		{name: "Unknown", token: aliceToken, movieID: 9, code: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := serveWithToken(t, server, http.MethodPost,
+				"/users/me/watchlist", tc.token,
+				map[string]any{"movie_id": tc.movieID})
+			require.Equal(t, tc.code, recorder.Code, recorder.Body.String())
+		})
+	}
+	require.Equal(t, []int32{1, 2}, store.watchlist[2])
+
+	recorder := serveWithToken(t, server, http.MethodGet,
+		"/users/me/watchlist?limit=1", aliceToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var page pageResponse[watchlistMovie]
+	err = json.Unmarshal(recorder.Body.Bytes(), &page)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	require.Equal(t, "Alien", page.Data[0].Title)
+	require.NotNil(t, page.NextCursor)
+
+	recorder = serveWithToken(t, server, http.MethodGet,
+		"/users/me/watchlist?limit=1&cursor="+*page.NextCursor, aliceToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	page = pageResponse[watchlistMovie]{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &page)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	require.Equal(t, "Dune", page.Data[0].Title)
+	require.Nil(t, page.NextCursor)
+
+	recorder = serveWithToken(t, server, http.MethodDelete,
+		"/users/me/watchlist/1", aliceToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, []int32{2}, store.watchlist[2])
+
+	recorder = serveWithToken(t, server, http.MethodDelete,
+		"/users/me/watchlist/1", aliceToken, nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -0,0 +1,4 @@
+ALTER TABLE IF EXISTS "users" DROP CONSTRAINT IF EXISTS "users_role_fkey";
+
+DROP TABLE IF EXISTS "role_permissions";
+DROP TABLE IF EXISTS "roles";
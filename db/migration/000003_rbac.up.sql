@@ -0,0 +1,31 @@
+CREATE TABLE "roles" (
+  "name" varchar PRIMARY KEY,
+  "description" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "role_permissions" (
+  "role" varchar NOT NULL,
+  "permission" varchar NOT NULL,
+  PRIMARY KEY ("role", "permission")
+);
+
+ALTER TABLE "role_permissions" ADD FOREIGN KEY ("role") REFERENCES "roles" ("name") ON UPDATE CASCADE ON DELETE CASCADE;
+
+-- Insert built-in roles
+INSERT INTO roles (name, description) VALUES
+  ('customer', 'Books and manages own reservations'),
+  ('admin', 'Full access to the catalog, schedule and accounts')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('customer', 'reservations:write'),
+  ('admin', 'reservations:write'),
+  ('admin', 'reservations:read_all'),
+  ('admin', 'movies:write'),
+  ('admin', 'showtimes:write'),
+  ('admin', 'checkin:scan'),
+  ('admin', 'roles:manage')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE "users" ADD FOREIGN KEY ("role") REFERENCES "roles" ("name") ON UPDATE CASCADE;
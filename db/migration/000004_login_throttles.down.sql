@@ -0,0 +1,4 @@
+DELETE FROM role_permissions WHERE permission = 'users:manage';
+
+DROP TABLE IF EXISTS "account_unlocks";
+DROP TABLE IF EXISTS "login_throttles";
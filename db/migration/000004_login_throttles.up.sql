@@ -0,0 +1,24 @@
+CREATE TABLE "login_throttles" (
+  "scope" varchar NOT NULL,
+  "throttle_key" varchar NOT NULL,
+  "failed_attempts" int NOT NULL DEFAULT 0,
+  "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("scope", "throttle_key")
+);
+
+CREATE TABLE "account_unlocks" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "email" text NOT NULL,
+  "secret_code" varchar NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL DEFAULT (now() + interval '1 hour')
+);
+
+ALTER TABLE "account_unlocks" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id") ON DELETE CASCADE;
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('admin', 'users:manage')
+ON CONFLICT DO NOTHING;
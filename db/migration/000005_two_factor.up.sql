@@ -0,0 +1,24 @@
+ALTER TABLE "users" ADD COLUMN "totp_secret" varchar NOT NULL DEFAULT '';
+ALTER TABLE "users" ADD COLUMN "totp_enabled" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "recovery_codes" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "hashed_code" varchar NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "login_challenges" (
+  "id" uuid PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "attempts" int NOT NULL DEFAULT 0,
+  "expired_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "recovery_codes" ("user_id", "hashed_code");
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id") ON DELETE CASCADE;
+
+ALTER TABLE "login_challenges" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id") ON DELETE CASCADE;
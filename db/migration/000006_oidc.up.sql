@@ -0,0 +1,21 @@
+CREATE TABLE "user_identities" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "issuer" varchar NOT NULL,
+  "subject" varchar NOT NULL,
+  "email" text NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "oidc_states" (
+  "state" varchar PRIMARY KEY,
+  "code_verifier" varchar NOT NULL,
+  "nonce" varchar NOT NULL,
+  "expired_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "user_identities" ("issuer", "subject");
+CREATE INDEX ON "user_identities" ("user_id");
+
+ALTER TABLE "user_identities" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id") ON DELETE CASCADE;
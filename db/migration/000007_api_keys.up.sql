@@ -0,0 +1,25 @@
+CREATE TABLE "api_keys" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "name" varchar NOT NULL,
+  "prefix" varchar UNIQUE NOT NULL,
+  "hashed_key" varchar NOT NULL,
+  "permissions" text[] NOT NULL DEFAULT '{}',
+  "allowed_ips" text[] NOT NULL DEFAULT '{}',
+  "created_by" bigint NOT NULL,
+  "expired_at" timestamptz,
+  "last_used_at" timestamptz,
+  "last_used_ip" varchar NOT NULL DEFAULT '',
+  "revoked_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "api_keys" ("user_id");
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id") ON DELETE CASCADE;
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("user_id");
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('admin', 'api_keys:manage')
+ON CONFLICT DO NOTHING;
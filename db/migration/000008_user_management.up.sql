@@ -0,0 +1,20 @@
+ALTER TABLE "users" ADD COLUMN "disabled_at" timestamptz;
+
+CREATE TABLE "audit_events" (
+  "id" bigserial PRIMARY KEY,
+  "actor_id" bigint,
+  "action" varchar NOT NULL,
+  "entity_type" varchar NOT NULL,
+  "entity_id" varchar NOT NULL,
+  "before" jsonb,
+  "after" jsonb,
+  "client_ip" varchar NOT NULL DEFAULT '',
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "audit_events" ("actor_id");
+CREATE INDEX ON "audit_events" ("entity_type", "entity_id");
+CREATE INDEX ON "audit_events" ("created_at");
+
+ALTER TABLE "audit_events" ADD FOREIGN KEY ("actor_id") REFERENCES "users" ("user_id") ON DELETE SET NULL;
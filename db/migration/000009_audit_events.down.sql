@@ -0,0 +1,5 @@
+DELETE FROM role_permissions WHERE permission = 'audit:read';
+
+DROP INDEX IF EXISTS "audit_events_action_idx";
+DROP TRIGGER IF EXISTS "audit_events_append_only" ON "audit_events";
+DROP FUNCTION IF EXISTS audit_events_append_only();
@@ -0,0 +1,21 @@
+-- audit events are never changed or removed, only the actor is cleared
+-- when their account is deleted
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'UPDATE' AND NEW.actor_id IS NULL
+    AND (to_jsonb(NEW) - 'actor_id') = (to_jsonb(OLD) - 'actor_id') THEN
+    RETURN NEW;
+  END IF;
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_events_append_only"
+  BEFORE UPDATE OR DELETE ON "audit_events"
+  FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE INDEX ON "audit_events" ("action");
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('admin', 'audit:read')
+ON CONFLICT DO NOTHING;
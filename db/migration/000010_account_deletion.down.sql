@@ -0,0 +1,6 @@
+ALTER TABLE "reservations" DROP CONSTRAINT IF EXISTS "reservations_user_id_fkey";
+
+ALTER TABLE "reservations" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id") ON DELETE CASCADE;
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "anonymized_at";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "deletion_requested_at";
@@ -0,0 +1,10 @@
+ALTER TABLE "users" ADD COLUMN "deletion_requested_at" timestamptz;
+ALTER TABLE "users" ADD COLUMN "anonymized_at" timestamptz;
+
+CREATE INDEX ON "users" ("deletion_requested_at") WHERE "anonymized_at" IS NULL;
+
+-- reservations are kept for accounting after an account is anonymized,
+-- so removing a user must never take their bookings with it
+ALTER TABLE "reservations" DROP CONSTRAINT IF EXISTS "reservations_user_id_fkey";
+
+ALTER TABLE "reservations" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id");
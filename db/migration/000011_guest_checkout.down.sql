@@ -0,0 +1,10 @@
+DELETE FROM "reservations" WHERE "user_id" IS NULL;
+
+ALTER TABLE "reservations" DROP CONSTRAINT IF EXISTS "reservations_owner_check";
+ALTER TABLE "reservations" DROP COLUMN IF EXISTS "guest_booking_id";
+ALTER TABLE "reservations" ALTER COLUMN "user_id" SET NOT NULL;
+
+DROP TABLE IF EXISTS "guest_bookings";
+DROP TABLE IF EXISTS "verify_emails";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "is_email_verified";
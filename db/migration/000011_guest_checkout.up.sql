@@ -0,0 +1,43 @@
+ALTER TABLE "users" ADD COLUMN "is_email_verified" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "verify_emails" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "email" text NOT NULL,
+  "secret_code" varchar NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL DEFAULT (now() + interval '1 day')
+);
+
+CREATE TABLE "guest_bookings" (
+  "id" bigserial PRIMARY KEY,
+  "reference" varchar UNIQUE NOT NULL,
+  "hashed_token" varchar NOT NULL,
+  "email" text NOT NULL,
+  "name" text NOT NULL,
+  "claimed_by" bigint,
+  "claimed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "guest_bookings" (lower("email")) WHERE "claimed_by" IS NULL;
+
+-- a reservation belongs to a user, a guest booking, or both once the
+-- guest has claimed it with an account
+ALTER TABLE "reservations" ALTER COLUMN "user_id" DROP DEFAULT;
+ALTER TABLE "reservations" ALTER COLUMN "user_id" DROP NOT NULL;
+DROP SEQUENCE IF EXISTS "reservations_user_id_seq";
+
+ALTER TABLE "reservations" ADD COLUMN "guest_booking_id" bigint;
+
+ALTER TABLE "reservations" ADD CONSTRAINT "reservations_owner_check"
+  CHECK ("user_id" IS NOT NULL OR "guest_booking_id" IS NOT NULL);
+
+CREATE INDEX ON "reservations" ("guest_booking_id");
+
+ALTER TABLE "verify_emails" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id") ON DELETE CASCADE;
+
+ALTER TABLE "guest_bookings" ADD FOREIGN KEY ("claimed_by") REFERENCES "users" ("user_id");
+
+ALTER TABLE "reservations" ADD FOREIGN KEY ("guest_booking_id") REFERENCES "guest_bookings" ("id");
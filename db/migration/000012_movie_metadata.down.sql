@@ -0,0 +1,11 @@
+ALTER TABLE "showtimes" DROP COLUMN IF EXISTS "end_time";
+
+ALTER TABLE "movies" DROP CONSTRAINT IF EXISTS "movies_status_check";
+ALTER TABLE "movies" DROP CONSTRAINT IF EXISTS "movies_runtime_minutes_check";
+
+ALTER TABLE "movies" DROP COLUMN IF EXISTS "status";
+ALTER TABLE "movies" DROP COLUMN IF EXISTS "trailer_url";
+ALTER TABLE "movies" DROP COLUMN IF EXISTS "original_language";
+ALTER TABLE "movies" DROP COLUMN IF EXISTS "release_date";
+ALTER TABLE "movies" DROP COLUMN IF EXISTS "certification";
+ALTER TABLE "movies" DROP COLUMN IF EXISTS "runtime_minutes";
@@ -0,0 +1,15 @@
+ALTER TABLE "movies" ADD COLUMN "runtime_minutes" int NOT NULL DEFAULT 0;
+ALTER TABLE "movies" ADD COLUMN "certification" varchar NOT NULL DEFAULT '';
+ALTER TABLE "movies" ADD COLUMN "release_date" date;
+ALTER TABLE "movies" ADD COLUMN "original_language" varchar NOT NULL DEFAULT '';
+ALTER TABLE "movies" ADD COLUMN "trailer_url" text NOT NULL DEFAULT '';
+ALTER TABLE "movies" ADD COLUMN "status" varchar NOT NULL DEFAULT 'now_showing';
+
+ALTER TABLE "movies" ADD CONSTRAINT "movies_runtime_minutes_check"
+  CHECK ("runtime_minutes" >= 0);
+
+ALTER TABLE "movies" ADD CONSTRAINT "movies_status_check"
+  CHECK ("status" IN ('coming_soon', 'now_showing', 'archived'));
+
+-- null while the movie has no runtime yet
+ALTER TABLE "showtimes" ADD COLUMN "end_time" timestamp;
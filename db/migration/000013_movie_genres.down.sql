@@ -0,0 +1,15 @@
+ALTER TABLE "movies" ADD COLUMN "genre_id" int;
+
+-- only one genre fits back, keep the lowest
+UPDATE "movies" m SET "genre_id" = (
+  SELECT min(mg."genre_id") FROM "movie_genres" mg
+  WHERE mg."movie_id" = m."movie_id"
+);
+
+UPDATE "movies" SET "genre_id" = (SELECT min("genre_id") FROM "genres")
+WHERE "genre_id" IS NULL;
+
+ALTER TABLE "movies" ALTER COLUMN "genre_id" SET NOT NULL;
+ALTER TABLE "movies" ADD FOREIGN KEY ("genre_id") REFERENCES "genres" ("genre_id");
+
+DROP TABLE IF EXISTS "movie_genres";
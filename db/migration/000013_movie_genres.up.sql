@@ -0,0 +1,17 @@
+CREATE TABLE "movie_genres" (
+  "movie_id" int NOT NULL,
+  "genre_id" int NOT NULL,
+  PRIMARY KEY ("movie_id", "genre_id")
+);
+
+CREATE INDEX ON "movie_genres" ("genre_id");
+
+ALTER TABLE "movie_genres" ADD FOREIGN KEY ("movie_id") REFERENCES "movies" ("movie_id") ON DELETE CASCADE;
+
+-- a genre can't be deleted while movies use it
+ALTER TABLE "movie_genres" ADD FOREIGN KEY ("genre_id") REFERENCES "genres" ("genre_id");
+
+INSERT INTO "movie_genres" ("movie_id", "genre_id")
+SELECT "movie_id", "genre_id" FROM "movies";
+
+ALTER TABLE "movies" DROP COLUMN "genre_id";
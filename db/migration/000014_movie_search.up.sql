@@ -0,0 +1,13 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- titles weigh more than descriptions when ranking
+ALTER TABLE "movies" ADD COLUMN "search_vector" tsvector
+  GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce("title", '')), 'A') ||
+    setweight(to_tsvector('english', coalesce("description", '')), 'B')
+  ) STORED;
+
+CREATE INDEX ON "movies" USING GIN ("search_vector");
+
+-- typo tolerant fallback when no word matches
+CREATE INDEX ON "movies" USING GIN ("title" gin_trgm_ops);
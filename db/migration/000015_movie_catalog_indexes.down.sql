@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS "showtimes_movie_id_start_time_idx";
+
+DROP INDEX IF EXISTS "movies_original_language_idx";
+DROP INDEX IF EXISTS "movies_certification_idx";
+DROP INDEX IF EXISTS "movies_release_date_idx";
+DROP INDEX IF EXISTS "movies_title_sort_idx";
+DROP INDEX IF EXISTS "movies_created_at_idx";
@@ -0,0 +1,9 @@
+CREATE INDEX ON "movies" ("created_at");
+-- movies_title_idx is the trigram index used by search
+CREATE INDEX "movies_title_sort_idx" ON "movies" ("title");
+CREATE INDEX ON "movies" ("release_date");
+CREATE INDEX ON "movies" ("certification");
+CREATE INDEX ON "movies" ("original_language");
+
+-- "now showing" and popularity look up showtimes by movie
+CREATE INDEX ON "showtimes" ("movie_id", "start_time");
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS "reservations_user_id_idx";
+DROP INDEX IF EXISTS "showtimes_start_time_showtime_id_idx";
+DROP INDEX IF EXISTS "movies_release_date_sort_idx";
+DROP INDEX IF EXISTS "movies_title_sort_idx";
+DROP INDEX IF EXISTS "movies_created_at_movie_id_idx";
+
+CREATE INDEX "movies_title_sort_idx" ON "movies" ("title");
+CREATE INDEX ON "movies" ("created_at");
@@ -0,0 +1,11 @@
+-- pages continue after (sort key, id), so every sort order needs both
+DROP INDEX IF EXISTS "movies_created_at_idx";
+DROP INDEX IF EXISTS "movies_title_sort_idx";
+
+CREATE INDEX ON "movies" ("created_at", "movie_id");
+CREATE INDEX "movies_title_sort_idx" ON "movies" ("title", "movie_id");
+CREATE INDEX "movies_release_date_sort_idx" ON "movies" ((coalesce("release_date", '-infinity'::date)), "movie_id");
+
+CREATE INDEX ON "showtimes" ("start_time", "showtime_id");
+
+CREATE INDEX ON "reservations" ("user_id");
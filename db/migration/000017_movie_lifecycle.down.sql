@@ -0,0 +1,19 @@
+ALTER TABLE "reservations" DROP CONSTRAINT IF EXISTS "reservations_showtime_id_fkey";
+
+ALTER TABLE "reservations" ADD FOREIGN KEY ("showtime_id") REFERENCES "showtimes" ("showtime_id") ON DELETE CASCADE;
+
+ALTER TABLE "showtimes" DROP CONSTRAINT IF EXISTS "showtimes_movie_id_fkey";
+
+ALTER TABLE "showtimes" ADD FOREIGN KEY ("movie_id") REFERENCES "movies" ("movie_id") ON DELETE CASCADE;
+
+-- deleted movies come back archived
+UPDATE "movies" SET "status" = 'archived' WHERE "deleted_at" IS NOT NULL;
+
+ALTER TABLE "movies" DROP COLUMN IF EXISTS "deleted_at";
+
+ALTER TABLE "movies" DROP CONSTRAINT IF EXISTS "movies_status_check";
+
+UPDATE "movies" SET "status" = 'coming_soon' WHERE "status" = 'draft';
+
+ALTER TABLE "movies" ADD CONSTRAINT "movies_status_check"
+  CHECK ("status" IN ('coming_soon', 'now_showing', 'archived'));
@@ -0,0 +1,16 @@
+ALTER TABLE "movies" DROP CONSTRAINT IF EXISTS "movies_status_check";
+
+ALTER TABLE "movies" ADD CONSTRAINT "movies_status_check"
+  CHECK ("status" IN ('draft', 'coming_soon', 'now_showing', 'archived'));
+
+-- deleted movies are kept so their showtimes and bookings stay intact
+ALTER TABLE "movies" ADD COLUMN "deleted_at" timestamptz;
+
+-- removing a movie or showtime must never take bookings with it
+ALTER TABLE "showtimes" DROP CONSTRAINT IF EXISTS "showtimes_movie_id_fkey";
+
+ALTER TABLE "showtimes" ADD FOREIGN KEY ("movie_id") REFERENCES "movies" ("movie_id");
+
+ALTER TABLE "reservations" DROP CONSTRAINT IF EXISTS "reservations_showtime_id_fkey";
+
+ALTER TABLE "reservations" ADD FOREIGN KEY ("showtime_id") REFERENCES "showtimes" ("showtime_id");
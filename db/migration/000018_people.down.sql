@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "movie_credits";
+DROP TABLE IF EXISTS "people";
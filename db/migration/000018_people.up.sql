@@ -0,0 +1,35 @@
+CREATE TABLE "people" (
+  "person_id" serial PRIMARY KEY,
+  "name" text NOT NULL,
+  "biography" text NOT NULL DEFAULT '',
+  "photo_url" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "people" ("name", "person_id");
+
+CREATE TABLE "movie_credits" (
+  "credit_id" serial PRIMARY KEY,
+  "movie_id" int NOT NULL,
+  "person_id" int NOT NULL,
+  "role" varchar NOT NULL,
+  "character_name" text NOT NULL DEFAULT '',
+  "billing_order" int NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "movie_credits" ADD CONSTRAINT "movie_credits_role_check"
+  CHECK ("role" IN ('actor', 'director', 'writer'));
+
+ALTER TABLE "movie_credits" ADD CONSTRAINT "movie_credits_billing_order_check"
+  CHECK ("billing_order" >= 0);
+
+-- one person can still play several characters in a movie
+CREATE UNIQUE INDEX ON "movie_credits" ("movie_id", "person_id", "role", "character_name");
+
+CREATE INDEX ON "movie_credits" ("person_id");
+
+ALTER TABLE "movie_credits" ADD FOREIGN KEY ("movie_id") REFERENCES "movies" ("movie_id");
+
+-- a person can't be deleted while they are credited
+ALTER TABLE "movie_credits" ADD FOREIGN KEY ("person_id") REFERENCES "people" ("person_id");
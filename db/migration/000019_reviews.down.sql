@@ -0,0 +1,6 @@
+DELETE FROM role_permissions WHERE permission = 'reviews:moderate';
+
+ALTER TABLE "movies" DROP COLUMN IF EXISTS "rating_count";
+ALTER TABLE "movies" DROP COLUMN IF EXISTS "rating_average";
+
+DROP TABLE IF EXISTS "reviews";
@@ -0,0 +1,33 @@
+CREATE TABLE "reviews" (
+  "review_id" bigserial PRIMARY KEY,
+  "movie_id" int NOT NULL,
+  "user_id" bigint NOT NULL,
+  "rating" int NOT NULL,
+  "body" text NOT NULL DEFAULT '',
+  "flagged_at" timestamptz,
+  "hidden_at" timestamptz,
+  "moderation_note" text NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "reviews" ADD CONSTRAINT "reviews_rating_check"
+  CHECK ("rating" BETWEEN 1 AND 5);
+
+-- one review per user and movie
+CREATE UNIQUE INDEX ON "reviews" ("movie_id", "user_id");
+
+CREATE INDEX ON "reviews" ("movie_id", "review_id") WHERE "hidden_at" IS NULL;
+CREATE INDEX ON "reviews" ("review_id") WHERE "flagged_at" IS NOT NULL OR "hidden_at" IS NOT NULL;
+
+ALTER TABLE "reviews" ADD FOREIGN KEY ("movie_id") REFERENCES "movies" ("movie_id");
+
+ALTER TABLE "reviews" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id");
+
+-- kept up to date with every visible review, so listings don't aggregate
+ALTER TABLE "movies" ADD COLUMN "rating_average" numeric(3,2) NOT NULL DEFAULT 0;
+ALTER TABLE "movies" ADD COLUMN "rating_count" int NOT NULL DEFAULT 0;
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('admin', 'reviews:moderate')
+ON CONFLICT DO NOTHING;
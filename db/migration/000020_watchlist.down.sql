@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "watchlist_notifications";
+DROP TABLE IF EXISTS "watchlist";
@@ -0,0 +1,34 @@
+CREATE TABLE "watchlist" (
+  "user_id" bigint NOT NULL,
+  "movie_id" int NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("user_id", "movie_id")
+);
+
+CREATE INDEX ON "watchlist" ("movie_id");
+
+CREATE INDEX ON "watchlist" ("user_id", "created_at", "movie_id");
+
+ALTER TABLE "watchlist" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id");
+
+ALTER TABLE "watchlist" ADD FOREIGN KEY ("movie_id") REFERENCES "movies" ("movie_id");
+
+-- alerts waiting to be emailed to watchers, sent_at is set once they are.
+-- notify_date is the UTC day the alert was raised on
+CREATE TABLE "watchlist_notifications" (
+  "notification_id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "movie_id" int NOT NULL,
+  "notify_date" date NOT NULL DEFAULT ((now() AT TIME ZONE 'UTC')::date),
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "sent_at" timestamptz
+);
+
+-- a watcher gets at most one alert per movie per day
+CREATE UNIQUE INDEX ON "watchlist_notifications" ("user_id", "movie_id", "notify_date");
+
+CREATE INDEX ON "watchlist_notifications" ("notification_id") WHERE "sent_at" IS NULL;
+
+ALTER TABLE "watchlist_notifications" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id");
+
+ALTER TABLE "watchlist_notifications" ADD FOREIGN KEY ("movie_id") REFERENCES "movies" ("movie_id");
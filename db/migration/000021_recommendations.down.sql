@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "reservations_showtime_id_user_id_idx";
+
+DROP TABLE IF EXISTS "movie_recommendations";
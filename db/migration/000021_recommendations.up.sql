@@ -0,0 +1,21 @@
+-- filled by the recommendation job, each part of the score is between 0
+-- and 1 so the weights in the query decide how much it counts
+CREATE TABLE "movie_recommendations" (
+  "user_id" bigint NOT NULL,
+  "movie_id" int NOT NULL,
+  "score" double precision NOT NULL,
+  "genre_score" double precision NOT NULL,
+  "co_booking_score" double precision NOT NULL,
+  "popularity_score" double precision NOT NULL,
+  "computed_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("user_id", "movie_id")
+);
+
+CREATE INDEX ON "movie_recommendations" ("user_id", "score" DESC, "movie_id");
+
+ALTER TABLE "movie_recommendations" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id");
+
+ALTER TABLE "movie_recommendations" ADD FOREIGN KEY ("movie_id") REFERENCES "movies" ("movie_id");
+
+-- co-booking looks up everyone who booked a movie
+CREATE INDEX ON "reservations" ("showtime_id", "user_id");
@@ -0,0 +1,16 @@
+-- emailed codes that confirm sensitive account changes for users without
+-- a password they know, e.g. ones that signed up through an identity
+-- provider
+CREATE TABLE "account_confirmations" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "purpose" varchar NOT NULL,
+  "secret_code" varchar NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL DEFAULT (now() + interval '15 minutes')
+);
+
+CREATE INDEX ON "account_confirmations" ("user_id", "purpose");
+
+ALTER TABLE "account_confirmations" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("user_id") ON DELETE CASCADE;
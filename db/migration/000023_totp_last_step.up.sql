@@ -0,0 +1,2 @@
+-- the time step of the last accepted TOTP code, a code is only good once
+ALTER TABLE "users" ADD COLUMN "totp_last_step" bigint NOT NULL DEFAULT 0;
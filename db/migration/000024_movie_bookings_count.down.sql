@@ -0,0 +1,6 @@
+DROP FUNCTION IF EXISTS catalog_movies(int[], text, text, bool, date, date,
+  timestamp, timestamp);
+
+DROP INDEX IF EXISTS "movies_bookings_count_sort_idx";
+
+ALTER TABLE "movies" DROP COLUMN IF EXISTS "bookings_count";
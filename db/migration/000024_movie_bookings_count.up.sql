@@ -0,0 +1,63 @@
+-- bookings per movie for the popularity sort, recounted by a background
+-- job so booking a seat never has to update the movie row
+ALTER TABLE "movies" ADD COLUMN "bookings_count" bigint NOT NULL DEFAULT 0;
+
+UPDATE "movies" m
+SET "bookings_count" = b."bookings"
+FROM (
+  SELECT s."movie_id", count(*) AS "bookings"
+  FROM "reservations" r
+  JOIN "showtimes" s ON s."showtime_id" = r."showtime_id"
+  GROUP BY s."movie_id"
+) b
+WHERE b."movie_id" = m."movie_id";
+
+CREATE INDEX "movies_bookings_count_sort_idx" ON "movies" ("bookings_count", "movie_id");
+
+-- the filters every catalog sort order shares, null values and empty
+-- genre_ids match every movie. plain sql and stable so the planner inlines
+-- it into each query and still walks the index of its sort order
+CREATE FUNCTION catalog_movies(
+  genre_ids int[],
+  certification text,
+  original_language text,
+  coming_soon bool,
+  released_from date,
+  released_to date,
+  showing_from timestamp,
+  showing_to timestamp
+) RETURNS SETOF movies
+LANGUAGE sql STABLE AS $$
+  SELECT m.* FROM movies m
+  WHERE m.deleted_at IS NULL
+    AND m.status IN ('coming_soon', 'now_showing')
+    AND (
+      cardinality(catalog_movies.genre_ids) = 0
+      OR EXISTS (
+        SELECT 1 FROM movie_genres mg
+        WHERE mg.movie_id = m.movie_id
+          AND mg.genre_id = ANY(catalog_movies.genre_ids)
+      )
+    )
+    AND (catalog_movies.certification IS NULL
+      OR m.certification = catalog_movies.certification)
+    AND (catalog_movies.original_language IS NULL
+      OR m.original_language = catalog_movies.original_language)
+    AND (NOT catalog_movies.coming_soon OR m.release_date > CURRENT_DATE)
+    AND (catalog_movies.released_from IS NULL
+      OR m.release_date >= catalog_movies.released_from)
+    AND (catalog_movies.released_to IS NULL
+      OR m.release_date <= catalog_movies.released_to)
+    AND (
+      (catalog_movies.showing_from IS NULL
+        AND catalog_movies.showing_to IS NULL)
+      OR EXISTS (
+        SELECT 1 FROM showtimes s
+        WHERE s.movie_id = m.movie_id
+          AND (catalog_movies.showing_from IS NULL
+            OR s.start_time >= catalog_movies.showing_from)
+          AND (catalog_movies.showing_to IS NULL
+            OR s.start_time < catalog_movies.showing_to)
+      )
+    )
+$$;
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS "movies_search_vector_idx";
+
+DROP FUNCTION IF EXISTS movie_search_vector(text, text);
+
+ALTER TABLE "movies" ADD COLUMN "search_vector" tsvector
+  GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce("title", '')), 'A') ||
+    setweight(to_tsvector('english', coalesce("description", '')), 'B')
+  ) STORED;
+
+CREATE INDEX ON "movies" USING GIN ("search_vector");
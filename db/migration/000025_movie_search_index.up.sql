@@ -0,0 +1,16 @@
+-- the search vector is only needed by search, index the expression instead
+-- of storing it on every movie row that the rest of the app reads
+DROP INDEX IF EXISTS "movies_search_vector_idx";
+
+ALTER TABLE "movies" DROP COLUMN IF EXISTS "search_vector";
+
+-- titles weigh more than descriptions when ranking
+CREATE FUNCTION movie_search_vector(title text, description text)
+RETURNS tsvector
+LANGUAGE sql IMMUTABLE AS $$
+  SELECT setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B')
+$$;
+
+CREATE INDEX "movies_search_vector_idx" ON "movies"
+  USING GIN (movie_search_vector("title", "description"));
@@ -0,0 +1,35 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+  user_id, name, prefix, hashed_key, permissions, allowed_ips,
+  created_by, expired_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: GetAPIKeyByPrefix :one
+SELECT * FROM api_keys
+WHERE prefix = $1 LIMIT 1;
+
+-- name: ListAPIKeys :many
+SELECT * FROM api_keys
+WHERE sqlc.narg(before_id)::bigint IS NULL
+  OR id < sqlc.narg(before_id)::bigint
+ORDER BY id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = now()
+WHERE id = $1 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = now(), last_used_ip = $2
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
+
+-- name: DeleteUserAPIKeys :exec
+DELETE FROM api_keys
+WHERE user_id = $1;
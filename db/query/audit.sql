@@ -0,0 +1,25 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+  actor_id, action, entity_type, entity_id, before, after,
+  client_ip, user_agent
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg(actor_id)::bigint IS NULL OR actor_id = sqlc.narg(actor_id))
+  AND (sqlc.narg(action)::varchar IS NULL OR action = sqlc.narg(action))
+  AND (sqlc.narg(entity_type)::varchar IS NULL
+    OR entity_type = sqlc.narg(entity_type))
+  AND (sqlc.narg(entity_id)::varchar IS NULL
+    OR entity_id = sqlc.narg(entity_id))
+  AND (sqlc.narg(created_from)::timestamptz IS NULL
+    OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL
+    OR created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(before_id)::bigint IS NULL
+    OR id < sqlc.narg(before_id)::bigint)
+ORDER BY id DESC
+LIMIT sqlc.arg(row_limit);
@@ -1,3 +1,53 @@
 -- name: ListGenres :many
 SELECT * FROM genres
 ORDER BY name;
+
+-- name: GetGenre :one
+SELECT * FROM genres
+WHERE genre_id = $1;
+
+-- name: ListGenresByIDs :many
+SELECT * FROM genres
+WHERE genre_id = ANY(sqlc.arg(genre_ids)::int[])
+ORDER BY name;
+
+-- name: CreateGenre :one
+INSERT INTO genres (name)
+VALUES ($1)
+RETURNING *;
+
+-- name: UpdateGenre :one
+UPDATE genres
+SET name = $2
+WHERE genre_id = $1
+RETURNING *;
+
+-- name: DeleteGenre :exec
+DELETE FROM genres
+WHERE genre_id = $1;
+
+-- name: CountMoviesWithGenre :one
+SELECT count(*) FROM movie_genres
+WHERE genre_id = $1;
+
+-- name: AddMovieGenres :exec
+INSERT INTO movie_genres (movie_id, genre_id)
+SELECT sqlc.arg(movie_id), unnest(sqlc.arg(genre_ids)::int[])
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteMovieGenres :exec
+DELETE FROM movie_genres
+WHERE movie_id = $1;
+
+-- name: ListMovieGenres :many
+SELECT g.* FROM genres g
+JOIN movie_genres mg ON mg.genre_id = g.genre_id
+WHERE mg.movie_id = $1
+ORDER BY g.name;
+
+-- name: ListGenresForMovies :many
+SELECT mg.movie_id, g.genre_id, g.name
+FROM movie_genres mg
+JOIN genres g ON g.genre_id = mg.genre_id
+WHERE mg.movie_id = ANY(sqlc.arg(movie_ids)::int[])
+ORDER BY mg.movie_id, g.name;
@@ -0,0 +1,44 @@
+-- name: CreateGuestBooking :one
+INSERT INTO guest_bookings (reference, hashed_token, email, name)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetGuestBookingByReference :one
+SELECT * FROM guest_bookings
+WHERE reference = $1;
+
+-- name: ListReservationsByGuestBooking :many
+SELECT r.*, s.start_time, m.title, se.row, se.number
+FROM reservations r
+JOIN showtimes s ON s.showtime_id = r.showtime_id
+JOIN movies m ON m.movie_id = s.movie_id
+JOIN seats se ON se.seat_id = r.seat_id
+WHERE r.guest_booking_id = sqlc.arg(guest_booking_id)::bigint
+ORDER BY s.start_time;
+
+-- name: CancelGuestBooking :execrows
+DELETE FROM reservations
+WHERE guest_booking_id = sqlc.arg(guest_booking_id)::bigint;
+
+-- name: ClaimGuestBookings :many
+UPDATE guest_bookings
+SET claimed_by = sqlc.arg(user_id)::bigint, claimed_at = now()
+WHERE lower(email) = lower(sqlc.arg(email)) AND claimed_by IS NULL
+RETURNING *;
+
+-- name: AssignGuestReservations :execrows
+UPDATE reservations r
+SET user_id = g.claimed_by
+FROM guest_bookings g
+WHERE r.guest_booking_id = g.id
+  AND g.claimed_by = sqlc.arg(user_id)::bigint
+  AND r.user_id IS NULL;
+
+-- name: AnonymizeUserGuestBookings :exec
+UPDATE guest_bookings
+SET email = '', name = ''
+WHERE claimed_by = sqlc.arg(user_id)::bigint
+   OR id IN (
+     SELECT guest_booking_id FROM reservations
+     WHERE user_id = sqlc.arg(user_id)::bigint
+   );
@@ -0,0 +1,45 @@
+-- name: GetLoginThrottle :one
+SELECT * FROM login_throttles
+WHERE scope = $1 AND throttle_key = $2;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (scope, throttle_key, failed_attempts, last_failed_at)
+VALUES (sqlc.arg(scope), sqlc.arg(throttle_key), 1, now())
+ON CONFLICT (scope, throttle_key) DO UPDATE
+SET failed_attempts = CASE
+      WHEN login_throttles.last_failed_at < sqlc.arg(reset_before)::timestamptz THEN 1
+      ELSE login_throttles.failed_attempts + 1
+    END,
+    last_failed_at = now()
+RETURNING *;
+
+-- name: LockLoginThrottle :exec
+UPDATE login_throttles
+SET locked_until = $3
+WHERE scope = $1 AND throttle_key = $2;
+
+-- name: DeleteLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE scope = $1 AND throttle_key = $2;
+
+-- name: CreateAccountUnlock :one
+INSERT INTO account_unlocks (user_id, email, secret_code)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: UseAccountUnlock :one
+UPDATE account_unlocks
+SET is_used = true
+WHERE id = $1
+  AND secret_code = $2
+  AND is_used = false
+  AND expired_at > now()
+RETURNING *;
+
+-- name: DeleteAccountUnlocks :exec
+DELETE FROM account_unlocks
+WHERE user_id = $1;
+
+-- name: DeleteLoginThrottlesByKey :exec
+DELETE FROM login_throttles
+WHERE throttle_key = $1;
@@ -1,27 +1,173 @@
 -- name: CreateMovie :one
-INSERT INTO movies (title, description, poster_url, genre_id)
-VALUES ($1, $2, $3, $4)
+INSERT INTO movies (
+  title,
+  description,
+  poster_url,
+  runtime_minutes,
+  certification,
+  release_date,
+  original_language,
+  trailer_url,
+  status
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
--- name: ListMovies :many
-SELECT * FROM movies
-ORDER BY created_at DESC
-LIMIT $1
-OFFSET $2;
+-- the ListMoviesBy* queries filter through catalog_movies, which skips
+-- deleted movies, drafts and archived movies like every public query. each
+-- one has a fixed order so it can walk its index, and pages continue after
+-- the sort key and movie_id of the last movie of the previous page, null
+-- on the first page
+
+-- name: ListMoviesByNewest :many
+SELECT m.* FROM catalog_movies(
+  sqlc.arg(genre_ids)::int[],
+  sqlc.narg(certification)::text,
+  sqlc.narg(original_language)::text,
+  sqlc.arg(coming_soon)::bool,
+  sqlc.narg(released_from)::date,
+  sqlc.narg(released_to)::date,
+  sqlc.narg(showing_from)::timestamp,
+  sqlc.narg(showing_to)::timestamp
+) m
+WHERE sqlc.narg(after_id)::int IS NULL
+  OR (m.created_at, m.movie_id) <
+    (sqlc.narg(after_created_at)::timestamptz, sqlc.narg(after_id)::int)
+ORDER BY m.created_at DESC, m.movie_id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListMoviesByTitle :many
+SELECT m.* FROM catalog_movies(
+  sqlc.arg(genre_ids)::int[],
+  sqlc.narg(certification)::text,
+  sqlc.narg(original_language)::text,
+  sqlc.arg(coming_soon)::bool,
+  sqlc.narg(released_from)::date,
+  sqlc.narg(released_to)::date,
+  sqlc.narg(showing_from)::timestamp,
+  sqlc.narg(showing_to)::timestamp
+) m
+WHERE sqlc.narg(after_id)::int IS NULL
+  OR (m.title, m.movie_id) >
+    (sqlc.narg(after_title)::text, sqlc.narg(after_id)::int)
+ORDER BY m.title, m.movie_id
+LIMIT sqlc.arg(row_limit);
+
+-- movies without a release date sort last as -infinity
+-- name: ListMoviesByReleaseDate :many
+SELECT m.* FROM catalog_movies(
+  sqlc.arg(genre_ids)::int[],
+  sqlc.narg(certification)::text,
+  sqlc.narg(original_language)::text,
+  sqlc.arg(coming_soon)::bool,
+  sqlc.narg(released_from)::date,
+  sqlc.narg(released_to)::date,
+  sqlc.narg(showing_from)::timestamp,
+  sqlc.narg(showing_to)::timestamp
+) m
+WHERE sqlc.narg(after_id)::int IS NULL
+  OR (coalesce(m.release_date, '-infinity'::date), m.movie_id) <
+    (sqlc.narg(after_release_date)::date, sqlc.narg(after_id)::int)
+ORDER BY coalesce(m.release_date, '-infinity'::date) DESC, m.movie_id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- bookings_count lags behind by up to one run of the booking counter
+-- name: ListMoviesByPopularity :many
+SELECT m.* FROM catalog_movies(
+  sqlc.arg(genre_ids)::int[],
+  sqlc.narg(certification)::text,
+  sqlc.narg(original_language)::text,
+  sqlc.arg(coming_soon)::bool,
+  sqlc.narg(released_from)::date,
+  sqlc.narg(released_to)::date,
+  sqlc.narg(showing_from)::timestamp,
+  sqlc.narg(showing_to)::timestamp
+) m
+WHERE sqlc.narg(after_id)::int IS NULL
+  OR (m.bookings_count, m.movie_id) <
+    (sqlc.narg(after_bookings)::bigint, sqlc.narg(after_id)::int)
+ORDER BY m.bookings_count DESC, m.movie_id DESC
+LIMIT sqlc.arg(row_limit);
 
 -- name: GetMovie :one
 SELECT * FROM movies
-WHERE movie_id = $1;
+WHERE movie_id = $1 AND deleted_at IS NULL;
+
+-- name: GetMovieForUpdate :one
+SELECT * FROM movies
+WHERE movie_id = $1 AND deleted_at IS NULL
+FOR NO KEY UPDATE;
 
 -- name: UpdateMovie :one
 UPDATE movies
 SET title = $2,
     description = $3,
     poster_url = $4,
-    genre_id = $5
-WHERE movie_id = $1
+    runtime_minutes = $5,
+    certification = $6,
+    release_date = $7,
+    original_language = $8,
+    trailer_url = $9,
+    status = $10
+WHERE movie_id = $1 AND deleted_at IS NULL
 RETURNING *;
 
--- name: DeleteMovie :exec
-DELETE FROM movies
-WHERE movie_id = $1;
+-- movies are never removed, showtimes and bookings keep pointing at them
+-- name: DeleteMovie :one
+UPDATE movies
+SET deleted_at = now(),
+    status = 'archived'
+WHERE movie_id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- start_time has no time zone and holds UTC
+-- recounts the bookings of every movie, only touching the ones that changed
+-- name: RefreshMovieBookingCounts :execrows
+UPDATE movies m
+SET bookings_count = b.bookings
+FROM (
+  SELECT mv.movie_id, count(r.reservation_id) AS bookings
+  FROM movies mv
+  LEFT JOIN showtimes s ON s.movie_id = mv.movie_id
+  LEFT JOIN reservations r ON r.showtime_id = s.showtime_id
+  GROUP BY mv.movie_id
+) b
+WHERE b.movie_id = m.movie_id
+  AND m.bookings_count <> b.bookings;
+
+-- name: CountUpcomingReservationsForMovie :one
+SELECT count(*) FROM reservations r
+JOIN showtimes s ON s.showtime_id = r.showtime_id
+WHERE s.movie_id = $1
+  AND s.start_time > (now() AT TIME ZONE 'UTC');
+
+-- name: SearchMovies :many
+WITH q AS (
+  SELECT to_tsquery('english', sqlc.arg(query)::text) AS query
+)
+SELECT m.movie_id, m.title, m.poster_url, m.release_date, m.status,
+  ts_headline('english', m.title, q.query,
+    'HighlightAll=true, StartSel=<mark>, StopSel=</mark>')::text AS highlighted_title,
+  ts_headline('english', m.description, q.query,
+    'MaxFragments=2, MinWords=5, MaxWords=20, StartSel=<mark>, StopSel=</mark>')::text AS snippet,
+  ts_rank(movie_search_vector(m.title, m.description), q.query)::real AS rank
+FROM movies m, q
+WHERE movie_search_vector(m.title, m.description) @@ q.query
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+ORDER BY rank DESC, m.movie_id
+LIMIT sqlc.arg(row_limit)
+OFFSET sqlc.arg(row_offset);
+
+-- name: SearchMoviesByTitleSimilarity :many
+SELECT movie_id, title, poster_url, release_date, status,
+  title AS highlighted_title,
+  ts_headline('english', description, plainto_tsquery('english', sqlc.arg(query)::text),
+    'MaxFragments=2, MinWords=5, MaxWords=20, StartSel=<mark>, StopSel=</mark>')::text AS snippet,
+  similarity(title, sqlc.arg(query)::text)::real AS rank
+FROM movies
+WHERE title % sqlc.arg(query)::text
+  AND deleted_at IS NULL
+  AND status IN ('coming_soon', 'now_showing')
+ORDER BY rank DESC, movie_id
+LIMIT sqlc.arg(row_limit)
+OFFSET sqlc.arg(row_offset);
@@ -0,0 +1,27 @@
+-- name: CreateOIDCState :one
+INSERT INTO oidc_states (state, code_verifier, nonce, expired_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: DeleteOIDCState :one
+DELETE FROM oidc_states
+WHERE state = $1
+RETURNING *;
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (user_id, issuer, subject, email)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities
+WHERE issuer = $1 AND subject = $2;
+
+-- name: ListUserIdentities :many
+SELECT * FROM user_identities
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: DeleteUserIdentities :exec
+DELETE FROM user_identities
+WHERE user_id = $1;
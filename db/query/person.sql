@@ -0,0 +1,84 @@
+-- name: CreatePerson :one
+INSERT INTO people (name, biography, photo_url)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetPerson :one
+SELECT * FROM people
+WHERE person_id = $1;
+
+-- pages continue after the name and person_id of the last person. search
+-- comes with its LIKE wildcards escaped
+-- name: ListPeople :many
+SELECT * FROM people
+WHERE (sqlc.narg(search)::text IS NULL
+    OR name ILIKE '%' || sqlc.narg(search) || '%')
+  AND (sqlc.narg(after_id)::int IS NULL
+    OR (name, person_id) >
+      (sqlc.narg(after_name)::text, sqlc.narg(after_id)::int))
+ORDER BY name, person_id
+LIMIT sqlc.arg(row_limit);
+
+-- name: UpdatePerson :one
+UPDATE people
+SET name = $2,
+    biography = $3,
+    photo_url = $4
+WHERE person_id = $1
+RETURNING *;
+
+-- name: DeletePerson :exec
+DELETE FROM people
+WHERE person_id = $1;
+
+-- name: CountCreditsForPerson :one
+SELECT count(*) FROM movie_credits
+WHERE person_id = $1;
+
+-- name: CreateMovieCredit :one
+INSERT INTO movie_credits (
+  movie_id,
+  person_id,
+  role,
+  character_name,
+  billing_order
+) VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetMovieCredit :one
+SELECT * FROM movie_credits
+WHERE credit_id = $1 AND movie_id = $2;
+
+-- name: UpdateMovieCredit :one
+UPDATE movie_credits
+SET role = $3,
+    character_name = $4,
+    billing_order = $5
+WHERE credit_id = $1 AND movie_id = $2
+RETURNING *;
+
+-- name: DeleteMovieCredit :exec
+DELETE FROM movie_credits
+WHERE credit_id = $1 AND movie_id = $2;
+
+-- directors first, then writers, then the cast by billing order
+-- name: ListMovieCredits :many
+SELECT c.credit_id, c.person_id, p.name, p.photo_url,
+  c.role, c.character_name, c.billing_order
+FROM movie_credits c
+JOIN people p ON p.person_id = c.person_id
+WHERE c.movie_id = $1
+ORDER BY array_position(ARRAY['director', 'writer', 'actor']::varchar[], c.role),
+  c.billing_order, c.credit_id;
+
+-- the public movies a person is credited in, newest release first
+-- name: ListPersonCredits :many
+SELECT c.credit_id, c.role, c.character_name, c.billing_order,
+  m.movie_id, m.title, m.poster_url, m.release_date, m.status
+FROM movie_credits c
+JOIN movies m ON m.movie_id = c.movie_id
+WHERE c.person_id = $1
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+ORDER BY coalesce(m.release_date, '-infinity'::date) DESC, m.movie_id,
+  c.billing_order, c.credit_id;
@@ -0,0 +1,134 @@
+-- users with booking history to recommend movies to, by user_id
+-- name: ListUsersWithBookings :many
+SELECT u.user_id FROM users u
+WHERE u.user_id > sqlc.arg(after_id)::bigint
+  AND u.disabled_at IS NULL
+  AND u.anonymized_at IS NULL
+  AND EXISTS (SELECT 1 FROM reservations r WHERE r.user_id = u.user_id)
+ORDER BY u.user_id
+LIMIT sqlc.arg(row_limit);
+
+-- name: DeleteUserRecommendations :exec
+DELETE FROM movie_recommendations
+WHERE user_id = $1;
+
+-- scores the public movies with upcoming showtimes the user hasn't booked
+-- yet and keeps the best ones. a movie's score is made of
+--   genre affinity: the largest share of the user's booked movies in one
+--     of its genres
+--   co-booking: how many people who booked the same movies as the user
+--     booked this one, relative to the best candidate
+--   popularity: bookings in the last 30 days, relative to the best
+--     candidate
+-- name: InsertUserRecommendations :execrows
+WITH booked AS (
+  SELECT DISTINCT s.movie_id
+  FROM reservations r
+  JOIN showtimes s ON s.showtime_id = r.showtime_id
+  WHERE r.user_id = sqlc.arg(user_id)::bigint
+), affinity AS (
+  SELECT mg.genre_id,
+    count(*)::float8 / (SELECT count(*) FROM booked) AS weight
+  FROM booked b
+  JOIN movie_genres mg ON mg.movie_id = b.movie_id
+  GROUP BY mg.genre_id
+), candidates AS (
+  SELECT m.movie_id FROM movies m
+  WHERE m.deleted_at IS NULL
+    AND m.status IN ('coming_soon', 'now_showing')
+    AND m.movie_id NOT IN (SELECT movie_id FROM booked)
+    AND EXISTS (
+      SELECT 1 FROM showtimes s
+      WHERE s.movie_id = m.movie_id
+        AND s.start_time > (now() AT TIME ZONE 'UTC')
+    )
+), genre AS (
+  SELECT mg.movie_id, max(a.weight) AS score
+  FROM movie_genres mg
+  JOIN affinity a ON a.genre_id = mg.genre_id
+  WHERE mg.movie_id IN (SELECT movie_id FROM candidates)
+  GROUP BY mg.movie_id
+), co_bookers AS (
+  SELECT DISTINCT r.user_id
+  FROM reservations r
+  JOIN showtimes s ON s.showtime_id = r.showtime_id
+  WHERE s.movie_id IN (SELECT movie_id FROM booked)
+    AND r.user_id <> sqlc.arg(user_id)::bigint
+), co_booking AS (
+  SELECT s.movie_id, count(DISTINCT r.user_id)::float8 AS bookers
+  FROM reservations r
+  JOIN showtimes s ON s.showtime_id = r.showtime_id
+  JOIN co_bookers cb ON cb.user_id = r.user_id
+  WHERE s.movie_id IN (SELECT movie_id FROM candidates)
+  GROUP BY s.movie_id
+), popularity AS (
+  SELECT s.movie_id, count(*)::float8 AS bookings
+  FROM reservations r
+  JOIN showtimes s ON s.showtime_id = r.showtime_id
+  WHERE s.movie_id IN (SELECT movie_id FROM candidates)
+    AND r.reserved_at > now() - interval '30 days'
+  GROUP BY s.movie_id
+), scored AS (
+  SELECT c.movie_id,
+    coalesce(g.score, 0) AS genre_score,
+    coalesce(cb.bookers / nullif(max(cb.bookers) OVER (), 0), 0)
+      AS co_booking_score,
+    coalesce(p.bookings / nullif(max(p.bookings) OVER (), 0), 0)
+      AS popularity_score
+  FROM candidates c
+  LEFT JOIN genre g ON g.movie_id = c.movie_id
+  LEFT JOIN co_booking cb ON cb.movie_id = c.movie_id
+  LEFT JOIN popularity p ON p.movie_id = c.movie_id
+)
+INSERT INTO movie_recommendations (user_id, movie_id, score, genre_score,
+  co_booking_score, popularity_score)
+SELECT sqlc.arg(user_id)::bigint, movie_id,
+  0.5 * genre_score + 0.3 * co_booking_score + 0.2 * popularity_score,
+  genre_score, co_booking_score, popularity_score
+FROM scored
+ORDER BY 3 DESC, movie_id
+LIMIT sqlc.arg(row_limit);
+
+-- drops recommendations of users the job no longer refreshes, e.g. ones
+-- that cancelled every booking since
+-- name: DeleteStaleRecommendations :execrows
+DELETE FROM movie_recommendations mr
+USING users u
+WHERE u.user_id = mr.user_id
+  AND (u.disabled_at IS NOT NULL
+    OR u.anonymized_at IS NOT NULL
+    OR NOT EXISTS (SELECT 1 FROM reservations r WHERE r.user_id = mr.user_id));
+
+-- best recommendations first, movies unpublished since are left out
+-- name: ListUserRecommendations :many
+SELECT sqlc.embed(m), r.score, r.genre_score, r.co_booking_score,
+  r.popularity_score
+FROM movie_recommendations r
+JOIN movies m ON m.movie_id = r.movie_id
+WHERE r.user_id = sqlc.arg(user_id)
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+ORDER BY r.score DESC, r.movie_id
+LIMIT sqlc.arg(row_limit);
+
+-- for users without booking history, the most booked movies of the last
+-- 30 days that have upcoming showtimes
+-- name: ListPopularUpcomingMovies :many
+SELECT sqlc.embed(m), coalesce(b.bookings, 0)::bigint AS bookings
+FROM movies m
+LEFT JOIN (
+  SELECT s.movie_id, count(*) AS bookings
+  FROM reservations r
+  JOIN showtimes s ON s.showtime_id = r.showtime_id
+  WHERE r.reserved_at > now() - interval '30 days'
+  GROUP BY s.movie_id
+) b ON b.movie_id = m.movie_id
+WHERE m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+  AND EXISTS (
+    SELECT 1 FROM showtimes s
+    WHERE s.movie_id = m.movie_id
+      AND s.start_time > (now() AT TIME ZONE 'UTC')
+  )
+ORDER BY bookings DESC, m.movie_id
+LIMIT sqlc.arg(row_limit);
@@ -1,11 +1,15 @@
 -- name: ReserveSeat :one
-INSERT INTO reservations (user_id, showtime_id, seat_id)
-VALUES ($1, $2, $3)
+INSERT INTO reservations (user_id, guest_booking_id, showtime_id, seat_id)
+VALUES (
+  sqlc.narg(user_id), sqlc.narg(guest_booking_id),
+  sqlc.arg(showtime_id), sqlc.arg(seat_id)
+)
 RETURNING *;
 
 -- name: CancelReservation :exec
 DELETE FROM reservations
-WHERE reservation_id = $1 AND user_id = $2;
+WHERE reservation_id = sqlc.arg(reservation_id)
+  AND user_id = sqlc.arg(user_id)::bigint;
 
 -- name: ListReservationsByUser :many
 SELECT r.*, s.start_time, m.title, se.row, se.number
@@ -13,20 +17,53 @@ FROM reservations r
 JOIN showtimes s ON s.showtime_id = r.showtime_id
 JOIN movies m ON m.movie_id = s.movie_id
 JOIN seats se ON se.seat_id = r.seat_id
-WHERE r.user_id = $1
+WHERE r.user_id = sqlc.arg(user_id)::bigint
 ORDER BY s.start_time;
 
+-- name: ListReservationsByUserPage :many
+SELECT r.*, s.start_time, m.title, se.row, se.number
+FROM reservations r
+JOIN showtimes s ON s.showtime_id = r.showtime_id
+JOIN movies m ON m.movie_id = s.movie_id
+JOIN seats se ON se.seat_id = r.seat_id
+WHERE r.user_id = sqlc.arg(user_id)::bigint
+  AND (sqlc.narg(after_id)::bigint IS NULL
+    OR (s.start_time, r.reservation_id) >
+      (sqlc.narg(after_start_time)::timestamp, sqlc.narg(after_id)::bigint))
+ORDER BY s.start_time, r.reservation_id
+LIMIT sqlc.arg(row_limit);
+
+-- showtimes of a movie that isn't public have no seats left to book
 -- name: ListAvailableSeatsForShowtime :many
 SELECT *
 FROM seats
 WHERE seat_id NOT IN (
-  SELECT seat_id FROM reservations WHERE showtime_id = $1
+  SELECT r.seat_id FROM reservations r
+  WHERE r.showtime_id = sqlc.arg(showtime_id)
 )
+  AND EXISTS (
+    SELECT 1 FROM showtimes s
+    JOIN movies m ON m.movie_id = s.movie_id
+    WHERE s.showtime_id = sqlc.arg(showtime_id)
+      AND m.deleted_at IS NULL
+      AND m.status IN ('coming_soon', 'now_showing')
+  )
 ORDER BY row, number;
 
 -- name: ListReservationsByShowtime :many
-SELECT r.*, u.name, se.row, se.number
+SELECT r.*, COALESCE(u.name, g.name)::text AS name, se.row, se.number
 FROM reservations r
-JOIN users u ON u.user_id = r.user_id
+LEFT JOIN users u ON u.user_id = r.user_id
+LEFT JOIN guest_bookings g ON g.id = r.guest_booking_id
 JOIN seats se ON se.seat_id = r.seat_id
-WHERE r.showtime_id = $1;
+WHERE r.showtime_id = sqlc.arg(showtime_id)
+  AND (sqlc.narg(after_id)::bigint IS NULL
+    OR r.reservation_id > sqlc.narg(after_id)::bigint)
+ORDER BY r.reservation_id
+LIMIT sqlc.arg(row_limit);
+
+-- name: LockShowtimeMovie :one
+SELECT m.movie_id FROM showtimes s
+JOIN movies m ON m.movie_id = s.movie_id
+WHERE s.showtime_id = $1 AND m.deleted_at IS NULL
+FOR SHARE OF m;
@@ -0,0 +1,102 @@
+-- name: CreateReview :one
+INSERT INTO reviews (movie_id, user_id, rating, body)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetReview :one
+SELECT * FROM reviews
+WHERE review_id = $1;
+
+-- name: UpdateReview :one
+UPDATE reviews
+SET rating = $2,
+    body = $3,
+    updated_at = now()
+WHERE review_id = $1
+RETURNING *;
+
+-- name: DeleteReview :exec
+DELETE FROM reviews
+WHERE review_id = $1;
+
+-- hiding or flagging again keeps the time it first happened
+-- name: ModerateReview :one
+UPDATE reviews
+SET hidden_at = CASE WHEN sqlc.arg(hidden)::bool
+      THEN coalesce(hidden_at, now()) END,
+    flagged_at = CASE WHEN sqlc.arg(flagged)::bool
+      THEN coalesce(flagged_at, now()) END,
+    moderation_note = sqlc.arg(moderation_note)
+WHERE review_id = sqlc.arg(review_id)
+RETURNING *;
+
+-- visible reviews of a movie, newest first. pages continue below the
+-- review_id of the last review
+-- name: ListMovieReviews :many
+SELECT r.review_id, r.movie_id, r.rating, r.body, r.created_at,
+  r.updated_at, u.username
+FROM reviews r
+JOIN users u ON u.user_id = r.user_id
+WHERE r.movie_id = sqlc.arg(movie_id)
+  AND r.hidden_at IS NULL
+  AND (sqlc.narg(before_id)::bigint IS NULL
+    OR r.review_id < sqlc.narg(before_id)::bigint)
+ORDER BY r.review_id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- flagged or hidden reviews, newest first, for moderators
+-- name: ListModeratedReviews :many
+SELECT * FROM reviews
+WHERE (flagged_at IS NOT NULL OR hidden_at IS NOT NULL)
+  AND (sqlc.narg(flagged)::bool IS NULL
+    OR (flagged_at IS NOT NULL) = sqlc.narg(flagged)::bool)
+  AND (sqlc.narg(hidden)::bool IS NULL
+    OR (hidden_at IS NOT NULL) = sqlc.narg(hidden)::bool)
+  AND (sqlc.narg(before_id)::bigint IS NULL
+    OR review_id < sqlc.narg(before_id)::bigint)
+ORDER BY review_id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- a user may review a movie once one of their showtimes for it started
+-- name: HasWatchedMovie :one
+SELECT EXISTS (
+  SELECT 1 FROM reservations r
+  JOIN showtimes s ON s.showtime_id = r.showtime_id
+  WHERE r.user_id = sqlc.arg(user_id)::bigint
+    AND s.movie_id = sqlc.arg(movie_id)
+    AND s.start_time <= (now() AT TIME ZONE 'UTC')
+);
+
+-- name: LockMovie :exec
+SELECT movie_id FROM movies
+WHERE movie_id = $1
+FOR NO KEY UPDATE;
+
+-- name: RefreshMovieRating :exec
+UPDATE movies m
+SET rating_average = r.average,
+    rating_count = r.count
+FROM (
+  SELECT coalesce(round(avg(rating), 2), 0)::numeric(3,2) AS average,
+    count(*)::int AS count
+  FROM reviews
+  WHERE movie_id = $1 AND hidden_at IS NULL
+) r
+WHERE m.movie_id = $1;
+
+-- name: ListUserReviews :many
+SELECT r.*, m.title
+FROM reviews r
+JOIN movies m ON m.movie_id = r.movie_id
+WHERE r.user_id = $1
+ORDER BY r.review_id;
+
+-- returns the movies whose rating has to be refreshed
+-- name: DeleteUserReviews :many
+WITH deleted AS (
+  DELETE FROM reviews
+  WHERE user_id = $1
+  RETURNING movie_id
+)
+SELECT DISTINCT movie_id FROM deleted
+ORDER BY movie_id;
@@ -0,0 +1,40 @@
+-- name: CreateRole :one
+INSERT INTO roles (name, description)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetRole :one
+SELECT * FROM roles
+WHERE name = $1;
+
+-- name: ListRoles :many
+SELECT * FROM roles
+ORDER BY name;
+
+-- name: UpdateRoleDescription :one
+UPDATE roles
+SET description = $2
+WHERE name = $1
+RETURNING *;
+
+-- name: DeleteRole :exec
+DELETE FROM roles
+WHERE name = $1;
+
+-- name: CountUsersWithRole :one
+SELECT COUNT(*) FROM users
+WHERE role = $1;
+
+-- name: AddRolePermission :exec
+INSERT INTO role_permissions (role, permission)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteRolePermissions :exec
+DELETE FROM role_permissions
+WHERE role = $1;
+
+-- name: ListRolePermissions :many
+SELECT permission FROM role_permissions
+WHERE role = $1
+ORDER BY permission;
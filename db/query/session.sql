@@ -9,3 +9,22 @@ RETURNING *;
 -- name: GetSessionByID :one
 SELECT * FROM sessions
 WHERE id = $1 LIMIT 1;
+
+-- name: BlockSession :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1 AND username = $2;
+
+-- name: BlockUserSessions :exec
+UPDATE sessions
+SET is_blocked = true
+WHERE username = $1;
+
+-- name: ListSessionsByUsername :many
+SELECT * FROM sessions
+WHERE username = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteUserSessions :exec
+DELETE FROM sessions
+WHERE username = $1;
@@ -1,21 +1,30 @@
 -- name: CreateShowtime :one
-INSERT INTO showtimes (movie_id, start_time, price)
-VALUES ($1, $2, $3)
+INSERT INTO showtimes (movie_id, start_time, end_time, price)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: ListShowtimesByDate :many
-SELECT s.showtime_id, s.movie_id, s.start_time, s.price, s.created_at, m.title, m.poster_url
+SELECT s.showtime_id, s.movie_id, s.start_time, s.end_time, s.price, s.created_at, m.title, m.poster_url
 FROM showtimes s
 JOIN movies m ON m.movie_id = s.movie_id
 WHERE s.start_time >= $1
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
 ORDER BY s.start_time;
 
 -- name: ListShowtimesBetween :many
-SELECT s.showtime_id, s.movie_id, s.start_time, s.price, s.created_at, m.title, m.poster_url
+SELECT s.showtime_id, s.movie_id, s.start_time, s.end_time, s.price, s.created_at, m.title, m.poster_url
 FROM showtimes s
 JOIN movies m ON m.movie_id = s.movie_id
-WHERE s.start_time >= $1 AND s.start_time < $2
-ORDER BY s.start_time;
+WHERE s.start_time >= sqlc.arg(starts_from)::timestamp
+  AND s.start_time < sqlc.arg(starts_before)::timestamp
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+  AND (sqlc.narg(after_id)::int IS NULL
+    OR (s.start_time, s.showtime_id) >
+      (sqlc.narg(after_start_time)::timestamp, sqlc.narg(after_id)::int))
+ORDER BY s.start_time, s.showtime_id
+LIMIT sqlc.arg(row_limit);
 
 -- name: GetShowtime :one
 SELECT * FROM showtimes
@@ -23,4 +32,10 @@ WHERE showtime_id = $1;
 
 -- name: DeleteShowtime :exec
 DELETE FROM showtimes
-WHERE showtime_id = $1;
\ No newline at end of file
+WHERE showtime_id = $1;
+-- start_time has no time zone and holds UTC
+-- name: ListUpcomingShowtimesForMovies :many
+SELECT * FROM showtimes
+WHERE movie_id = ANY(sqlc.arg(movie_ids)::int[])
+  AND start_time > (now() AT TIME ZONE 'UTC')
+ORDER BY movie_id, start_time, showtime_id;
@@ -0,0 +1,64 @@
+-- name: SetUserTOTPSecret :one
+UPDATE users
+SET totp_secret = $2,
+    totp_enabled = false
+WHERE user_id = $1
+RETURNING *;
+
+-- name: EnableUserTOTP :one
+UPDATE users
+SET totp_enabled = true
+WHERE user_id = $1 AND totp_secret <> ''
+RETURNING *;
+
+-- name: DisableUserTOTP :one
+UPDATE users
+SET totp_secret = '',
+    totp_enabled = false
+WHERE user_id = $1
+RETURNING *;
+
+-- steps only move forward, so a code can't be replayed within its period
+-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = sqlc.arg(step)
+WHERE user_id = sqlc.arg(user_id) AND totp_last_step < sqlc.arg(step);
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, hashed_code)
+VALUES ($1, $2);
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
+
+-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET used_at = now()
+WHERE user_id = $1
+  AND hashed_code = $2
+  AND used_at IS NULL
+RETURNING *;
+
+-- name: CreateLoginChallenge :one
+INSERT INTO login_challenges (id, user_id, expired_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetLoginChallenge :one
+SELECT * FROM login_challenges
+WHERE id = $1;
+
+-- name: IncrementLoginChallengeAttempts :one
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteLoginChallenge :exec
+DELETE FROM login_challenges
+WHERE id = $1;
+
+-- name: DeleteUserLoginChallenges :exec
+DELETE FROM login_challenges
+WHERE user_id = $1;
@@ -10,3 +10,92 @@ WHERE email = $1;
 -- name: GetUserByID :one
 SELECT * FROM users
 WHERE user_id = $1;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE user_id = $1
+RETURNING *;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2
+WHERE user_id = $1
+RETURNING *;
+
+-- search comes with its LIKE wildcards escaped
+-- name: ListUsers :many
+SELECT * FROM users
+WHERE (sqlc.narg(search)::text IS NULL
+    OR username ILIKE '%' || sqlc.narg(search) || '%'
+    OR name ILIKE '%' || sqlc.narg(search) || '%'
+    OR email ILIKE '%' || sqlc.narg(search) || '%')
+  AND (sqlc.narg(role)::varchar IS NULL OR role = sqlc.narg(role))
+  AND (sqlc.narg(after_id)::bigint IS NULL
+    OR user_id > sqlc.narg(after_id)::bigint)
+ORDER BY user_id
+LIMIT sqlc.arg(row_limit);
+
+-- name: DisableUser :one
+UPDATE users
+SET disabled_at = COALESCE(disabled_at, now())
+WHERE user_id = $1
+RETURNING *;
+
+-- name: EnableUser :one
+UPDATE users
+SET disabled_at = NULL,
+    deletion_requested_at = NULL
+WHERE user_id = $1 AND anonymized_at IS NULL
+RETURNING *;
+
+-- name: RequestUserDeletion :one
+UPDATE users
+SET deletion_requested_at = COALESCE(deletion_requested_at, now()),
+    disabled_at = COALESCE(disabled_at, now())
+WHERE user_id = $1 AND anonymized_at IS NULL
+RETURNING *;
+
+-- name: ListUsersDueForAnonymization :many
+SELECT user_id FROM users
+WHERE deletion_requested_at <= sqlc.arg(requested_before)::timestamptz
+  AND anonymized_at IS NULL
+ORDER BY user_id
+LIMIT sqlc.arg(row_limit);
+
+-- name: AnonymizeUser :one
+UPDATE users
+SET username = 'deleted-' || user_id,
+    name = 'Deleted user',
+    email = 'deleted-' || user_id || '@anonymized.invalid',
+    hashed_password = '',
+    totp_secret = '',
+    totp_enabled = false,
+    anonymized_at = now()
+WHERE user_id = $1 AND anonymized_at IS NULL
+RETURNING *;
+
+-- name: SetUserEmailVerified :one
+UPDATE users
+SET is_email_verified = true
+WHERE user_id = sqlc.arg(user_id) AND email = sqlc.arg(email)
+RETURNING *;
+
+-- name: CreateAccountConfirmation :one
+INSERT INTO account_confirmations (user_id, purpose, secret_code)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: UseAccountConfirmation :one
+UPDATE account_confirmations
+SET is_used = true
+WHERE user_id = $1
+  AND purpose = $2
+  AND secret_code = $3
+  AND is_used = false
+  AND expired_at > now()
+RETURNING *;
+
+-- name: DeleteAccountConfirmations :exec
+DELETE FROM account_confirmations
+WHERE user_id = $1;
@@ -0,0 +1,17 @@
+-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (user_id, email, secret_code)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: UseVerifyEmail :one
+UPDATE verify_emails
+SET is_used = true
+WHERE id = $1
+  AND secret_code = $2
+  AND is_used = false
+  AND expired_at > now()
+RETURNING *;
+
+-- name: DeleteVerifyEmails :exec
+DELETE FROM verify_emails
+WHERE user_id = $1;
@@ -0,0 +1,79 @@
+-- adding a movie twice keeps it where it was
+-- name: AddToWatchlist :exec
+INSERT INTO watchlist (user_id, movie_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, movie_id) DO NOTHING;
+
+-- name: RemoveFromWatchlist :execrows
+DELETE FROM watchlist
+WHERE user_id = $1 AND movie_id = $2;
+
+-- movies a user watches, most recently added first. pages continue after
+-- the created_at and movie_id of the last entry
+-- name: ListWatchlist :many
+SELECT sqlc.embed(m), w.created_at AS added_at
+FROM watchlist w
+JOIN movies m ON m.movie_id = w.movie_id
+WHERE w.user_id = sqlc.arg(user_id)
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+  AND (sqlc.narg(before_movie_id)::int IS NULL
+    OR (w.created_at, w.movie_id) <
+      (sqlc.narg(before_added_at)::timestamptz, sqlc.narg(before_movie_id)::int))
+ORDER BY w.created_at DESC, w.movie_id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: DeleteUserWatchlist :exec
+DELETE FROM watchlist
+WHERE user_id = $1;
+
+-- name: DeleteUserWatchlistNotifications :exec
+DELETE FROM watchlist_notifications
+WHERE user_id = $1;
+
+-- start_time has no time zone and holds UTC
+-- name: CountUpcomingShowtimesForMovie :one
+SELECT count(*) FROM showtimes
+WHERE movie_id = $1
+  AND start_time > (now() AT TIME ZONE 'UTC');
+
+-- queues an alert for everyone watching a public movie, watchers already
+-- alerted about it today are skipped
+-- name: EnqueueWatchlistNotifications :execrows
+INSERT INTO watchlist_notifications (user_id, movie_id)
+SELECT w.user_id, w.movie_id
+FROM watchlist w
+JOIN movies m ON m.movie_id = w.movie_id
+WHERE w.movie_id = $1
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+ON CONFLICT (user_id, movie_id, notify_date) DO NOTHING;
+
+-- alerts still to be emailed, oldest first. accounts that are disabled or
+-- being deleted don't get any, neither do movies unpublished or deleted
+-- since the alert was queued
+-- name: ListPendingWatchlistNotifications :many
+SELECT n.notification_id, n.movie_id, m.title, u.user_id, u.name, u.email
+FROM watchlist_notifications n
+JOIN users u ON u.user_id = n.user_id
+JOIN movies m ON m.movie_id = n.movie_id
+WHERE n.sent_at IS NULL
+  AND u.disabled_at IS NULL
+  AND u.deletion_requested_at IS NULL
+  AND u.anonymized_at IS NULL
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+ORDER BY n.notification_id
+LIMIT sqlc.arg(row_limit);
+
+-- name: MarkWatchlistNotificationSent :exec
+UPDATE watchlist_notifications
+SET sent_at = now()
+WHERE notification_id = $1;
+
+-- name: ListUserWatchlist :many
+SELECT w.movie_id, m.title, w.created_at AS added_at
+FROM watchlist w
+JOIN movies m ON m.movie_id = w.movie_id
+WHERE w.user_id = $1
+ORDER BY w.created_at, w.movie_id;
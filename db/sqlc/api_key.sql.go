@@ -0,0 +1,191 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: api_key.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+  user_id, name, prefix, hashed_key, permissions, allowed_ips,
+  created_by, expired_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING id, user_id, name, prefix, hashed_key, permissions, allowed_ips, created_by, expired_at, last_used_at, last_used_ip, revoked_at, created_at
+`
+
+type CreateAPIKeyParams struct {
+	UserID      int64              `json:"user_id"`
+	Name        string             `json:"name"`
+	Prefix      string             `json:"prefix"`
+	HashedKey   string             `json:"hashed_key"`
+	Permissions []string           `json:"permissions"`
+	AllowedIps  []string           `json:"allowed_ips"`
+	CreatedBy   int64              `json:"created_by"`
+	ExpiredAt   pgtype.Timestamptz `json:"expired_at"`
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, createAPIKey,
+		arg.UserID,
+		arg.Name,
+		arg.Prefix,
+		arg.HashedKey,
+		arg.Permissions,
+		arg.AllowedIps,
+		arg.CreatedBy,
+		arg.ExpiredAt,
+	)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.HashedKey,
+		&i.Permissions,
+		&i.AllowedIps,
+		&i.CreatedBy,
+		&i.ExpiredAt,
+		&i.LastUsedAt,
+		&i.LastUsedIp,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteUserAPIKeys = `-- name: DeleteUserAPIKeys :exec
+DELETE FROM api_keys
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserAPIKeys(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteUserAPIKeys, userID)
+	return err
+}
+
+const getAPIKeyByPrefix = `-- name: GetAPIKeyByPrefix :one
+SELECT id, user_id, name, prefix, hashed_key, permissions, allowed_ips, created_by, expired_at, last_used_at, last_used_ip, revoked_at, created_at FROM api_keys
+WHERE prefix = $1 LIMIT 1
+`
+
+func (q *Queries) GetAPIKeyByPrefix(ctx context.Context, prefix string) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, getAPIKeyByPrefix, prefix)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.HashedKey,
+		&i.Permissions,
+		&i.AllowedIps,
+		&i.CreatedBy,
+		&i.ExpiredAt,
+		&i.LastUsedAt,
+		&i.LastUsedIp,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAPIKeys = `-- name: ListAPIKeys :many
+SELECT id, user_id, name, prefix, hashed_key, permissions, allowed_ips, created_by, expired_at, last_used_at, last_used_ip, revoked_at, created_at FROM api_keys
+WHERE $1::bigint IS NULL
+  OR id < $1::bigint
+ORDER BY id DESC
+LIMIT $2
+`
+
+type ListAPIKeysParams struct {
+	BeforeID pgtype.Int8 `json:"before_id"`
+	RowLimit int32       `json:"row_limit"`
+}
+
+func (q *Queries) ListAPIKeys(ctx context.Context, arg ListAPIKeysParams) ([]ApiKey, error) {
+	rows, err := q.db.Query(ctx, listAPIKeys, arg.BeforeID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ApiKey{}
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Prefix,
+			&i.HashedKey,
+			&i.Permissions,
+			&i.AllowedIps,
+			&i.CreatedBy,
+			&i.ExpiredAt,
+			&i.LastUsedAt,
+			&i.LastUsedIp,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = now()
+WHERE id = $1 AND revoked_at IS NULL
+RETURNING id, user_id, name, prefix, hashed_key, permissions, allowed_ips, created_by, expired_at, last_used_at, last_used_ip, revoked_at, created_at
+`
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, id int64) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, revokeAPIKey, id)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.HashedKey,
+		&i.Permissions,
+		&i.AllowedIps,
+		&i.CreatedBy,
+		&i.ExpiredAt,
+		&i.LastUsedAt,
+		&i.LastUsedIp,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const touchAPIKey = `-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = now(), last_used_ip = $2
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+`
+
+type TouchAPIKeyParams struct {
+	ID         int64  `json:"id"`
+	LastUsedIp string `json:"last_used_ip"`
+}
+
+func (q *Queries) TouchAPIKey(ctx context.Context, arg TouchAPIKeyParams) error {
+	_, err := q.db.Exec(ctx, touchAPIKey, arg.ID, arg.LastUsedIp)
+	return err
+}
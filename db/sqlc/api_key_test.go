@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAPIKey(t *testing.T, user User) ApiKey {
+	arg := CreateAPIKeyParams{
+		UserID:      user.UserID,
+		Name:        util.RandomOwner(),
+		Prefix:      util.RandomString(8),
+		HashedKey:   util.RandomString(64),
+		Permissions: []string{util.CheckinScanPermission},
+		AllowedIps:  []string{"10.0.0.0/24"},
+		CreatedBy:   user.UserID,
+		ExpiredAt: pgtype.Timestamptz{
+			Time:  time.Now().Add(time.Hour),
+			Valid: true,
+		},
+	}
+
+	key, err := testStore.CreateAPIKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, key.ID)
+	require.Equal(t, arg.Prefix, key.Prefix)
+	require.Equal(t, arg.Permissions, key.Permissions)
+	require.Equal(t, arg.AllowedIps, key.AllowedIps)
+	require.False(t, key.LastUsedAt.Valid)
+	require.False(t, key.RevokedAt.Valid)
+
+	return key
+}
+
+func TestGetAPIKeyByPrefix(t *testing.T) {
+	key1 := createRandomAPIKey(t, createRandomUser(t))
+
+	key2, err := testStore.GetAPIKeyByPrefix(context.Background(), key1.Prefix)
+	require.NoError(t, err)
+	require.Equal(t, key1.ID, key2.ID)
+	require.Equal(t, key1.HashedKey, key2.HashedKey)
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	key := createRandomAPIKey(t, createRandomUser(t))
+
+	revoked, err := testStore.RevokeAPIKey(context.Background(), key.ID)
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+
+	// revoking twice finds nothing
+	_, err = testStore.RevokeAPIKey(context.Background(), key.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestTouchAPIKey(t *testing.T) {
+	key := createRandomAPIKey(t, createRandomUser(t))
+
+	err := testStore.TouchAPIKey(context.Background(), TouchAPIKeyParams{
+		ID:         key.ID,
+		LastUsedIp: "10.0.0.1",
+	})
+	require.NoError(t, err)
+
+	// within a minute the first use is kept
+	err = testStore.TouchAPIKey(context.Background(), TouchAPIKeyParams{
+		ID:         key.ID,
+		LastUsedIp: "10.0.0.2",
+	})
+	require.NoError(t, err)
+
+	touched, err := testStore.GetAPIKeyByPrefix(context.Background(), key.Prefix)
+	require.NoError(t, err)
+	require.True(t, touched.LastUsedAt.Valid)
+	require.Equal(t, "10.0.0.1", touched.LastUsedIp)
+}
+
+func TestListAPIKeys(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomAPIKey(t, user)
+	}
+
+	keys, err := testStore.ListAPIKeys(context.Background(), ListAPIKeysParams{
+		RowLimit: 3,
+	})
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	require.Greater(t, keys[0].ID, keys[1].ID)
+
+	// the next page starts below the last key of the first one
+	next, err := testStore.ListAPIKeys(context.Background(), ListAPIKeysParams{
+		BeforeID: pgtype.Int8{Int64: keys[2].ID, Valid: true},
+		RowLimit: 3,
+	})
+	require.NoError(t, err)
+	for _, key := range next {
+		require.Less(t, key.ID, keys[2].ID)
+	}
+}
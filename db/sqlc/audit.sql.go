@@ -0,0 +1,129 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: audit.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createAuditEvent = `-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+  actor_id, action, entity_type, entity_id, before, after,
+  client_ip, user_agent
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING id, actor_id, action, entity_type, entity_id, before, after, client_ip, user_agent, created_at
+`
+
+type CreateAuditEventParams struct {
+	ActorID    pgtype.Int8 `json:"actor_id"`
+	Action     string      `json:"action"`
+	EntityType string      `json:"entity_type"`
+	EntityID   string      `json:"entity_id"`
+	Before     []byte      `json:"before"`
+	After      []byte      `json:"after"`
+	ClientIp   string      `json:"client_ip"`
+	UserAgent  string      `json:"user_agent"`
+}
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error) {
+	row := q.db.QueryRow(ctx, createAuditEvent,
+		arg.ActorID,
+		arg.Action,
+		arg.EntityType,
+		arg.EntityID,
+		arg.Before,
+		arg.After,
+		arg.ClientIp,
+		arg.UserAgent,
+	)
+	var i AuditEvent
+	err := row.Scan(
+		&i.ID,
+		&i.ActorID,
+		&i.Action,
+		&i.EntityType,
+		&i.EntityID,
+		&i.Before,
+		&i.After,
+		&i.ClientIp,
+		&i.UserAgent,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, actor_id, action, entity_type, entity_id, before, after, client_ip, user_agent, created_at FROM audit_events
+WHERE ($1::bigint IS NULL OR actor_id = $1)
+  AND ($2::varchar IS NULL OR action = $2)
+  AND ($3::varchar IS NULL
+    OR entity_type = $3)
+  AND ($4::varchar IS NULL
+    OR entity_id = $4)
+  AND ($5::timestamptz IS NULL
+    OR created_at >= $5)
+  AND ($6::timestamptz IS NULL
+    OR created_at < $6)
+  AND ($7::bigint IS NULL
+    OR id < $7::bigint)
+ORDER BY id DESC
+LIMIT $8
+`
+
+type ListAuditEventsParams struct {
+	ActorID     pgtype.Int8        `json:"actor_id"`
+	Action      pgtype.Text        `json:"action"`
+	EntityType  pgtype.Text        `json:"entity_type"`
+	EntityID    pgtype.Text        `json:"entity_id"`
+	CreatedFrom pgtype.Timestamptz `json:"created_from"`
+	CreatedTo   pgtype.Timestamptz `json:"created_to"`
+	BeforeID    pgtype.Int8        `json:"before_id"`
+	RowLimit    int32              `json:"row_limit"`
+}
+
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.Query(ctx, listAuditEvents,
+		arg.ActorID,
+		arg.Action,
+		arg.EntityType,
+		arg.EntityID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditEvent{}
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActorID,
+			&i.Action,
+			&i.EntityType,
+			&i.EntityID,
+			&i.Before,
+			&i.After,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAuditEvent(t *testing.T, actor User) AuditEvent {
+	arg := CreateAuditEventParams{
+		ActorID:    pgtype.Int8{Int64: actor.UserID, Valid: true},
+		Action:     "user.disable",
+		EntityType: "user",
+		EntityID:   fmt.Sprint(actor.UserID),
+		Before:     []byte(`{"disabled_at":null}`),
+		After:      []byte(`{"disabled_at":"2025-05-01T20:00:00Z"}`),
+		ClientIp:   "127.0.0.1",
+		UserAgent:  "test",
+	}
+
+	event, err := testStore.CreateAuditEvent(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, event.ID)
+	require.Equal(t, arg.ActorID, event.ActorID)
+	require.Equal(t, arg.Action, event.Action)
+	require.Equal(t, arg.EntityID, event.EntityID)
+	require.JSONEq(t, string(arg.After), string(event.After))
+	require.NotZero(t, event.CreatedAt)
+
+	return event
+}
+
+func TestCreateAuditEvent(t *testing.T) {
+	createRandomAuditEvent(t, createRandomUser(t))
+}
+
+func TestListAuditEvents(t *testing.T) {
+	actor := createRandomUser(t)
+	var last AuditEvent
+	for i := 0; i < 3; i++ {
+		last = createRandomAuditEvent(t, actor)
+	}
+
+	events, err := testStore.ListAuditEvents(context.Background(),
+		ListAuditEventsParams{
+			ActorID:    pgtype.Int8{Int64: actor.UserID, Valid: true},
+			EntityType: pgtype.Text{String: "user", Valid: true},
+			CreatedFrom: pgtype.Timestamptz{
+				Time:  last.CreatedAt.Add(-time.Minute),
+				Valid: true,
+			},
+			RowLimit: 2,
+		})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, last.ID, events[0].ID)
+
+	events, err = testStore.ListAuditEvents(context.Background(),
+		ListAuditEventsParams{
+			ActorID:  pgtype.Int8{Int64: actor.UserID, Valid: true},
+			Action:   pgtype.Text{String: "movie.delete", Valid: true},
+			RowLimit: 10,
+		})
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestAuditEventsAppendOnly(t *testing.T) {
+	event := createRandomAuditEvent(t, createRandomUser(t))
+	connPool := testStore.(*SQLStore).connPool
+
+	_, err := connPool.Exec(context.Background(),
+		"UPDATE audit_events SET action = 'edited' WHERE id = $1", event.ID)
+	require.Error(t, err)
+
+	_, err = connPool.Exec(context.Background(),
+		"DELETE FROM audit_events WHERE id = $1", event.ID)
+	require.Error(t, err)
+}
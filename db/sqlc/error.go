@@ -0,0 +1,30 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	ForeignKeyViolation = "23503"
+	UniqueViolation     = "23505"
+)
+
+var ErrRecordNotFound = pgx.ErrNoRows
+
+// a movie can't be deleted while customers hold seats for its showtimes
+var ErrMovieHasBookings = errors.New("movie has upcoming showtimes with bookings")
+
+// showtimes are only scheduled for movies customers can see
+var ErrMovieNotPublic = errors.New("movie is not published")
+
+// returns postgres error code of err, or empty string
+func ErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
@@ -9,6 +9,79 @@ import (
 	"context"
 )
 
+const addMovieGenres = `-- name: AddMovieGenres :exec
+INSERT INTO movie_genres (movie_id, genre_id)
+SELECT $1, unnest($2::int[])
+ON CONFLICT DO NOTHING
+`
+
+type AddMovieGenresParams struct {
+	MovieID  int32   `json:"movie_id"`
+	GenreIds []int32 `json:"genre_ids"`
+}
+
+func (q *Queries) AddMovieGenres(ctx context.Context, arg AddMovieGenresParams) error {
+	_, err := q.db.Exec(ctx, addMovieGenres, arg.MovieID, arg.GenreIds)
+	return err
+}
+
+const countMoviesWithGenre = `-- name: CountMoviesWithGenre :one
+SELECT count(*) FROM movie_genres
+WHERE genre_id = $1
+`
+
+func (q *Queries) CountMoviesWithGenre(ctx context.Context, genreID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countMoviesWithGenre, genreID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createGenre = `-- name: CreateGenre :one
+INSERT INTO genres (name)
+VALUES ($1)
+RETURNING genre_id, name
+`
+
+func (q *Queries) CreateGenre(ctx context.Context, name string) (Genre, error) {
+	row := q.db.QueryRow(ctx, createGenre, name)
+	var i Genre
+	err := row.Scan(&i.GenreID, &i.Name)
+	return i, err
+}
+
+const deleteGenre = `-- name: DeleteGenre :exec
+DELETE FROM genres
+WHERE genre_id = $1
+`
+
+func (q *Queries) DeleteGenre(ctx context.Context, genreID int32) error {
+	_, err := q.db.Exec(ctx, deleteGenre, genreID)
+	return err
+}
+
+const deleteMovieGenres = `-- name: DeleteMovieGenres :exec
+DELETE FROM movie_genres
+WHERE movie_id = $1
+`
+
+func (q *Queries) DeleteMovieGenres(ctx context.Context, movieID int32) error {
+	_, err := q.db.Exec(ctx, deleteMovieGenres, movieID)
+	return err
+}
+
+const getGenre = `-- name: GetGenre :one
+SELECT genre_id, name FROM genres
+WHERE genre_id = $1
+`
+
+func (q *Queries) GetGenre(ctx context.Context, genreID int32) (Genre, error) {
+	row := q.db.QueryRow(ctx, getGenre, genreID)
+	var i Genre
+	err := row.Scan(&i.GenreID, &i.Name)
+	return i, err
+}
+
 const listGenres = `-- name: ListGenres :many
 SELECT genre_id, name FROM genres
 ORDER BY name
@@ -33,3 +106,109 @@ func (q *Queries) ListGenres(ctx context.Context) ([]Genre, error) {
 	}
 	return items, nil
 }
+
+const listGenresByIDs = `-- name: ListGenresByIDs :many
+SELECT genre_id, name FROM genres
+WHERE genre_id = ANY($1::int[])
+ORDER BY name
+`
+
+func (q *Queries) ListGenresByIDs(ctx context.Context, genreIds []int32) ([]Genre, error) {
+	rows, err := q.db.Query(ctx, listGenresByIDs, genreIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Genre{}
+	for rows.Next() {
+		var i Genre
+		if err := rows.Scan(&i.GenreID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGenresForMovies = `-- name: ListGenresForMovies :many
+SELECT mg.movie_id, g.genre_id, g.name
+FROM movie_genres mg
+JOIN genres g ON g.genre_id = mg.genre_id
+WHERE mg.movie_id = ANY($1::int[])
+ORDER BY mg.movie_id, g.name
+`
+
+type ListGenresForMoviesRow struct {
+	MovieID int32  `json:"movie_id"`
+	GenreID int32  `json:"genre_id"`
+	Name    string `json:"name"`
+}
+
+func (q *Queries) ListGenresForMovies(ctx context.Context, movieIds []int32) ([]ListGenresForMoviesRow, error) {
+	rows, err := q.db.Query(ctx, listGenresForMovies, movieIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListGenresForMoviesRow{}
+	for rows.Next() {
+		var i ListGenresForMoviesRow
+		if err := rows.Scan(&i.MovieID, &i.GenreID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMovieGenres = `-- name: ListMovieGenres :many
+SELECT g.genre_id, g.name FROM genres g
+JOIN movie_genres mg ON mg.genre_id = g.genre_id
+WHERE mg.movie_id = $1
+ORDER BY g.name
+`
+
+func (q *Queries) ListMovieGenres(ctx context.Context, movieID int32) ([]Genre, error) {
+	rows, err := q.db.Query(ctx, listMovieGenres, movieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Genre{}
+	for rows.Next() {
+		var i Genre
+		if err := rows.Scan(&i.GenreID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateGenre = `-- name: UpdateGenre :one
+UPDATE genres
+SET name = $2
+WHERE genre_id = $1
+RETURNING genre_id, name
+`
+
+type UpdateGenreParams struct {
+	GenreID int32  `json:"genre_id"`
+	Name    string `json:"name"`
+}
+
+func (q *Queries) UpdateGenre(ctx context.Context, arg UpdateGenreParams) (Genre, error) {
+	row := q.db.QueryRow(ctx, updateGenre, arg.GenreID, arg.Name)
+	var i Genre
+	err := row.Scan(&i.GenreID, &i.Name)
+	return i, err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/kratos69/movie-app/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -11,5 +12,115 @@ func TestListGenre(t *testing.T) {
 	genres, err := testStore.ListGenres(context.Background())
 	require.NoError(t, err)
 	require.NotEmpty(t, genres)
-	require.Equal(t, len(genres), 10)
+	// the seeded genres, other tests may add more
+	require.GreaterOrEqual(t, len(genres), 10)
+}
+
+func createRandomGenre(t *testing.T) Genre {
+	name := "Genre " + util.RandomString(8)
+
+	genre, err := testStore.CreateGenre(context.Background(), name)
+	require.NoError(t, err)
+	require.NotZero(t, genre.GenreID)
+	require.Equal(t, name, genre.Name)
+
+	return genre
+}
+
+func TestCreateGenreUnique(t *testing.T) {
+	genre := createRandomGenre(t)
+
+	_, err := testStore.CreateGenre(context.Background(), genre.Name)
+	require.Error(t, err)
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+}
+
+func TestUpdateGenre(t *testing.T) {
+	genre1 := createRandomGenre(t)
+
+	genre2, err := testStore.UpdateGenre(context.Background(), UpdateGenreParams{
+		GenreID: genre1.GenreID,
+		Name:    "Genre " + util.RandomString(8),
+	})
+	require.NoError(t, err)
+	require.Equal(t, genre1.GenreID, genre2.GenreID)
+	require.NotEqual(t, genre1.Name, genre2.Name)
+}
+
+func TestDeleteGenreInUse(t *testing.T) {
+	genre := createRandomGenre(t)
+	movie := createRandomMovie(t)
+
+	_, err := testStore.UpdateMovieTx(context.Background(), UpdateMovieTxParams{
+		UpdateMovieParams: UpdateMovieParams{
+			MovieID:     movie.MovieID,
+			Title:       movie.Title,
+			Description: movie.Description,
+			PosterUrl:   movie.PosterUrl,
+			Status:      movie.Status,
+		},
+		GenreIDs: []int32{genre.GenreID},
+	})
+	require.NoError(t, err)
+
+	count, err := testStore.CountMoviesWithGenre(context.Background(), genre.GenreID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+
+	err = testStore.DeleteGenre(context.Background(), genre.GenreID)
+	require.Error(t, err)
+	require.Equal(t, ForeignKeyViolation, ErrorCode(err))
+
+	err = testStore.DeleteMovieGenres(context.Background(), movie.MovieID)
+	require.NoError(t, err)
+
+	err = testStore.DeleteGenre(context.Background(), genre.GenreID)
+	require.NoError(t, err)
+}
+
+func TestListMoviesByGenres(t *testing.T) {
+	genre1 := createRandomGenre(t)
+	genre2 := createRandomGenre(t)
+
+	movieIDs := map[int32][]int32{}
+	for _, genreIDs := range [][]int32{
+		{genre1.GenreID},
+		{genre1.GenreID, genre2.GenreID},
+		{genre2.GenreID},
+		{util.RandomGenreID()},
+	} {
+		movie := createRandomMovie(t)
+		_, err := testStore.UpdateMovieTx(context.Background(), UpdateMovieTxParams{
+			UpdateMovieParams: UpdateMovieParams{
+				MovieID:     movie.MovieID,
+				Title:       movie.Title,
+				Description: movie.Description,
+				PosterUrl:   movie.PosterUrl,
+				Status:      movie.Status,
+			},
+			GenreIDs: genreIDs,
+		})
+		require.NoError(t, err)
+		movieIDs[movie.MovieID] = genreIDs
+	}
+
+	movies, err := testStore.ListMoviesByNewest(context.Background(),
+		ListMoviesByNewestParams{
+			GenreIds: []int32{genre1.GenreID, genre2.GenreID},
+			RowLimit: 10,
+		})
+	require.NoError(t, err)
+	require.Len(t, movies, 3)
+
+	ids := make([]int32, 0, len(movies))
+	for _, movie := range movies {
+		ids = append(ids, movie.MovieID)
+	}
+
+	rows, err := testStore.ListGenresForMovies(context.Background(), ids)
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	for _, row := range rows {
+		require.Contains(t, movieIDs[row.MovieID], row.GenreID)
+	}
 }
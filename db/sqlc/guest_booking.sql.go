@@ -0,0 +1,208 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: guest_booking.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const anonymizeUserGuestBookings = `-- name: AnonymizeUserGuestBookings :exec
+UPDATE guest_bookings
+SET email = '', name = ''
+WHERE claimed_by = $1::bigint
+   OR id IN (
+     SELECT guest_booking_id FROM reservations
+     WHERE user_id = $1::bigint
+   )
+`
+
+func (q *Queries) AnonymizeUserGuestBookings(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, anonymizeUserGuestBookings, userID)
+	return err
+}
+
+const assignGuestReservations = `-- name: AssignGuestReservations :execrows
+UPDATE reservations r
+SET user_id = g.claimed_by
+FROM guest_bookings g
+WHERE r.guest_booking_id = g.id
+  AND g.claimed_by = $1::bigint
+  AND r.user_id IS NULL
+`
+
+func (q *Queries) AssignGuestReservations(ctx context.Context, userID int64) (int64, error) {
+	result, err := q.db.Exec(ctx, assignGuestReservations, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const cancelGuestBooking = `-- name: CancelGuestBooking :execrows
+DELETE FROM reservations
+WHERE guest_booking_id = $1::bigint
+`
+
+func (q *Queries) CancelGuestBooking(ctx context.Context, guestBookingID int64) (int64, error) {
+	result, err := q.db.Exec(ctx, cancelGuestBooking, guestBookingID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const claimGuestBookings = `-- name: ClaimGuestBookings :many
+UPDATE guest_bookings
+SET claimed_by = $1::bigint, claimed_at = now()
+WHERE lower(email) = lower($2) AND claimed_by IS NULL
+RETURNING id, reference, hashed_token, email, name, claimed_by, claimed_at, created_at
+`
+
+type ClaimGuestBookingsParams struct {
+	UserID int64  `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+func (q *Queries) ClaimGuestBookings(ctx context.Context, arg ClaimGuestBookingsParams) ([]GuestBooking, error) {
+	rows, err := q.db.Query(ctx, claimGuestBookings, arg.UserID, arg.Email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GuestBooking{}
+	for rows.Next() {
+		var i GuestBooking
+		if err := rows.Scan(
+			&i.ID,
+			&i.Reference,
+			&i.HashedToken,
+			&i.Email,
+			&i.Name,
+			&i.ClaimedBy,
+			&i.ClaimedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createGuestBooking = `-- name: CreateGuestBooking :one
+INSERT INTO guest_bookings (reference, hashed_token, email, name)
+VALUES ($1, $2, $3, $4)
+RETURNING id, reference, hashed_token, email, name, claimed_by, claimed_at, created_at
+`
+
+type CreateGuestBookingParams struct {
+	Reference   string `json:"reference"`
+	HashedToken string `json:"hashed_token"`
+	Email       string `json:"email"`
+	Name        string `json:"name"`
+}
+
+func (q *Queries) CreateGuestBooking(ctx context.Context, arg CreateGuestBookingParams) (GuestBooking, error) {
+	row := q.db.QueryRow(ctx, createGuestBooking,
+		arg.Reference,
+		arg.HashedToken,
+		arg.Email,
+		arg.Name,
+	)
+	var i GuestBooking
+	err := row.Scan(
+		&i.ID,
+		&i.Reference,
+		&i.HashedToken,
+		&i.Email,
+		&i.Name,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getGuestBookingByReference = `-- name: GetGuestBookingByReference :one
+SELECT id, reference, hashed_token, email, name, claimed_by, claimed_at, created_at FROM guest_bookings
+WHERE reference = $1
+`
+
+func (q *Queries) GetGuestBookingByReference(ctx context.Context, reference string) (GuestBooking, error) {
+	row := q.db.QueryRow(ctx, getGuestBookingByReference, reference)
+	var i GuestBooking
+	err := row.Scan(
+		&i.ID,
+		&i.Reference,
+		&i.HashedToken,
+		&i.Email,
+		&i.Name,
+		&i.ClaimedBy,
+		&i.ClaimedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listReservationsByGuestBooking = `-- name: ListReservationsByGuestBooking :many
+SELECT r.reservation_id, r.user_id, r.showtime_id, r.seat_id, r.reserved_at, r.guest_booking_id, s.start_time, m.title, se.row, se.number
+FROM reservations r
+JOIN showtimes s ON s.showtime_id = r.showtime_id
+JOIN movies m ON m.movie_id = s.movie_id
+JOIN seats se ON se.seat_id = r.seat_id
+WHERE r.guest_booking_id = $1::bigint
+ORDER BY s.start_time
+`
+
+type ListReservationsByGuestBookingRow struct {
+	ReservationID  int64            `json:"reservation_id"`
+	UserID         pgtype.Int8      `json:"user_id"`
+	ShowtimeID     int32            `json:"showtime_id"`
+	SeatID         int32            `json:"seat_id"`
+	ReservedAt     time.Time        `json:"reserved_at"`
+	GuestBookingID pgtype.Int8      `json:"guest_booking_id"`
+	StartTime      pgtype.Timestamp `json:"start_time"`
+	Title          string           `json:"title"`
+	Row            int32            `json:"row"`
+	Number         int32            `json:"number"`
+}
+
+func (q *Queries) ListReservationsByGuestBooking(ctx context.Context, guestBookingID int64) ([]ListReservationsByGuestBookingRow, error) {
+	rows, err := q.db.Query(ctx, listReservationsByGuestBooking, guestBookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListReservationsByGuestBookingRow{}
+	for rows.Next() {
+		var i ListReservationsByGuestBookingRow
+		if err := rows.Scan(
+			&i.ReservationID,
+			&i.UserID,
+			&i.ShowtimeID,
+			&i.SeatID,
+			&i.ReservedAt,
+			&i.GuestBookingID,
+			&i.StartTime,
+			&i.Title,
+			&i.Row,
+			&i.Number,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
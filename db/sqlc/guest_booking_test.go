@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomGuestBooking(t *testing.T, email string,
+	seats int) ReserveGuestSeatsTxResult {
+	showtime := createRandomShowtime(t)
+	available := getRandomAvailableSeats(t, showtime.ShowtimeID, seats)
+
+	seatIDs := make([]int32, 0, seats)
+	for _, seat := range available {
+		seatIDs = append(seatIDs, seat.SeatID)
+	}
+
+	arg := ReserveGuestSeatsTxParams{
+		CreateGuestBookingParams: CreateGuestBookingParams{
+			Reference:   strings.ToUpper(util.RandomString(8)),
+			HashedToken: util.RandomString(64),
+			Email:       email,
+			Name:        util.RandomOwner(),
+		},
+		ShowtimeID: showtime.ShowtimeID,
+		SeatIDs:    seatIDs,
+	}
+
+	result, err := testStore.ReserveGuestSeatsTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Reference, result.GuestBooking.Reference)
+	require.False(t, result.GuestBooking.ClaimedBy.Valid)
+	require.Len(t, result.Reservations, seats)
+
+	for _, res := range result.Reservations {
+		require.False(t, res.UserID.Valid)
+		require.Equal(t, result.GuestBooking.ID, res.GuestBookingID.Int64)
+	}
+
+	return result
+}
+
+func TestReserveGuestSeatsTx(t *testing.T) {
+	result := createRandomGuestBooking(t, util.RandomEmail(), 2)
+
+	booking, err := testStore.GetGuestBookingByReference(context.Background(),
+		result.GuestBooking.Reference)
+	require.NoError(t, err)
+	require.Equal(t, result.GuestBooking.ID, booking.ID)
+
+	reservations, err := testStore.ListReservationsByGuestBooking(
+		context.Background(), booking.ID)
+	require.NoError(t, err)
+	require.Len(t, reservations, 2)
+
+	// the seats are taken, so booking them again fails as a whole
+	_, err = testStore.ReserveGuestSeatsTx(context.Background(),
+		ReserveGuestSeatsTxParams{
+			CreateGuestBookingParams: CreateGuestBookingParams{
+				Reference:   strings.ToUpper(util.RandomString(8)),
+				HashedToken: util.RandomString(64),
+				Email:       util.RandomEmail(),
+				Name:        util.RandomOwner(),
+			},
+			ShowtimeID: result.Reservations[0].ShowtimeID,
+			SeatIDs:    []int32{result.Reservations[0].SeatID},
+		})
+	require.Error(t, err)
+}
+
+func TestCancelGuestBooking(t *testing.T) {
+	result := createRandomGuestBooking(t, util.RandomEmail(), 2)
+
+	cancelled, err := testStore.CancelGuestBooking(context.Background(),
+		result.GuestBooking.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), cancelled)
+
+	reservations, err := testStore.ListReservationsByGuestBooking(
+		context.Background(), result.GuestBooking.ID)
+	require.NoError(t, err)
+	require.Empty(t, reservations)
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	user := createRandomUser(t)
+	require.False(t, user.IsEmailVerified)
+
+	// the guest used different casing when booking
+	guest := createRandomGuestBooking(t, strings.ToUpper(user.Email), 1)
+
+	verifyEmail, err := testStore.CreateVerifyEmail(context.Background(),
+		CreateVerifyEmailParams{
+			UserID:     user.UserID,
+			Email:      user.Email,
+			SecretCode: util.RandomString(32),
+		})
+	require.NoError(t, err)
+
+	_, err = testStore.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: util.RandomString(32),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	result, err := testStore.VerifyEmailTx(context.Background(),
+		VerifyEmailTxParams{
+			EmailID:    verifyEmail.ID,
+			SecretCode: verifyEmail.SecretCode,
+		})
+	require.NoError(t, err)
+	require.True(t, result.User.IsEmailVerified)
+	require.True(t, result.VerifyEmail.IsUsed)
+	require.Len(t, result.ClaimedBookings, 1)
+	require.Equal(t, guest.GuestBooking.ID, result.ClaimedBookings[0].ID)
+	require.Equal(t, user.UserID, result.ClaimedBookings[0].ClaimedBy.Int64)
+
+	reservations, err := testStore.ListReservationsByUser(context.Background(),
+		user.UserID)
+	require.NoError(t, err)
+	require.Len(t, reservations, 1)
+	require.Equal(t, guest.Reservations[0].ReservationID,
+		reservations[0].ReservationID)
+
+	// links work once
+	_, err = testStore.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// nothing left to claim
+	claimed, err := testStore.ClaimGuestBookingsTx(context.Background(),
+		ClaimGuestBookingsParams{UserID: user.UserID, Email: user.Email})
+	require.NoError(t, err)
+	require.Empty(t, claimed)
+}
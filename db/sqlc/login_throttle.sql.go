@@ -0,0 +1,174 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: login_throttle.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createAccountUnlock = `-- name: CreateAccountUnlock :one
+INSERT INTO account_unlocks (user_id, email, secret_code)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, email, secret_code, is_used, created_at, expired_at
+`
+
+type CreateAccountUnlockParams struct {
+	UserID     int64  `json:"user_id"`
+	Email      string `json:"email"`
+	SecretCode string `json:"secret_code"`
+}
+
+func (q *Queries) CreateAccountUnlock(ctx context.Context, arg CreateAccountUnlockParams) (AccountUnlock, error) {
+	row := q.db.QueryRow(ctx, createAccountUnlock, arg.UserID, arg.Email, arg.SecretCode)
+	var i AccountUnlock
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const deleteAccountUnlocks = `-- name: DeleteAccountUnlocks :exec
+DELETE FROM account_unlocks
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteAccountUnlocks(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteAccountUnlocks, userID)
+	return err
+}
+
+const deleteLoginThrottle = `-- name: DeleteLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE scope = $1 AND throttle_key = $2
+`
+
+type DeleteLoginThrottleParams struct {
+	Scope       string `json:"scope"`
+	ThrottleKey string `json:"throttle_key"`
+}
+
+func (q *Queries) DeleteLoginThrottle(ctx context.Context, arg DeleteLoginThrottleParams) error {
+	_, err := q.db.Exec(ctx, deleteLoginThrottle, arg.Scope, arg.ThrottleKey)
+	return err
+}
+
+const deleteLoginThrottlesByKey = `-- name: DeleteLoginThrottlesByKey :exec
+DELETE FROM login_throttles
+WHERE throttle_key = $1
+`
+
+func (q *Queries) DeleteLoginThrottlesByKey(ctx context.Context, throttleKey string) error {
+	_, err := q.db.Exec(ctx, deleteLoginThrottlesByKey, throttleKey)
+	return err
+}
+
+const getLoginThrottle = `-- name: GetLoginThrottle :one
+SELECT scope, throttle_key, failed_attempts, last_failed_at, locked_until FROM login_throttles
+WHERE scope = $1 AND throttle_key = $2
+`
+
+type GetLoginThrottleParams struct {
+	Scope       string `json:"scope"`
+	ThrottleKey string `json:"throttle_key"`
+}
+
+func (q *Queries) GetLoginThrottle(ctx context.Context, arg GetLoginThrottleParams) (LoginThrottle, error) {
+	row := q.db.QueryRow(ctx, getLoginThrottle, arg.Scope, arg.ThrottleKey)
+	var i LoginThrottle
+	err := row.Scan(
+		&i.Scope,
+		&i.ThrottleKey,
+		&i.FailedAttempts,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const lockLoginThrottle = `-- name: LockLoginThrottle :exec
+UPDATE login_throttles
+SET locked_until = $3
+WHERE scope = $1 AND throttle_key = $2
+`
+
+type LockLoginThrottleParams struct {
+	Scope       string    `json:"scope"`
+	ThrottleKey string    `json:"throttle_key"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+func (q *Queries) LockLoginThrottle(ctx context.Context, arg LockLoginThrottleParams) error {
+	_, err := q.db.Exec(ctx, lockLoginThrottle, arg.Scope, arg.ThrottleKey, arg.LockedUntil)
+	return err
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (scope, throttle_key, failed_attempts, last_failed_at)
+VALUES ($1, $2, 1, now())
+ON CONFLICT (scope, throttle_key) DO UPDATE
+SET failed_attempts = CASE
+      WHEN login_throttles.last_failed_at < $3::timestamptz THEN 1
+      ELSE login_throttles.failed_attempts + 1
+    END,
+    last_failed_at = now()
+RETURNING scope, throttle_key, failed_attempts, last_failed_at, locked_until
+`
+
+type RecordLoginFailureParams struct {
+	Scope       string    `json:"scope"`
+	ThrottleKey string    `json:"throttle_key"`
+	ResetBefore time.Time `json:"reset_before"`
+}
+
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error) {
+	row := q.db.QueryRow(ctx, recordLoginFailure, arg.Scope, arg.ThrottleKey, arg.ResetBefore)
+	var i LoginThrottle
+	err := row.Scan(
+		&i.Scope,
+		&i.ThrottleKey,
+		&i.FailedAttempts,
+		&i.LastFailedAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const useAccountUnlock = `-- name: UseAccountUnlock :one
+UPDATE account_unlocks
+SET is_used = true
+WHERE id = $1
+  AND secret_code = $2
+  AND is_used = false
+  AND expired_at > now()
+RETURNING id, user_id, email, secret_code, is_used, created_at, expired_at
+`
+
+type UseAccountUnlockParams struct {
+	ID         int64  `json:"id"`
+	SecretCode string `json:"secret_code"`
+}
+
+func (q *Queries) UseAccountUnlock(ctx context.Context, arg UseAccountUnlockParams) (AccountUnlock, error) {
+	row := q.db.QueryRow(ctx, useAccountUnlock, arg.ID, arg.SecretCode)
+	var i AccountUnlock
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	arg := RecordLoginFailureParams{
+		Scope:       "account",
+		ThrottleKey: util.RandomEmail(),
+		ResetBefore: time.Now().Add(-time.Hour),
+	}
+
+	for i := 1; i <= 3; i++ {
+		throttle, err := testStore.RecordLoginFailure(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, int32(i), throttle.FailedAttempts)
+	}
+
+	// failures older than reset_before start counting from scratch
+	arg.ResetBefore = time.Now().Add(time.Minute)
+	throttle, err := testStore.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), throttle.FailedAttempts)
+}
+
+func TestLockLoginThrottle(t *testing.T) {
+	key := util.RandomEmail()
+	_, err := testStore.RecordLoginFailure(context.Background(),
+		RecordLoginFailureParams{
+			Scope:       "account",
+			ThrottleKey: key,
+			ResetBefore: time.Now().Add(-time.Hour),
+		})
+	require.NoError(t, err)
+
+	lockedUntil := time.Now().Add(time.Hour)
+	err = testStore.LockLoginThrottle(context.Background(),
+		LockLoginThrottleParams{
+			Scope:       "account",
+			ThrottleKey: key,
+			LockedUntil: lockedUntil,
+		})
+	require.NoError(t, err)
+
+	throttle, err := testStore.GetLoginThrottle(context.Background(),
+		GetLoginThrottleParams{Scope: "account", ThrottleKey: key})
+	require.NoError(t, err)
+	require.WithinDuration(t, lockedUntil, throttle.LockedUntil, time.Second)
+
+	err = testStore.DeleteLoginThrottle(context.Background(),
+		DeleteLoginThrottleParams{Scope: "account", ThrottleKey: key})
+	require.NoError(t, err)
+
+	_, err = testStore.GetLoginThrottle(context.Background(),
+		GetLoginThrottleParams{Scope: "account", ThrottleKey: key})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestUseAccountUnlock(t *testing.T) {
+	user := createRandomUser(t)
+
+	unlock, err := testStore.CreateAccountUnlock(context.Background(),
+		CreateAccountUnlockParams{
+			UserID:     user.UserID,
+			Email:      user.Email,
+			SecretCode: util.RandomString(32),
+		})
+	require.NoError(t, err)
+	require.False(t, unlock.IsUsed)
+
+	arg := UseAccountUnlockParams{
+		ID:         unlock.ID,
+		SecretCode: unlock.SecretCode,
+	}
+	used, err := testStore.UseAccountUnlock(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, used.IsUsed)
+
+	// links work only once
+	_, err = testStore.UseAccountUnlock(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
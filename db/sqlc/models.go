@@ -11,26 +11,185 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AccountConfirmation struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	Purpose    string    `json:"purpose"`
+	SecretCode string    `json:"secret_code"`
+	IsUsed     bool      `json:"is_used"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+}
+
+type AccountUnlock struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	Email      string    `json:"email"`
+	SecretCode string    `json:"secret_code"`
+	IsUsed     bool      `json:"is_used"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+}
+
+type ApiKey struct {
+	ID          int64              `json:"id"`
+	UserID      int64              `json:"user_id"`
+	Name        string             `json:"name"`
+	Prefix      string             `json:"prefix"`
+	HashedKey   string             `json:"hashed_key"`
+	Permissions []string           `json:"permissions"`
+	AllowedIps  []string           `json:"allowed_ips"`
+	CreatedBy   int64              `json:"created_by"`
+	ExpiredAt   pgtype.Timestamptz `json:"expired_at"`
+	LastUsedAt  pgtype.Timestamptz `json:"last_used_at"`
+	LastUsedIp  string             `json:"last_used_ip"`
+	RevokedAt   pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+type AuditEvent struct {
+	ID         int64       `json:"id"`
+	ActorID    pgtype.Int8 `json:"actor_id"`
+	Action     string      `json:"action"`
+	EntityType string      `json:"entity_type"`
+	EntityID   string      `json:"entity_id"`
+	Before     []byte      `json:"before"`
+	After      []byte      `json:"after"`
+	ClientIp   string      `json:"client_ip"`
+	UserAgent  string      `json:"user_agent"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
 type Genre struct {
 	GenreID int32  `json:"genre_id"`
 	Name    string `json:"name"`
 }
 
+type GuestBooking struct {
+	ID          int64              `json:"id"`
+	Reference   string             `json:"reference"`
+	HashedToken string             `json:"hashed_token"`
+	Email       string             `json:"email"`
+	Name        string             `json:"name"`
+	ClaimedBy   pgtype.Int8        `json:"claimed_by"`
+	ClaimedAt   pgtype.Timestamptz `json:"claimed_at"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+type LoginChallenge struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Attempts  int32     `json:"attempts"`
+	ExpiredAt time.Time `json:"expired_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginThrottle struct {
+	Scope          string    `json:"scope"`
+	ThrottleKey    string    `json:"throttle_key"`
+	FailedAttempts int32     `json:"failed_attempts"`
+	LastFailedAt   time.Time `json:"last_failed_at"`
+	LockedUntil    time.Time `json:"locked_until"`
+}
+
 type Movie struct {
-	MovieID     int32     `json:"movie_id"`
-	Title       string    `json:"title"`
+	MovieID          int32              `json:"movie_id"`
+	Title            string             `json:"title"`
+	Description      string             `json:"description"`
+	PosterUrl        string             `json:"poster_url"`
+	CreatedAt        time.Time          `json:"created_at"`
+	RuntimeMinutes   int32              `json:"runtime_minutes"`
+	Certification    string             `json:"certification"`
+	ReleaseDate      pgtype.Date        `json:"release_date"`
+	OriginalLanguage string             `json:"original_language"`
+	TrailerUrl       string             `json:"trailer_url"`
+	Status           string             `json:"status"`
+	DeletedAt        pgtype.Timestamptz `json:"deleted_at"`
+	RatingAverage    pgtype.Numeric     `json:"rating_average"`
+	RatingCount      int32              `json:"rating_count"`
+	BookingsCount    int64              `json:"-"`
+}
+
+type MovieCredit struct {
+	CreditID      int32     `json:"credit_id"`
+	MovieID       int32     `json:"movie_id"`
+	PersonID      int32     `json:"person_id"`
+	Role          string    `json:"role"`
+	CharacterName string    `json:"character_name"`
+	BillingOrder  int32     `json:"billing_order"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type MovieGenre struct {
+	MovieID int32 `json:"movie_id"`
+	GenreID int32 `json:"genre_id"`
+}
+
+type MovieRecommendation struct {
+	UserID          int64     `json:"user_id"`
+	MovieID         int32     `json:"movie_id"`
+	Score           float64   `json:"score"`
+	GenreScore      float64   `json:"genre_score"`
+	CoBookingScore  float64   `json:"co_booking_score"`
+	PopularityScore float64   `json:"popularity_score"`
+	ComputedAt      time.Time `json:"computed_at"`
+}
+
+type OidcState struct {
+	State        string    `json:"state"`
+	CodeVerifier string    `json:"code_verifier"`
+	Nonce        string    `json:"nonce"`
+	ExpiredAt    time.Time `json:"expired_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Person struct {
+	PersonID  int32     `json:"person_id"`
+	Name      string    `json:"name"`
+	Biography string    `json:"biography"`
+	PhotoUrl  string    `json:"photo_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RecoveryCode struct {
+	ID         int64              `json:"id"`
+	UserID     int64              `json:"user_id"`
+	HashedCode string             `json:"hashed_code"`
+	UsedAt     pgtype.Timestamptz `json:"used_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type Reservation struct {
+	ReservationID  int64       `json:"reservation_id"`
+	UserID         pgtype.Int8 `json:"user_id"`
+	ShowtimeID     int32       `json:"showtime_id"`
+	SeatID         int32       `json:"seat_id"`
+	ReservedAt     time.Time   `json:"reserved_at"`
+	GuestBookingID pgtype.Int8 `json:"guest_booking_id"`
+}
+
+type Review struct {
+	ReviewID       int64              `json:"review_id"`
+	MovieID        int32              `json:"movie_id"`
+	UserID         int64              `json:"user_id"`
+	Rating         int32              `json:"rating"`
+	Body           string             `json:"body"`
+	FlaggedAt      pgtype.Timestamptz `json:"flagged_at"`
+	HiddenAt       pgtype.Timestamptz `json:"hidden_at"`
+	ModerationNote string             `json:"moderation_note"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+type Role struct {
+	Name        string    `json:"name"`
 	Description string    `json:"description"`
-	PosterUrl   string    `json:"poster_url"`
-	GenreID     int32     `json:"genre_id"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
-type Reservation struct {
-	ReservationID int64     `json:"reservation_id"`
-	UserID        int64     `json:"user_id"`
-	ShowtimeID    int32     `json:"showtime_id"`
-	SeatID        int32     `json:"seat_id"`
-	ReservedAt    time.Time `json:"reserved_at"`
+type RolePermission struct {
+	Role       string `json:"role"`
+	Permission string `json:"permission"`
 }
 
 // This table represents the fixed seat layout
@@ -58,14 +217,56 @@ type Showtime struct {
 	StartTime  pgtype.Timestamp `json:"start_time"`
 	Price      pgtype.Numeric   `json:"price"`
 	CreatedAt  time.Time        `json:"created_at"`
+	EndTime    pgtype.Timestamp `json:"end_time"`
 }
 
 type User struct {
-	UserID         int64     `json:"user_id"`
-	Username       string    `json:"username"`
-	Name           string    `json:"name"`
-	Email          string    `json:"email"`
-	HashedPassword string    `json:"hashed_password"`
-	Role           string    `json:"role"`
-	CreatedAt      time.Time `json:"created_at"`
+	UserID              int64              `json:"user_id"`
+	Username            string             `json:"username"`
+	Name                string             `json:"name"`
+	Email               string             `json:"email"`
+	HashedPassword      string             `json:"hashed_password"`
+	Role                string             `json:"role"`
+	CreatedAt           time.Time          `json:"created_at"`
+	TotpSecret          string             `json:"totp_secret"`
+	TotpEnabled         bool               `json:"totp_enabled"`
+	DisabledAt          pgtype.Timestamptz `json:"disabled_at"`
+	DeletionRequestedAt pgtype.Timestamptz `json:"deletion_requested_at"`
+	AnonymizedAt        pgtype.Timestamptz `json:"anonymized_at"`
+	IsEmailVerified     bool               `json:"is_email_verified"`
+	TotpLastStep        int64              `json:"totp_last_step"`
+}
+
+type UserIdentity struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type VerifyEmail struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	Email      string    `json:"email"`
+	SecretCode string    `json:"secret_code"`
+	IsUsed     bool      `json:"is_used"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+}
+
+type Watchlist struct {
+	UserID    int64     `json:"user_id"`
+	MovieID   int32     `json:"movie_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WatchlistNotification struct {
+	NotificationID int64              `json:"notification_id"`
+	UserID         int64              `json:"user_id"`
+	MovieID        int32              `json:"movie_id"`
+	NotifyDate     pgtype.Date        `json:"notify_date"`
+	CreatedAt      time.Time          `json:"created_at"`
+	SentAt         pgtype.Timestamptz `json:"sent_at"`
 }
@@ -7,19 +7,49 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countUpcomingReservationsForMovie = `-- name: CountUpcomingReservationsForMovie :one
+SELECT count(*) FROM reservations r
+JOIN showtimes s ON s.showtime_id = r.showtime_id
+WHERE s.movie_id = $1
+  AND s.start_time > (now() AT TIME ZONE 'UTC')
+`
+
+func (q *Queries) CountUpcomingReservationsForMovie(ctx context.Context, movieID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countUpcomingReservationsForMovie, movieID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createMovie = `-- name: CreateMovie :one
-INSERT INTO movies (title, description, poster_url, genre_id)
-VALUES ($1, $2, $3, $4)
-RETURNING movie_id, title, description, poster_url, genre_id, created_at
+INSERT INTO movies (
+  title,
+  description,
+  poster_url,
+  runtime_minutes,
+  certification,
+  release_date,
+  original_language,
+  trailer_url,
+  status
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING movie_id, title, description, poster_url, created_at, runtime_minutes, certification, release_date, original_language, trailer_url, status, deleted_at, rating_average, rating_count, bookings_count
 `
 
 type CreateMovieParams struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	PosterUrl   string `json:"poster_url"`
-	GenreID     int32  `json:"genre_id"`
+	Title            string      `json:"title"`
+	Description      string      `json:"description"`
+	PosterUrl        string      `json:"poster_url"`
+	RuntimeMinutes   int32       `json:"runtime_minutes"`
+	Certification    string      `json:"certification"`
+	ReleaseDate      pgtype.Date `json:"release_date"`
+	OriginalLanguage string      `json:"original_language"`
+	TrailerUrl       string      `json:"trailer_url"`
+	Status           string      `json:"status"`
 }
 
 func (q *Queries) CreateMovie(ctx context.Context, arg CreateMovieParams) (Movie, error) {
@@ -27,7 +57,12 @@ func (q *Queries) CreateMovie(ctx context.Context, arg CreateMovieParams) (Movie
 		arg.Title,
 		arg.Description,
 		arg.PosterUrl,
-		arg.GenreID,
+		arg.RuntimeMinutes,
+		arg.Certification,
+		arg.ReleaseDate,
+		arg.OriginalLanguage,
+		arg.TrailerUrl,
+		arg.Status,
 	)
 	var i Movie
 	err := row.Scan(
@@ -35,25 +70,56 @@ func (q *Queries) CreateMovie(ctx context.Context, arg CreateMovieParams) (Movie
 		&i.Title,
 		&i.Description,
 		&i.PosterUrl,
-		&i.GenreID,
 		&i.CreatedAt,
+		&i.RuntimeMinutes,
+		&i.Certification,
+		&i.ReleaseDate,
+		&i.OriginalLanguage,
+		&i.TrailerUrl,
+		&i.Status,
+		&i.DeletedAt,
+		&i.RatingAverage,
+		&i.RatingCount,
+		&i.BookingsCount,
 	)
 	return i, err
 }
 
-const deleteMovie = `-- name: DeleteMovie :exec
-DELETE FROM movies
-WHERE movie_id = $1
+const deleteMovie = `-- name: DeleteMovie :one
+UPDATE movies
+SET deleted_at = now(),
+    status = 'archived'
+WHERE movie_id = $1 AND deleted_at IS NULL
+RETURNING movie_id, title, description, poster_url, created_at, runtime_minutes, certification, release_date, original_language, trailer_url, status, deleted_at, rating_average, rating_count, bookings_count
 `
 
-func (q *Queries) DeleteMovie(ctx context.Context, movieID int32) error {
-	_, err := q.db.Exec(ctx, deleteMovie, movieID)
-	return err
+// movies are never removed, showtimes and bookings keep pointing at them
+func (q *Queries) DeleteMovie(ctx context.Context, movieID int32) (Movie, error) {
+	row := q.db.QueryRow(ctx, deleteMovie, movieID)
+	var i Movie
+	err := row.Scan(
+		&i.MovieID,
+		&i.Title,
+		&i.Description,
+		&i.PosterUrl,
+		&i.CreatedAt,
+		&i.RuntimeMinutes,
+		&i.Certification,
+		&i.ReleaseDate,
+		&i.OriginalLanguage,
+		&i.TrailerUrl,
+		&i.Status,
+		&i.DeletedAt,
+		&i.RatingAverage,
+		&i.RatingCount,
+		&i.BookingsCount,
+	)
+	return i, err
 }
 
 const getMovie = `-- name: GetMovie :one
-SELECT movie_id, title, description, poster_url, genre_id, created_at FROM movies
-WHERE movie_id = $1
+SELECT movie_id, title, description, poster_url, created_at, runtime_minutes, certification, release_date, original_language, trailer_url, status, deleted_at, rating_average, rating_count, bookings_count FROM movies
+WHERE movie_id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetMovie(ctx context.Context, movieID int32) (Movie, error) {
@@ -64,26 +130,344 @@ func (q *Queries) GetMovie(ctx context.Context, movieID int32) (Movie, error) {
 		&i.Title,
 		&i.Description,
 		&i.PosterUrl,
-		&i.GenreID,
 		&i.CreatedAt,
+		&i.RuntimeMinutes,
+		&i.Certification,
+		&i.ReleaseDate,
+		&i.OriginalLanguage,
+		&i.TrailerUrl,
+		&i.Status,
+		&i.DeletedAt,
+		&i.RatingAverage,
+		&i.RatingCount,
+		&i.BookingsCount,
 	)
 	return i, err
 }
 
-const listMovies = `-- name: ListMovies :many
-SELECT movie_id, title, description, poster_url, genre_id, created_at FROM movies
-ORDER BY created_at DESC
-LIMIT $1
-OFFSET $2
+const getMovieForUpdate = `-- name: GetMovieForUpdate :one
+SELECT movie_id, title, description, poster_url, created_at, runtime_minutes, certification, release_date, original_language, trailer_url, status, deleted_at, rating_average, rating_count, bookings_count FROM movies
+WHERE movie_id = $1 AND deleted_at IS NULL
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetMovieForUpdate(ctx context.Context, movieID int32) (Movie, error) {
+	row := q.db.QueryRow(ctx, getMovieForUpdate, movieID)
+	var i Movie
+	err := row.Scan(
+		&i.MovieID,
+		&i.Title,
+		&i.Description,
+		&i.PosterUrl,
+		&i.CreatedAt,
+		&i.RuntimeMinutes,
+		&i.Certification,
+		&i.ReleaseDate,
+		&i.OriginalLanguage,
+		&i.TrailerUrl,
+		&i.Status,
+		&i.DeletedAt,
+		&i.RatingAverage,
+		&i.RatingCount,
+		&i.BookingsCount,
+	)
+	return i, err
+}
+
+const listMoviesByNewest = `-- name: ListMoviesByNewest :many
+
+SELECT m.movie_id, m.title, m.description, m.poster_url, m.created_at, m.runtime_minutes, m.certification, m.release_date, m.original_language, m.trailer_url, m.status, m.deleted_at, m.rating_average, m.rating_count, m.bookings_count FROM catalog_movies(
+  $1::int[],
+  $2::text,
+  $3::text,
+  $4::bool,
+  $5::date,
+  $6::date,
+  $7::timestamp,
+  $8::timestamp
+) m
+WHERE $9::int IS NULL
+  OR (m.created_at, m.movie_id) <
+    ($10::timestamptz, $9::int)
+ORDER BY m.created_at DESC, m.movie_id DESC
+LIMIT $11
+`
+
+type ListMoviesByNewestParams struct {
+	GenreIds         []int32            `json:"genre_ids"`
+	Certification    pgtype.Text        `json:"certification"`
+	OriginalLanguage pgtype.Text        `json:"original_language"`
+	ComingSoon       bool               `json:"coming_soon"`
+	ReleasedFrom     pgtype.Date        `json:"released_from"`
+	ReleasedTo       pgtype.Date        `json:"released_to"`
+	ShowingFrom      pgtype.Timestamp   `json:"showing_from"`
+	ShowingTo        pgtype.Timestamp   `json:"showing_to"`
+	AfterID          pgtype.Int4        `json:"after_id"`
+	AfterCreatedAt   pgtype.Timestamptz `json:"after_created_at"`
+	RowLimit         int32              `json:"row_limit"`
+}
+
+// the ListMoviesBy* queries filter through catalog_movies, which skips
+// deleted movies, drafts and archived movies like every public query. each
+// one has a fixed order so it can walk its index, and pages continue after
+// the sort key and movie_id of the last movie of the previous page, null
+// on the first page
+func (q *Queries) ListMoviesByNewest(ctx context.Context, arg ListMoviesByNewestParams) ([]Movie, error) {
+	rows, err := q.db.Query(ctx, listMoviesByNewest,
+		arg.GenreIds,
+		arg.Certification,
+		arg.OriginalLanguage,
+		arg.ComingSoon,
+		arg.ReleasedFrom,
+		arg.ReleasedTo,
+		arg.ShowingFrom,
+		arg.ShowingTo,
+		arg.AfterID,
+		arg.AfterCreatedAt,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Movie{}
+	for rows.Next() {
+		var i Movie
+		if err := rows.Scan(
+			&i.MovieID,
+			&i.Title,
+			&i.Description,
+			&i.PosterUrl,
+			&i.CreatedAt,
+			&i.RuntimeMinutes,
+			&i.Certification,
+			&i.ReleaseDate,
+			&i.OriginalLanguage,
+			&i.TrailerUrl,
+			&i.Status,
+			&i.DeletedAt,
+			&i.RatingAverage,
+			&i.RatingCount,
+			&i.BookingsCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByPopularity = `-- name: ListMoviesByPopularity :many
+SELECT m.movie_id, m.title, m.description, m.poster_url, m.created_at, m.runtime_minutes, m.certification, m.release_date, m.original_language, m.trailer_url, m.status, m.deleted_at, m.rating_average, m.rating_count, m.bookings_count FROM catalog_movies(
+  $1::int[],
+  $2::text,
+  $3::text,
+  $4::bool,
+  $5::date,
+  $6::date,
+  $7::timestamp,
+  $8::timestamp
+) m
+WHERE $9::int IS NULL
+  OR (m.bookings_count, m.movie_id) <
+    ($10::bigint, $9::int)
+ORDER BY m.bookings_count DESC, m.movie_id DESC
+LIMIT $11
+`
+
+type ListMoviesByPopularityParams struct {
+	GenreIds         []int32          `json:"genre_ids"`
+	Certification    pgtype.Text      `json:"certification"`
+	OriginalLanguage pgtype.Text      `json:"original_language"`
+	ComingSoon       bool             `json:"coming_soon"`
+	ReleasedFrom     pgtype.Date      `json:"released_from"`
+	ReleasedTo       pgtype.Date      `json:"released_to"`
+	ShowingFrom      pgtype.Timestamp `json:"showing_from"`
+	ShowingTo        pgtype.Timestamp `json:"showing_to"`
+	AfterID          pgtype.Int4      `json:"after_id"`
+	AfterBookings    pgtype.Int8      `json:"after_bookings"`
+	RowLimit         int32            `json:"row_limit"`
+}
+
+// bookings_count lags behind by up to one run of the booking counter
+func (q *Queries) ListMoviesByPopularity(ctx context.Context, arg ListMoviesByPopularityParams) ([]Movie, error) {
+	rows, err := q.db.Query(ctx, listMoviesByPopularity,
+		arg.GenreIds,
+		arg.Certification,
+		arg.OriginalLanguage,
+		arg.ComingSoon,
+		arg.ReleasedFrom,
+		arg.ReleasedTo,
+		arg.ShowingFrom,
+		arg.ShowingTo,
+		arg.AfterID,
+		arg.AfterBookings,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Movie{}
+	for rows.Next() {
+		var i Movie
+		if err := rows.Scan(
+			&i.MovieID,
+			&i.Title,
+			&i.Description,
+			&i.PosterUrl,
+			&i.CreatedAt,
+			&i.RuntimeMinutes,
+			&i.Certification,
+			&i.ReleaseDate,
+			&i.OriginalLanguage,
+			&i.TrailerUrl,
+			&i.Status,
+			&i.DeletedAt,
+			&i.RatingAverage,
+			&i.RatingCount,
+			&i.BookingsCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByReleaseDate = `-- name: ListMoviesByReleaseDate :many
+SELECT m.movie_id, m.title, m.description, m.poster_url, m.created_at, m.runtime_minutes, m.certification, m.release_date, m.original_language, m.trailer_url, m.status, m.deleted_at, m.rating_average, m.rating_count, m.bookings_count FROM catalog_movies(
+  $1::int[],
+  $2::text,
+  $3::text,
+  $4::bool,
+  $5::date,
+  $6::date,
+  $7::timestamp,
+  $8::timestamp
+) m
+WHERE $9::int IS NULL
+  OR (coalesce(m.release_date, '-infinity'::date), m.movie_id) <
+    ($10::date, $9::int)
+ORDER BY coalesce(m.release_date, '-infinity'::date) DESC, m.movie_id DESC
+LIMIT $11
+`
+
+type ListMoviesByReleaseDateParams struct {
+	GenreIds         []int32          `json:"genre_ids"`
+	Certification    pgtype.Text      `json:"certification"`
+	OriginalLanguage pgtype.Text      `json:"original_language"`
+	ComingSoon       bool             `json:"coming_soon"`
+	ReleasedFrom     pgtype.Date      `json:"released_from"`
+	ReleasedTo       pgtype.Date      `json:"released_to"`
+	ShowingFrom      pgtype.Timestamp `json:"showing_from"`
+	ShowingTo        pgtype.Timestamp `json:"showing_to"`
+	AfterID          pgtype.Int4      `json:"after_id"`
+	AfterReleaseDate pgtype.Date      `json:"after_release_date"`
+	RowLimit         int32            `json:"row_limit"`
+}
+
+// movies without a release date sort last as -infinity
+func (q *Queries) ListMoviesByReleaseDate(ctx context.Context, arg ListMoviesByReleaseDateParams) ([]Movie, error) {
+	rows, err := q.db.Query(ctx, listMoviesByReleaseDate,
+		arg.GenreIds,
+		arg.Certification,
+		arg.OriginalLanguage,
+		arg.ComingSoon,
+		arg.ReleasedFrom,
+		arg.ReleasedTo,
+		arg.ShowingFrom,
+		arg.ShowingTo,
+		arg.AfterID,
+		arg.AfterReleaseDate,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Movie{}
+	for rows.Next() {
+		var i Movie
+		if err := rows.Scan(
+			&i.MovieID,
+			&i.Title,
+			&i.Description,
+			&i.PosterUrl,
+			&i.CreatedAt,
+			&i.RuntimeMinutes,
+			&i.Certification,
+			&i.ReleaseDate,
+			&i.OriginalLanguage,
+			&i.TrailerUrl,
+			&i.Status,
+			&i.DeletedAt,
+			&i.RatingAverage,
+			&i.RatingCount,
+			&i.BookingsCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByTitle = `-- name: ListMoviesByTitle :many
+SELECT m.movie_id, m.title, m.description, m.poster_url, m.created_at, m.runtime_minutes, m.certification, m.release_date, m.original_language, m.trailer_url, m.status, m.deleted_at, m.rating_average, m.rating_count, m.bookings_count FROM catalog_movies(
+  $1::int[],
+  $2::text,
+  $3::text,
+  $4::bool,
+  $5::date,
+  $6::date,
+  $7::timestamp,
+  $8::timestamp
+) m
+WHERE $9::int IS NULL
+  OR (m.title, m.movie_id) >
+    ($10::text, $9::int)
+ORDER BY m.title, m.movie_id
+LIMIT $11
 `
 
-type ListMoviesParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+type ListMoviesByTitleParams struct {
+	GenreIds         []int32          `json:"genre_ids"`
+	Certification    pgtype.Text      `json:"certification"`
+	OriginalLanguage pgtype.Text      `json:"original_language"`
+	ComingSoon       bool             `json:"coming_soon"`
+	ReleasedFrom     pgtype.Date      `json:"released_from"`
+	ReleasedTo       pgtype.Date      `json:"released_to"`
+	ShowingFrom      pgtype.Timestamp `json:"showing_from"`
+	ShowingTo        pgtype.Timestamp `json:"showing_to"`
+	AfterID          pgtype.Int4      `json:"after_id"`
+	AfterTitle       pgtype.Text      `json:"after_title"`
+	RowLimit         int32            `json:"row_limit"`
 }
 
-func (q *Queries) ListMovies(ctx context.Context, arg ListMoviesParams) ([]Movie, error) {
-	rows, err := q.db.Query(ctx, listMovies, arg.Limit, arg.Offset)
+func (q *Queries) ListMoviesByTitle(ctx context.Context, arg ListMoviesByTitleParams) ([]Movie, error) {
+	rows, err := q.db.Query(ctx, listMoviesByTitle,
+		arg.GenreIds,
+		arg.Certification,
+		arg.OriginalLanguage,
+		arg.ComingSoon,
+		arg.ReleasedFrom,
+		arg.ReleasedTo,
+		arg.ShowingFrom,
+		arg.ShowingTo,
+		arg.AfterID,
+		arg.AfterTitle,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -96,8 +480,167 @@ func (q *Queries) ListMovies(ctx context.Context, arg ListMoviesParams) ([]Movie
 			&i.Title,
 			&i.Description,
 			&i.PosterUrl,
-			&i.GenreID,
 			&i.CreatedAt,
+			&i.RuntimeMinutes,
+			&i.Certification,
+			&i.ReleaseDate,
+			&i.OriginalLanguage,
+			&i.TrailerUrl,
+			&i.Status,
+			&i.DeletedAt,
+			&i.RatingAverage,
+			&i.RatingCount,
+			&i.BookingsCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const refreshMovieBookingCounts = `-- name: RefreshMovieBookingCounts :execrows
+UPDATE movies m
+SET bookings_count = b.bookings
+FROM (
+  SELECT mv.movie_id, count(r.reservation_id) AS bookings
+  FROM movies mv
+  LEFT JOIN showtimes s ON s.movie_id = mv.movie_id
+  LEFT JOIN reservations r ON r.showtime_id = s.showtime_id
+  GROUP BY mv.movie_id
+) b
+WHERE b.movie_id = m.movie_id
+  AND m.bookings_count <> b.bookings
+`
+
+// start_time has no time zone and holds UTC
+// recounts the bookings of every movie, only touching the ones that changed
+func (q *Queries) RefreshMovieBookingCounts(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, refreshMovieBookingCounts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const searchMovies = `-- name: SearchMovies :many
+WITH q AS (
+  SELECT to_tsquery('english', $3::text) AS query
+)
+SELECT m.movie_id, m.title, m.poster_url, m.release_date, m.status,
+  ts_headline('english', m.title, q.query,
+    'HighlightAll=true, StartSel=<mark>, StopSel=</mark>')::text AS highlighted_title,
+  ts_headline('english', m.description, q.query,
+    'MaxFragments=2, MinWords=5, MaxWords=20, StartSel=<mark>, StopSel=</mark>')::text AS snippet,
+  ts_rank(movie_search_vector(m.title, m.description), q.query)::real AS rank
+FROM movies m, q
+WHERE movie_search_vector(m.title, m.description) @@ q.query
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+ORDER BY rank DESC, m.movie_id
+LIMIT $2
+OFFSET $1
+`
+
+type SearchMoviesParams struct {
+	RowOffset int32  `json:"row_offset"`
+	RowLimit  int32  `json:"row_limit"`
+	Query     string `json:"query"`
+}
+
+type SearchMoviesRow struct {
+	MovieID          int32       `json:"movie_id"`
+	Title            string      `json:"title"`
+	PosterUrl        string      `json:"poster_url"`
+	ReleaseDate      pgtype.Date `json:"release_date"`
+	Status           string      `json:"status"`
+	HighlightedTitle string      `json:"highlighted_title"`
+	Snippet          string      `json:"snippet"`
+	Rank             float32     `json:"rank"`
+}
+
+func (q *Queries) SearchMovies(ctx context.Context, arg SearchMoviesParams) ([]SearchMoviesRow, error) {
+	rows, err := q.db.Query(ctx, searchMovies, arg.RowOffset, arg.RowLimit, arg.Query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchMoviesRow{}
+	for rows.Next() {
+		var i SearchMoviesRow
+		if err := rows.Scan(
+			&i.MovieID,
+			&i.Title,
+			&i.PosterUrl,
+			&i.ReleaseDate,
+			&i.Status,
+			&i.HighlightedTitle,
+			&i.Snippet,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchMoviesByTitleSimilarity = `-- name: SearchMoviesByTitleSimilarity :many
+SELECT movie_id, title, poster_url, release_date, status,
+  title AS highlighted_title,
+  ts_headline('english', description, plainto_tsquery('english', $1::text),
+    'MaxFragments=2, MinWords=5, MaxWords=20, StartSel=<mark>, StopSel=</mark>')::text AS snippet,
+  similarity(title, $1::text)::real AS rank
+FROM movies
+WHERE title % $1::text
+  AND deleted_at IS NULL
+  AND status IN ('coming_soon', 'now_showing')
+ORDER BY rank DESC, movie_id
+LIMIT $3
+OFFSET $2
+`
+
+type SearchMoviesByTitleSimilarityParams struct {
+	Query     string `json:"query"`
+	RowOffset int32  `json:"row_offset"`
+	RowLimit  int32  `json:"row_limit"`
+}
+
+type SearchMoviesByTitleSimilarityRow struct {
+	MovieID          int32       `json:"movie_id"`
+	Title            string      `json:"title"`
+	PosterUrl        string      `json:"poster_url"`
+	ReleaseDate      pgtype.Date `json:"release_date"`
+	Status           string      `json:"status"`
+	HighlightedTitle string      `json:"highlighted_title"`
+	Snippet          string      `json:"snippet"`
+	Rank             float32     `json:"rank"`
+}
+
+func (q *Queries) SearchMoviesByTitleSimilarity(ctx context.Context, arg SearchMoviesByTitleSimilarityParams) ([]SearchMoviesByTitleSimilarityRow, error) {
+	rows, err := q.db.Query(ctx, searchMoviesByTitleSimilarity, arg.Query, arg.RowOffset, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchMoviesByTitleSimilarityRow{}
+	for rows.Next() {
+		var i SearchMoviesByTitleSimilarityRow
+		if err := rows.Scan(
+			&i.MovieID,
+			&i.Title,
+			&i.PosterUrl,
+			&i.ReleaseDate,
+			&i.Status,
+			&i.HighlightedTitle,
+			&i.Snippet,
+			&i.Rank,
 		); err != nil {
 			return nil, err
 		}
@@ -114,17 +657,27 @@ UPDATE movies
 SET title = $2,
     description = $3,
     poster_url = $4,
-    genre_id = $5
-WHERE movie_id = $1
-RETURNING movie_id, title, description, poster_url, genre_id, created_at
+    runtime_minutes = $5,
+    certification = $6,
+    release_date = $7,
+    original_language = $8,
+    trailer_url = $9,
+    status = $10
+WHERE movie_id = $1 AND deleted_at IS NULL
+RETURNING movie_id, title, description, poster_url, created_at, runtime_minutes, certification, release_date, original_language, trailer_url, status, deleted_at, rating_average, rating_count, bookings_count
 `
 
 type UpdateMovieParams struct {
-	MovieID     int32  `json:"movie_id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	PosterUrl   string `json:"poster_url"`
-	GenreID     int32  `json:"genre_id"`
+	MovieID          int32       `json:"movie_id"`
+	Title            string      `json:"title"`
+	Description      string      `json:"description"`
+	PosterUrl        string      `json:"poster_url"`
+	RuntimeMinutes   int32       `json:"runtime_minutes"`
+	Certification    string      `json:"certification"`
+	ReleaseDate      pgtype.Date `json:"release_date"`
+	OriginalLanguage string      `json:"original_language"`
+	TrailerUrl       string      `json:"trailer_url"`
+	Status           string      `json:"status"`
 }
 
 func (q *Queries) UpdateMovie(ctx context.Context, arg UpdateMovieParams) (Movie, error) {
@@ -133,7 +686,12 @@ func (q *Queries) UpdateMovie(ctx context.Context, arg UpdateMovieParams) (Movie
 		arg.Title,
 		arg.Description,
 		arg.PosterUrl,
-		arg.GenreID,
+		arg.RuntimeMinutes,
+		arg.Certification,
+		arg.ReleaseDate,
+		arg.OriginalLanguage,
+		arg.TrailerUrl,
+		arg.Status,
 	)
 	var i Movie
 	err := row.Scan(
@@ -141,8 +699,17 @@ func (q *Queries) UpdateMovie(ctx context.Context, arg UpdateMovieParams) (Movie
 		&i.Title,
 		&i.Description,
 		&i.PosterUrl,
-		&i.GenreID,
 		&i.CreatedAt,
+		&i.RuntimeMinutes,
+		&i.Certification,
+		&i.ReleaseDate,
+		&i.OriginalLanguage,
+		&i.TrailerUrl,
+		&i.Status,
+		&i.DeletedAt,
+		&i.RatingAverage,
+		&i.RatingCount,
+		&i.BookingsCount,
 	)
 	return i, err
 }
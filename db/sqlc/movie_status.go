@@ -0,0 +1,16 @@
+package db
+
+// where a movie is in its run
+const (
+	MovieStatusDraft      = "draft"
+	MovieStatusComingSoon = "coming_soon"
+	MovieStatusNowShowing = "now_showing"
+	MovieStatusArchived   = "archived"
+)
+
+// drafts aren't published yet and archived movies are done, customers
+// only see the others. mirrors the status filter of the public movie
+// queries
+func IsPublicMovieStatus(status string) bool {
+	return status == MovieStatusComingSoon || status == MovieStatusNowShowing
+}
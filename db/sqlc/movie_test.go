@@ -2,28 +2,48 @@ package db
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/kratos69/movie-app/util"
 	"github.com/stretchr/testify/require"
 )
 
 func createRandomMovie(t *testing.T) Movie {
 	arg := CreateMovieParams{
-		Title:       util.RandomTitle(),
-		Description: util.RandomDescription(),
-		PosterUrl:   util.RandomPosterURL(),
-		GenreID:     util.RandomGenreID(),
+		Title:            util.RandomTitle(),
+		Description:      util.RandomDescription(),
+		PosterUrl:        util.RandomPosterURL(),
+		RuntimeMinutes:   int32(util.RandomInt(80, 180)),
+		Certification:    "PG-13",
+		ReleaseDate:      pgtype.Date{Time: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		OriginalLanguage: "en",
+		TrailerUrl:       "https://videos.kratos69.org/" + util.RandomString(10),
+		Status:           MovieStatusNowShowing,
 	}
-	movie, err := testStore.CreateMovie(context.Background(), arg)
+	genreID := util.RandomGenreID()
+	result, err := testStore.CreateMovieTx(context.Background(), CreateMovieTxParams{
+		CreateMovieParams: arg,
+		GenreIDs:          []int32{genreID},
+	})
 	require.NoError(t, err)
-	require.NotEmpty(t, movie)
+	require.NotEmpty(t, result.Movie)
+	require.Len(t, result.Genres, 1)
+	require.Equal(t, genreID, result.Genres[0].GenreID)
+
+	movie := result.Movie
 
 	require.Equal(t, arg.Title, movie.Title)
 	require.Equal(t, arg.Description, movie.Description)
 	require.Equal(t, arg.PosterUrl, movie.PosterUrl)
-	require.Equal(t, arg.GenreID, movie.GenreID)
+	require.Equal(t, arg.RuntimeMinutes, movie.RuntimeMinutes)
+	require.Equal(t, arg.Certification, movie.Certification)
+	require.Equal(t, arg.ReleaseDate, movie.ReleaseDate)
+	require.Equal(t, arg.OriginalLanguage, movie.OriginalLanguage)
+	require.Equal(t, arg.TrailerUrl, movie.TrailerUrl)
+	require.Equal(t, arg.Status, movie.Status)
 
 	require.NotZero(t, movie.MovieID)
 	require.NotZero(t, movie.CreatedAt)
@@ -44,43 +64,147 @@ func TestGetMovie(t *testing.T) {
 	require.Equal(t, movie1.Title, movie2.Title)
 	require.Equal(t, movie1.Description, movie2.Description)
 	require.Equal(t, movie1.PosterUrl, movie2.PosterUrl)
-	require.Equal(t, movie1.GenreID, movie2.GenreID)
 	require.WithinDuration(t, movie1.CreatedAt, movie2.CreatedAt, time.Second)
 }
 
 func TestDeleteMovie(t *testing.T) {
 	movie1 := createRandomMovie(t)
-	err := testStore.DeleteMovie(context.Background(), movie1.MovieID)
+	deleted, err := testStore.DeleteMovie(context.Background(), movie1.MovieID)
 	require.NoError(t, err)
+	require.True(t, deleted.DeletedAt.Valid)
+	require.Equal(t, MovieStatusArchived, deleted.Status)
 
 	movie2, err := testStore.GetMovie(context.Background(), movie1.MovieID)
-	require.Error(t, err)
+	require.ErrorIs(t, err, ErrRecordNotFound)
 	require.Empty(t, movie2)
+
+	_, err = testStore.DeleteMovie(context.Background(), movie1.MovieID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
 }
 
-func TestListMovies(t *testing.T) {
-	for i := 0; i < 10; i++ {
-		createRandomMovie(t)
+func TestDeleteMovieTx(t *testing.T) {
+	user := createRandomUser(t)
+	movie := createRandomMovie(t)
+
+	showtime, err := testStore.CreateShowtime(context.Background(),
+		CreateShowtimeParams{
+			MovieID:   movie.MovieID,
+			StartTime: pgtype.Timestamp{Time: time.Now().UTC().Add(2 * time.Hour), Valid: true},
+			Price:     util.RandomPrice(),
+		})
+	require.NoError(t, err)
+
+	seats := getRandomAvailableSeats(t, showtime.ShowtimeID, 1)
+	_, err = testStore.ReserveMultipleSeatsTx(context.Background(),
+		ReserveMultipleSeatsTxParams{
+			UserID:     user.UserID,
+			ShowtimeID: showtime.ShowtimeID,
+			SeatIDs:    []int32{seats[0].SeatID},
+		})
+	require.NoError(t, err)
+
+	_, err = testStore.DeleteMovieTx(context.Background(), movie.MovieID)
+	require.ErrorIs(t, err, ErrMovieHasBookings)
+
+	// the showtime keeps its booking, so it can't go either
+	err = testStore.DeleteShowtime(context.Background(), showtime.ShowtimeID)
+	require.Equal(t, ForeignKeyViolation, ErrorCode(err))
+
+	// past showtimes don't hold the movie back
+	past := createRandomMovie(t)
+	pastShowtime, err := testStore.CreateShowtime(context.Background(),
+		CreateShowtimeParams{
+			MovieID:   past.MovieID,
+			StartTime: pgtype.Timestamp{Time: time.Now().UTC().Add(-48 * time.Hour), Valid: true},
+			Price:     util.RandomPrice(),
+		})
+	require.NoError(t, err)
+
+	pastSeats := getRandomAvailableSeats(t, pastShowtime.ShowtimeID, 1)
+	_, err = testStore.ReserveMultipleSeatsTx(context.Background(),
+		ReserveMultipleSeatsTxParams{
+			UserID:     user.UserID,
+			ShowtimeID: pastShowtime.ShowtimeID,
+			SeatIDs:    []int32{pastSeats[0].SeatID},
+		})
+	require.NoError(t, err)
+
+	deleted, err := testStore.DeleteMovieTx(context.Background(), past.MovieID)
+	require.NoError(t, err)
+	require.True(t, deleted.DeletedAt.Valid)
+
+	// nothing more can be booked for it
+	available, err := testStore.ListAvailableSeatsForShowtime(
+		context.Background(), pastShowtime.ShowtimeID)
+	require.NoError(t, err)
+	require.Empty(t, available)
+
+	_, err = testStore.ReserveMultipleSeatsTx(context.Background(),
+		ReserveMultipleSeatsTxParams{
+			UserID:     user.UserID,
+			ShowtimeID: pastShowtime.ShowtimeID,
+			SeatIDs:    []int32{pastSeats[0].SeatID + 1},
+		})
+	require.ErrorContains(t, err, "is not available")
+
+	reservations, err := testStore.ListReservationsByUser(
+		context.Background(), user.UserID)
+	require.NoError(t, err)
+	require.Len(t, reservations, 2)
+}
+
+func TestListMoviesHidesUnpublished(t *testing.T) {
+	genre := createRandomGenre(t)
+
+	statuses := []string{
+		MovieStatusDraft,
+		MovieStatusComingSoon,
+		MovieStatusNowShowing,
+		MovieStatusArchived,
 	}
 
-	arg := ListMoviesParams{
-		Limit: 5,
-		Offset: 5,
+	movieIDs := map[string]int32{}
+	for _, status := range statuses {
+		result, err := testStore.CreateMovieTx(context.Background(),
+			CreateMovieTxParams{
+				CreateMovieParams: CreateMovieParams{
+					Title:       util.RandomTitle(),
+					Description: util.RandomDescription(),
+					Status:      status,
+				},
+				GenreIDs: []int32{genre.GenreID},
+			})
+		require.NoError(t, err)
+		movieIDs[status] = result.Movie.MovieID
 	}
-	movies, err := testStore.ListMovies(context.Background(), arg)
+
+	deleted, err := testStore.DeleteMovie(context.Background(),
+		movieIDs[MovieStatusNowShowing])
 	require.NoError(t, err)
-	require.NotEmpty(t, movies)
+	require.True(t, deleted.DeletedAt.Valid)
+
+	movies, err := testStore.ListMoviesByNewest(context.Background(),
+		ListMoviesByNewestParams{
+			GenreIds: []int32{genre.GenreID},
+			RowLimit: 10,
+		})
+	require.NoError(t, err)
+	require.Len(t, movies, 1)
+	require.Equal(t, movieIDs[MovieStatusComingSoon], movies[0].MovieID)
 }
 
 func TestUpdateMovie(t *testing.T) {
 	movie1 := createRandomMovie(t)
 
 	arg := UpdateMovieParams{
-		MovieID:     movie1.MovieID,
-		Title:       util.RandomTitle(),
-		Description: util.RandomDescription(),
-		PosterUrl:   util.RandomPosterURL(),
-		GenreID:     util.RandomGenreID(),
+		MovieID:          movie1.MovieID,
+		Title:            util.RandomTitle(),
+		Description:      util.RandomDescription(),
+		PosterUrl:        util.RandomPosterURL(),
+		RuntimeMinutes:   movie1.RuntimeMinutes + 10,
+		Certification:    "R",
+		OriginalLanguage: "fr",
+		Status:           MovieStatusArchived,
 	}
 
 	movie2, err := testStore.UpdateMovie(context.Background(), arg)
@@ -88,4 +212,271 @@ func TestUpdateMovie(t *testing.T) {
 	require.NotEmpty(t, movie2)
 
 	require.Equal(t, movie1.MovieID, movie2.MovieID)
+	require.Equal(t, arg.RuntimeMinutes, movie2.RuntimeMinutes)
+	require.Equal(t, arg.Certification, movie2.Certification)
+	require.False(t, movie2.ReleaseDate.Valid)
+	require.Empty(t, movie2.TrailerUrl)
+	require.Equal(t, MovieStatusArchived, movie2.Status)
+}
+
+func TestSearchMovies(t *testing.T) {
+	word := strings.ToLower(util.RandomString(12))
+
+	result, err := testStore.CreateMovieTx(context.Background(), CreateMovieTxParams{
+		CreateMovieParams: CreateMovieParams{
+			Title:       "The " + word + " Chronicles",
+			Description: "An old story.",
+			PosterUrl:   util.RandomPosterURL(),
+			Status:      MovieStatusNowShowing,
+		},
+		GenreIDs: []int32{util.RandomGenreID()},
+	})
+	require.NoError(t, err)
+	inTitle := result.Movie
+
+	result, err = testStore.CreateMovieTx(context.Background(), CreateMovieTxParams{
+		CreateMovieParams: CreateMovieParams{
+			Title:       util.RandomTitle(),
+			Description: "A story about the " + word + " people.",
+			PosterUrl:   util.RandomPosterURL(),
+			Status:      MovieStatusNowShowing,
+		},
+		GenreIDs: []int32{util.RandomGenreID()},
+	})
+	require.NoError(t, err)
+	inDescription := result.Movie
+
+	// a prefix of the word finds both, the title match ranks first
+	rows, err := testStore.SearchMovies(context.Background(), SearchMoviesParams{
+		Query:    word[:8] + ":*",
+		RowLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, inTitle.MovieID, rows[0].MovieID)
+	require.Equal(t, inDescription.MovieID, rows[1].MovieID)
+	require.Greater(t, rows[0].Rank, rows[1].Rank)
+	require.Contains(t, rows[0].HighlightedTitle, "<mark>")
+	require.Contains(t, rows[1].Snippet, "<mark>"+word+"</mark>")
+
+	// one letter off still finds the title
+	typo := []byte(word)
+	typo[3] = 'z'
+	if word[3] == 'z' {
+		typo[3] = 'y'
+	}
+	similar, err := testStore.SearchMoviesByTitleSimilarity(context.Background(),
+		SearchMoviesByTitleSimilarityParams{
+			Query:    "the " + string(typo) + " chronicles",
+			RowLimit: 10,
+		})
+	require.NoError(t, err)
+	require.NotEmpty(t, similar)
+	require.Equal(t, inTitle.MovieID, similar[0].MovieID)
+}
+
+// creates a movie in genre with a showtime at showtimeAt (none when
+// zero) booked by bookings users
+func createCatalogMovie(t *testing.T, genre Genre, title string,
+	arg CreateMovieParams, showtimeAt time.Time, bookings int) Movie {
+	arg.Title = title + " " + util.RandomString(6)
+	arg.Description = util.RandomDescription()
+	arg.PosterUrl = util.RandomPosterURL()
+	arg.Status = MovieStatusNowShowing
+
+	result, err := testStore.CreateMovieTx(context.Background(), CreateMovieTxParams{
+		CreateMovieParams: arg,
+		GenreIDs:          []int32{genre.GenreID},
+	})
+	require.NoError(t, err)
+
+	if showtimeAt.IsZero() {
+		return result.Movie
+	}
+
+	showtime, err := testStore.CreateShowtime(context.Background(), CreateShowtimeParams{
+		MovieID:   result.Movie.MovieID,
+		StartTime: pgtype.Timestamp{Time: showtimeAt, Valid: true},
+		Price:     util.RandomPrice(),
+	})
+	require.NoError(t, err)
+
+	for _, seat := range getRandomAvailableSeats(t, showtime.ShowtimeID, bookings) {
+		user := createRandomUser(t)
+		_, err = testStore.ReserveSeat(context.Background(), ReserveSeatParams{
+			UserID:     pgtype.Int8{Int64: user.UserID, Valid: true},
+			ShowtimeID: showtime.ShowtimeID,
+			SeatID:     seat.SeatID,
+		})
+		require.NoError(t, err)
+	}
+
+	return result.Movie
+}
+
+func TestListMoviesFiltered(t *testing.T) {
+	genre := createRandomGenre(t)
+	now := time.Now().UTC()
+
+	date := func(year int, month time.Month, day int) pgtype.Date {
+		return pgtype.Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC), Valid: true}
+	}
+
+	movieA := createCatalogMovie(t, genre, "A", CreateMovieParams{
+		Certification:    "PG",
+		OriginalLanguage: "en",
+		ReleaseDate:      date(2020, 1, 1),
+	}, now.Add(48*time.Hour), 2)
+	movieB := createCatalogMovie(t, genre, "B", CreateMovieParams{
+		Certification:    "R",
+		OriginalLanguage: "fr",
+		ReleaseDate:      pgtype.Date{Time: now.AddDate(0, 1, 0), Valid: true},
+	}, time.Time{}, 0)
+	movieC := createCatalogMovie(t, genre, "C", CreateMovieParams{
+		Certification:    "PG",
+		OriginalLanguage: "en",
+		ReleaseDate:      date(2022, 6, 1),
+	}, now.Add(-48*time.Hour), 1)
+
+	base := ListMoviesByNewestParams{
+		GenreIds: []int32{genre.GenreID},
+		RowLimit: 10,
+	}
+
+	ids := func(movies []Movie, err error) []int32 {
+		require.NoError(t, err)
+		movieIDs := []int32{}
+		for _, movie := range movies {
+			movieIDs = append(movieIDs, movie.MovieID)
+		}
+		return movieIDs
+	}
+
+	testCases := []struct {
+		name     string
+		update   func(arg *ListMoviesByNewestParams)
+		expected []int32
+	}{
+		{
+			name:     "Genre",
+			update:   func(arg *ListMoviesByNewestParams) {},
+			expected: []int32{movieC.MovieID, movieB.MovieID, movieA.MovieID},
+		},
+		{
+			name: "Certification",
+			update: func(arg *ListMoviesByNewestParams) {
+				arg.Certification = pgtype.Text{String: "PG", Valid: true}
+			},
+			expected: []int32{movieC.MovieID, movieA.MovieID},
+		},
+		{
+			name: "Language",
+			update: func(arg *ListMoviesByNewestParams) {
+				arg.OriginalLanguage = pgtype.Text{String: "fr", Valid: true}
+			},
+			expected: []int32{movieB.MovieID},
+		},
+		{
+			name: "ComingSoon",
+			update: func(arg *ListMoviesByNewestParams) {
+				arg.ComingSoon = true
+			},
+			expected: []int32{movieB.MovieID},
+		},
+		{
+			name: "ReleaseRange",
+			update: func(arg *ListMoviesByNewestParams) {
+				arg.ReleasedFrom = date(2021, 1, 1)
+				arg.ReleasedTo = date(2022, 6, 1)
+			},
+			expected: []int32{movieC.MovieID},
+		},
+		{
+			name: "NowShowing",
+			update: func(arg *ListMoviesByNewestParams) {
+				arg.ShowingFrom = pgtype.Timestamp{Time: now, Valid: true}
+			},
+			expected: []int32{movieA.MovieID},
+		},
+		{
+			name: "ShowingRange",
+			update: func(arg *ListMoviesByNewestParams) {
+				arg.ShowingFrom = pgtype.Timestamp{Time: now.Add(-72 * time.Hour), Valid: true}
+				arg.ShowingTo = pgtype.Timestamp{Time: now, Valid: true}
+			},
+			expected: []int32{movieC.MovieID},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			arg := base
+			tc.update(&arg)
+
+			got := ids(testStore.ListMoviesByNewest(context.Background(), arg))
+			require.Equal(t, tc.expected, got)
+		})
+	}
+
+	genreIDs := []int32{genre.GenreID}
+
+	byTitle := ids(testStore.ListMoviesByTitle(context.Background(),
+		ListMoviesByTitleParams{GenreIds: genreIDs, RowLimit: 10}))
+	require.Equal(t, []int32{movieA.MovieID, movieB.MovieID, movieC.MovieID}, byTitle)
+
+	byRelease := ids(testStore.ListMoviesByReleaseDate(context.Background(),
+		ListMoviesByReleaseDateParams{GenreIds: genreIDs, RowLimit: 10}))
+	require.Equal(t, []int32{movieB.MovieID, movieC.MovieID, movieA.MovieID}, byRelease)
+
+	// popularity sorts on the counts of the last recount
+	_, err := testStore.RefreshMovieBookingCounts(context.Background())
+	require.NoError(t, err)
+
+	rows, err := testStore.ListMoviesByPopularity(context.Background(),
+		ListMoviesByPopularityParams{GenreIds: genreIDs, RowLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	require.Equal(t, movieA.MovieID, rows[0].MovieID)
+	require.Equal(t, int64(2), rows[0].BookingsCount)
+	require.Equal(t, movieC.MovieID, rows[1].MovieID)
+	require.Equal(t, movieB.MovieID, rows[2].MovieID)
+
+	// each page continues after the last movie of the previous one
+	second := ids(testStore.ListMoviesByTitle(context.Background(),
+		ListMoviesByTitleParams{
+			GenreIds:   genreIDs,
+			AfterID:    pgtype.Int4{Int32: movieA.MovieID, Valid: true},
+			AfterTitle: pgtype.Text{String: movieA.Title, Valid: true},
+			RowLimit:   1,
+		}))
+	require.Equal(t, []int32{movieB.MovieID}, second)
+
+	newest := ids(testStore.ListMoviesByNewest(context.Background(),
+		ListMoviesByNewestParams{
+			GenreIds:       genreIDs,
+			AfterID:        pgtype.Int4{Int32: movieC.MovieID, Valid: true},
+			AfterCreatedAt: pgtype.Timestamptz{Time: movieC.CreatedAt, Valid: true},
+			RowLimit:       10,
+		}))
+	require.Equal(t, []int32{movieB.MovieID, movieA.MovieID}, newest)
+
+	released := ids(testStore.ListMoviesByReleaseDate(context.Background(),
+		ListMoviesByReleaseDateParams{
+			GenreIds:         genreIDs,
+			AfterID:          pgtype.Int4{Int32: movieC.MovieID, Valid: true},
+			AfterReleaseDate: movieC.ReleaseDate,
+			RowLimit:         10,
+		}))
+	require.Equal(t, []int32{movieA.MovieID}, released)
+
+	popular, err := testStore.ListMoviesByPopularity(context.Background(),
+		ListMoviesByPopularityParams{
+			GenreIds:      genreIDs,
+			AfterID:       pgtype.Int4{Int32: movieA.MovieID, Valid: true},
+			AfterBookings: pgtype.Int8{Int64: rows[0].BookingsCount, Valid: true},
+			RowLimit:      10,
+		})
+	require.NoError(t, err)
+	require.Len(t, popular, 2)
+	require.Equal(t, movieC.MovieID, popular[0].MovieID)
 }
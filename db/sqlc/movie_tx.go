@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+)
+
+type CreateMovieTxParams struct {
+	CreateMovieParams
+	GenreIDs []int32 `json:"genre_ids"`
+}
+
+type MovieTxResult struct {
+	Movie  Movie   `json:"movie"`
+	Genres []Genre `json:"genres"`
+	// watchers alerted, only when an update made the movie public
+	Notified int64 `json:"notified"`
+}
+
+// Creates a movie together with its genres
+func (store *SQLStore) CreateMovieTx(ctx context.Context,
+	arg CreateMovieTxParams) (MovieTxResult, error) {
+	var result MovieTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Movie, err = q.CreateMovie(ctx, arg.CreateMovieParams)
+		if err != nil {
+			return err
+		}
+
+		err = q.AddMovieGenres(ctx, AddMovieGenresParams{
+			MovieID:  result.Movie.MovieID,
+			GenreIds: arg.GenreIDs,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Genres, err = q.ListMovieGenres(ctx, result.Movie.MovieID)
+		return err
+	})
+
+	return result, err
+}
+
+type UpdateMovieTxParams struct {
+	UpdateMovieParams
+	// nil keeps the current genres, otherwise they are replaced
+	GenreIDs []int32 `json:"genre_ids"`
+}
+
+// Updates a movie and replaces its genres when new ones are given. A
+// movie made public with showtimes already scheduled counts as going on
+// sale, so its watchers are queued an alert
+func (store *SQLStore) UpdateMovieTx(ctx context.Context,
+	arg UpdateMovieTxParams) (MovieTxResult, error) {
+	var result MovieTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetMovieForUpdate(ctx, arg.MovieID)
+		if err != nil {
+			return err
+		}
+
+		result.Movie, err = q.UpdateMovie(ctx, arg.UpdateMovieParams)
+		if err != nil {
+			return err
+		}
+
+		if !IsPublicMovieStatus(before.Status) &&
+			IsPublicMovieStatus(result.Movie.Status) {
+			upcoming, err := q.CountUpcomingShowtimesForMovie(ctx,
+				arg.MovieID)
+			if err != nil {
+				return err
+			}
+
+			if upcoming > 0 {
+				result.Notified, err = q.EnqueueWatchlistNotifications(ctx,
+					arg.MovieID)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if arg.GenreIDs != nil {
+			err = q.DeleteMovieGenres(ctx, arg.MovieID)
+			if err != nil {
+				return err
+			}
+
+			err = q.AddMovieGenres(ctx, AddMovieGenresParams{
+				MovieID:  arg.MovieID,
+				GenreIds: arg.GenreIDs,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Genres, err = q.ListMovieGenres(ctx, arg.MovieID)
+		return err
+	})
+
+	return result, err
+}
+
+// Soft deletes a movie, refused with ErrMovieHasBookings while any of its
+// upcoming showtimes has reservations. The movie row stays locked until
+// commit so it can't be edited halfway
+func (store *SQLStore) DeleteMovieTx(ctx context.Context,
+	movieID int32) (Movie, error) {
+	var movie Movie
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		_, err := q.GetMovieForUpdate(ctx, movieID)
+		if err != nil {
+			return err
+		}
+
+		bookings, err := q.CountUpcomingReservationsForMovie(ctx, movieID)
+		if err != nil {
+			return err
+		}
+		if bookings > 0 {
+			return ErrMovieHasBookings
+		}
+
+		movie, err = q.DeleteMovie(ctx, movieID)
+		return err
+	})
+
+	return movie, err
+}
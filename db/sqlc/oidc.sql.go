@@ -0,0 +1,160 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: oidc.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createOIDCState = `-- name: CreateOIDCState :one
+INSERT INTO oidc_states (state, code_verifier, nonce, expired_at)
+VALUES ($1, $2, $3, $4)
+RETURNING state, code_verifier, nonce, expired_at, created_at
+`
+
+type CreateOIDCStateParams struct {
+	State        string    `json:"state"`
+	CodeVerifier string    `json:"code_verifier"`
+	Nonce        string    `json:"nonce"`
+	ExpiredAt    time.Time `json:"expired_at"`
+}
+
+func (q *Queries) CreateOIDCState(ctx context.Context, arg CreateOIDCStateParams) (OidcState, error) {
+	row := q.db.QueryRow(ctx, createOIDCState,
+		arg.State,
+		arg.CodeVerifier,
+		arg.Nonce,
+		arg.ExpiredAt,
+	)
+	var i OidcState
+	err := row.Scan(
+		&i.State,
+		&i.CodeVerifier,
+		&i.Nonce,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createUserIdentity = `-- name: CreateUserIdentity :one
+INSERT INTO user_identities (user_id, issuer, subject, email)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, issuer, subject, email, created_at
+`
+
+type CreateUserIdentityParams struct {
+	UserID  int64  `json:"user_id"`
+	Issuer  string `json:"issuer"`
+	Subject string `json:"subject"`
+	Email   string `json:"email"`
+}
+
+func (q *Queries) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error) {
+	row := q.db.QueryRow(ctx, createUserIdentity,
+		arg.UserID,
+		arg.Issuer,
+		arg.Subject,
+		arg.Email,
+	)
+	var i UserIdentity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Issuer,
+		&i.Subject,
+		&i.Email,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteOIDCState = `-- name: DeleteOIDCState :one
+DELETE FROM oidc_states
+WHERE state = $1
+RETURNING state, code_verifier, nonce, expired_at, created_at
+`
+
+func (q *Queries) DeleteOIDCState(ctx context.Context, state string) (OidcState, error) {
+	row := q.db.QueryRow(ctx, deleteOIDCState, state)
+	var i OidcState
+	err := row.Scan(
+		&i.State,
+		&i.CodeVerifier,
+		&i.Nonce,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteUserIdentities = `-- name: DeleteUserIdentities :exec
+DELETE FROM user_identities
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserIdentities(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteUserIdentities, userID)
+	return err
+}
+
+const getUserIdentity = `-- name: GetUserIdentity :one
+SELECT id, user_id, issuer, subject, email, created_at FROM user_identities
+WHERE issuer = $1 AND subject = $2
+`
+
+type GetUserIdentityParams struct {
+	Issuer  string `json:"issuer"`
+	Subject string `json:"subject"`
+}
+
+func (q *Queries) GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error) {
+	row := q.db.QueryRow(ctx, getUserIdentity, arg.Issuer, arg.Subject)
+	var i UserIdentity
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Issuer,
+		&i.Subject,
+		&i.Email,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listUserIdentities = `-- name: ListUserIdentities :many
+SELECT id, user_id, issuer, subject, email, created_at FROM user_identities
+WHERE user_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListUserIdentities(ctx context.Context, userID int64) ([]UserIdentity, error) {
+	rows, err := q.db.Query(ctx, listUserIdentities, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UserIdentity{}
+	for rows.Next() {
+		var i UserIdentity
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Issuer,
+			&i.Subject,
+			&i.Email,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteOIDCState(t *testing.T) {
+	arg := CreateOIDCStateParams{
+		State:        util.RandomString(32),
+		CodeVerifier: util.RandomString(43),
+		Nonce:        util.RandomString(32),
+		ExpiredAt:    time.Now().Add(time.Minute),
+	}
+
+	state1, err := testStore.CreateOIDCState(context.Background(), arg)
+	require.NoError(t, err)
+
+	state2, err := testStore.DeleteOIDCState(context.Background(), arg.State)
+	require.NoError(t, err)
+	require.Equal(t, state1.CodeVerifier, state2.CodeVerifier)
+	require.Equal(t, state1.Nonce, state2.Nonce)
+
+	_, err = testStore.DeleteOIDCState(context.Background(), arg.State)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestCreateUserWithIdentityTx(t *testing.T) {
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := CreateUserWithIdentityTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       util.RandomOwner(),
+			Name:           util.RandomOwner(),
+			HashedPassword: hashedPassword,
+			Email:          util.RandomEmail(),
+		},
+		Issuer:  "https://accounts.example.com",
+		Subject: util.RandomString(12),
+	}
+
+	user, err := testStore.CreateUserWithIdentityTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Email, user.Email)
+	require.True(t, user.IsEmailVerified)
+
+	identity, err := testStore.GetUserIdentity(context.Background(),
+		GetUserIdentityParams{Issuer: arg.Issuer, Subject: arg.Subject})
+	require.NoError(t, err)
+	require.Equal(t, user.UserID, identity.UserID)
+	require.Equal(t, user.Email, identity.Email)
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+)
+
+type CreateUserWithIdentityTxParams struct {
+	CreateUserParams
+	Issuer  string `json:"issuer"`
+	Subject string `json:"subject"`
+}
+
+// Creates a user signing in through an identity provider for the
+// first time, together with the link to their external identity, and
+// hands them the guest bookings made with their email
+func (store *SQLStore) CreateUserWithIdentityTx(ctx context.Context,
+	arg CreateUserWithIdentityTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateUserIdentity(ctx, CreateUserIdentityParams{
+			UserID:  user.UserID,
+			Issuer:  arg.Issuer,
+			Subject: arg.Subject,
+			Email:   user.Email,
+		})
+		if err != nil {
+			return err
+		}
+
+		// the provider already verified the email
+		user, err = q.SetUserEmailVerified(ctx, SetUserEmailVerifiedParams{
+			UserID: user.UserID,
+			Email:  user.Email,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = claimGuestBookingsForUser(ctx, q, ClaimGuestBookingsParams{
+			UserID: user.UserID,
+			Email:  user.Email,
+		})
+		return err
+	})
+
+	return user, err
+}
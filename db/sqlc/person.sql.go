@@ -0,0 +1,384 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: person.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countCreditsForPerson = `-- name: CountCreditsForPerson :one
+SELECT count(*) FROM movie_credits
+WHERE person_id = $1
+`
+
+func (q *Queries) CountCreditsForPerson(ctx context.Context, personID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countCreditsForPerson, personID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createMovieCredit = `-- name: CreateMovieCredit :one
+INSERT INTO movie_credits (
+  movie_id,
+  person_id,
+  role,
+  character_name,
+  billing_order
+) VALUES ($1, $2, $3, $4, $5)
+RETURNING credit_id, movie_id, person_id, role, character_name, billing_order, created_at
+`
+
+type CreateMovieCreditParams struct {
+	MovieID       int32  `json:"movie_id"`
+	PersonID      int32  `json:"person_id"`
+	Role          string `json:"role"`
+	CharacterName string `json:"character_name"`
+	BillingOrder  int32  `json:"billing_order"`
+}
+
+func (q *Queries) CreateMovieCredit(ctx context.Context, arg CreateMovieCreditParams) (MovieCredit, error) {
+	row := q.db.QueryRow(ctx, createMovieCredit,
+		arg.MovieID,
+		arg.PersonID,
+		arg.Role,
+		arg.CharacterName,
+		arg.BillingOrder,
+	)
+	var i MovieCredit
+	err := row.Scan(
+		&i.CreditID,
+		&i.MovieID,
+		&i.PersonID,
+		&i.Role,
+		&i.CharacterName,
+		&i.BillingOrder,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPerson = `-- name: CreatePerson :one
+INSERT INTO people (name, biography, photo_url)
+VALUES ($1, $2, $3)
+RETURNING person_id, name, biography, photo_url, created_at
+`
+
+type CreatePersonParams struct {
+	Name      string `json:"name"`
+	Biography string `json:"biography"`
+	PhotoUrl  string `json:"photo_url"`
+}
+
+func (q *Queries) CreatePerson(ctx context.Context, arg CreatePersonParams) (Person, error) {
+	row := q.db.QueryRow(ctx, createPerson, arg.Name, arg.Biography, arg.PhotoUrl)
+	var i Person
+	err := row.Scan(
+		&i.PersonID,
+		&i.Name,
+		&i.Biography,
+		&i.PhotoUrl,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteMovieCredit = `-- name: DeleteMovieCredit :exec
+DELETE FROM movie_credits
+WHERE credit_id = $1 AND movie_id = $2
+`
+
+type DeleteMovieCreditParams struct {
+	CreditID int32 `json:"credit_id"`
+	MovieID  int32 `json:"movie_id"`
+}
+
+func (q *Queries) DeleteMovieCredit(ctx context.Context, arg DeleteMovieCreditParams) error {
+	_, err := q.db.Exec(ctx, deleteMovieCredit, arg.CreditID, arg.MovieID)
+	return err
+}
+
+const deletePerson = `-- name: DeletePerson :exec
+DELETE FROM people
+WHERE person_id = $1
+`
+
+func (q *Queries) DeletePerson(ctx context.Context, personID int32) error {
+	_, err := q.db.Exec(ctx, deletePerson, personID)
+	return err
+}
+
+const getMovieCredit = `-- name: GetMovieCredit :one
+SELECT credit_id, movie_id, person_id, role, character_name, billing_order, created_at FROM movie_credits
+WHERE credit_id = $1 AND movie_id = $2
+`
+
+type GetMovieCreditParams struct {
+	CreditID int32 `json:"credit_id"`
+	MovieID  int32 `json:"movie_id"`
+}
+
+func (q *Queries) GetMovieCredit(ctx context.Context, arg GetMovieCreditParams) (MovieCredit, error) {
+	row := q.db.QueryRow(ctx, getMovieCredit, arg.CreditID, arg.MovieID)
+	var i MovieCredit
+	err := row.Scan(
+		&i.CreditID,
+		&i.MovieID,
+		&i.PersonID,
+		&i.Role,
+		&i.CharacterName,
+		&i.BillingOrder,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPerson = `-- name: GetPerson :one
+SELECT person_id, name, biography, photo_url, created_at FROM people
+WHERE person_id = $1
+`
+
+func (q *Queries) GetPerson(ctx context.Context, personID int32) (Person, error) {
+	row := q.db.QueryRow(ctx, getPerson, personID)
+	var i Person
+	err := row.Scan(
+		&i.PersonID,
+		&i.Name,
+		&i.Biography,
+		&i.PhotoUrl,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listMovieCredits = `-- name: ListMovieCredits :many
+SELECT c.credit_id, c.person_id, p.name, p.photo_url,
+  c.role, c.character_name, c.billing_order
+FROM movie_credits c
+JOIN people p ON p.person_id = c.person_id
+WHERE c.movie_id = $1
+ORDER BY array_position(ARRAY['director', 'writer', 'actor']::varchar[], c.role),
+  c.billing_order, c.credit_id
+`
+
+type ListMovieCreditsRow struct {
+	CreditID      int32  `json:"credit_id"`
+	PersonID      int32  `json:"person_id"`
+	Name          string `json:"name"`
+	PhotoUrl      string `json:"photo_url"`
+	Role          string `json:"role"`
+	CharacterName string `json:"character_name"`
+	BillingOrder  int32  `json:"billing_order"`
+}
+
+// directors first, then writers, then the cast by billing order
+func (q *Queries) ListMovieCredits(ctx context.Context, movieID int32) ([]ListMovieCreditsRow, error) {
+	rows, err := q.db.Query(ctx, listMovieCredits, movieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMovieCreditsRow{}
+	for rows.Next() {
+		var i ListMovieCreditsRow
+		if err := rows.Scan(
+			&i.CreditID,
+			&i.PersonID,
+			&i.Name,
+			&i.PhotoUrl,
+			&i.Role,
+			&i.CharacterName,
+			&i.BillingOrder,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPeople = `-- name: ListPeople :many
+SELECT person_id, name, biography, photo_url, created_at FROM people
+WHERE ($1::text IS NULL
+    OR name ILIKE '%' || $1 || '%')
+  AND ($2::int IS NULL
+    OR (name, person_id) >
+      ($3::text, $2::int))
+ORDER BY name, person_id
+LIMIT $4
+`
+
+type ListPeopleParams struct {
+	Search    pgtype.Text `json:"search"`
+	AfterID   pgtype.Int4 `json:"after_id"`
+	AfterName pgtype.Text `json:"after_name"`
+	RowLimit  int32       `json:"row_limit"`
+}
+
+// pages continue after the name and person_id of the last person. search
+// comes with its LIKE wildcards escaped
+func (q *Queries) ListPeople(ctx context.Context, arg ListPeopleParams) ([]Person, error) {
+	rows, err := q.db.Query(ctx, listPeople,
+		arg.Search,
+		arg.AfterID,
+		arg.AfterName,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Person{}
+	for rows.Next() {
+		var i Person
+		if err := rows.Scan(
+			&i.PersonID,
+			&i.Name,
+			&i.Biography,
+			&i.PhotoUrl,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPersonCredits = `-- name: ListPersonCredits :many
+SELECT c.credit_id, c.role, c.character_name, c.billing_order,
+  m.movie_id, m.title, m.poster_url, m.release_date, m.status
+FROM movie_credits c
+JOIN movies m ON m.movie_id = c.movie_id
+WHERE c.person_id = $1
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+ORDER BY coalesce(m.release_date, '-infinity'::date) DESC, m.movie_id,
+  c.billing_order, c.credit_id
+`
+
+type ListPersonCreditsRow struct {
+	CreditID      int32       `json:"credit_id"`
+	Role          string      `json:"role"`
+	CharacterName string      `json:"character_name"`
+	BillingOrder  int32       `json:"billing_order"`
+	MovieID       int32       `json:"movie_id"`
+	Title         string      `json:"title"`
+	PosterUrl     string      `json:"poster_url"`
+	ReleaseDate   pgtype.Date `json:"release_date"`
+	Status        string      `json:"status"`
+}
+
+// the public movies a person is credited in, newest release first
+func (q *Queries) ListPersonCredits(ctx context.Context, personID int32) ([]ListPersonCreditsRow, error) {
+	rows, err := q.db.Query(ctx, listPersonCredits, personID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPersonCreditsRow{}
+	for rows.Next() {
+		var i ListPersonCreditsRow
+		if err := rows.Scan(
+			&i.CreditID,
+			&i.Role,
+			&i.CharacterName,
+			&i.BillingOrder,
+			&i.MovieID,
+			&i.Title,
+			&i.PosterUrl,
+			&i.ReleaseDate,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateMovieCredit = `-- name: UpdateMovieCredit :one
+UPDATE movie_credits
+SET role = $3,
+    character_name = $4,
+    billing_order = $5
+WHERE credit_id = $1 AND movie_id = $2
+RETURNING credit_id, movie_id, person_id, role, character_name, billing_order, created_at
+`
+
+type UpdateMovieCreditParams struct {
+	CreditID      int32  `json:"credit_id"`
+	MovieID       int32  `json:"movie_id"`
+	Role          string `json:"role"`
+	CharacterName string `json:"character_name"`
+	BillingOrder  int32  `json:"billing_order"`
+}
+
+func (q *Queries) UpdateMovieCredit(ctx context.Context, arg UpdateMovieCreditParams) (MovieCredit, error) {
+	row := q.db.QueryRow(ctx, updateMovieCredit,
+		arg.CreditID,
+		arg.MovieID,
+		arg.Role,
+		arg.CharacterName,
+		arg.BillingOrder,
+	)
+	var i MovieCredit
+	err := row.Scan(
+		&i.CreditID,
+		&i.MovieID,
+		&i.PersonID,
+		&i.Role,
+		&i.CharacterName,
+		&i.BillingOrder,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const updatePerson = `-- name: UpdatePerson :one
+UPDATE people
+SET name = $2,
+    biography = $3,
+    photo_url = $4
+WHERE person_id = $1
+RETURNING person_id, name, biography, photo_url, created_at
+`
+
+type UpdatePersonParams struct {
+	PersonID  int32  `json:"person_id"`
+	Name      string `json:"name"`
+	Biography string `json:"biography"`
+	PhotoUrl  string `json:"photo_url"`
+}
+
+func (q *Queries) UpdatePerson(ctx context.Context, arg UpdatePersonParams) (Person, error) {
+	row := q.db.QueryRow(ctx, updatePerson,
+		arg.PersonID,
+		arg.Name,
+		arg.Biography,
+		arg.PhotoUrl,
+	)
+	var i Person
+	err := row.Scan(
+		&i.PersonID,
+		&i.Name,
+		&i.Biography,
+		&i.PhotoUrl,
+		&i.CreatedAt,
+	)
+	return i, err
+}
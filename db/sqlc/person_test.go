@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPerson(t *testing.T) Person {
+	arg := CreatePersonParams{
+		Name:      util.RandomOwner(),
+		Biography: util.RandomDescription(),
+		PhotoUrl:  util.RandomPosterURL(),
+	}
+
+	person, err := testStore.CreatePerson(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Name, person.Name)
+	require.Equal(t, arg.Biography, person.Biography)
+	require.Equal(t, arg.PhotoUrl, person.PhotoUrl)
+	require.NotZero(t, person.PersonID)
+
+	return person
+}
+
+func createRandomCredit(t *testing.T, movie Movie, person Person,
+	role string, billingOrder int32) MovieCredit {
+	arg := CreateMovieCreditParams{
+		MovieID:      movie.MovieID,
+		PersonID:     person.PersonID,
+		Role:         role,
+		BillingOrder: billingOrder,
+	}
+	if role == util.CreditRoleActor {
+		arg.CharacterName = util.RandomOwner()
+	}
+
+	credit, err := testStore.CreateMovieCredit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.MovieID, credit.MovieID)
+	require.Equal(t, arg.PersonID, credit.PersonID)
+	require.Equal(t, arg.Role, credit.Role)
+	require.Equal(t, arg.CharacterName, credit.CharacterName)
+
+	return credit
+}
+
+func TestUpdatePerson(t *testing.T) {
+	person := createRandomPerson(t)
+
+	updated, err := testStore.UpdatePerson(context.Background(),
+		UpdatePersonParams{
+			PersonID: person.PersonID,
+			Name:     util.RandomOwner(),
+		})
+	require.NoError(t, err)
+	require.Equal(t, person.PersonID, updated.PersonID)
+	require.NotEqual(t, person.Name, updated.Name)
+	require.Empty(t, updated.Biography)
+}
+
+func TestDeletePersonInUse(t *testing.T) {
+	person := createRandomPerson(t)
+	movie := createRandomMovie(t)
+	credit := createRandomCredit(t, movie, person, util.CreditRoleWriter, 0)
+
+	err := testStore.DeletePerson(context.Background(), person.PersonID)
+	require.Equal(t, ForeignKeyViolation, ErrorCode(err))
+
+	err = testStore.DeleteMovieCredit(context.Background(),
+		DeleteMovieCreditParams{CreditID: credit.CreditID, MovieID: movie.MovieID})
+	require.NoError(t, err)
+
+	err = testStore.DeletePerson(context.Background(), person.PersonID)
+	require.NoError(t, err)
+
+	_, err = testStore.GetPerson(context.Background(), person.PersonID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestMovieCreditUnique(t *testing.T) {
+	person := createRandomPerson(t)
+	movie := createRandomMovie(t)
+	createRandomCredit(t, movie, person, util.CreditRoleDirector, 0)
+
+	_, err := testStore.CreateMovieCredit(context.Background(),
+		CreateMovieCreditParams{
+			MovieID:  movie.MovieID,
+			PersonID: person.PersonID,
+			Role:     util.CreditRoleDirector,
+		})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	// directing and writing the same movie are two credits
+	createRandomCredit(t, movie, person, util.CreditRoleWriter, 0)
+}
+
+func TestListMovieCredits(t *testing.T) {
+	movie := createRandomMovie(t)
+	lead := createRandomCredit(t, movie, createRandomPerson(t), util.CreditRoleActor, 1)
+	support := createRandomCredit(t, movie, createRandomPerson(t), util.CreditRoleActor, 2)
+	director := createRandomCredit(t, movie, createRandomPerson(t), util.CreditRoleDirector, 0)
+	writer := createRandomCredit(t, movie, createRandomPerson(t), util.CreditRoleWriter, 0)
+
+	credits, err := testStore.ListMovieCredits(context.Background(), movie.MovieID)
+	require.NoError(t, err)
+	require.Len(t, credits, 4)
+
+	creditIDs := []int32{}
+	for _, credit := range credits {
+		creditIDs = append(creditIDs, credit.CreditID)
+		require.NotEmpty(t, credit.Name)
+	}
+	require.Equal(t, []int32{director.CreditID, writer.CreditID,
+		lead.CreditID, support.CreditID}, creditIDs)
+
+	updated, err := testStore.UpdateMovieCredit(context.Background(),
+		UpdateMovieCreditParams{
+			CreditID:      support.CreditID,
+			MovieID:       movie.MovieID,
+			Role:          util.CreditRoleActor,
+			CharacterName: support.CharacterName,
+			BillingOrder:  0,
+		})
+	require.NoError(t, err)
+	require.Equal(t, int32(0), updated.BillingOrder)
+
+	// a credit is only found through its own movie
+	_, err = testStore.GetMovieCredit(context.Background(), GetMovieCreditParams{
+		CreditID: lead.CreditID,
+		MovieID:  createRandomMovie(t).MovieID,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestListPersonCredits(t *testing.T) {
+	person := createRandomPerson(t)
+	movie := createRandomMovie(t)
+	createRandomCredit(t, movie, person, util.CreditRoleActor, 1)
+
+	// drafts, archived and deleted movies don't show up
+	for _, status := range []string{MovieStatusDraft, MovieStatusArchived} {
+		result, err := testStore.CreateMovieTx(context.Background(),
+			CreateMovieTxParams{
+				CreateMovieParams: CreateMovieParams{
+					Title:       util.RandomTitle(),
+					Description: util.RandomDescription(),
+					Status:      status,
+				},
+			})
+		require.NoError(t, err)
+		createRandomCredit(t, result.Movie, person, util.CreditRoleActor, 1)
+	}
+
+	deleted := createRandomMovie(t)
+	createRandomCredit(t, deleted, person, util.CreditRoleActor, 1)
+	_, err := testStore.DeleteMovie(context.Background(), deleted.MovieID)
+	require.NoError(t, err)
+
+	credits, err := testStore.ListPersonCredits(context.Background(), person.PersonID)
+	require.NoError(t, err)
+	require.Len(t, credits, 1)
+	require.Equal(t, movie.MovieID, credits[0].MovieID)
+	require.Equal(t, movie.Title, credits[0].Title)
+
+	now := time.Now().UTC()
+	for _, start := range []time.Time{now.Add(-time.Hour), now.Add(time.Hour)} {
+		_, err := testStore.CreateShowtime(context.Background(), CreateShowtimeParams{
+			MovieID:   movie.MovieID,
+			StartTime: pgtype.Timestamp{Time: start, Valid: true},
+			Price:     util.RandomPrice(),
+		})
+		require.NoError(t, err)
+	}
+
+	showtimes, err := testStore.ListUpcomingShowtimesForMovies(
+		context.Background(), []int32{movie.MovieID})
+	require.NoError(t, err)
+	require.Len(t, showtimes, 1)
+	require.True(t, showtimes[0].StartTime.Time.After(now))
+}
+
+func TestListPeople(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		createRandomPerson(t)
+	}
+
+	people, err := testStore.ListPeople(context.Background(), ListPeopleParams{
+		RowLimit: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, people, 2)
+
+	next, err := testStore.ListPeople(context.Background(), ListPeopleParams{
+		AfterID:   pgtype.Int4{Int32: people[1].PersonID, Valid: true},
+		AfterName: pgtype.Text{String: people[1].Name, Valid: true},
+		RowLimit:  2,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, next)
+	require.GreaterOrEqual(t, next[0].Name, people[1].Name)
+}
@@ -12,29 +12,206 @@ import (
 )
 
 type Querier interface {
+	AddMovieGenres(ctx context.Context, arg AddMovieGenresParams) error
+	AddRolePermission(ctx context.Context, arg AddRolePermissionParams) error
+	// adding a movie twice keeps it where it was
+	AddToWatchlist(ctx context.Context, arg AddToWatchlistParams) error
+	AnonymizeUser(ctx context.Context, userID int64) (User, error)
+	AnonymizeUserGuestBookings(ctx context.Context, userID int64) error
+	AssignGuestReservations(ctx context.Context, userID int64) (int64, error)
+	BlockSession(ctx context.Context, arg BlockSessionParams) (int64, error)
+	BlockUserSessions(ctx context.Context, username string) error
+	CancelGuestBooking(ctx context.Context, guestBookingID int64) (int64, error)
 	CancelReservation(ctx context.Context, arg CancelReservationParams) error
+	ClaimGuestBookings(ctx context.Context, arg ClaimGuestBookingsParams) ([]GuestBooking, error)
+	CountCreditsForPerson(ctx context.Context, personID int32) (int64, error)
+	CountMoviesWithGenre(ctx context.Context, genreID int32) (int64, error)
+	CountUpcomingReservationsForMovie(ctx context.Context, movieID int32) (int64, error)
+	// start_time has no time zone and holds UTC
+	CountUpcomingShowtimesForMovie(ctx context.Context, movieID int32) (int64, error)
+	CountUsersWithRole(ctx context.Context, role string) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
+	CreateAccountConfirmation(ctx context.Context, arg CreateAccountConfirmationParams) (AccountConfirmation, error)
+	CreateAccountUnlock(ctx context.Context, arg CreateAccountUnlockParams) (AccountUnlock, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error)
+	CreateGenre(ctx context.Context, name string) (Genre, error)
+	CreateGuestBooking(ctx context.Context, arg CreateGuestBookingParams) (GuestBooking, error)
+	CreateLoginChallenge(ctx context.Context, arg CreateLoginChallengeParams) (LoginChallenge, error)
 	CreateMovie(ctx context.Context, arg CreateMovieParams) (Movie, error)
+	CreateMovieCredit(ctx context.Context, arg CreateMovieCreditParams) (MovieCredit, error)
+	CreateOIDCState(ctx context.Context, arg CreateOIDCStateParams) (OidcState, error)
+	CreatePerson(ctx context.Context, arg CreatePersonParams) (Person, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error
+	CreateReview(ctx context.Context, arg CreateReviewParams) (Review, error)
+	CreateRole(ctx context.Context, arg CreateRoleParams) (Role, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateShowtime(ctx context.Context, arg CreateShowtimeParams) (Showtime, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	DeleteMovie(ctx context.Context, movieID int32) error
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error)
+	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
+	DeleteAccountConfirmations(ctx context.Context, userID int64) error
+	DeleteAccountUnlocks(ctx context.Context, userID int64) error
+	DeleteGenre(ctx context.Context, genreID int32) error
+	DeleteLoginChallenge(ctx context.Context, id uuid.UUID) error
+	DeleteLoginThrottle(ctx context.Context, arg DeleteLoginThrottleParams) error
+	DeleteLoginThrottlesByKey(ctx context.Context, throttleKey string) error
+	// movies are never removed, showtimes and bookings keep pointing at them
+	DeleteMovie(ctx context.Context, movieID int32) (Movie, error)
+	DeleteMovieCredit(ctx context.Context, arg DeleteMovieCreditParams) error
+	DeleteMovieGenres(ctx context.Context, movieID int32) error
+	DeleteOIDCState(ctx context.Context, state string) (OidcState, error)
+	DeletePerson(ctx context.Context, personID int32) error
+	DeleteRecoveryCodes(ctx context.Context, userID int64) error
+	DeleteReview(ctx context.Context, reviewID int64) error
+	DeleteRole(ctx context.Context, name string) error
+	DeleteRolePermissions(ctx context.Context, role string) error
 	DeleteShowtime(ctx context.Context, showtimeID int32) error
+	// drops recommendations of users the job no longer refreshes, e.g. ones
+	// that cancelled every booking since
+	DeleteStaleRecommendations(ctx context.Context) (int64, error)
+	DeleteUserAPIKeys(ctx context.Context, userID int64) error
+	DeleteUserIdentities(ctx context.Context, userID int64) error
+	DeleteUserLoginChallenges(ctx context.Context, userID int64) error
+	DeleteUserRecommendations(ctx context.Context, userID int64) error
+	// returns the movies whose rating has to be refreshed
+	DeleteUserReviews(ctx context.Context, userID int64) ([]int32, error)
+	DeleteUserSessions(ctx context.Context, username string) error
+	DeleteUserWatchlist(ctx context.Context, userID int64) error
+	DeleteUserWatchlistNotifications(ctx context.Context, userID int64) error
+	DeleteVerifyEmails(ctx context.Context, userID int64) error
+	DisableUser(ctx context.Context, userID int64) (User, error)
+	DisableUserTOTP(ctx context.Context, userID int64) (User, error)
+	EnableUser(ctx context.Context, userID int64) (User, error)
+	EnableUserTOTP(ctx context.Context, userID int64) (User, error)
+	// queues an alert for everyone watching a public movie, watchers already
+	// alerted about it today are skipped
+	EnqueueWatchlistNotifications(ctx context.Context, movieID int32) (int64, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (ApiKey, error)
+	GetGenre(ctx context.Context, genreID int32) (Genre, error)
+	GetGuestBookingByReference(ctx context.Context, reference string) (GuestBooking, error)
+	GetLoginChallenge(ctx context.Context, id uuid.UUID) (LoginChallenge, error)
+	GetLoginThrottle(ctx context.Context, arg GetLoginThrottleParams) (LoginThrottle, error)
 	GetMovie(ctx context.Context, movieID int32) (Movie, error)
+	GetMovieCredit(ctx context.Context, arg GetMovieCreditParams) (MovieCredit, error)
+	GetMovieForUpdate(ctx context.Context, movieID int32) (Movie, error)
+	GetPerson(ctx context.Context, personID int32) (Person, error)
+	GetReview(ctx context.Context, reviewID int64) (Review, error)
+	GetRole(ctx context.Context, name string) (Role, error)
 	GetSessionByID(ctx context.Context, id uuid.UUID) (Session, error)
 	GetShowtime(ctx context.Context, showtimeID int32) (Showtime, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, userID int64) (User, error)
+	GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error)
+	// a user may review a movie once one of their showtimes for it started
+	HasWatchedMovie(ctx context.Context, arg HasWatchedMovieParams) (bool, error)
+	IncrementLoginChallengeAttempts(ctx context.Context, id uuid.UUID) (LoginChallenge, error)
+	// scores the public movies with upcoming showtimes the user hasn't booked
+	// yet and keeps the best ones. a movie's score is made of
+	//   genre affinity: the largest share of the user's booked movies in one
+	//     of its genres
+	//   co-booking: how many people who booked the same movies as the user
+	//     booked this one, relative to the best candidate
+	//   popularity: bookings in the last 30 days, relative to the best
+	//     candidate
+	InsertUserRecommendations(ctx context.Context, arg InsertUserRecommendationsParams) (int64, error)
+	ListAPIKeys(ctx context.Context, arg ListAPIKeysParams) ([]ApiKey, error)
 	ListAllSeats(ctx context.Context) ([]Seat, error)
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	// showtimes of a movie that isn't public have no seats left to book
 	ListAvailableSeatsForShowtime(ctx context.Context, showtimeID int32) ([]Seat, error)
 	ListGenres(ctx context.Context) ([]Genre, error)
-	ListMovies(ctx context.Context, arg ListMoviesParams) ([]Movie, error)
-	ListReservationsByShowtime(ctx context.Context, showtimeID int32) ([]ListReservationsByShowtimeRow, error)
+	ListGenresByIDs(ctx context.Context, genreIds []int32) ([]Genre, error)
+	ListGenresForMovies(ctx context.Context, movieIds []int32) ([]ListGenresForMoviesRow, error)
+	// flagged or hidden reviews, newest first, for moderators
+	ListModeratedReviews(ctx context.Context, arg ListModeratedReviewsParams) ([]Review, error)
+	// directors first, then writers, then the cast by billing order
+	ListMovieCredits(ctx context.Context, movieID int32) ([]ListMovieCreditsRow, error)
+	ListMovieGenres(ctx context.Context, movieID int32) ([]Genre, error)
+	// visible reviews of a movie, newest first. pages continue below the
+	// review_id of the last review
+	ListMovieReviews(ctx context.Context, arg ListMovieReviewsParams) ([]ListMovieReviewsRow, error)
+	// the ListMoviesBy* queries filter through catalog_movies, which skips
+	// deleted movies, drafts and archived movies like every public query. each
+	// one has a fixed order so it can walk its index, and pages continue after
+	// the sort key and movie_id of the last movie of the previous page, null
+	// on the first page
+	ListMoviesByNewest(ctx context.Context, arg ListMoviesByNewestParams) ([]Movie, error)
+	// bookings_count lags behind by up to one run of the booking counter
+	ListMoviesByPopularity(ctx context.Context, arg ListMoviesByPopularityParams) ([]Movie, error)
+	// movies without a release date sort last as -infinity
+	ListMoviesByReleaseDate(ctx context.Context, arg ListMoviesByReleaseDateParams) ([]Movie, error)
+	ListMoviesByTitle(ctx context.Context, arg ListMoviesByTitleParams) ([]Movie, error)
+	// alerts still to be emailed, oldest first. accounts that are disabled or
+	// being deleted don't get any, neither do movies unpublished or deleted
+	// since the alert was queued
+	ListPendingWatchlistNotifications(ctx context.Context, rowLimit int32) ([]ListPendingWatchlistNotificationsRow, error)
+	// pages continue after the name and person_id of the last person. search
+	// comes with its LIKE wildcards escaped
+	ListPeople(ctx context.Context, arg ListPeopleParams) ([]Person, error)
+	// the public movies a person is credited in, newest release first
+	ListPersonCredits(ctx context.Context, personID int32) ([]ListPersonCreditsRow, error)
+	// for users without booking history, the most booked movies of the last
+	// 30 days that have upcoming showtimes
+	ListPopularUpcomingMovies(ctx context.Context, rowLimit int32) ([]ListPopularUpcomingMoviesRow, error)
+	ListReservationsByGuestBooking(ctx context.Context, guestBookingID int64) ([]ListReservationsByGuestBookingRow, error)
+	ListReservationsByShowtime(ctx context.Context, arg ListReservationsByShowtimeParams) ([]ListReservationsByShowtimeRow, error)
 	ListReservationsByUser(ctx context.Context, userID int64) ([]ListReservationsByUserRow, error)
+	ListReservationsByUserPage(ctx context.Context, arg ListReservationsByUserPageParams) ([]ListReservationsByUserPageRow, error)
+	ListRolePermissions(ctx context.Context, role string) ([]string, error)
+	ListRoles(ctx context.Context) ([]Role, error)
 	ListSeatsForShowtime(ctx context.Context, showtimeID int32) ([]ListSeatsForShowtimeRow, error)
+	ListSessionsByUsername(ctx context.Context, username string) ([]Session, error)
 	ListShowtimesBetween(ctx context.Context, arg ListShowtimesBetweenParams) ([]ListShowtimesBetweenRow, error)
 	ListShowtimesByDate(ctx context.Context, startTime pgtype.Timestamp) ([]ListShowtimesByDateRow, error)
+	// start_time has no time zone and holds UTC
+	ListUpcomingShowtimesForMovies(ctx context.Context, movieIds []int32) ([]Showtime, error)
+	ListUserIdentities(ctx context.Context, userID int64) ([]UserIdentity, error)
+	// best recommendations first, movies unpublished since are left out
+	ListUserRecommendations(ctx context.Context, arg ListUserRecommendationsParams) ([]ListUserRecommendationsRow, error)
+	ListUserReviews(ctx context.Context, userID int64) ([]ListUserReviewsRow, error)
+	ListUserWatchlist(ctx context.Context, userID int64) ([]ListUserWatchlistRow, error)
+	// search comes with its LIKE wildcards escaped
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	ListUsersDueForAnonymization(ctx context.Context, arg ListUsersDueForAnonymizationParams) ([]int64, error)
+	// users with booking history to recommend movies to, by user_id
+	ListUsersWithBookings(ctx context.Context, arg ListUsersWithBookingsParams) ([]int64, error)
+	// movies a user watches, most recently added first. pages continue after
+	// the created_at and movie_id of the last entry
+	ListWatchlist(ctx context.Context, arg ListWatchlistParams) ([]ListWatchlistRow, error)
+	LockLoginThrottle(ctx context.Context, arg LockLoginThrottleParams) error
+	LockMovie(ctx context.Context, movieID int32) error
+	LockShowtimeMovie(ctx context.Context, showtimeID int32) (int32, error)
+	MarkWatchlistNotificationSent(ctx context.Context, notificationID int64) error
+	// hiding or flagging again keeps the time it first happened
+	ModerateReview(ctx context.Context, arg ModerateReviewParams) (Review, error)
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error)
+	// start_time has no time zone and holds UTC
+	// recounts the bookings of every movie, only touching the ones that changed
+	RefreshMovieBookingCounts(ctx context.Context) (int64, error)
+	RefreshMovieRating(ctx context.Context, movieID int32) error
+	RemoveFromWatchlist(ctx context.Context, arg RemoveFromWatchlistParams) (int64, error)
+	RequestUserDeletion(ctx context.Context, userID int64) (User, error)
 	ReserveSeat(ctx context.Context, arg ReserveSeatParams) (Reservation, error)
+	RevokeAPIKey(ctx context.Context, id int64) (ApiKey, error)
+	SearchMovies(ctx context.Context, arg SearchMoviesParams) ([]SearchMoviesRow, error)
+	SearchMoviesByTitleSimilarity(ctx context.Context, arg SearchMoviesByTitleSimilarityParams) ([]SearchMoviesByTitleSimilarityRow, error)
+	SetUserEmailVerified(ctx context.Context, arg SetUserEmailVerifiedParams) (User, error)
+	SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error)
+	TouchAPIKey(ctx context.Context, arg TouchAPIKeyParams) error
+	UpdateGenre(ctx context.Context, arg UpdateGenreParams) (Genre, error)
 	UpdateMovie(ctx context.Context, arg UpdateMovieParams) (Movie, error)
+	UpdateMovieCredit(ctx context.Context, arg UpdateMovieCreditParams) (MovieCredit, error)
+	UpdatePerson(ctx context.Context, arg UpdatePersonParams) (Person, error)
+	UpdateReview(ctx context.Context, arg UpdateReviewParams) (Review, error)
+	UpdateRoleDescription(ctx context.Context, arg UpdateRoleDescriptionParams) (Role, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UseAccountConfirmation(ctx context.Context, arg UseAccountConfirmationParams) (AccountConfirmation, error)
+	UseAccountUnlock(ctx context.Context, arg UseAccountUnlockParams) (AccountUnlock, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error)
+	// steps only move forward, so a code can't be replayed within its period
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error)
+	UseVerifyEmail(ctx context.Context, arg UseVerifyEmailParams) (VerifyEmail, error)
 }
 
 var _ Querier = (*Queries)(nil)
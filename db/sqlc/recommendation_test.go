@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func bookShowtime(t *testing.T, user User, showtime Showtime) {
+	seat := getRandomAvailableSeats(t, showtime.ShowtimeID, 1)[0]
+
+	_, err := testStore.ReserveSeat(context.Background(), ReserveSeatParams{
+		UserID:     pgtype.Int8{Int64: user.UserID, Valid: true},
+		ShowtimeID: showtime.ShowtimeID,
+		SeatID:     seat.SeatID,
+	})
+	require.NoError(t, err)
+}
+
+func TestRefreshUserRecommendationsTx(t *testing.T) {
+	genre := createRandomGenre(t)
+	other := createRandomGenre(t)
+	showtimeAt := time.Now().UTC().Add(48 * time.Hour)
+
+	seen := createCatalogMovie(t, genre, "Seen", CreateMovieParams{},
+		time.Time{}, 0)
+	sameGenre := createCatalogMovie(t, genre, "Same Genre", CreateMovieParams{},
+		showtimeAt, 0)
+	coBooked := createCatalogMovie(t, other, "Co Booked", CreateMovieParams{},
+		showtimeAt, 0)
+	// no upcoming showtime, nothing to book
+	createCatalogMovie(t, genre, "Not Showing", CreateMovieParams{},
+		time.Time{}, 0)
+
+	user := createRandomUser(t)
+	neighbour := createRandomUser(t)
+
+	seenShowtime := createUpcomingShowtime(t, seen).Showtime
+	bookShowtime(t, user, seenShowtime)
+	bookShowtime(t, neighbour, seenShowtime)
+
+	coBookedShowtime, err := testStore.ListUpcomingShowtimesForMovies(
+		context.Background(), []int32{coBooked.MovieID})
+	require.NoError(t, err)
+	require.Len(t, coBookedShowtime, 1)
+	bookShowtime(t, neighbour, coBookedShowtime[0])
+
+	count, err := testStore.RefreshUserRecommendationsTx(context.Background(),
+		InsertUserRecommendationsParams{UserID: user.UserID, RowLimit: 1000})
+	require.NoError(t, err)
+	require.NotZero(t, count)
+
+	rows, err := testStore.ListUserRecommendations(context.Background(),
+		ListUserRecommendationsParams{UserID: user.UserID, RowLimit: 1000})
+	require.NoError(t, err)
+	require.Len(t, rows, int(count))
+
+	scores := map[int32]ListUserRecommendationsRow{}
+	for _, row := range rows {
+		scores[row.Movie.MovieID] = row
+		require.GreaterOrEqual(t, row.Score, 0.0)
+		require.LessOrEqual(t, row.Score, 1.0)
+	}
+
+	// movies already booked aren't recommended
+	require.NotContains(t, scores, seen.MovieID)
+
+	require.Contains(t, scores, sameGenre.MovieID)
+	require.Equal(t, 1.0, scores[sameGenre.MovieID].GenreScore)
+	require.Zero(t, scores[sameGenre.MovieID].CoBookingScore)
+
+	require.Contains(t, scores, coBooked.MovieID)
+	require.Zero(t, scores[coBooked.MovieID].GenreScore)
+	require.Equal(t, 1.0, scores[coBooked.MovieID].CoBookingScore)
+
+	// refreshing replaces the previous set
+	again, err := testStore.RefreshUserRecommendationsTx(context.Background(),
+		InsertUserRecommendationsParams{UserID: user.UserID, RowLimit: 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), again)
+
+	rows, err = testStore.ListUserRecommendations(context.Background(),
+		ListUserRecommendationsParams{UserID: user.UserID, RowLimit: 1000})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+}
+
+func TestListUsersWithBookings(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomUser(t)
+	bookShowtime(t, user, createRandomShowtime(t))
+
+	userIDs, err := testStore.ListUsersWithBookings(context.Background(),
+		ListUsersWithBookingsParams{AfterID: user.UserID - 1, RowLimit: 10})
+	require.NoError(t, err)
+	require.Equal(t, []int64{user.UserID}, userIDs)
+}
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"context"
+)
+
+// Replaces a user's recommendations with freshly scored ones and returns
+// how many were kept, readers see either the old or the new set
+func (store *SQLStore) RefreshUserRecommendationsTx(ctx context.Context,
+	arg InsertUserRecommendationsParams) (int64, error) {
+	var count int64
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.DeleteUserRecommendations(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		count, err = q.InsertUserRecommendations(ctx, arg)
+		return err
+	})
+
+	return count, err
+}
@@ -14,7 +14,8 @@ import (
 
 const cancelReservation = `-- name: CancelReservation :exec
 DELETE FROM reservations
-WHERE reservation_id = $1 AND user_id = $2
+WHERE reservation_id = $1
+  AND user_id = $2::bigint
 `
 
 type CancelReservationParams struct {
@@ -31,11 +32,20 @@ const listAvailableSeatsForShowtime = `-- name: ListAvailableSeatsForShowtime :m
 SELECT seat_id, row, number, created_at
 FROM seats
 WHERE seat_id NOT IN (
-  SELECT seat_id FROM reservations WHERE showtime_id = $1
+  SELECT r.seat_id FROM reservations r
+  WHERE r.showtime_id = $1
 )
+  AND EXISTS (
+    SELECT 1 FROM showtimes s
+    JOIN movies m ON m.movie_id = s.movie_id
+    WHERE s.showtime_id = $1
+      AND m.deleted_at IS NULL
+      AND m.status IN ('coming_soon', 'now_showing')
+  )
 ORDER BY row, number
 `
 
+// showtimes of a movie that isn't public have no seats left to book
 func (q *Queries) ListAvailableSeatsForShowtime(ctx context.Context, showtimeID int32) ([]Seat, error) {
 	rows, err := q.db.Query(ctx, listAvailableSeatsForShowtime, showtimeID)
 	if err != nil {
@@ -62,26 +72,38 @@ func (q *Queries) ListAvailableSeatsForShowtime(ctx context.Context, showtimeID
 }
 
 const listReservationsByShowtime = `-- name: ListReservationsByShowtime :many
-SELECT r.reservation_id, r.user_id, r.showtime_id, r.seat_id, r.reserved_at, u.name, se.row, se.number
+SELECT r.reservation_id, r.user_id, r.showtime_id, r.seat_id, r.reserved_at, r.guest_booking_id, COALESCE(u.name, g.name)::text AS name, se.row, se.number
 FROM reservations r
-JOIN users u ON u.user_id = r.user_id
+LEFT JOIN users u ON u.user_id = r.user_id
+LEFT JOIN guest_bookings g ON g.id = r.guest_booking_id
 JOIN seats se ON se.seat_id = r.seat_id
 WHERE r.showtime_id = $1
+  AND ($2::bigint IS NULL
+    OR r.reservation_id > $2::bigint)
+ORDER BY r.reservation_id
+LIMIT $3
 `
 
+type ListReservationsByShowtimeParams struct {
+	ShowtimeID int32       `json:"showtime_id"`
+	AfterID    pgtype.Int8 `json:"after_id"`
+	RowLimit   int32       `json:"row_limit"`
+}
+
 type ListReservationsByShowtimeRow struct {
-	ReservationID int64     `json:"reservation_id"`
-	UserID        int64     `json:"user_id"`
-	ShowtimeID    int32     `json:"showtime_id"`
-	SeatID        int32     `json:"seat_id"`
-	ReservedAt    time.Time `json:"reserved_at"`
-	Name          string    `json:"name"`
-	Row           int32     `json:"row"`
-	Number        int32     `json:"number"`
+	ReservationID  int64       `json:"reservation_id"`
+	UserID         pgtype.Int8 `json:"user_id"`
+	ShowtimeID     int32       `json:"showtime_id"`
+	SeatID         int32       `json:"seat_id"`
+	ReservedAt     time.Time   `json:"reserved_at"`
+	GuestBookingID pgtype.Int8 `json:"guest_booking_id"`
+	Name           string      `json:"name"`
+	Row            int32       `json:"row"`
+	Number         int32       `json:"number"`
 }
 
-func (q *Queries) ListReservationsByShowtime(ctx context.Context, showtimeID int32) ([]ListReservationsByShowtimeRow, error) {
-	rows, err := q.db.Query(ctx, listReservationsByShowtime, showtimeID)
+func (q *Queries) ListReservationsByShowtime(ctx context.Context, arg ListReservationsByShowtimeParams) ([]ListReservationsByShowtimeRow, error) {
+	rows, err := q.db.Query(ctx, listReservationsByShowtime, arg.ShowtimeID, arg.AfterID, arg.RowLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -95,6 +117,7 @@ func (q *Queries) ListReservationsByShowtime(ctx context.Context, showtimeID int
 			&i.ShowtimeID,
 			&i.SeatID,
 			&i.ReservedAt,
+			&i.GuestBookingID,
 			&i.Name,
 			&i.Row,
 			&i.Number,
@@ -110,25 +133,26 @@ func (q *Queries) ListReservationsByShowtime(ctx context.Context, showtimeID int
 }
 
 const listReservationsByUser = `-- name: ListReservationsByUser :many
-SELECT r.reservation_id, r.user_id, r.showtime_id, r.seat_id, r.reserved_at, s.start_time, m.title, se.row, se.number
+SELECT r.reservation_id, r.user_id, r.showtime_id, r.seat_id, r.reserved_at, r.guest_booking_id, s.start_time, m.title, se.row, se.number
 FROM reservations r
 JOIN showtimes s ON s.showtime_id = r.showtime_id
 JOIN movies m ON m.movie_id = s.movie_id
 JOIN seats se ON se.seat_id = r.seat_id
-WHERE r.user_id = $1
+WHERE r.user_id = $1::bigint
 ORDER BY s.start_time
 `
 
 type ListReservationsByUserRow struct {
-	ReservationID int64            `json:"reservation_id"`
-	UserID        int64            `json:"user_id"`
-	ShowtimeID    int32            `json:"showtime_id"`
-	SeatID        int32            `json:"seat_id"`
-	ReservedAt    time.Time        `json:"reserved_at"`
-	StartTime     pgtype.Timestamp `json:"start_time"`
-	Title         string           `json:"title"`
-	Row           int32            `json:"row"`
-	Number        int32            `json:"number"`
+	ReservationID  int64            `json:"reservation_id"`
+	UserID         pgtype.Int8      `json:"user_id"`
+	ShowtimeID     int32            `json:"showtime_id"`
+	SeatID         int32            `json:"seat_id"`
+	ReservedAt     time.Time        `json:"reserved_at"`
+	GuestBookingID pgtype.Int8      `json:"guest_booking_id"`
+	StartTime      pgtype.Timestamp `json:"start_time"`
+	Title          string           `json:"title"`
+	Row            int32            `json:"row"`
+	Number         int32            `json:"number"`
 }
 
 func (q *Queries) ListReservationsByUser(ctx context.Context, userID int64) ([]ListReservationsByUserRow, error) {
@@ -146,6 +170,77 @@ func (q *Queries) ListReservationsByUser(ctx context.Context, userID int64) ([]L
 			&i.ShowtimeID,
 			&i.SeatID,
 			&i.ReservedAt,
+			&i.GuestBookingID,
+			&i.StartTime,
+			&i.Title,
+			&i.Row,
+			&i.Number,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReservationsByUserPage = `-- name: ListReservationsByUserPage :many
+SELECT r.reservation_id, r.user_id, r.showtime_id, r.seat_id, r.reserved_at, r.guest_booking_id, s.start_time, m.title, se.row, se.number
+FROM reservations r
+JOIN showtimes s ON s.showtime_id = r.showtime_id
+JOIN movies m ON m.movie_id = s.movie_id
+JOIN seats se ON se.seat_id = r.seat_id
+WHERE r.user_id = $1::bigint
+  AND ($2::bigint IS NULL
+    OR (s.start_time, r.reservation_id) >
+      ($3::timestamp, $2::bigint))
+ORDER BY s.start_time, r.reservation_id
+LIMIT $4
+`
+
+type ListReservationsByUserPageParams struct {
+	UserID         int64            `json:"user_id"`
+	AfterID        pgtype.Int8      `json:"after_id"`
+	AfterStartTime pgtype.Timestamp `json:"after_start_time"`
+	RowLimit       int32            `json:"row_limit"`
+}
+
+type ListReservationsByUserPageRow struct {
+	ReservationID  int64            `json:"reservation_id"`
+	UserID         pgtype.Int8      `json:"user_id"`
+	ShowtimeID     int32            `json:"showtime_id"`
+	SeatID         int32            `json:"seat_id"`
+	ReservedAt     time.Time        `json:"reserved_at"`
+	GuestBookingID pgtype.Int8      `json:"guest_booking_id"`
+	StartTime      pgtype.Timestamp `json:"start_time"`
+	Title          string           `json:"title"`
+	Row            int32            `json:"row"`
+	Number         int32            `json:"number"`
+}
+
+func (q *Queries) ListReservationsByUserPage(ctx context.Context, arg ListReservationsByUserPageParams) ([]ListReservationsByUserPageRow, error) {
+	rows, err := q.db.Query(ctx, listReservationsByUserPage,
+		arg.UserID,
+		arg.AfterID,
+		arg.AfterStartTime,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListReservationsByUserPageRow{}
+	for rows.Next() {
+		var i ListReservationsByUserPageRow
+		if err := rows.Scan(
+			&i.ReservationID,
+			&i.UserID,
+			&i.ShowtimeID,
+			&i.SeatID,
+			&i.ReservedAt,
+			&i.GuestBookingID,
 			&i.StartTime,
 			&i.Title,
 			&i.Row,
@@ -161,20 +256,43 @@ func (q *Queries) ListReservationsByUser(ctx context.Context, userID int64) ([]L
 	return items, nil
 }
 
+const lockShowtimeMovie = `-- name: LockShowtimeMovie :one
+SELECT m.movie_id FROM showtimes s
+JOIN movies m ON m.movie_id = s.movie_id
+WHERE s.showtime_id = $1 AND m.deleted_at IS NULL
+FOR SHARE OF m
+`
+
+func (q *Queries) LockShowtimeMovie(ctx context.Context, showtimeID int32) (int32, error) {
+	row := q.db.QueryRow(ctx, lockShowtimeMovie, showtimeID)
+	var movie_id int32
+	err := row.Scan(&movie_id)
+	return movie_id, err
+}
+
 const reserveSeat = `-- name: ReserveSeat :one
-INSERT INTO reservations (user_id, showtime_id, seat_id)
-VALUES ($1, $2, $3)
-RETURNING reservation_id, user_id, showtime_id, seat_id, reserved_at
+INSERT INTO reservations (user_id, guest_booking_id, showtime_id, seat_id)
+VALUES (
+  $1, $2,
+  $3, $4
+)
+RETURNING reservation_id, user_id, showtime_id, seat_id, reserved_at, guest_booking_id
 `
 
 type ReserveSeatParams struct {
-	UserID     int64 `json:"user_id"`
-	ShowtimeID int32 `json:"showtime_id"`
-	SeatID     int32 `json:"seat_id"`
+	UserID         pgtype.Int8 `json:"user_id"`
+	GuestBookingID pgtype.Int8 `json:"guest_booking_id"`
+	ShowtimeID     int32       `json:"showtime_id"`
+	SeatID         int32       `json:"seat_id"`
 }
 
 func (q *Queries) ReserveSeat(ctx context.Context, arg ReserveSeatParams) (Reservation, error) {
-	row := q.db.QueryRow(ctx, reserveSeat, arg.UserID, arg.ShowtimeID, arg.SeatID)
+	row := q.db.QueryRow(ctx, reserveSeat,
+		arg.UserID,
+		arg.GuestBookingID,
+		arg.ShowtimeID,
+		arg.SeatID,
+	)
 	var i Reservation
 	err := row.Scan(
 		&i.ReservationID,
@@ -182,6 +300,7 @@ func (q *Queries) ReserveSeat(ctx context.Context, arg ReserveSeatParams) (Reser
 		&i.ShowtimeID,
 		&i.SeatID,
 		&i.ReservedAt,
+		&i.GuestBookingID,
 	)
 	return i, err
 }
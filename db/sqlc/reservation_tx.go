@@ -2,8 +2,11 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // To prevent race conditions, like two users reserving
@@ -27,49 +30,112 @@ func (store *SQLStore) ReserveMultipleSeatsTx(
 	var result ReserveMultipleSeatsTxResult
 
 	err := store.execTx(ctx, func(q *Queries) error {
-		// Step 1: get available seats
-		availableSeats, err := q.ListAvailableSeatsForShowtime(ctx,
-			arg.ShowtimeID)
+		var err error
+
+		result.Reservations, err = reserveSeats(ctx, q, ReserveSeatParams{
+			UserID:     pgtype.Int8{Int64: arg.UserID, Valid: true},
+			ShowtimeID: arg.ShowtimeID,
+		}, arg.SeatIDs)
+		return err
+	})
+
+	return result, err
+}
+
+type ReserveGuestSeatsTxParams struct {
+	CreateGuestBookingParams
+	ShowtimeID int32   `json:"showtime_id"`
+	SeatIDs    []int32 `json:"seat_ids"`
+}
+
+type ReserveGuestSeatsTxResult struct {
+	GuestBooking GuestBooking  `json:"guest_booking"`
+	Reservations []Reservation `json:"reservations"`
+}
+
+// Creates a guest booking and reserves its seats, same rules as for
+// users, either everything is booked or nothing is
+func (store *SQLStore) ReserveGuestSeatsTx(
+	ctx context.Context, arg ReserveGuestSeatsTxParams,
+) (ReserveGuestSeatsTxResult, error) {
+	var result ReserveGuestSeatsTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.GuestBooking, err = q.CreateGuestBooking(ctx,
+			arg.CreateGuestBookingParams)
 		if err != nil {
 			return err
 		}
 
-		// put available seats in map
-		availableMap := make(map[int32]bool)
-		for _, s := range availableSeats {
-			availableMap[s.SeatID] = true
+		result.Reservations, err = reserveSeats(ctx, q, ReserveSeatParams{
+			GuestBookingID: pgtype.Int8{
+				Int64: result.GuestBooking.ID,
+				Valid: true,
+			},
+			ShowtimeID: arg.ShowtimeID,
+		}, arg.SeatIDs)
+		return err
+	})
+
+	return result, err
+}
+
+// reserves every seat in seatIDs for the owner in base
+func reserveSeats(ctx context.Context, q *Queries, base ReserveSeatParams,
+	seatIDs []int32) ([]Reservation, error) {
+	// Step 0: hold the movie so it can't be deleted while booking,
+	// DeleteMovieTx locks it before counting the bookings
+	_, err := q.LockShowtimeMovie(ctx, base.ShowtimeID)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return nil, fmt.Errorf("showtime %d is not available",
+				base.ShowtimeID)
 		}
+		return nil, err
+	}
 
-		// Step 2: validate all requested seats are available
-		for _, seatID := range arg.SeatIDs {
-			if !availableMap[seatID] {
-				return fmt.Errorf(
-					"seat %d is not available for showtime %d",
-					seatID, arg.ShowtimeID)
-			}
+	// Step 1: get available seats
+	availableSeats, err := q.ListAvailableSeatsForShowtime(ctx,
+		base.ShowtimeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// put available seats in map
+	availableMap := make(map[int32]bool)
+	for _, s := range availableSeats {
+		availableMap[s.SeatID] = true
+	}
+
+	// Step 2: validate all requested seats are available
+	for _, seatID := range seatIDs {
+		if !availableMap[seatID] {
+			return nil, fmt.Errorf(
+				"seat %d is not available for showtime %d",
+				seatID, base.ShowtimeID)
 		}
+	}
 
-		// Step 3: insert each seat one by one
-		for _, seatID := range arg.SeatIDs {
-			res, err := q.ReserveSeat(ctx, ReserveSeatParams{
-				UserID:     arg.UserID,
-				ShowtimeID: arg.ShowtimeID,
-				SeatID:     seatID,
-			})
-			if err != nil {
-				// Handle DB unique constraint (concurrent race case)
-				if strings.Contains(err.Error(), "duplicate key") {
-					return fmt.Errorf("seat %d already reserved", seatID)
-				}
-				return err
+	// Step 3: insert each seat one by one
+	reservations := make([]Reservation, 0, len(seatIDs))
+	for _, seatID := range seatIDs {
+		arg := base
+		arg.SeatID = seatID
+
+		res, err := q.ReserveSeat(ctx, arg)
+		if err != nil {
+			// Handle DB unique constraint (concurrent race case)
+			if strings.Contains(err.Error(), "duplicate key") {
+				return nil, fmt.Errorf("seat %d already reserved", seatID)
 			}
-			result.Reservations = append(result.Reservations, res)
+			return nil, err
 		}
+		reservations = append(reservations, res)
+	}
 
-		return nil
-	})
-
-	return result, err
+	return reservations, nil
 }
 
 // Cancelling reservation in tx
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
@@ -32,7 +33,7 @@ func TestReserveMultipleSeatsTx(t *testing.T) {
 	require.Len(t, result.Reservations, len(seatIDs))
 
 	for i, res := range result.Reservations {
-		require.Equal(t, user.UserID, res.UserID)
+		require.Equal(t, user.UserID, res.UserID.Int64)
 		require.Equal(t, showtime.ShowtimeID, res.ShowtimeID)
 		require.Equal(t, seatIDs[i], res.SeatID)
 		require.NotZero(t, res.ReservationID)
@@ -54,7 +55,7 @@ func TestCancelReservationTx(t *testing.T) {
 
 	// Reserve the seat
 	reserveArg := ReserveSeatParams{
-		UserID:     user.UserID,
+		UserID:     pgtype.Int8{Int64: user.UserID, Valid: true},
 		ShowtimeID: showtime.ShowtimeID,
 		SeatID:     seat.SeatID,
 	}
@@ -65,7 +66,7 @@ func TestCancelReservationTx(t *testing.T) {
 	// Cancel the reservation
 	cancelArg := CancelReservationParams{
 		ReservationID: reserveResult.ReservationID,
-		UserID:        reserveResult.UserID,
+		UserID:        reserveResult.UserID.Int64,
 	}
 	err = testStore.CancelReservationTx(context.Background(), cancelArg)
 	require.NoError(t, err)
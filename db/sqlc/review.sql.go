@@ -0,0 +1,410 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: review.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createReview = `-- name: CreateReview :one
+INSERT INTO reviews (movie_id, user_id, rating, body)
+VALUES ($1, $2, $3, $4)
+RETURNING review_id, movie_id, user_id, rating, body, flagged_at, hidden_at, moderation_note, created_at, updated_at
+`
+
+type CreateReviewParams struct {
+	MovieID int32  `json:"movie_id"`
+	UserID  int64  `json:"user_id"`
+	Rating  int32  `json:"rating"`
+	Body    string `json:"body"`
+}
+
+func (q *Queries) CreateReview(ctx context.Context, arg CreateReviewParams) (Review, error) {
+	row := q.db.QueryRow(ctx, createReview,
+		arg.MovieID,
+		arg.UserID,
+		arg.Rating,
+		arg.Body,
+	)
+	var i Review
+	err := row.Scan(
+		&i.ReviewID,
+		&i.MovieID,
+		&i.UserID,
+		&i.Rating,
+		&i.Body,
+		&i.FlaggedAt,
+		&i.HiddenAt,
+		&i.ModerationNote,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteReview = `-- name: DeleteReview :exec
+DELETE FROM reviews
+WHERE review_id = $1
+`
+
+func (q *Queries) DeleteReview(ctx context.Context, reviewID int64) error {
+	_, err := q.db.Exec(ctx, deleteReview, reviewID)
+	return err
+}
+
+const deleteUserReviews = `-- name: DeleteUserReviews :many
+WITH deleted AS (
+  DELETE FROM reviews
+  WHERE user_id = $1
+  RETURNING movie_id
+)
+SELECT DISTINCT movie_id FROM deleted
+ORDER BY movie_id
+`
+
+// returns the movies whose rating has to be refreshed
+func (q *Queries) DeleteUserReviews(ctx context.Context, userID int64) ([]int32, error) {
+	rows, err := q.db.Query(ctx, deleteUserReviews, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var movie_id int32
+		if err := rows.Scan(&movie_id); err != nil {
+			return nil, err
+		}
+		items = append(items, movie_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReview = `-- name: GetReview :one
+SELECT review_id, movie_id, user_id, rating, body, flagged_at, hidden_at, moderation_note, created_at, updated_at FROM reviews
+WHERE review_id = $1
+`
+
+func (q *Queries) GetReview(ctx context.Context, reviewID int64) (Review, error) {
+	row := q.db.QueryRow(ctx, getReview, reviewID)
+	var i Review
+	err := row.Scan(
+		&i.ReviewID,
+		&i.MovieID,
+		&i.UserID,
+		&i.Rating,
+		&i.Body,
+		&i.FlaggedAt,
+		&i.HiddenAt,
+		&i.ModerationNote,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const hasWatchedMovie = `-- name: HasWatchedMovie :one
+SELECT EXISTS (
+  SELECT 1 FROM reservations r
+  JOIN showtimes s ON s.showtime_id = r.showtime_id
+  WHERE r.user_id = $1::bigint
+    AND s.movie_id = $2
+    AND s.start_time <= (now() AT TIME ZONE 'UTC')
+)
+`
+
+type HasWatchedMovieParams struct {
+	UserID  int64 `json:"user_id"`
+	MovieID int32 `json:"movie_id"`
+}
+
+// a user may review a movie once one of their showtimes for it started
+func (q *Queries) HasWatchedMovie(ctx context.Context, arg HasWatchedMovieParams) (bool, error) {
+	row := q.db.QueryRow(ctx, hasWatchedMovie, arg.UserID, arg.MovieID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listModeratedReviews = `-- name: ListModeratedReviews :many
+SELECT review_id, movie_id, user_id, rating, body, flagged_at, hidden_at, moderation_note, created_at, updated_at FROM reviews
+WHERE (flagged_at IS NOT NULL OR hidden_at IS NOT NULL)
+  AND ($1::bool IS NULL
+    OR (flagged_at IS NOT NULL) = $1::bool)
+  AND ($2::bool IS NULL
+    OR (hidden_at IS NOT NULL) = $2::bool)
+  AND ($3::bigint IS NULL
+    OR review_id < $3::bigint)
+ORDER BY review_id DESC
+LIMIT $4
+`
+
+type ListModeratedReviewsParams struct {
+	Flagged  pgtype.Bool `json:"flagged"`
+	Hidden   pgtype.Bool `json:"hidden"`
+	BeforeID pgtype.Int8 `json:"before_id"`
+	RowLimit int32       `json:"row_limit"`
+}
+
+// flagged or hidden reviews, newest first, for moderators
+func (q *Queries) ListModeratedReviews(ctx context.Context, arg ListModeratedReviewsParams) ([]Review, error) {
+	rows, err := q.db.Query(ctx, listModeratedReviews,
+		arg.Flagged,
+		arg.Hidden,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Review{}
+	for rows.Next() {
+		var i Review
+		if err := rows.Scan(
+			&i.ReviewID,
+			&i.MovieID,
+			&i.UserID,
+			&i.Rating,
+			&i.Body,
+			&i.FlaggedAt,
+			&i.HiddenAt,
+			&i.ModerationNote,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMovieReviews = `-- name: ListMovieReviews :many
+SELECT r.review_id, r.movie_id, r.rating, r.body, r.created_at,
+  r.updated_at, u.username
+FROM reviews r
+JOIN users u ON u.user_id = r.user_id
+WHERE r.movie_id = $1
+  AND r.hidden_at IS NULL
+  AND ($2::bigint IS NULL
+    OR r.review_id < $2::bigint)
+ORDER BY r.review_id DESC
+LIMIT $3
+`
+
+type ListMovieReviewsParams struct {
+	MovieID  int32       `json:"movie_id"`
+	BeforeID pgtype.Int8 `json:"before_id"`
+	RowLimit int32       `json:"row_limit"`
+}
+
+type ListMovieReviewsRow struct {
+	ReviewID  int64     `json:"review_id"`
+	MovieID   int32     `json:"movie_id"`
+	Rating    int32     `json:"rating"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Username  string    `json:"username"`
+}
+
+// visible reviews of a movie, newest first. pages continue below the
+// review_id of the last review
+func (q *Queries) ListMovieReviews(ctx context.Context, arg ListMovieReviewsParams) ([]ListMovieReviewsRow, error) {
+	rows, err := q.db.Query(ctx, listMovieReviews, arg.MovieID, arg.BeforeID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMovieReviewsRow{}
+	for rows.Next() {
+		var i ListMovieReviewsRow
+		if err := rows.Scan(
+			&i.ReviewID,
+			&i.MovieID,
+			&i.Rating,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserReviews = `-- name: ListUserReviews :many
+SELECT r.review_id, r.movie_id, r.user_id, r.rating, r.body, r.flagged_at, r.hidden_at, r.moderation_note, r.created_at, r.updated_at, m.title
+FROM reviews r
+JOIN movies m ON m.movie_id = r.movie_id
+WHERE r.user_id = $1
+ORDER BY r.review_id
+`
+
+type ListUserReviewsRow struct {
+	ReviewID       int64              `json:"review_id"`
+	MovieID        int32              `json:"movie_id"`
+	UserID         int64              `json:"user_id"`
+	Rating         int32              `json:"rating"`
+	Body           string             `json:"body"`
+	FlaggedAt      pgtype.Timestamptz `json:"flagged_at"`
+	HiddenAt       pgtype.Timestamptz `json:"hidden_at"`
+	ModerationNote string             `json:"moderation_note"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	Title          string             `json:"title"`
+}
+
+func (q *Queries) ListUserReviews(ctx context.Context, userID int64) ([]ListUserReviewsRow, error) {
+	rows, err := q.db.Query(ctx, listUserReviews, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUserReviewsRow{}
+	for rows.Next() {
+		var i ListUserReviewsRow
+		if err := rows.Scan(
+			&i.ReviewID,
+			&i.MovieID,
+			&i.UserID,
+			&i.Rating,
+			&i.Body,
+			&i.FlaggedAt,
+			&i.HiddenAt,
+			&i.ModerationNote,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockMovie = `-- name: LockMovie :exec
+SELECT movie_id FROM movies
+WHERE movie_id = $1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) LockMovie(ctx context.Context, movieID int32) error {
+	_, err := q.db.Exec(ctx, lockMovie, movieID)
+	return err
+}
+
+const moderateReview = `-- name: ModerateReview :one
+UPDATE reviews
+SET hidden_at = CASE WHEN $1::bool
+      THEN coalesce(hidden_at, now()) END,
+    flagged_at = CASE WHEN $2::bool
+      THEN coalesce(flagged_at, now()) END,
+    moderation_note = $3
+WHERE review_id = $4
+RETURNING review_id, movie_id, user_id, rating, body, flagged_at, hidden_at, moderation_note, created_at, updated_at
+`
+
+type ModerateReviewParams struct {
+	Hidden         bool   `json:"hidden"`
+	Flagged        bool   `json:"flagged"`
+	ModerationNote string `json:"moderation_note"`
+	ReviewID       int64  `json:"review_id"`
+}
+
+// hiding or flagging again keeps the time it first happened
+func (q *Queries) ModerateReview(ctx context.Context, arg ModerateReviewParams) (Review, error) {
+	row := q.db.QueryRow(ctx, moderateReview,
+		arg.Hidden,
+		arg.Flagged,
+		arg.ModerationNote,
+		arg.ReviewID,
+	)
+	var i Review
+	err := row.Scan(
+		&i.ReviewID,
+		&i.MovieID,
+		&i.UserID,
+		&i.Rating,
+		&i.Body,
+		&i.FlaggedAt,
+		&i.HiddenAt,
+		&i.ModerationNote,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const refreshMovieRating = `-- name: RefreshMovieRating :exec
+UPDATE movies m
+SET rating_average = r.average,
+    rating_count = r.count
+FROM (
+  SELECT coalesce(round(avg(rating), 2), 0)::numeric(3,2) AS average,
+    count(*)::int AS count
+  FROM reviews
+  WHERE movie_id = $1 AND hidden_at IS NULL
+) r
+WHERE m.movie_id = $1
+`
+
+func (q *Queries) RefreshMovieRating(ctx context.Context, movieID int32) error {
+	_, err := q.db.Exec(ctx, refreshMovieRating, movieID)
+	return err
+}
+
+const updateReview = `-- name: UpdateReview :one
+UPDATE reviews
+SET rating = $2,
+    body = $3,
+    updated_at = now()
+WHERE review_id = $1
+RETURNING review_id, movie_id, user_id, rating, body, flagged_at, hidden_at, moderation_note, created_at, updated_at
+`
+
+type UpdateReviewParams struct {
+	ReviewID int64  `json:"review_id"`
+	Rating   int32  `json:"rating"`
+	Body     string `json:"body"`
+}
+
+func (q *Queries) UpdateReview(ctx context.Context, arg UpdateReviewParams) (Review, error) {
+	row := q.db.QueryRow(ctx, updateReview, arg.ReviewID, arg.Rating, arg.Body)
+	var i Review
+	err := row.Scan(
+		&i.ReviewID,
+		&i.MovieID,
+		&i.UserID,
+		&i.Rating,
+		&i.Body,
+		&i.FlaggedAt,
+		&i.HiddenAt,
+		&i.ModerationNote,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// reserves a seat for user on a showtime that already started
+func watchRandomMovie(t *testing.T, user User) Showtime {
+	showtime := createRandomShowtime(t)
+	seat := getRandomAvailableSeats(t, showtime.ShowtimeID, 1)[0]
+
+	_, err := testStore.ReserveSeat(context.Background(), ReserveSeatParams{
+		UserID:     pgtype.Int8{Int64: user.UserID, Valid: true},
+		ShowtimeID: showtime.ShowtimeID,
+		SeatID:     seat.SeatID,
+	})
+	require.NoError(t, err)
+
+	return showtime
+}
+
+func createRandomReview(t *testing.T, user User, movieID int32,
+	rating int32) Review {
+	arg := CreateReviewParams{
+		MovieID: movieID,
+		UserID:  user.UserID,
+		Rating:  rating,
+		Body:    "worth a watch",
+	}
+
+	review, err := testStore.CreateReviewTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.MovieID, review.MovieID)
+	require.Equal(t, arg.UserID, review.UserID)
+	require.Equal(t, arg.Rating, review.Rating)
+	require.Equal(t, arg.Body, review.Body)
+	require.NotZero(t, review.ReviewID)
+
+	return review
+}
+
+func requireMovieRating(t *testing.T, movieID int32, average float64,
+	count int32) {
+	movie, err := testStore.GetMovie(context.Background(), movieID)
+	require.NoError(t, err)
+
+	value, err := movie.RatingAverage.Float64Value()
+	require.NoError(t, err)
+	require.InDelta(t, average, value.Float64, 0.001)
+	require.Equal(t, count, movie.RatingCount)
+}
+
+func TestHasWatchedMovie(t *testing.T) {
+	user := createRandomUser(t)
+	showtime := watchRandomMovie(t, user)
+
+	watched, err := testStore.HasWatchedMovie(context.Background(),
+		HasWatchedMovieParams{UserID: user.UserID, MovieID: showtime.MovieID})
+	require.NoError(t, err)
+	require.True(t, watched)
+
+	other := createRandomUser(t)
+	watched, err = testStore.HasWatchedMovie(context.Background(),
+		HasWatchedMovieParams{UserID: other.UserID, MovieID: showtime.MovieID})
+	require.NoError(t, err)
+	require.False(t, watched)
+}
+
+func TestReviewRating(t *testing.T) {
+	movie := createRandomMovie(t)
+	user1 := createRandomUser(t)
+	user2 := createRandomUser(t)
+
+	review1 := createRandomReview(t, user1, movie.MovieID, 5)
+	requireMovieRating(t, movie.MovieID, 5, 1)
+
+	review2 := createRandomReview(t, user2, movie.MovieID, 2)
+	requireMovieRating(t, movie.MovieID, 3.5, 2)
+
+	// one review per user
+	_, err := testStore.CreateReviewTx(context.Background(), CreateReviewParams{
+		MovieID: movie.MovieID,
+		UserID:  user1.UserID,
+		Rating:  1,
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	_, err = testStore.UpdateReviewTx(context.Background(), UpdateReviewParams{
+		ReviewID: review2.ReviewID,
+		Rating:   4,
+	})
+	require.NoError(t, err)
+	requireMovieRating(t, movie.MovieID, 4.5, 2)
+
+	// hidden reviews don't count
+	hidden, err := testStore.ModerateReviewTx(context.Background(),
+		ModerateReviewParams{ReviewID: review1.ReviewID, Hidden: true})
+	require.NoError(t, err)
+	require.True(t, hidden.HiddenAt.Valid)
+	require.False(t, hidden.FlaggedAt.Valid)
+	requireMovieRating(t, movie.MovieID, 4, 1)
+
+	reviews, err := testStore.ListMovieReviews(context.Background(),
+		ListMovieReviewsParams{MovieID: movie.MovieID, RowLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	require.Equal(t, review2.ReviewID, reviews[0].ReviewID)
+	require.Equal(t, user2.Username, reviews[0].Username)
+
+	err = testStore.DeleteReviewTx(context.Background(), review2.ReviewID)
+	require.NoError(t, err)
+	requireMovieRating(t, movie.MovieID, 0, 0)
+
+	err = testStore.DeleteReviewTx(context.Background(), review2.ReviewID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestListModeratedReviews(t *testing.T) {
+	movie := createRandomMovie(t)
+	review := createRandomReview(t, createRandomUser(t), movie.MovieID, 1)
+
+	flagged, err := testStore.ModerateReviewTx(context.Background(),
+		ModerateReviewParams{ReviewID: review.ReviewID, Flagged: true})
+	require.NoError(t, err)
+	require.True(t, flagged.FlaggedAt.Valid)
+
+	// the flag is kept when it's set again
+	again, err := testStore.ModerateReviewTx(context.Background(),
+		ModerateReviewParams{ReviewID: review.ReviewID, Flagged: true})
+	require.NoError(t, err)
+	require.Equal(t, flagged.FlaggedAt.Time, again.FlaggedAt.Time)
+
+	reviews, err := testStore.ListModeratedReviews(context.Background(),
+		ListModeratedReviewsParams{
+			Flagged:  pgtype.Bool{Bool: true, Valid: true},
+			BeforeID: pgtype.Int8{Int64: review.ReviewID + 1, Valid: true},
+			RowLimit: 1,
+		})
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	require.Equal(t, review.ReviewID, reviews[0].ReviewID)
+
+	reviews, err = testStore.ListModeratedReviews(context.Background(),
+		ListModeratedReviewsParams{
+			Hidden:   pgtype.Bool{Bool: true, Valid: true},
+			BeforeID: pgtype.Int8{Int64: review.ReviewID + 1, Valid: true},
+			RowLimit: 1,
+		})
+	require.NoError(t, err)
+	for _, r := range reviews {
+		require.NotEqual(t, review.ReviewID, r.ReviewID)
+	}
+}
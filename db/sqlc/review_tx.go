@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+)
+
+// Adds a review and updates the movie's rating
+func (store *SQLStore) CreateReviewTx(ctx context.Context,
+	arg CreateReviewParams) (Review, error) {
+	var review Review
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		return withMovieRating(ctx, q, arg.MovieID, func() error {
+			var err error
+			review, err = q.CreateReview(ctx, arg)
+			return err
+		})
+	})
+
+	return review, err
+}
+
+// Changes the rating or text of a review and updates the movie's rating
+func (store *SQLStore) UpdateReviewTx(ctx context.Context,
+	arg UpdateReviewParams) (Review, error) {
+	var review Review
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetReview(ctx, arg.ReviewID)
+		if err != nil {
+			return err
+		}
+
+		return withMovieRating(ctx, q, before.MovieID, func() error {
+			review, err = q.UpdateReview(ctx, arg)
+			return err
+		})
+	})
+
+	return review, err
+}
+
+// Removes a review and updates the movie's rating
+func (store *SQLStore) DeleteReviewTx(ctx context.Context,
+	reviewID int64) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetReview(ctx, reviewID)
+		if err != nil {
+			return err
+		}
+
+		return withMovieRating(ctx, q, before.MovieID, func() error {
+			return q.DeleteReview(ctx, reviewID)
+		})
+	})
+}
+
+// Hides or flags a review, hidden reviews don't count toward the
+// movie's rating
+func (store *SQLStore) ModerateReviewTx(ctx context.Context,
+	arg ModerateReviewParams) (Review, error) {
+	var review Review
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		before, err := q.GetReview(ctx, arg.ReviewID)
+		if err != nil {
+			return err
+		}
+
+		return withMovieRating(ctx, q, before.MovieID, func() error {
+			review, err = q.ModerateReview(ctx, arg)
+			return err
+		})
+	})
+
+	return review, err
+}
+
+// runs fn with the movie locked, so concurrent reviews of the same movie
+// take turns and the rating always counts all of them
+func withMovieRating(ctx context.Context, q *Queries, movieID int32,
+	fn func() error) error {
+	err := q.LockMovie(ctx, movieID)
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	if err != nil {
+		return err
+	}
+
+	return q.RefreshMovieRating(ctx, movieID)
+}
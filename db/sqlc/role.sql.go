@@ -0,0 +1,158 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: role.sql
+
+package db
+
+import (
+	"context"
+)
+
+const addRolePermission = `-- name: AddRolePermission :exec
+INSERT INTO role_permissions (role, permission)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type AddRolePermissionParams struct {
+	Role       string `json:"role"`
+	Permission string `json:"permission"`
+}
+
+func (q *Queries) AddRolePermission(ctx context.Context, arg AddRolePermissionParams) error {
+	_, err := q.db.Exec(ctx, addRolePermission, arg.Role, arg.Permission)
+	return err
+}
+
+const countUsersWithRole = `-- name: CountUsersWithRole :one
+SELECT COUNT(*) FROM users
+WHERE role = $1
+`
+
+func (q *Queries) CountUsersWithRole(ctx context.Context, role string) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsersWithRole, role)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createRole = `-- name: CreateRole :one
+INSERT INTO roles (name, description)
+VALUES ($1, $2)
+RETURNING name, description, created_at
+`
+
+type CreateRoleParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (q *Queries) CreateRole(ctx context.Context, arg CreateRoleParams) (Role, error) {
+	row := q.db.QueryRow(ctx, createRole, arg.Name, arg.Description)
+	var i Role
+	err := row.Scan(&i.Name, &i.Description, &i.CreatedAt)
+	return i, err
+}
+
+const deleteRole = `-- name: DeleteRole :exec
+DELETE FROM roles
+WHERE name = $1
+`
+
+func (q *Queries) DeleteRole(ctx context.Context, name string) error {
+	_, err := q.db.Exec(ctx, deleteRole, name)
+	return err
+}
+
+const deleteRolePermissions = `-- name: DeleteRolePermissions :exec
+DELETE FROM role_permissions
+WHERE role = $1
+`
+
+func (q *Queries) DeleteRolePermissions(ctx context.Context, role string) error {
+	_, err := q.db.Exec(ctx, deleteRolePermissions, role)
+	return err
+}
+
+const getRole = `-- name: GetRole :one
+SELECT name, description, created_at FROM roles
+WHERE name = $1
+`
+
+func (q *Queries) GetRole(ctx context.Context, name string) (Role, error) {
+	row := q.db.QueryRow(ctx, getRole, name)
+	var i Role
+	err := row.Scan(&i.Name, &i.Description, &i.CreatedAt)
+	return i, err
+}
+
+const listRolePermissions = `-- name: ListRolePermissions :many
+SELECT permission FROM role_permissions
+WHERE role = $1
+ORDER BY permission
+`
+
+func (q *Queries) ListRolePermissions(ctx context.Context, role string) ([]string, error) {
+	rows, err := q.db.Query(ctx, listRolePermissions, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		items = append(items, permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRoles = `-- name: ListRoles :many
+SELECT name, description, created_at FROM roles
+ORDER BY name
+`
+
+func (q *Queries) ListRoles(ctx context.Context) ([]Role, error) {
+	rows, err := q.db.Query(ctx, listRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Role{}
+	for rows.Next() {
+		var i Role
+		if err := rows.Scan(&i.Name, &i.Description, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateRoleDescription = `-- name: UpdateRoleDescription :one
+UPDATE roles
+SET description = $2
+WHERE name = $1
+RETURNING name, description, created_at
+`
+
+type UpdateRoleDescriptionParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (q *Queries) UpdateRoleDescription(ctx context.Context, arg UpdateRoleDescriptionParams) (Role, error) {
+	row := q.db.QueryRow(ctx, updateRoleDescription, arg.Name, arg.Description)
+	var i Role
+	err := row.Scan(&i.Name, &i.Description, &i.CreatedAt)
+	return i, err
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomRole(t *testing.T) RoleTxResult {
+	arg := CreateRoleTxParams{
+		Name:        util.RandomString(8),
+		Description: util.RandomDescription(),
+		Permissions: []string{
+			util.ShowtimesWritePermission,
+			util.CheckinScanPermission,
+		},
+	}
+
+	result, err := testStore.CreateRoleTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, result)
+
+	require.Equal(t, arg.Name, result.Role.Name)
+	require.Equal(t, arg.Description, result.Role.Description)
+	require.ElementsMatch(t, arg.Permissions, result.Permissions)
+	require.NotZero(t, result.Role.CreatedAt)
+
+	return result
+}
+
+func TestCreateRoleTx(t *testing.T) {
+	createRandomRole(t)
+}
+
+func TestBuiltInRolePermissions(t *testing.T) {
+	permissions, err := testStore.ListRolePermissions(context.Background(),
+		util.AdminRole)
+	require.NoError(t, err)
+	require.ElementsMatch(t, util.Permissions, permissions)
+
+	permissions, err = testStore.ListRolePermissions(context.Background(),
+		util.CustomerRole)
+	require.NoError(t, err)
+	require.Equal(t, []string{util.ReservationsWritePermission}, permissions)
+}
+
+func TestUpdateRoleTx(t *testing.T) {
+	role := createRandomRole(t)
+
+	arg := UpdateRoleTxParams{
+		Name:        role.Role.Name,
+		Description: util.RandomDescription(),
+		Permissions: []string{util.MoviesWritePermission},
+	}
+
+	result, err := testStore.UpdateRoleTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.Description, result.Role.Description)
+	require.Equal(t, arg.Permissions, result.Permissions)
+}
+
+func TestDeleteRole(t *testing.T) {
+	role := createRandomRole(t)
+
+	err := testStore.DeleteRole(context.Background(), role.Role.Name)
+	require.NoError(t, err)
+
+	_, err = testStore.GetRole(context.Background(), role.Role.Name)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	permissions, err := testStore.ListRolePermissions(context.Background(),
+		role.Role.Name)
+	require.NoError(t, err)
+	require.Empty(t, permissions)
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	user := createRandomUser(t)
+	require.Equal(t, util.CustomerRole, user.Role)
+
+	updated, err := testStore.UpdateUserRole(context.Background(),
+		UpdateUserRoleParams{
+			UserID: user.UserID,
+			Role:   util.AdminRole,
+		})
+	require.NoError(t, err)
+	require.Equal(t, util.AdminRole, updated.Role)
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+)
+
+type CreateRoleTxParams struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleTxResult struct {
+	Role        Role     `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+// Creates a role together with its permission set
+func (store *SQLStore) CreateRoleTx(ctx context.Context,
+	arg CreateRoleTxParams) (RoleTxResult, error) {
+	var result RoleTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Role, err = q.CreateRole(ctx, CreateRoleParams{
+			Name:        arg.Name,
+			Description: arg.Description,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, permission := range arg.Permissions {
+			err = q.AddRolePermission(ctx, AddRolePermissionParams{
+				Role:       arg.Name,
+				Permission: permission,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Permissions, err = q.ListRolePermissions(ctx, arg.Name)
+		return err
+	})
+
+	return result, err
+}
+
+type UpdateRoleTxParams struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// Updates a role's description and replaces its whole permission set
+func (store *SQLStore) UpdateRoleTx(ctx context.Context,
+	arg UpdateRoleTxParams) (RoleTxResult, error) {
+	var result RoleTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Role, err = q.UpdateRoleDescription(ctx,
+			UpdateRoleDescriptionParams{
+				Name:        arg.Name,
+				Description: arg.Description,
+			})
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteRolePermissions(ctx, arg.Name)
+		if err != nil {
+			return err
+		}
+
+		for _, permission := range arg.Permissions {
+			err = q.AddRolePermission(ctx, AddRolePermissionParams{
+				Role:       arg.Name,
+				Permission: permission,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Permissions, err = q.ListRolePermissions(ctx, arg.Name)
+		return err
+	})
+
+	return result, err
+}
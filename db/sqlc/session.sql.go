@@ -12,6 +12,36 @@ import (
 	"github.com/google/uuid"
 )
 
+const blockSession = `-- name: BlockSession :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1 AND username = $2
+`
+
+type BlockSessionParams struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+}
+
+func (q *Queries) BlockSession(ctx context.Context, arg BlockSessionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, blockSession, arg.ID, arg.Username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const blockUserSessions = `-- name: BlockUserSessions :exec
+UPDATE sessions
+SET is_blocked = true
+WHERE username = $1
+`
+
+func (q *Queries) BlockUserSessions(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, blockUserSessions, username)
+	return err
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO sessions (
   id, username, refresh_token, user_agent, client_ip, is_blocked, expired_at
@@ -55,6 +85,16 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 	return i, err
 }
 
+const deleteUserSessions = `-- name: DeleteUserSessions :exec
+DELETE FROM sessions
+WHERE username = $1
+`
+
+func (q *Queries) DeleteUserSessions(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, deleteUserSessions, username)
+	return err
+}
+
 const getSessionByID = `-- name: GetSessionByID :one
 SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expired_at, created_at FROM sessions
 WHERE id = $1 LIMIT 1
@@ -75,3 +115,38 @@ func (q *Queries) GetSessionByID(ctx context.Context, id uuid.UUID) (Session, er
 	)
 	return i, err
 }
+
+const listSessionsByUsername = `-- name: ListSessionsByUsername :many
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expired_at, created_at FROM sessions
+WHERE username = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListSessionsByUsername(ctx context.Context, username string) ([]Session, error) {
+	rows, err := q.db.Query(ctx, listSessionsByUsername, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RefreshToken,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.IsBlocked,
+			&i.ExpiredAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomSession(t *testing.T, user User) Session {
+	arg := CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    "test",
+		ClientIp:     "127.0.0.1",
+		ExpiredAt:    time.Now().Add(time.Hour),
+	}
+
+	session, err := testStore.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, session.ID)
+	require.False(t, session.IsBlocked)
+
+	return session
+}
+
+func TestBlockSession(t *testing.T) {
+	user := createRandomUser(t)
+	other := createRandomUser(t)
+	session := createRandomSession(t, user)
+
+	// only the owner can block a session
+	rows, err := testStore.BlockSession(context.Background(),
+		BlockSessionParams{ID: session.ID, Username: other.Username})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testStore.BlockSession(context.Background(),
+		BlockSessionParams{ID: session.ID, Username: user.Username})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	blocked, err := testStore.GetSessionByID(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+}
@@ -13,19 +13,25 @@ import (
 )
 
 const createShowtime = `-- name: CreateShowtime :one
-INSERT INTO showtimes (movie_id, start_time, price)
-VALUES ($1, $2, $3)
-RETURNING showtime_id, movie_id, start_time, price, created_at
+INSERT INTO showtimes (movie_id, start_time, end_time, price)
+VALUES ($1, $2, $3, $4)
+RETURNING showtime_id, movie_id, start_time, price, created_at, end_time
 `
 
 type CreateShowtimeParams struct {
 	MovieID   int32            `json:"movie_id"`
 	StartTime pgtype.Timestamp `json:"start_time"`
+	EndTime   pgtype.Timestamp `json:"end_time"`
 	Price     pgtype.Numeric   `json:"price"`
 }
 
 func (q *Queries) CreateShowtime(ctx context.Context, arg CreateShowtimeParams) (Showtime, error) {
-	row := q.db.QueryRow(ctx, createShowtime, arg.MovieID, arg.StartTime, arg.Price)
+	row := q.db.QueryRow(ctx, createShowtime,
+		arg.MovieID,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Price,
+	)
 	var i Showtime
 	err := row.Scan(
 		&i.ShowtimeID,
@@ -33,6 +39,7 @@ func (q *Queries) CreateShowtime(ctx context.Context, arg CreateShowtimeParams)
 		&i.StartTime,
 		&i.Price,
 		&i.CreatedAt,
+		&i.EndTime,
 	)
 	return i, err
 }
@@ -48,7 +55,7 @@ func (q *Queries) DeleteShowtime(ctx context.Context, showtimeID int32) error {
 }
 
 const getShowtime = `-- name: GetShowtime :one
-SELECT showtime_id, movie_id, start_time, price, created_at FROM showtimes
+SELECT showtime_id, movie_id, start_time, price, created_at, end_time FROM showtimes
 WHERE showtime_id = $1
 `
 
@@ -61,27 +68,39 @@ func (q *Queries) GetShowtime(ctx context.Context, showtimeID int32) (Showtime,
 		&i.StartTime,
 		&i.Price,
 		&i.CreatedAt,
+		&i.EndTime,
 	)
 	return i, err
 }
 
 const listShowtimesBetween = `-- name: ListShowtimesBetween :many
-SELECT s.showtime_id, s.movie_id, s.start_time, s.price, s.created_at, m.title, m.poster_url
+SELECT s.showtime_id, s.movie_id, s.start_time, s.end_time, s.price, s.created_at, m.title, m.poster_url
 FROM showtimes s
 JOIN movies m ON m.movie_id = s.movie_id
-WHERE s.start_time >= $1 AND s.start_time < $2
-ORDER BY s.start_time
+WHERE s.start_time >= $1::timestamp
+  AND s.start_time < $2::timestamp
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+  AND ($3::int IS NULL
+    OR (s.start_time, s.showtime_id) >
+      ($4::timestamp, $3::int))
+ORDER BY s.start_time, s.showtime_id
+LIMIT $5
 `
 
 type ListShowtimesBetweenParams struct {
-	StartTime   pgtype.Timestamp `json:"start_time"`
-	StartTime_2 pgtype.Timestamp `json:"start_time_2"`
+	StartsFrom     pgtype.Timestamp `json:"starts_from"`
+	StartsBefore   pgtype.Timestamp `json:"starts_before"`
+	AfterID        pgtype.Int4      `json:"after_id"`
+	AfterStartTime pgtype.Timestamp `json:"after_start_time"`
+	RowLimit       int32            `json:"row_limit"`
 }
 
 type ListShowtimesBetweenRow struct {
 	ShowtimeID int32            `json:"showtime_id"`
 	MovieID    int32            `json:"movie_id"`
 	StartTime  pgtype.Timestamp `json:"start_time"`
+	EndTime    pgtype.Timestamp `json:"end_time"`
 	Price      pgtype.Numeric   `json:"price"`
 	CreatedAt  time.Time        `json:"created_at"`
 	Title      string           `json:"title"`
@@ -89,7 +108,13 @@ type ListShowtimesBetweenRow struct {
 }
 
 func (q *Queries) ListShowtimesBetween(ctx context.Context, arg ListShowtimesBetweenParams) ([]ListShowtimesBetweenRow, error) {
-	rows, err := q.db.Query(ctx, listShowtimesBetween, arg.StartTime, arg.StartTime_2)
+	rows, err := q.db.Query(ctx, listShowtimesBetween,
+		arg.StartsFrom,
+		arg.StartsBefore,
+		arg.AfterID,
+		arg.AfterStartTime,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -101,6 +126,7 @@ func (q *Queries) ListShowtimesBetween(ctx context.Context, arg ListShowtimesBet
 			&i.ShowtimeID,
 			&i.MovieID,
 			&i.StartTime,
+			&i.EndTime,
 			&i.Price,
 			&i.CreatedAt,
 			&i.Title,
@@ -117,10 +143,12 @@ func (q *Queries) ListShowtimesBetween(ctx context.Context, arg ListShowtimesBet
 }
 
 const listShowtimesByDate = `-- name: ListShowtimesByDate :many
-SELECT s.showtime_id, s.movie_id, s.start_time, s.price, s.created_at, m.title, m.poster_url
+SELECT s.showtime_id, s.movie_id, s.start_time, s.end_time, s.price, s.created_at, m.title, m.poster_url
 FROM showtimes s
 JOIN movies m ON m.movie_id = s.movie_id
 WHERE s.start_time >= $1
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
 ORDER BY s.start_time
 `
 
@@ -128,6 +156,7 @@ type ListShowtimesByDateRow struct {
 	ShowtimeID int32            `json:"showtime_id"`
 	MovieID    int32            `json:"movie_id"`
 	StartTime  pgtype.Timestamp `json:"start_time"`
+	EndTime    pgtype.Timestamp `json:"end_time"`
 	Price      pgtype.Numeric   `json:"price"`
 	CreatedAt  time.Time        `json:"created_at"`
 	Title      string           `json:"title"`
@@ -147,6 +176,7 @@ func (q *Queries) ListShowtimesByDate(ctx context.Context, startTime pgtype.Time
 			&i.ShowtimeID,
 			&i.MovieID,
 			&i.StartTime,
+			&i.EndTime,
 			&i.Price,
 			&i.CreatedAt,
 			&i.Title,
@@ -161,3 +191,38 @@ func (q *Queries) ListShowtimesByDate(ctx context.Context, startTime pgtype.Time
 	}
 	return items, nil
 }
+
+const listUpcomingShowtimesForMovies = `-- name: ListUpcomingShowtimesForMovies :many
+SELECT showtime_id, movie_id, start_time, price, created_at, end_time FROM showtimes
+WHERE movie_id = ANY($1::int[])
+  AND start_time > (now() AT TIME ZONE 'UTC')
+ORDER BY movie_id, start_time, showtime_id
+`
+
+// start_time has no time zone and holds UTC
+func (q *Queries) ListUpcomingShowtimesForMovies(ctx context.Context, movieIds []int32) ([]Showtime, error) {
+	rows, err := q.db.Query(ctx, listUpcomingShowtimesForMovies, movieIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Showtime{}
+	for rows.Next() {
+		var i Showtime
+		if err := rows.Scan(
+			&i.ShowtimeID,
+			&i.MovieID,
+			&i.StartTime,
+			&i.Price,
+			&i.CreatedAt,
+			&i.EndTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
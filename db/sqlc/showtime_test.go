@@ -23,7 +23,11 @@ func createRandomShowtime(t *testing.T) Showtime {
 	arg := CreateShowtimeParams{
 		MovieID:   movie.MovieID,
 		StartTime: startTime,
-		Price:     util.RandomPrice(),
+		EndTime: pgtype.Timestamp{
+			Time:  startTime.Time.Add(time.Duration(movie.RuntimeMinutes) * time.Minute),
+			Valid: true,
+		},
+		Price: util.RandomPrice(),
 	}
 
 	showtime, err := testStore.CreateShowtime(context.Background(), arg)
@@ -32,6 +36,7 @@ func createRandomShowtime(t *testing.T) Showtime {
 
 	require.Equal(t, arg.MovieID, showtime.MovieID)
 	require.Equal(t, arg.Price, showtime.Price)
+	require.WithinDuration(t, arg.EndTime.Time, showtime.EndTime.Time, time.Second)
 
 	require.NotZero(t, showtime.ShowtimeID)
 	require.NotZero(t, showtime.CreatedAt)
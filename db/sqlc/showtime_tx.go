@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+type CreateShowtimeTxResult struct {
+	Showtime Showtime `json:"showtime"`
+	// watchers alerted that tickets went on sale
+	Notified int64 `json:"notified"`
+}
+
+// Creates a showtime for a published movie, when it's upcoming and the
+// only upcoming one of its movie the movie's watchers are queued an
+// alert. The movie row stays locked until commit so two new showtimes
+// can't both count as the first and the movie can't be unpublished
+// meanwhile
+func (store *SQLStore) CreateShowtimeTx(ctx context.Context,
+	arg CreateShowtimeParams) (CreateShowtimeTxResult, error) {
+	var result CreateShowtimeTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		movie, err := q.GetMovieForUpdate(ctx, arg.MovieID)
+		if err != nil {
+			return err
+		}
+
+		if !IsPublicMovieStatus(movie.Status) {
+			return ErrMovieNotPublic
+		}
+
+		upcoming, err := q.CountUpcomingShowtimesForMovie(ctx, arg.MovieID)
+		if err != nil {
+			return err
+		}
+
+		result.Showtime, err = q.CreateShowtime(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		// a showtime that already started doesn't put anything on sale
+		if upcoming > 0 || !arg.StartTime.Time.After(time.Now().UTC()) {
+			return nil
+		}
+
+		result.Notified, err = q.EnqueueWatchlistNotifications(ctx, arg.MovieID)
+		return err
+	})
+
+	return result, err
+}
@@ -14,10 +14,42 @@ type Store interface {
 	) (ReserveMultipleSeatsTxResult, error)
 	CancelReservationTx(ctx context.Context,
 		arg CancelReservationParams) error
+	CreateRoleTx(ctx context.Context,
+		arg CreateRoleTxParams) (RoleTxResult, error)
+	UpdateRoleTx(ctx context.Context,
+		arg UpdateRoleTxParams) (RoleTxResult, error)
+	EnableTOTPTx(ctx context.Context,
+		arg EnableTOTPTxParams) (User, error)
+	DisableTOTPTx(ctx context.Context, userID int64) (User, error)
+	CreateUserWithIdentityTx(ctx context.Context,
+		arg CreateUserWithIdentityTxParams) (User, error)
+	DisableUserTx(ctx context.Context, userID int64) (User, error)
+	RequestUserDeletionTx(ctx context.Context, userID int64) (User, error)
+	AnonymizeUserTx(ctx context.Context, userID int64) (User, error)
+	ReserveGuestSeatsTx(ctx context.Context,
+		arg ReserveGuestSeatsTxParams) (ReserveGuestSeatsTxResult, error)
+	VerifyEmailTx(ctx context.Context,
+		arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	ClaimGuestBookingsTx(ctx context.Context,
+		arg ClaimGuestBookingsParams) ([]GuestBooking, error)
+	CreateMovieTx(ctx context.Context,
+		arg CreateMovieTxParams) (MovieTxResult, error)
+	UpdateMovieTx(ctx context.Context,
+		arg UpdateMovieTxParams) (MovieTxResult, error)
+	DeleteMovieTx(ctx context.Context, movieID int32) (Movie, error)
+	CreateReviewTx(ctx context.Context,
+		arg CreateReviewParams) (Review, error)
+	UpdateReviewTx(ctx context.Context,
+		arg UpdateReviewParams) (Review, error)
+	DeleteReviewTx(ctx context.Context, reviewID int64) error
+	ModerateReviewTx(ctx context.Context,
+		arg ModerateReviewParams) (Review, error)
+	CreateShowtimeTx(ctx context.Context,
+		arg CreateShowtimeParams) (CreateShowtimeTxResult, error)
+	RefreshUserRecommendationsTx(ctx context.Context,
+		arg InsertUserRecommendationsParams) (int64, error)
 	// CreateUserTx(ctx context.Context,
 	// 	arg CreateUserTxParams) (CreateUserTxResults, error)
-	// VerifyEmailTx(ctx context.Context,
-	// 	arg VerifyEmailTxParams) (VerifyEmailTxResults, error)
 }
 
 // SQLStore provides all funcs for SQL queries and transactions
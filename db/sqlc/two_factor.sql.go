@@ -0,0 +1,262 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: two_factor.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createLoginChallenge = `-- name: CreateLoginChallenge :one
+INSERT INTO login_challenges (id, user_id, expired_at)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, attempts, expired_at, created_at
+`
+
+type CreateLoginChallengeParams struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    int64     `json:"user_id"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+func (q *Queries) CreateLoginChallenge(ctx context.Context, arg CreateLoginChallengeParams) (LoginChallenge, error) {
+	row := q.db.QueryRow(ctx, createLoginChallenge, arg.ID, arg.UserID, arg.ExpiredAt)
+	var i LoginChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Attempts,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, hashed_code)
+VALUES ($1, $2)
+`
+
+type CreateRecoveryCodeParams struct {
+	UserID     int64  `json:"user_id"`
+	HashedCode string `json:"hashed_code"`
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.db.Exec(ctx, createRecoveryCode, arg.UserID, arg.HashedCode)
+	return err
+}
+
+const deleteLoginChallenge = `-- name: DeleteLoginChallenge :exec
+DELETE FROM login_challenges
+WHERE id = $1
+`
+
+func (q *Queries) DeleteLoginChallenge(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteLoginChallenge, id)
+	return err
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteRecoveryCodes, userID)
+	return err
+}
+
+const deleteUserLoginChallenges = `-- name: DeleteUserLoginChallenges :exec
+DELETE FROM login_challenges
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserLoginChallenges(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteUserLoginChallenges, userID)
+	return err
+}
+
+const disableUserTOTP = `-- name: DisableUserTOTP :one
+UPDATE users
+SET totp_secret = '',
+    totp_enabled = false
+WHERE user_id = $1
+RETURNING user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step
+`
+
+func (q *Queries) DisableUserTOTP(ctx context.Context, userID int64) (User, error) {
+	row := q.db.QueryRow(ctx, disableUserTOTP, userID)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Username,
+		&i.Name,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Role,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DisabledAt,
+		&i.DeletionRequestedAt,
+		&i.AnonymizedAt,
+		&i.IsEmailVerified,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :one
+UPDATE users
+SET totp_enabled = true
+WHERE user_id = $1 AND totp_secret <> ''
+RETURNING user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step
+`
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, userID int64) (User, error) {
+	row := q.db.QueryRow(ctx, enableUserTOTP, userID)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Username,
+		&i.Name,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Role,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DisabledAt,
+		&i.DeletionRequestedAt,
+		&i.AnonymizedAt,
+		&i.IsEmailVerified,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getLoginChallenge = `-- name: GetLoginChallenge :one
+SELECT id, user_id, attempts, expired_at, created_at FROM login_challenges
+WHERE id = $1
+`
+
+func (q *Queries) GetLoginChallenge(ctx context.Context, id uuid.UUID) (LoginChallenge, error) {
+	row := q.db.QueryRow(ctx, getLoginChallenge, id)
+	var i LoginChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Attempts,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const incrementLoginChallengeAttempts = `-- name: IncrementLoginChallengeAttempts :one
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING id, user_id, attempts, expired_at, created_at
+`
+
+func (q *Queries) IncrementLoginChallengeAttempts(ctx context.Context, id uuid.UUID) (LoginChallenge, error) {
+	row := q.db.QueryRow(ctx, incrementLoginChallengeAttempts, id)
+	var i LoginChallenge
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Attempts,
+		&i.ExpiredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :one
+UPDATE users
+SET totp_secret = $2,
+    totp_enabled = false
+WHERE user_id = $1
+RETURNING user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step
+`
+
+type SetUserTOTPSecretParams struct {
+	UserID     int64  `json:"user_id"`
+	TotpSecret string `json:"totp_secret"`
+}
+
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserTOTPSecret, arg.UserID, arg.TotpSecret)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Username,
+		&i.Name,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Role,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DisabledAt,
+		&i.DeletionRequestedAt,
+		&i.AnonymizedAt,
+		&i.IsEmailVerified,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET used_at = now()
+WHERE user_id = $1
+  AND hashed_code = $2
+  AND used_at IS NULL
+RETURNING id, user_id, hashed_code, used_at, created_at
+`
+
+type UseRecoveryCodeParams struct {
+	UserID     int64  `json:"user_id"`
+	HashedCode string `json:"hashed_code"`
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error) {
+	row := q.db.QueryRow(ctx, useRecoveryCode, arg.UserID, arg.HashedCode)
+	var i RecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.HashedCode,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE users
+SET totp_last_step = $1
+WHERE user_id = $2 AND totp_last_step < $1
+`
+
+type UseTOTPStepParams struct {
+	Step   int64 `json:"step"`
+	UserID int64 `json:"user_id"`
+}
+
+// steps only move forward, so a code can't be replayed within its period
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.db.Exec(ctx, useTOTPStep, arg.Step, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableTOTPTx(t *testing.T) {
+	user := createRandomUser(t)
+	require.False(t, user.TotpEnabled)
+
+	// can't enable without a secret
+	_, err := testStore.EnableTOTPTx(context.Background(),
+		EnableTOTPTxParams{UserID: user.UserID})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	secret, err := util.GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	user, err = testStore.SetUserTOTPSecret(context.Background(),
+		SetUserTOTPSecretParams{UserID: user.UserID, TotpSecret: secret})
+	require.NoError(t, err)
+	require.Equal(t, secret, user.TotpSecret)
+	require.False(t, user.TotpEnabled)
+
+	hashedCode := util.RandomString(64)
+	user, err = testStore.EnableTOTPTx(context.Background(),
+		EnableTOTPTxParams{
+			UserID:              user.UserID,
+			HashedRecoveryCodes: []string{hashedCode},
+		})
+	require.NoError(t, err)
+	require.True(t, user.TotpEnabled)
+
+	arg := UseRecoveryCodeParams{UserID: user.UserID, HashedCode: hashedCode}
+	code, err := testStore.UseRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, code.UsedAt.Valid)
+
+	// recovery codes work only once
+	_, err = testStore.UseRecoveryCode(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	user, err = testStore.DisableTOTPTx(context.Background(), user.UserID)
+	require.NoError(t, err)
+	require.False(t, user.TotpEnabled)
+	require.Empty(t, user.TotpSecret)
+}
+
+func TestUseTOTPStep(t *testing.T) {
+	user := createRandomUser(t)
+	step := time.Now().Unix() / 30
+
+	used, err := testStore.UseTOTPStep(context.Background(),
+		UseTOTPStepParams{UserID: user.UserID, Step: step})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), used)
+
+	// the same code, or one from an earlier period, is rejected
+	for _, replayed := range []int64{step, step - 1} {
+		used, err = testStore.UseTOTPStep(context.Background(),
+			UseTOTPStepParams{UserID: user.UserID, Step: replayed})
+		require.NoError(t, err)
+		require.Zero(t, used)
+	}
+
+	used, err = testStore.UseTOTPStep(context.Background(),
+		UseTOTPStepParams{UserID: user.UserID, Step: step + 1})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), used)
+}
+
+func TestLoginChallenge(t *testing.T) {
+	user := createRandomUser(t)
+
+	challenge, err := testStore.CreateLoginChallenge(context.Background(),
+		CreateLoginChallengeParams{
+			ID:        uuid.New(),
+			UserID:    user.UserID,
+			ExpiredAt: time.Now().Add(time.Minute),
+		})
+	require.NoError(t, err)
+	require.Zero(t, challenge.Attempts)
+
+	challenge, err = testStore.IncrementLoginChallengeAttempts(
+		context.Background(), challenge.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), challenge.Attempts)
+
+	err = testStore.DeleteLoginChallenge(context.Background(), challenge.ID)
+	require.NoError(t, err)
+
+	_, err = testStore.GetLoginChallenge(context.Background(), challenge.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+)
+
+type EnableTOTPTxParams struct {
+	UserID              int64    `json:"user_id"`
+	HashedRecoveryCodes []string `json:"hashed_recovery_codes"`
+}
+
+// Turns on two factor authentication for a user that already has a
+// TOTP secret, replacing any recovery codes they had before
+func (store *SQLStore) EnableTOTPTx(ctx context.Context,
+	arg EnableTOTPTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.EnableUserTOTP(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteRecoveryCodes(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		for _, hashedCode := range arg.HashedRecoveryCodes {
+			err = q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				UserID:     arg.UserID,
+				HashedCode: hashedCode,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return user, err
+}
+
+// Turns off two factor authentication and drops the recovery codes
+func (store *SQLStore) DisableTOTPTx(ctx context.Context,
+	userID int64) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.DisableUserTOTP(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteRecoveryCodes(ctx, userID)
+	})
+
+	return user, err
+}
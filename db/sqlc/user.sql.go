@@ -7,12 +7,77 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const anonymizeUser = `-- name: AnonymizeUser :one
+UPDATE users
+SET username = 'deleted-' || user_id,
+    name = 'Deleted user',
+    email = 'deleted-' || user_id || '@anonymized.invalid',
+    hashed_password = '',
+    totp_secret = '',
+    totp_enabled = false,
+    anonymized_at = now()
+WHERE user_id = $1 AND anonymized_at IS NULL
+RETURNING user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step
+`
+
+func (q *Queries) AnonymizeUser(ctx context.Context, userID int64) (User, error) {
+	row := q.db.QueryRow(ctx, anonymizeUser, userID)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Username,
+		&i.Name,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Role,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DisabledAt,
+		&i.DeletionRequestedAt,
+		&i.AnonymizedAt,
+		&i.IsEmailVerified,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const createAccountConfirmation = `-- name: CreateAccountConfirmation :one
+INSERT INTO account_confirmations (user_id, purpose, secret_code)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, purpose, secret_code, is_used, created_at, expired_at
+`
+
+type CreateAccountConfirmationParams struct {
+	UserID     int64  `json:"user_id"`
+	Purpose    string `json:"purpose"`
+	SecretCode string `json:"secret_code"`
+}
+
+func (q *Queries) CreateAccountConfirmation(ctx context.Context, arg CreateAccountConfirmationParams) (AccountConfirmation, error) {
+	row := q.db.QueryRow(ctx, createAccountConfirmation, arg.UserID, arg.Purpose, arg.SecretCode)
+	var i AccountConfirmation
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Purpose,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (name, username, email, hashed_password)
 VALUES ($1, $2, $3, $4)
-RETURNING user_id, username, name, email, hashed_password, role, created_at
+RETURNING user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step
 `
 
 type CreateUserParams struct {
@@ -38,12 +103,88 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.HashedPassword,
 		&i.Role,
 		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DisabledAt,
+		&i.DeletionRequestedAt,
+		&i.AnonymizedAt,
+		&i.IsEmailVerified,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const deleteAccountConfirmations = `-- name: DeleteAccountConfirmations :exec
+DELETE FROM account_confirmations
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteAccountConfirmations(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteAccountConfirmations, userID)
+	return err
+}
+
+const disableUser = `-- name: DisableUser :one
+UPDATE users
+SET disabled_at = COALESCE(disabled_at, now())
+WHERE user_id = $1
+RETURNING user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step
+`
+
+func (q *Queries) DisableUser(ctx context.Context, userID int64) (User, error) {
+	row := q.db.QueryRow(ctx, disableUser, userID)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Username,
+		&i.Name,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Role,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DisabledAt,
+		&i.DeletionRequestedAt,
+		&i.AnonymizedAt,
+		&i.IsEmailVerified,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const enableUser = `-- name: EnableUser :one
+UPDATE users
+SET disabled_at = NULL,
+    deletion_requested_at = NULL
+WHERE user_id = $1 AND anonymized_at IS NULL
+RETURNING user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step
+`
+
+func (q *Queries) EnableUser(ctx context.Context, userID int64) (User, error) {
+	row := q.db.QueryRow(ctx, enableUser, userID)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Username,
+		&i.Name,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Role,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DisabledAt,
+		&i.DeletionRequestedAt,
+		&i.AnonymizedAt,
+		&i.IsEmailVerified,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT user_id, username, name, email, hashed_password, role, created_at FROM users
+SELECT user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step FROM users
 WHERE email = $1
 `
 
@@ -58,12 +199,19 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.HashedPassword,
 		&i.Role,
 		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DisabledAt,
+		&i.DeletionRequestedAt,
+		&i.AnonymizedAt,
+		&i.IsEmailVerified,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT user_id, username, name, email, hashed_password, role, created_at FROM users
+SELECT user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step FROM users
 WHERE user_id = $1
 `
 
@@ -78,6 +226,271 @@ func (q *Queries) GetUserByID(ctx context.Context, userID int64) (User, error) {
 		&i.HashedPassword,
 		&i.Role,
 		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DisabledAt,
+		&i.DeletionRequestedAt,
+		&i.AnonymizedAt,
+		&i.IsEmailVerified,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step FROM users
+WHERE ($1::text IS NULL
+    OR username ILIKE '%' || $1 || '%'
+    OR name ILIKE '%' || $1 || '%'
+    OR email ILIKE '%' || $1 || '%')
+  AND ($2::varchar IS NULL OR role = $2)
+  AND ($3::bigint IS NULL
+    OR user_id > $3::bigint)
+ORDER BY user_id
+LIMIT $4
+`
+
+type ListUsersParams struct {
+	Search   pgtype.Text `json:"search"`
+	Role     pgtype.Text `json:"role"`
+	AfterID  pgtype.Int8 `json:"after_id"`
+	RowLimit int32       `json:"row_limit"`
+}
+
+// search comes with its LIKE wildcards escaped
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsers,
+		arg.Search,
+		arg.Role,
+		arg.AfterID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Username,
+			&i.Name,
+			&i.Email,
+			&i.HashedPassword,
+			&i.Role,
+			&i.CreatedAt,
+			&i.TotpSecret,
+			&i.TotpEnabled,
+			&i.DisabledAt,
+			&i.DeletionRequestedAt,
+			&i.AnonymizedAt,
+			&i.IsEmailVerified,
+			&i.TotpLastStep,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersDueForAnonymization = `-- name: ListUsersDueForAnonymization :many
+SELECT user_id FROM users
+WHERE deletion_requested_at <= $1::timestamptz
+  AND anonymized_at IS NULL
+ORDER BY user_id
+LIMIT $2
+`
+
+type ListUsersDueForAnonymizationParams struct {
+	RequestedBefore time.Time `json:"requested_before"`
+	RowLimit        int32     `json:"row_limit"`
+}
+
+func (q *Queries) ListUsersDueForAnonymization(ctx context.Context, arg ListUsersDueForAnonymizationParams) ([]int64, error) {
+	rows, err := q.db.Query(ctx, listUsersDueForAnonymization, arg.RequestedBefore, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var user_id int64
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const requestUserDeletion = `-- name: RequestUserDeletion :one
+UPDATE users
+SET deletion_requested_at = COALESCE(deletion_requested_at, now()),
+    disabled_at = COALESCE(disabled_at, now())
+WHERE user_id = $1 AND anonymized_at IS NULL
+RETURNING user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step
+`
+
+func (q *Queries) RequestUserDeletion(ctx context.Context, userID int64) (User, error) {
+	row := q.db.QueryRow(ctx, requestUserDeletion, userID)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Username,
+		&i.Name,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Role,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DisabledAt,
+		&i.DeletionRequestedAt,
+		&i.AnonymizedAt,
+		&i.IsEmailVerified,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const setUserEmailVerified = `-- name: SetUserEmailVerified :one
+UPDATE users
+SET is_email_verified = true
+WHERE user_id = $1 AND email = $2
+RETURNING user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step
+`
+
+type SetUserEmailVerifiedParams struct {
+	UserID int64  `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+func (q *Queries) SetUserEmailVerified(ctx context.Context, arg SetUserEmailVerifiedParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserEmailVerified, arg.UserID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Username,
+		&i.Name,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Role,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DisabledAt,
+		&i.DeletionRequestedAt,
+		&i.AnonymizedAt,
+		&i.IsEmailVerified,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2
+WHERE user_id = $1
+RETURNING user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step
+`
+
+type UpdateUserPasswordParams struct {
+	UserID         int64  `json:"user_id"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserPassword, arg.UserID, arg.HashedPassword)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Username,
+		&i.Name,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Role,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DisabledAt,
+		&i.DeletionRequestedAt,
+		&i.AnonymizedAt,
+		&i.IsEmailVerified,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE user_id = $1
+RETURNING user_id, username, name, email, hashed_password, role, created_at, totp_secret, totp_enabled, disabled_at, deletion_requested_at, anonymized_at, is_email_verified, totp_last_step
+`
+
+type UpdateUserRoleParams struct {
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.UserID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.UserID,
+		&i.Username,
+		&i.Name,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Role,
+		&i.CreatedAt,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.DisabledAt,
+		&i.DeletionRequestedAt,
+		&i.AnonymizedAt,
+		&i.IsEmailVerified,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const useAccountConfirmation = `-- name: UseAccountConfirmation :one
+UPDATE account_confirmations
+SET is_used = true
+WHERE user_id = $1
+  AND purpose = $2
+  AND secret_code = $3
+  AND is_used = false
+  AND expired_at > now()
+RETURNING id, user_id, purpose, secret_code, is_used, created_at, expired_at
+`
+
+type UseAccountConfirmationParams struct {
+	UserID     int64  `json:"user_id"`
+	Purpose    string `json:"purpose"`
+	SecretCode string `json:"secret_code"`
+}
+
+func (q *Queries) UseAccountConfirmation(ctx context.Context, arg UseAccountConfirmationParams) (AccountConfirmation, error) {
+	row := q.db.QueryRow(ctx, useAccountConfirmation, arg.UserID, arg.Purpose, arg.SecretCode)
+	var i AccountConfirmation
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Purpose,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
 	)
 	return i, err
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/kratos69/movie-app/util"
 	"github.com/stretchr/testify/require"
 )
@@ -60,3 +61,165 @@ func TestGetUserByID(t *testing.T) {
 // 	require.Equal(t, user1.Email, user2.Email)
 // 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 // }
+
+func TestUpdateUserPassword(t *testing.T) {
+	user := createRandomUser(t)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	updated, err := testStore.UpdateUserPassword(context.Background(),
+		UpdateUserPasswordParams{
+			UserID:         user.UserID,
+			HashedPassword: hashedPassword,
+		})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, updated.HashedPassword)
+	require.Equal(t, user.Email, updated.Email)
+}
+
+func TestListUsers(t *testing.T) {
+	user := createRandomUser(t)
+
+	users, err := testStore.ListUsers(context.Background(), ListUsersParams{
+		Search:   pgtype.Text{String: user.Email, Valid: true},
+		Role:     pgtype.Text{String: util.CustomerRole, Valid: true},
+		RowLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, user.UserID, users[0].UserID)
+
+	users, err = testStore.ListUsers(context.Background(), ListUsersParams{
+		Search:   pgtype.Text{String: user.Email, Valid: true},
+		Role:     pgtype.Text{String: util.AdminRole, Valid: true},
+		RowLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, users)
+
+	users, err = testStore.ListUsers(context.Background(), ListUsersParams{
+		RowLimit: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+}
+
+func TestDisableUserTx(t *testing.T) {
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+
+	disabled, err := testStore.DisableUserTx(context.Background(), user.UserID)
+	require.NoError(t, err)
+	require.True(t, disabled.DisabledAt.Valid)
+
+	blocked, err := testStore.GetSessionByID(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	// disabling again keeps the original time
+	again, err := testStore.DisableUserTx(context.Background(), user.UserID)
+	require.NoError(t, err)
+	require.Equal(t, disabled.DisabledAt.Time, again.DisabledAt.Time)
+
+	enabled, err := testStore.EnableUser(context.Background(), user.UserID)
+	require.NoError(t, err)
+	require.False(t, enabled.DisabledAt.Valid)
+}
+
+func TestRequestUserDeletionTx(t *testing.T) {
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+
+	requested, err := testStore.RequestUserDeletionTx(context.Background(),
+		user.UserID)
+	require.NoError(t, err)
+	require.True(t, requested.DeletionRequestedAt.Valid)
+	require.True(t, requested.DisabledAt.Valid)
+
+	blocked, err := testStore.GetSessionByID(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	due, err := testStore.ListUsersDueForAnonymization(context.Background(),
+		ListUsersDueForAnonymizationParams{
+			RequestedBefore: time.Now().Add(time.Minute),
+			RowLimit:        1000,
+		})
+	require.NoError(t, err)
+	require.Contains(t, due, user.UserID)
+
+	// enabling the account cancels the pending deletion
+	enabled, err := testStore.EnableUser(context.Background(), user.UserID)
+	require.NoError(t, err)
+	require.False(t, enabled.DeletionRequestedAt.Valid)
+	require.False(t, enabled.DisabledAt.Valid)
+}
+
+func TestAnonymizeUserTx(t *testing.T) {
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+	key := createRandomAPIKey(t, user)
+
+	showtime := createRandomShowtime(t)
+	seats := getRandomAvailableSeats(t, showtime.ShowtimeID, 1)
+	reservation, err := testStore.ReserveSeat(context.Background(),
+		ReserveSeatParams{
+			UserID:     pgtype.Int8{Int64: user.UserID, Valid: true},
+			ShowtimeID: showtime.ShowtimeID,
+			SeatID:     seats[0].SeatID,
+		})
+	require.NoError(t, err)
+
+	createRandomReview(t, user, showtime.MovieID, 1)
+	createRandomReview(t, createRandomUser(t), showtime.MovieID, 5)
+	requireMovieRating(t, showtime.MovieID, 3, 2)
+
+	guest := createRandomGuestBooking(t, user.Email, 1)
+	_, err = testStore.ClaimGuestBookingsTx(context.Background(),
+		ClaimGuestBookingsParams{UserID: user.UserID, Email: user.Email})
+	require.NoError(t, err)
+
+	anonymized, err := testStore.AnonymizeUserTx(context.Background(),
+		user.UserID)
+	require.NoError(t, err)
+	require.True(t, anonymized.AnonymizedAt.Valid)
+	require.NotEqual(t, user.Username, anonymized.Username)
+	require.NotEqual(t, user.Name, anonymized.Name)
+	require.NotEqual(t, user.Email, anonymized.Email)
+	require.Empty(t, anonymized.HashedPassword)
+
+	_, err = testStore.GetSessionByID(context.Background(), session.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	_, err = testStore.GetAPIKeyByPrefix(context.Background(), key.Prefix)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// bookings survive for accounting
+	reservations, err := testStore.ListReservationsByUser(context.Background(),
+		user.UserID)
+	require.NoError(t, err)
+	require.Len(t, reservations, 2)
+
+	reservationIDs := []int64{}
+	for _, res := range reservations {
+		reservationIDs = append(reservationIDs, res.ReservationID)
+	}
+	require.Contains(t, reservationIDs, reservation.ReservationID)
+
+	booking, err := testStore.GetGuestBookingByReference(context.Background(),
+		guest.GuestBooking.Reference)
+	require.NoError(t, err)
+	require.Empty(t, booking.Email)
+	require.Empty(t, booking.Name)
+
+	// the user's review no longer counts
+	requireMovieRating(t, showtime.MovieID, 5, 1)
+
+	// a second run has nothing left to do
+	_, err = testStore.AnonymizeUserTx(context.Background(), user.UserID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	_, err = testStore.EnableUser(context.Background(), user.UserID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
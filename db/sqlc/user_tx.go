@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"strings"
+)
+
+// Disables a user and blocks every session they have, so neither a new
+// login nor a refresh token gets them back in
+func (store *SQLStore) DisableUserTx(ctx context.Context,
+	userID int64) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.DisableUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		return q.BlockUserSessions(ctx, user.Username)
+	})
+
+	return user, err
+}
+
+// Marks a user for deletion and signs them out everywhere, the account
+// stays disabled until it is anonymized or an admin enables it again
+func (store *SQLStore) RequestUserDeletionTx(ctx context.Context,
+	userID int64) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.RequestUserDeletion(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		return q.BlockUserSessions(ctx, user.Username)
+	})
+
+	return user, err
+}
+
+// Strips every piece of personal data from a user, their reservations
+// are kept and stay linked to the anonymized row for accounting
+func (store *SQLStore) AnonymizeUserTx(ctx context.Context,
+	userID int64) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		original, err := q.GetUserByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		// sessions reference the username, so they go before it changes
+		err = q.DeleteUserSessions(ctx, original.Username)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteLoginThrottlesByKey(ctx, strings.ToLower(original.Email))
+		if err != nil {
+			return err
+		}
+
+		for _, deleteFn := range []func(context.Context, int64) error{
+			q.DeleteUserIdentities,
+			q.DeleteUserAPIKeys,
+			q.DeleteRecoveryCodes,
+			q.DeleteUserLoginChallenges,
+			q.DeleteAccountUnlocks,
+			q.DeleteVerifyEmails,
+			q.DeleteAccountConfirmations,
+			q.DeleteUserWatchlist,
+			q.DeleteUserWatchlistNotifications,
+			q.DeleteUserRecommendations,
+		} {
+			if err = deleteFn(ctx, userID); err != nil {
+				return err
+			}
+		}
+
+		// reviews are signed with the user's name, the movies they rated
+		// get their average recomputed without them
+		movieIDs, err := q.DeleteUserReviews(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, movieID := range movieIDs {
+			if err = q.RefreshMovieRating(ctx, movieID); err != nil {
+				return err
+			}
+		}
+
+		// claimed guest bookings still carry the name and email they
+		// were made with
+		err = q.AnonymizeUserGuestBookings(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.AnonymizeUser(ctx, userID)
+		return err
+	})
+
+	return user, err
+}
@@ -0,0 +1,77 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: verify_email.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createVerifyEmail = `-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (user_id, email, secret_code)
+VALUES ($1, $2, $3)
+RETURNING id, user_id, email, secret_code, is_used, created_at, expired_at
+`
+
+type CreateVerifyEmailParams struct {
+	UserID     int64  `json:"user_id"`
+	Email      string `json:"email"`
+	SecretCode string `json:"secret_code"`
+}
+
+func (q *Queries) CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error) {
+	row := q.db.QueryRow(ctx, createVerifyEmail, arg.UserID, arg.Email, arg.SecretCode)
+	var i VerifyEmail
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const deleteVerifyEmails = `-- name: DeleteVerifyEmails :exec
+DELETE FROM verify_emails
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteVerifyEmails(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteVerifyEmails, userID)
+	return err
+}
+
+const useVerifyEmail = `-- name: UseVerifyEmail :one
+UPDATE verify_emails
+SET is_used = true
+WHERE id = $1
+  AND secret_code = $2
+  AND is_used = false
+  AND expired_at > now()
+RETURNING id, user_id, email, secret_code, is_used, created_at, expired_at
+`
+
+type UseVerifyEmailParams struct {
+	ID         int64  `json:"id"`
+	SecretCode string `json:"secret_code"`
+}
+
+func (q *Queries) UseVerifyEmail(ctx context.Context, arg UseVerifyEmailParams) (VerifyEmail, error) {
+	row := q.db.QueryRow(ctx, useVerifyEmail, arg.ID, arg.SecretCode)
+	var i VerifyEmail
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+)
+
+type VerifyEmailTxParams struct {
+	EmailID    int64
+	SecretCode string
+}
+
+type VerifyEmailTxResult struct {
+	User            User
+	VerifyEmail     VerifyEmail
+	ClaimedBookings []GuestBooking
+}
+
+// Marks the user's email as verified and hands them every guest booking
+// made with that email
+func (store *SQLStore) VerifyEmailTx(ctx context.Context,
+	arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
+	var result VerifyEmailTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.VerifyEmail, err = q.UseVerifyEmail(ctx, UseVerifyEmailParams{
+			ID:         arg.EmailID,
+			SecretCode: arg.SecretCode,
+		})
+		if err != nil {
+			return err
+		}
+
+		// no row when the user changed their email since the link was sent
+		result.User, err = q.SetUserEmailVerified(ctx,
+			SetUserEmailVerifiedParams{
+				UserID: result.VerifyEmail.UserID,
+				Email:  result.VerifyEmail.Email,
+			})
+		if err != nil {
+			return err
+		}
+
+		result.ClaimedBookings, err = claimGuestBookingsForUser(ctx, q,
+			ClaimGuestBookingsParams{
+				UserID: result.User.UserID,
+				Email:  result.User.Email,
+			})
+		return err
+	})
+
+	return result, err
+}
+
+// Moves the unclaimed guest bookings of an email, and their reservations,
+// to a user. Callers make sure the user owns the email
+func (store *SQLStore) ClaimGuestBookingsTx(ctx context.Context,
+	arg ClaimGuestBookingsParams) ([]GuestBooking, error) {
+	var bookings []GuestBooking
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		bookings, err = claimGuestBookingsForUser(ctx, q, arg)
+		return err
+	})
+
+	return bookings, err
+}
+
+func claimGuestBookingsForUser(ctx context.Context, q *Queries,
+	arg ClaimGuestBookingsParams) ([]GuestBooking, error) {
+	bookings, err := q.ClaimGuestBookings(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = q.AssignGuestReservations(ctx, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
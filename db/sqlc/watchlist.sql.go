@@ -0,0 +1,272 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: watchlist.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const addToWatchlist = `-- name: AddToWatchlist :exec
+INSERT INTO watchlist (user_id, movie_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, movie_id) DO NOTHING
+`
+
+type AddToWatchlistParams struct {
+	UserID  int64 `json:"user_id"`
+	MovieID int32 `json:"movie_id"`
+}
+
+// adding a movie twice keeps it where it was
+func (q *Queries) AddToWatchlist(ctx context.Context, arg AddToWatchlistParams) error {
+	_, err := q.db.Exec(ctx, addToWatchlist, arg.UserID, arg.MovieID)
+	return err
+}
+
+const countUpcomingShowtimesForMovie = `-- name: CountUpcomingShowtimesForMovie :one
+SELECT count(*) FROM showtimes
+WHERE movie_id = $1
+  AND start_time > (now() AT TIME ZONE 'UTC')
+`
+
+// start_time has no time zone and holds UTC
+func (q *Queries) CountUpcomingShowtimesForMovie(ctx context.Context, movieID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countUpcomingShowtimesForMovie, movieID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteUserWatchlist = `-- name: DeleteUserWatchlist :exec
+DELETE FROM watchlist
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserWatchlist(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteUserWatchlist, userID)
+	return err
+}
+
+const deleteUserWatchlistNotifications = `-- name: DeleteUserWatchlistNotifications :exec
+DELETE FROM watchlist_notifications
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserWatchlistNotifications(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteUserWatchlistNotifications, userID)
+	return err
+}
+
+const enqueueWatchlistNotifications = `-- name: EnqueueWatchlistNotifications :execrows
+INSERT INTO watchlist_notifications (user_id, movie_id)
+SELECT w.user_id, w.movie_id
+FROM watchlist w
+JOIN movies m ON m.movie_id = w.movie_id
+WHERE w.movie_id = $1
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+ON CONFLICT (user_id, movie_id, notify_date) DO NOTHING
+`
+
+// queues an alert for everyone watching a public movie, watchers already
+// alerted about it today are skipped
+func (q *Queries) EnqueueWatchlistNotifications(ctx context.Context, movieID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, enqueueWatchlistNotifications, movieID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listPendingWatchlistNotifications = `-- name: ListPendingWatchlistNotifications :many
+SELECT n.notification_id, n.movie_id, m.title, u.user_id, u.name, u.email
+FROM watchlist_notifications n
+JOIN users u ON u.user_id = n.user_id
+JOIN movies m ON m.movie_id = n.movie_id
+WHERE n.sent_at IS NULL
+  AND u.disabled_at IS NULL
+  AND u.deletion_requested_at IS NULL
+  AND u.anonymized_at IS NULL
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+ORDER BY n.notification_id
+LIMIT $1
+`
+
+type ListPendingWatchlistNotificationsRow struct {
+	NotificationID int64  `json:"notification_id"`
+	MovieID        int32  `json:"movie_id"`
+	Title          string `json:"title"`
+	UserID         int64  `json:"user_id"`
+	Name           string `json:"name"`
+	Email          string `json:"email"`
+}
+
+// alerts still to be emailed, oldest first. accounts that are disabled or
+// being deleted don't get any, neither do movies unpublished or deleted
+// since the alert was queued
+func (q *Queries) ListPendingWatchlistNotifications(ctx context.Context, rowLimit int32) ([]ListPendingWatchlistNotificationsRow, error) {
+	rows, err := q.db.Query(ctx, listPendingWatchlistNotifications, rowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPendingWatchlistNotificationsRow{}
+	for rows.Next() {
+		var i ListPendingWatchlistNotificationsRow
+		if err := rows.Scan(
+			&i.NotificationID,
+			&i.MovieID,
+			&i.Title,
+			&i.UserID,
+			&i.Name,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserWatchlist = `-- name: ListUserWatchlist :many
+SELECT w.movie_id, m.title, w.created_at AS added_at
+FROM watchlist w
+JOIN movies m ON m.movie_id = w.movie_id
+WHERE w.user_id = $1
+ORDER BY w.created_at, w.movie_id
+`
+
+type ListUserWatchlistRow struct {
+	MovieID int32     `json:"movie_id"`
+	Title   string    `json:"title"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+func (q *Queries) ListUserWatchlist(ctx context.Context, userID int64) ([]ListUserWatchlistRow, error) {
+	rows, err := q.db.Query(ctx, listUserWatchlist, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUserWatchlistRow{}
+	for rows.Next() {
+		var i ListUserWatchlistRow
+		if err := rows.Scan(&i.MovieID, &i.Title, &i.AddedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWatchlist = `-- name: ListWatchlist :many
+SELECT m.movie_id, m.title, m.description, m.poster_url, m.created_at, m.runtime_minutes, m.certification, m.release_date, m.original_language, m.trailer_url, m.status, m.deleted_at, m.rating_average, m.rating_count, m.bookings_count, w.created_at AS added_at
+FROM watchlist w
+JOIN movies m ON m.movie_id = w.movie_id
+WHERE w.user_id = $1
+  AND m.deleted_at IS NULL
+  AND m.status IN ('coming_soon', 'now_showing')
+  AND ($2::int IS NULL
+    OR (w.created_at, w.movie_id) <
+      ($3::timestamptz, $2::int))
+ORDER BY w.created_at DESC, w.movie_id DESC
+LIMIT $4
+`
+
+type ListWatchlistParams struct {
+	UserID        int64              `json:"user_id"`
+	BeforeMovieID pgtype.Int4        `json:"before_movie_id"`
+	BeforeAddedAt pgtype.Timestamptz `json:"before_added_at"`
+	RowLimit      int32              `json:"row_limit"`
+}
+
+type ListWatchlistRow struct {
+	Movie   Movie     `json:"movie"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// movies a user watches, most recently added first. pages continue after
+// the created_at and movie_id of the last entry
+func (q *Queries) ListWatchlist(ctx context.Context, arg ListWatchlistParams) ([]ListWatchlistRow, error) {
+	rows, err := q.db.Query(ctx, listWatchlist,
+		arg.UserID,
+		arg.BeforeMovieID,
+		arg.BeforeAddedAt,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListWatchlistRow{}
+	for rows.Next() {
+		var i ListWatchlistRow
+		if err := rows.Scan(
+			&i.Movie.MovieID,
+			&i.Movie.Title,
+			&i.Movie.Description,
+			&i.Movie.PosterUrl,
+			&i.Movie.CreatedAt,
+			&i.Movie.RuntimeMinutes,
+			&i.Movie.Certification,
+			&i.Movie.ReleaseDate,
+			&i.Movie.OriginalLanguage,
+			&i.Movie.TrailerUrl,
+			&i.Movie.Status,
+			&i.Movie.DeletedAt,
+			&i.Movie.RatingAverage,
+			&i.Movie.RatingCount,
+			&i.Movie.BookingsCount,
+			&i.AddedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markWatchlistNotificationSent = `-- name: MarkWatchlistNotificationSent :exec
+UPDATE watchlist_notifications
+SET sent_at = now()
+WHERE notification_id = $1
+`
+
+func (q *Queries) MarkWatchlistNotificationSent(ctx context.Context, notificationID int64) error {
+	_, err := q.db.Exec(ctx, markWatchlistNotificationSent, notificationID)
+	return err
+}
+
+const removeFromWatchlist = `-- name: RemoveFromWatchlist :execrows
+DELETE FROM watchlist
+WHERE user_id = $1 AND movie_id = $2
+`
+
+type RemoveFromWatchlistParams struct {
+	UserID  int64 `json:"user_id"`
+	MovieID int32 `json:"movie_id"`
+}
+
+func (q *Queries) RemoveFromWatchlist(ctx context.Context, arg RemoveFromWatchlistParams) (int64, error) {
+	result, err := q.db.Exec(ctx, removeFromWatchlist, arg.UserID, arg.MovieID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
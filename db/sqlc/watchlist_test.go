@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func watchMovie(t *testing.T, user User, movie Movie) {
+	err := testStore.AddToWatchlist(context.Background(), AddToWatchlistParams{
+		UserID:  user.UserID,
+		MovieID: movie.MovieID,
+	})
+	require.NoError(t, err)
+}
+
+func createUpcomingShowtime(t *testing.T, movie Movie) CreateShowtimeTxResult {
+	start := time.Now().UTC().Add(24 * time.Hour)
+
+	result, err := testStore.CreateShowtimeTx(context.Background(),
+		CreateShowtimeParams{
+			MovieID:   movie.MovieID,
+			StartTime: pgtype.Timestamp{Time: start, Valid: true},
+			Price:     util.RandomPrice(),
+		})
+	require.NoError(t, err)
+	require.Equal(t, movie.MovieID, result.Showtime.MovieID)
+
+	return result
+}
+
+func TestListWatchlist(t *testing.T) {
+	user := createRandomUser(t)
+	movie1 := createRandomMovie(t)
+	movie2 := createRandomMovie(t)
+
+	watchMovie(t, user, movie1)
+	watchMovie(t, user, movie2)
+	// adding again is a no-op
+	watchMovie(t, user, movie1)
+
+	rows, err := testStore.ListWatchlist(context.Background(),
+		ListWatchlistParams{UserID: user.UserID, RowLimit: 1})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, movie2.MovieID, rows[0].Movie.MovieID)
+
+	rows, err = testStore.ListWatchlist(context.Background(),
+		ListWatchlistParams{
+			UserID:        user.UserID,
+			BeforeMovieID: pgtype.Int4{Int32: rows[0].Movie.MovieID, Valid: true},
+			BeforeAddedAt: pgtype.Timestamptz{Time: rows[0].AddedAt, Valid: true},
+			RowLimit:      10,
+		})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, movie1.MovieID, rows[0].Movie.MovieID)
+
+	removed, err := testStore.RemoveFromWatchlist(context.Background(),
+		RemoveFromWatchlistParams{UserID: user.UserID, MovieID: movie1.MovieID})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), removed)
+
+	removed, err = testStore.RemoveFromWatchlist(context.Background(),
+		RemoveFromWatchlistParams{UserID: user.UserID, MovieID: movie1.MovieID})
+	require.NoError(t, err)
+	require.Zero(t, removed)
+}
+
+func TestCreateShowtimeTxNotifiesWatchers(t *testing.T) {
+	movie := createRandomMovie(t)
+	watcher1 := createRandomUser(t)
+	watcher2 := createRandomUser(t)
+	watchMovie(t, watcher1, movie)
+	watchMovie(t, watcher2, movie)
+
+	first := createUpcomingShowtime(t, movie)
+	require.Equal(t, int64(2), first.Notified)
+
+	// tickets were already on sale
+	second := createUpcomingShowtime(t, movie)
+	require.Zero(t, second.Notified)
+
+	pending, err := testStore.ListPendingWatchlistNotifications(
+		context.Background(), 1000)
+	require.NoError(t, err)
+
+	notified := map[int64]bool{}
+	for _, notification := range pending {
+		if notification.MovieID == movie.MovieID {
+			notified[notification.UserID] = true
+		}
+	}
+	require.Equal(t, map[int64]bool{
+		watcher1.UserID: true,
+		watcher2.UserID: true,
+	}, notified)
+
+	// going on sale again the same day doesn't alert anyone twice
+	for _, showtime := range []Showtime{first.Showtime, second.Showtime} {
+		err = testStore.DeleteShowtime(context.Background(), showtime.ShowtimeID)
+		require.NoError(t, err)
+	}
+
+	again := createUpcomingShowtime(t, movie)
+	require.Zero(t, again.Notified)
+}
+
+func TestWatchlistNotificationsSkipPastAndUnpublished(t *testing.T) {
+	movie := createRandomMovie(t)
+	watcher := createRandomUser(t)
+	watchMovie(t, watcher, movie)
+
+	// a showtime that already started puts nothing on sale
+	start := time.Now().UTC().Add(-time.Hour)
+	past, err := testStore.CreateShowtimeTx(context.Background(),
+		CreateShowtimeParams{
+			MovieID:   movie.MovieID,
+			StartTime: pgtype.Timestamp{Time: start, Valid: true},
+			Price:     util.RandomPrice(),
+		})
+	require.NoError(t, err)
+	require.Zero(t, past.Notified)
+
+	upcoming := createUpcomingShowtime(t, movie)
+	require.Equal(t, int64(1), upcoming.Notified)
+
+	// unpublished before the alert went out
+	_, err = testStore.UpdateMovieTx(context.Background(), UpdateMovieTxParams{
+		UpdateMovieParams: UpdateMovieParams{
+			MovieID: movie.MovieID,
+			Title:   movie.Title,
+			Status:  MovieStatusDraft,
+		},
+	})
+	require.NoError(t, err)
+
+	pending, err := testStore.ListPendingWatchlistNotifications(
+		context.Background(), 1000)
+	require.NoError(t, err)
+	for _, notification := range pending {
+		require.NotEqual(t, movie.MovieID, notification.MovieID)
+	}
+}
+
+func TestUpdateMovieTxNotifiesWatchersWhenPublished(t *testing.T) {
+	movie := createRandomMovie(t)
+
+	// scheduled before anyone watched the movie
+	scheduled := createUpcomingShowtime(t, movie)
+	require.Zero(t, scheduled.Notified)
+
+	watcher := createRandomUser(t)
+	watchMovie(t, watcher, movie)
+
+	arg := UpdateMovieTxParams{
+		UpdateMovieParams: UpdateMovieParams{
+			MovieID:          movie.MovieID,
+			Title:            movie.Title,
+			Description:      movie.Description,
+			PosterUrl:        movie.PosterUrl,
+			RuntimeMinutes:   movie.RuntimeMinutes,
+			Certification:    movie.Certification,
+			ReleaseDate:      movie.ReleaseDate,
+			OriginalLanguage: movie.OriginalLanguage,
+			TrailerUrl:       movie.TrailerUrl,
+			Status:           MovieStatusDraft,
+		},
+	}
+	result, err := testStore.UpdateMovieTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, result.Notified)
+
+	// drafts can't get new showtimes
+	_, err = testStore.CreateShowtimeTx(context.Background(),
+		CreateShowtimeParams{
+			MovieID:   movie.MovieID,
+			StartTime: scheduled.Showtime.StartTime,
+			Price:     util.RandomPrice(),
+		})
+	require.ErrorIs(t, err, ErrMovieNotPublic)
+
+	arg.Status = MovieStatusComingSoon
+	result, err = testStore.UpdateMovieTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), result.Notified)
+
+	// later edits of a public movie don't alert again
+	arg.Status = MovieStatusNowShowing
+	result, err = testStore.UpdateMovieTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, result.Notified)
+}
@@ -0,0 +1,105 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Window for a single key
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// outcome of a single Allow call
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
+
+// Limiter is an interface for counting requests per key
+type Limiter interface {
+	// counts one request for key, unless that would exceed limit
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// parses a limit written as "requests/window", e.g. "100/1m"
+func ParseLimit(s string) (Limit, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, use requests/window", s)
+	}
+
+	requests, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || requests < 1 {
+		return Limit{}, fmt.Errorf("invalid request count in rate limit %q", s)
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || window <= 0 {
+		return Limit{}, fmt.Errorf("invalid window in rate limit %q", s)
+	}
+
+	return Limit{Requests: requests, Window: window}, nil
+}
+
+// Both backends use a sliding window counter: the previous fixed window's
+// count is weighted by how much of it still overlaps the sliding window.
+
+// splits now into the start of its fixed window and the time elapsed in it
+func fixedWindow(now time.Time, window time.Duration) (time.Time, time.Duration) {
+	start := now.Truncate(window)
+	return start, now.Sub(start)
+}
+
+// requests counted in the sliding window ending elapsed into the current one
+func estimate(prev int, curr int, elapsed time.Duration,
+	window time.Duration) float64 {
+	weight := float64(window-elapsed) / float64(window)
+	return float64(prev)*weight + float64(curr)
+}
+
+// builds the result from the window counts after the request was handled
+func newResult(limit Limit, prev int, curr int, elapsed time.Duration,
+	allowed bool) Result {
+	result := Result{
+		Allowed:    allowed,
+		Limit:      limit.Requests,
+		ResetAfter: limit.Window - elapsed,
+	}
+
+	used := estimate(prev, curr, elapsed, limit.Window)
+	result.Remaining = int(math.Max(0, math.Floor(float64(limit.Requests)-used)))
+
+	if !allowed {
+		result.RetryAfter = retryAfter(limit, prev, curr, elapsed)
+	}
+
+	return result
+}
+
+// how long until the sliding window has room for one more request
+func retryAfter(limit Limit, prev int, curr int,
+	elapsed time.Duration) time.Duration {
+	free := limit.Requests - curr - 1
+	if free < 0 || prev == 0 {
+		// the current window alone is full, wait for it to become
+		// the previous one
+		return limit.Window - elapsed
+	}
+
+	// prev * (window - elapsed - t) / window <= free
+	wait := limit.Window - elapsed -
+		time.Duration(float64(limit.Window)*float64(free)/float64(prev))
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
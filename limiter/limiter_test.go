@@ -0,0 +1,19 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("100/1m")
+	require.NoError(t, err)
+	require.Equal(t, Limit{Requests: 100, Window: time.Minute}, limit)
+
+	for _, value := range []string{"", "100", "0/1m", "abc/1m", "10/x", "10/-1s"} {
+		_, err := ParseLimit(value)
+		require.Error(t, err, value)
+	}
+}
@@ -0,0 +1,84 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// keys idle for this long are dropped on the next sweep
+const memorySweepInterval = 10 * time.Minute
+
+type memoryWindow struct {
+	start    time.Time
+	prev     int
+	curr     int
+	lastSeen time.Time
+}
+
+// keeps counters in process memory, for single instance deployments
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	windows   map[string]*memoryWindow
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryLimiter() Limiter {
+	return &MemoryLimiter{
+		windows:   make(map[string]*memoryWindow),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (limiter *MemoryLimiter) Allow(ctx context.Context, key string,
+	limit Limit) (Result, error) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+	limiter.sweep(now)
+
+	start, elapsed := fixedWindow(now, limit.Window)
+
+	w, ok := limiter.windows[key]
+	if !ok {
+		w = &memoryWindow{start: start}
+		limiter.windows[key] = w
+	}
+	w.lastSeen = now
+
+	// roll the counters over when a new fixed window started
+	if !w.start.Equal(start) {
+		if start.Sub(w.start) == limit.Window {
+			w.prev = w.curr
+		} else {
+			w.prev = 0
+		}
+		w.curr = 0
+		w.start = start
+	}
+
+	if estimate(w.prev, w.curr, elapsed, limit.Window)+1 >
+		float64(limit.Requests) {
+		return newResult(limit, w.prev, w.curr, elapsed, false), nil
+	}
+
+	w.curr++
+	return newResult(limit, w.prev, w.curr, elapsed, true), nil
+}
+
+// drops keys that weren't seen for a while so memory doesn't grow forever
+func (limiter *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(limiter.lastSweep) < memorySweepInterval {
+		return
+	}
+
+	for key, w := range limiter.windows {
+		if now.Sub(w.lastSeen) > memorySweepInterval {
+			delete(limiter.windows, key)
+		}
+	}
+	limiter.lastSweep = now
+}
@@ -0,0 +1,57 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Date(2025, 5, 1, 20, 0, 0, 0, time.UTC)
+	l := NewMemoryLimiter().(*MemoryLimiter)
+	l.now = func() time.Time { return now }
+
+	limit := Limit{Requests: 3, Window: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		result, err := l.Allow(context.Background(), "key", limit)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, 2-i, result.Remaining)
+	}
+
+	result, err := l.Allow(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, time.Minute, result.RetryAfter)
+
+	// other keys have their own counters
+	result, err = l.Allow(context.Background(), "other", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	// a third into the next window, the previous one still weighs 2
+	now = now.Add(time.Minute + 20*time.Second)
+	result, err = l.Allow(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	result, err = l.Allow(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 20*time.Second, result.RetryAfter)
+
+	now = now.Add(20 * time.Second)
+	result, err = l.Allow(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	// after two idle windows nothing is carried over
+	now = now.Add(3 * time.Minute)
+	result, err = l.Allow(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 2, result.Remaining)
+}
@@ -0,0 +1,66 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KEYS[1] current window counter, KEYS[2] previous window counter
+// ARGV[1] limit, ARGV[2] window in ms, ARGV[3] ms elapsed in window
+var slidingWindowScript = redis.NewScript(`
+local curr = tonumber(redis.call('GET', KEYS[1]) or '0')
+local prev = tonumber(redis.call('GET', KEYS[2]) or '0')
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+
+if prev * (window - elapsed) / window + curr + 1 > limit then
+  return {0, prev, curr}
+end
+
+curr = redis.call('INCR', KEYS[1])
+if curr == 1 then
+  redis.call('PEXPIRE', KEYS[1], window * 2)
+end
+return {1, prev, curr}
+`)
+
+// keeps counters in redis so every app instance shares them
+type RedisLimiter struct {
+	client redis.UniversalClient
+	prefix string
+	now    func() time.Time
+}
+
+func NewRedisLimiter(client redis.UniversalClient) Limiter {
+	return &RedisLimiter{
+		client: client,
+		prefix: "ratelimit",
+		now:    time.Now,
+	}
+}
+
+func (limiter *RedisLimiter) Allow(ctx context.Context, key string,
+	limit Limit) (Result, error) {
+	start, elapsed := fixedWindow(limiter.now(), limit.Window)
+
+	windowID := start.UnixMilli() / limit.Window.Milliseconds()
+	currKey := fmt.Sprintf("%s:%s:%d", limiter.prefix, key, windowID)
+	prevKey := fmt.Sprintf("%s:%s:%d", limiter.prefix, key, windowID-1)
+
+	values, err := slidingWindowScript.Run(ctx, limiter.client,
+		[]string{currKey, prevKey},
+		limit.Requests, limit.Window.Milliseconds(), elapsed.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to run rate limit script: %w", err)
+	}
+
+	allowed := values[0] == 1
+	prev, curr := int(values[1]), int(values[2])
+
+	return newResult(limit, prev, curr, elapsed, allowed), nil
+}
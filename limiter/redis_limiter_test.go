@@ -0,0 +1,55 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisLimiter(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+
+	now := time.Date(2025, 5, 1, 20, 0, 0, 0, time.UTC)
+	l := NewRedisLimiter(client).(*RedisLimiter)
+	l.now = func() time.Time { return now }
+
+	limit := Limit{Requests: 2, Window: time.Minute}
+
+	for i := 0; i < 2; i++ {
+		result, err := l.Allow(context.Background(), "key", limit)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, 1-i, result.Remaining)
+	}
+
+	result, err := l.Allow(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, time.Minute, result.RetryAfter)
+
+	// halfway through the next window one previous request still counts
+	now = now.Add(90 * time.Second)
+	result, err = l.Allow(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	result, err = l.Allow(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+}
+
+func TestRedisLimiterUnavailable(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	redisServer.Close()
+
+	l := NewRedisLimiter(client)
+	_, err := l.Allow(context.Background(), "key",
+		Limit{Requests: 1, Window: time.Minute})
+	require.Error(t, err)
+}
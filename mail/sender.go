@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+const (
+	smtpAuthAddress   = "smtp.gmail.com"
+	smtpServerAddress = "smtp.gmail.com:587"
+)
+
+// EmailSender is an interface for sending emails
+type EmailSender interface {
+	SendEmail(subject string, content string, to []string) error
+}
+
+// sends emails through a gmail account
+type GmailSender struct {
+	name              string
+	fromEmailAddress  string
+	fromEmailPassword string
+}
+
+func NewGmailSender(name string, fromEmailAddress string,
+	fromEmailPassword string) EmailSender {
+	return &GmailSender{
+		name:              name,
+		fromEmailAddress:  fromEmailAddress,
+		fromEmailPassword: fromEmailPassword,
+	}
+}
+
+// sends an html email to all recipients
+func (sender *GmailSender) SendEmail(subject string, content string,
+	to []string) error {
+	from := fmt.Sprintf("%s <%s>", sender.name, sender.fromEmailAddress)
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n\r\n")
+	msg.WriteString(content)
+
+	auth := smtp.PlainAuth("", sender.fromEmailAddress,
+		sender.fromEmailPassword, smtpAuthAddress)
+
+	err := smtp.SendMail(smtpServerAddress, auth, sender.fromEmailAddress,
+		to, []byte(msg.String()))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
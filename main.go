@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -11,7 +12,9 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kratos69/movie-app/api"
 	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/mail"
 	"github.com/kratos69/movie-app/util"
+	"github.com/kratos69/movie-app/worker"
 )
 
 func main() {
@@ -32,6 +35,27 @@ func main() {
 
 	store := db.NewStore(connPool)
 
+	// anonymize accounts once their deletion grace period is over
+	anonymizer := worker.NewAccountAnonymizer(store, config.DeletionGracePeriod)
+	go anonymizer.Start(context.Background(), time.Hour)
+
+	// score upcoming movies for each user from their bookings
+	recommender := worker.NewRecommendationBuilder(store)
+	go recommender.Start(context.Background(), time.Hour)
+
+	// recount movie bookings for the popularity sort
+	counter := worker.NewBookingCounter(store)
+	go counter.Start(context.Background(), 5*time.Minute)
+
+	// email watchers when tickets for a movie they follow go on sale
+	if config.EmailSenderAddress != "" {
+		mailer := mail.NewGmailSender(config.EmailSenderName,
+			config.EmailSenderAddress, config.EmailSenderPassword)
+		notifier := worker.NewWatchlistNotifier(store, mailer,
+			config.AppBaseURL)
+		go notifier.Start(context.Background(), time.Minute)
+	}
+
 	runGinServer(config, store)
 }
 
@@ -0,0 +1,67 @@
+package revocation
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// writes every revocation to both stores and keeps answering from the
+// fallback while the primary is unreachable, so a redis outage doesn't
+// bring back the tokens this instance revoked
+type FallbackStore struct {
+	primary  Store
+	fallback Store
+}
+
+func NewFallbackStore(primary Store, fallback Store) Store {
+	return &FallbackStore{
+		primary:  primary,
+		fallback: fallback,
+	}
+}
+
+func (store *FallbackStore) RevokeToken(ctx context.Context,
+	tokenID uuid.UUID, expiresAt time.Time) error {
+	err := store.fallback.RevokeToken(ctx, tokenID, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	err = store.primary.RevokeToken(ctx, tokenID, expiresAt)
+	if err != nil {
+		log.Printf("revocation store unavailable: %v\n", err)
+	}
+	return nil
+}
+
+func (store *FallbackStore) RevokeUserTokens(ctx context.Context,
+	userID int64, issuedBefore time.Time, ttl time.Duration) error {
+	err := store.fallback.RevokeUserTokens(ctx, userID, issuedBefore, ttl)
+	if err != nil {
+		return err
+	}
+
+	err = store.primary.RevokeUserTokens(ctx, userID, issuedBefore, ttl)
+	if err != nil {
+		log.Printf("revocation store unavailable: %v\n", err)
+	}
+	return nil
+}
+
+func (store *FallbackStore) IsRevoked(ctx context.Context, tokenID uuid.UUID,
+	userID int64, issuedAt time.Time) (bool, error) {
+	revoked, err := store.fallback.IsRevoked(ctx, tokenID, userID, issuedAt)
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	revoked, err = store.primary.IsRevoked(ctx, tokenID, userID, issuedAt)
+	if err != nil {
+		log.Printf("revocation store unavailable: %v\n", err)
+		return false, nil
+	}
+	return revoked, nil
+}
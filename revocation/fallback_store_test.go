@@ -0,0 +1,72 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallbackStore(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+
+	now := time.Now()
+	store := NewFallbackStore(NewRedisStore(client), NewMemoryStore())
+
+	testStore(t, store, &now)
+}
+
+func TestFallbackStorePrimaryDown(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	store := NewFallbackStore(NewRedisStore(client), NewMemoryStore())
+
+	revokedID := uuid.New()
+	err := store.RevokeToken(ctx, revokedID, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	redisServer.Close()
+
+	// revocations made here still hold
+	revoked, err := store.IsRevoked(ctx, revokedID, 1, time.Now())
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// and new ones are kept in memory until redis is back
+	tokenID := uuid.New()
+	err = store.RevokeToken(ctx, tokenID, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	revoked, err = store.IsRevoked(ctx, tokenID, 1, time.Now())
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = store.IsRevoked(ctx, uuid.New(), 1, time.Now())
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestFallbackStoreSharedRevocations(t *testing.T) {
+	ctx := context.Background()
+	redisServer := miniredis.RunT(t)
+
+	// two app instances sharing one redis
+	first := NewFallbackStore(NewRedisStore(redis.NewClient(
+		&redis.Options{Addr: redisServer.Addr()})), NewMemoryStore())
+	second := NewFallbackStore(NewRedisStore(redis.NewClient(
+		&redis.Options{Addr: redisServer.Addr()})), NewMemoryStore())
+
+	err := first.RevokeUserTokens(ctx, 1, time.Now(), time.Hour)
+	require.NoError(t, err)
+
+	revoked, err := second.IsRevoked(ctx, uuid.New(), 1,
+		time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
@@ -0,0 +1,109 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// expired entries are dropped on the next write after this long
+const memorySweepInterval = 10 * time.Minute
+
+type watermark struct {
+	issuedBefore time.Time
+	expiresAt    time.Time
+}
+
+// keeps revocations in process memory, for single instance deployments
+type MemoryStore struct {
+	mu         sync.Mutex
+	tokens     map[uuid.UUID]time.Time
+	watermarks map[int64]watermark
+	lastSweep  time.Time
+	now        func() time.Time
+}
+
+func NewMemoryStore() Store {
+	return &MemoryStore{
+		tokens:     make(map[uuid.UUID]time.Time),
+		watermarks: make(map[int64]watermark),
+		lastSweep:  time.Now(),
+		now:        time.Now,
+	}
+}
+
+func (store *MemoryStore) RevokeToken(ctx context.Context,
+	tokenID uuid.UUID, expiresAt time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := store.now()
+	store.sweep(now)
+
+	if expiresAt.After(now) {
+		store.tokens[tokenID] = expiresAt
+	}
+	return nil
+}
+
+func (store *MemoryStore) RevokeUserTokens(ctx context.Context, userID int64,
+	issuedBefore time.Time, ttl time.Duration) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := store.now()
+	store.sweep(now)
+
+	// never move a watermark back
+	current, ok := store.watermarks[userID]
+	if ok && current.issuedBefore.After(issuedBefore) {
+		issuedBefore = current.issuedBefore
+	}
+
+	store.watermarks[userID] = watermark{
+		issuedBefore: issuedBefore,
+		expiresAt:    now.Add(ttl),
+	}
+	return nil
+}
+
+func (store *MemoryStore) IsRevoked(ctx context.Context, tokenID uuid.UUID,
+	userID int64, issuedAt time.Time) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := store.now()
+
+	if expiresAt, ok := store.tokens[tokenID]; ok && expiresAt.After(now) {
+		return true, nil
+	}
+
+	mark, ok := store.watermarks[userID]
+	if ok && mark.expiresAt.After(now) && issuedAt.Before(mark.issuedBefore) {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// drops entries nothing can match anymore, at most once per interval
+func (store *MemoryStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < memorySweepInterval {
+		return
+	}
+	store.lastSweep = now
+
+	for tokenID, expiresAt := range store.tokens {
+		if !expiresAt.After(now) {
+			delete(store.tokens, tokenID)
+		}
+	}
+
+	for userID, mark := range store.watermarks {
+		if !mark.expiresAt.After(now) {
+			delete(store.watermarks, userID)
+		}
+	}
+}
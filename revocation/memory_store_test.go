@@ -0,0 +1,51 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Date(2025, 5, 1, 20, 0, 0, 0, time.UTC)
+	store := NewMemoryStore().(*MemoryStore)
+	store.now = func() time.Time { return now }
+
+	testStore(t, store, &now)
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 5, 1, 20, 0, 0, 0, time.UTC)
+	store := NewMemoryStore().(*MemoryStore)
+	store.now = func() time.Time { return now }
+	store.lastSweep = now
+
+	tokenID := uuid.New()
+	issuedAt := now.Add(-time.Minute)
+
+	err := store.RevokeToken(ctx, tokenID, now.Add(time.Minute))
+	require.NoError(t, err)
+	err = store.RevokeUserTokens(ctx, 1, now, time.Hour)
+	require.NoError(t, err)
+
+	// already expired tokens aren't stored at all
+	err = store.RevokeToken(ctx, uuid.New(), now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.Len(t, store.tokens, 1)
+
+	now = now.Add(2 * time.Hour)
+
+	revoked, err := store.IsRevoked(ctx, tokenID, 1, issuedAt)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// the next write sweeps both entries
+	err = store.RevokeToken(ctx, uuid.New(), now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, store.tokens, 1)
+	require.Empty(t, store.watermarks)
+}
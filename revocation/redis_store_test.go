@@ -0,0 +1,60 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStore(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+
+	now := time.Now()
+	store := NewRedisStore(client).(*RedisStore)
+	store.now = func() time.Time { return now }
+
+	testStore(t, store, &now)
+
+	// entries expire with the tokens they cover
+	require.Greater(t, redisServer.TTL(store.userKey(7)), 59*time.Minute)
+	redisServer.FastForward(2 * time.Hour)
+	require.Empty(t, redisServer.Keys())
+}
+
+func TestRedisStoreSameMicrosecond(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	store := NewRedisStore(client)
+
+	ctx := context.Background()
+	issuedBefore := time.Date(2025, 5, 1, 20, 0, 0, 1500, time.UTC)
+
+	err := store.RevokeUserTokens(ctx, 1, issuedBefore, time.Hour)
+	require.NoError(t, err)
+
+	revoked, err := store.IsRevoked(ctx, uuid.New(), 1,
+		issuedBefore.Add(-time.Nanosecond))
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = store.IsRevoked(ctx, uuid.New(), 1,
+		issuedBefore.Add(time.Microsecond))
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestRedisStoreUnavailable(t *testing.T) {
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	redisServer.Close()
+
+	store := NewRedisStore(client)
+	_, err := store.IsRevoked(context.Background(), uuid.New(), 1, time.Now())
+	require.Error(t, err)
+}
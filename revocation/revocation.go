@@ -0,0 +1,23 @@
+package revocation
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store is an interface for revoking access tokens before they expire
+type Store interface {
+	// revokes a single token until expiresAt, when it would be
+	// rejected anyway
+	RevokeToken(ctx context.Context, tokenID uuid.UUID,
+		expiresAt time.Time) error
+	// revokes every token of the user issued before issuedBefore, the
+	// watermark is kept for ttl, the lifetime of the longest token
+	RevokeUserTokens(ctx context.Context, userID int64,
+		issuedBefore time.Time, ttl time.Duration) error
+	// reports whether the token was revoked on its own or by a watermark
+	IsRevoked(ctx context.Context, tokenID uuid.UUID, userID int64,
+		issuedAt time.Time) (bool, error)
+}
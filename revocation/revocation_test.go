@@ -0,0 +1,60 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// runs the behaviour every store shares, now moves the store's clock
+func testStore(t *testing.T, store Store, now *time.Time) {
+	ctx := context.Background()
+	userID := int64(7)
+
+	tokenID := uuid.New()
+	otherID := uuid.New()
+	issuedAt := now.Add(-time.Minute)
+
+	revoked, err := store.IsRevoked(ctx, tokenID, userID, issuedAt)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// single token
+	err = store.RevokeToken(ctx, tokenID, now.Add(time.Minute))
+	require.NoError(t, err)
+
+	revoked, err = store.IsRevoked(ctx, tokenID, userID, issuedAt)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = store.IsRevoked(ctx, otherID, userID, issuedAt)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// watermark covers older tokens only
+	err = store.RevokeUserTokens(ctx, userID, *now, time.Hour)
+	require.NoError(t, err)
+
+	revoked, err = store.IsRevoked(ctx, otherID, userID, issuedAt)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = store.IsRevoked(ctx, otherID, userID, now.Add(time.Second))
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	revoked, err = store.IsRevoked(ctx, otherID, userID+1, issuedAt)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// an older watermark doesn't undo a newer one
+	err = store.RevokeUserTokens(ctx, userID, now.Add(-time.Hour), time.Hour)
+	require.NoError(t, err)
+
+	revoked, err = store.IsRevoked(ctx, otherID, userID, issuedAt)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
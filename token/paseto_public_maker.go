@@ -0,0 +1,235 @@
+package token
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const v4PublicHeader = "v4.public."
+
+var errNoSigningKey = errors.New("token maker has no signing key")
+
+// PublicKey is a key that verifies tokens, identified by its key ID
+type PublicKey struct {
+	KeyID string
+	Key   ed25519.PublicKey
+}
+
+// PublicKeyProvider is implemented by makers whose tokens anyone holding
+// the public keys can verify
+type PublicKeyProvider interface {
+	PublicKeys() []PublicKey
+}
+
+type publicFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// is a paseto v4.public token maker, tokens are signed with an Ed25519
+// key and carry its key ID in the footer.
+//
+// To rotate keys, move the public half of the current signing key into
+// the verification keys, switch to a new signing key and drop the old
+// public key once every token it signed has expired.
+type PublicPasetoMaker struct {
+	signingKeyID string
+	signingKey   ed25519.PrivateKey
+	verifyKeys   map[string]ed25519.PublicKey
+}
+
+// signingKey is "kid:seed" and every verifyKey is "kid:public key", both
+// base64url encoded; signingKey may be empty for verify only services
+func NewPublicPasetoMaker(signingKey string,
+	verifyKeys []string) (Maker, error) {
+	maker := &PublicPasetoMaker{
+		verifyKeys: make(map[string]ed25519.PublicKey),
+	}
+
+	if signingKey != "" {
+		kid, seed, err := parseKey(signingKey, ed25519.SeedSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signing key: %w", err)
+		}
+
+		maker.signingKeyID = kid
+		maker.signingKey = ed25519.NewKeyFromSeed(seed)
+		maker.verifyKeys[kid] = maker.signingKey.Public().(ed25519.PublicKey)
+	}
+
+	for _, verifyKey := range verifyKeys {
+		kid, key, err := parseKey(verifyKey, ed25519.PublicKeySize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid verification key: %w", err)
+		}
+
+		if _, exists := maker.verifyKeys[kid]; exists {
+			return nil, fmt.Errorf("duplicate key id %s", kid)
+		}
+		maker.verifyKeys[kid] = ed25519.PublicKey(key)
+	}
+
+	if len(maker.verifyKeys) == 0 {
+		return nil, errors.New("at least one signing or verification key is required")
+	}
+
+	return maker, nil
+}
+
+// creates a token for specific username and valid duration
+func (maker *PublicPasetoMaker) CreateToken(username string, userID int64,
+	role string, duration time.Duration) (string, *Payload, error) {
+	if maker.signingKey == nil {
+		return "", nil, errNoSigningKey
+	}
+
+	payload, err := NewPayload(username, userID, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", payload, err
+	}
+
+	footer, err := json.Marshal(publicFooter{KeyID: maker.signingKeyID})
+	if err != nil {
+		return "", payload, err
+	}
+
+	signature := ed25519.Sign(maker.signingKey,
+		pae([]byte(v4PublicHeader), message, footer, nil))
+
+	token := v4PublicHeader +
+		base64.RawURLEncoding.EncodeToString(append(message, signature...)) +
+		"." + base64.RawURLEncoding.EncodeToString(footer)
+
+	return token, payload, nil
+}
+
+// check if input token is valid or not
+func (maker *PublicPasetoMaker) VerifyToken(token string) (*Payload, error) {
+	if !strings.HasPrefix(token, v4PublicHeader) {
+		return nil, ErrInvalidToken
+	}
+
+	parts := strings.Split(strings.TrimPrefix(token, v4PublicHeader), ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidToken
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(body) < ed25519.SignatureSize {
+		return nil, ErrInvalidToken
+	}
+
+	footer, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var f publicFooter
+	if err := json.Unmarshal(footer, &f); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	key, ok := maker.verifyKeys[f.KeyID]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	message := body[:len(body)-ed25519.SignatureSize]
+	signature := body[len(body)-ed25519.SignatureSize:]
+	if !ed25519.Verify(key,
+		pae([]byte(v4PublicHeader), message, footer, nil), signature) {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+	if err := json.Unmarshal(message, payload); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// returns every key tokens are verified with, sorted by key ID
+func (maker *PublicPasetoMaker) PublicKeys() []PublicKey {
+	keys := make([]PublicKey, 0, len(maker.verifyKeys))
+	for kid, key := range maker.verifyKeys {
+		keys = append(keys, PublicKey{KeyID: kid, Key: key})
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].KeyID < keys[j].KeyID
+	})
+	return keys
+}
+
+// generates a new key pair in the format NewPublicPasetoMaker reads
+func GenerateKeyPair(kid string) (signingKey string, verifyKey string,
+	err error) {
+	if kid == "" || strings.Contains(kid, ":") {
+		return "", "", fmt.Errorf("invalid key id %q", kid)
+	}
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	signingKey = kid + ":" + base64.RawURLEncoding.EncodeToString(private.Seed())
+	verifyKey = kid + ":" + base64.RawURLEncoding.EncodeToString(public)
+	return signingKey, verifyKey, nil
+}
+
+// splits "kid:base64url" and checks the decoded key size
+func parseKey(value string, size int) (string, []byte, error) {
+	kid, encoded, found := strings.Cut(strings.TrimSpace(value), ":")
+	if !found || kid == "" {
+		return "", nil, errors.New("key must be formatted as kid:base64url")
+	}
+
+	key, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("key %s is not base64url: %w", kid, err)
+	}
+	if len(key) != size {
+		return "", nil, fmt.Errorf("key %s must be %d bytes", kid, size)
+	}
+
+	return kid, key, nil
+}
+
+// pre-authentication encoding from the paseto spec
+func pae(pieces ...[]byte) []byte {
+	var buf bytes.Buffer
+
+	le64 := func(n int) {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(n)&^(1<<63))
+		buf.Write(b[:])
+	}
+
+	le64(len(pieces))
+	for _, piece := range pieces {
+		le64(len(piece))
+		buf.Write(piece)
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,183 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kratos69/movie-app/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPAE(t *testing.T) {
+	// test vector 4-S-1 from the paseto spec
+	secretKey, err := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307" +
+		"fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f098" +
+		"7e16b28b757225c11f00415d0e20b1a2")
+	require.NoError(t, err)
+
+	message := []byte(`{"data":"this is a signed message",` +
+		`"exp":"2022-01-01T00:00:00+00:00"}`)
+	signature := ed25519.Sign(ed25519.PrivateKey(secretKey),
+		pae([]byte(v4PublicHeader), message, nil, nil))
+
+	token := v4PublicHeader +
+		base64.RawURLEncoding.EncodeToString(append(message, signature...))
+	require.Equal(t, "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdl"+
+		"IiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwK"+
+		"SgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA",
+		token)
+}
+
+func TestPublicPasetoMaker(t *testing.T) {
+	signingKey, _, err := GenerateKeyPair("2025-05")
+	require.NoError(t, err)
+
+	maker, err := NewPublicPasetoMaker(signingKey, nil)
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	userID := util.RandomInt(100, 1)
+	role := util.CustomerRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, userID, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+	require.True(t, strings.HasPrefix(token, v4PublicHeader))
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, userID, payload.UserID)
+	require.Equal(t, role, payload.Role)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+}
+
+func TestExpiredPublicPasetoToken(t *testing.T) {
+	signingKey, _, err := GenerateKeyPair("2025-05")
+	require.NoError(t, err)
+
+	maker, err := NewPublicPasetoMaker(signingKey, nil)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(),
+		util.RandomInt(100, 1), util.CustomerRole, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestPublicPasetoKeyRotation(t *testing.T) {
+	oldSigningKey, oldVerifyKey, err := GenerateKeyPair("old")
+	require.NoError(t, err)
+	newSigningKey, _, err := GenerateKeyPair("new")
+	require.NoError(t, err)
+
+	oldMaker, err := NewPublicPasetoMaker(oldSigningKey, nil)
+	require.NoError(t, err)
+
+	oldToken, _, err := oldMaker.CreateToken(util.RandomOwner(),
+		util.RandomInt(100, 1), util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	// after rotating, tokens of the old key stay valid
+	rotatedMaker, err := NewPublicPasetoMaker(newSigningKey,
+		[]string{oldVerifyKey})
+	require.NoError(t, err)
+
+	_, err = rotatedMaker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	keys := rotatedMaker.(PublicKeyProvider).PublicKeys()
+	require.Len(t, keys, 2)
+	require.Equal(t, "new", keys[0].KeyID)
+	require.Equal(t, "old", keys[1].KeyID)
+
+	// and are rejected once the old key is dropped
+	newMaker, err := NewPublicPasetoMaker(newSigningKey, nil)
+	require.NoError(t, err)
+
+	_, err = newMaker.VerifyToken(oldToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestVerifyOnlyPublicPasetoMaker(t *testing.T) {
+	signingKey, verifyKey, err := GenerateKeyPair("2025-05")
+	require.NoError(t, err)
+
+	signer, err := NewPublicPasetoMaker(signingKey, nil)
+	require.NoError(t, err)
+
+	verifier, err := NewPublicPasetoMaker("", []string{verifyKey})
+	require.NoError(t, err)
+
+	token, _, err := signer.CreateToken(util.RandomOwner(),
+		util.RandomInt(100, 1), util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	_, err = verifier.VerifyToken(token)
+	require.NoError(t, err)
+
+	_, _, err = verifier.CreateToken(util.RandomOwner(),
+		util.RandomInt(100, 1), util.CustomerRole, time.Minute)
+	require.Error(t, err)
+}
+
+func TestTamperedPublicPasetoToken(t *testing.T) {
+	signingKey, _, err := GenerateKeyPair("2025-05")
+	require.NoError(t, err)
+
+	maker, err := NewPublicPasetoMaker(signingKey, nil)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(),
+		util.RandomInt(100, 1), util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	// swap the signed footer for one naming an unknown key
+	body := strings.Split(token, ".")[2]
+	footer := base64.RawURLEncoding.EncodeToString([]byte(`{"kid":"other"}`))
+	_, err = maker.VerifyToken(v4PublicHeader + body + "." + footer)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	// symmetric tokens aren't accepted either
+	localMaker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	localToken, _, err := localMaker.CreateToken(util.RandomOwner(),
+		util.RandomInt(100, 1), util.CustomerRole, time.Minute)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(localToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestNewPublicPasetoMakerInvalidKeys(t *testing.T) {
+	_, err := NewPublicPasetoMaker("", nil)
+	require.Error(t, err)
+
+	_, err = NewPublicPasetoMaker("missing-kid", nil)
+	require.Error(t, err)
+
+	_, err = NewPublicPasetoMaker("kid:dG9vLXNob3J0", nil)
+	require.Error(t, err)
+
+	signingKey, verifyKey, err := GenerateKeyPair("same")
+	require.NoError(t, err)
+	_, err = NewPublicPasetoMaker(signingKey, []string{verifyKey})
+	require.Error(t, err)
+}
@@ -16,6 +16,21 @@ type Config struct {
 	GRPCServerAddress    string        `mapstructure:"GRPC_SERVER_ADDRESS"`
 	RedisAddress         string        `mapstructure:"REDIS_ADDRESS"`
 	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenMaker           string        `mapstructure:"TOKEN_MAKER"`
+	TokenSigningKey      string        `mapstructure:"TOKEN_SIGNING_KEY"`
+	TokenVerifyKeys      []string      `mapstructure:"TOKEN_VERIFY_KEYS"`
+	CookieSessions       bool          `mapstructure:"COOKIE_SESSIONS"`
+	CookieDomain         string        `mapstructure:"COOKIE_DOMAIN"`
+	CookieSameSite       string        `mapstructure:"COOKIE_SAME_SITE"`
+	CORSAllowedOrigins   []string      `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	CORSAllowedMethods   []string      `mapstructure:"CORS_ALLOWED_METHODS"`
+	CORSAllowedHeaders   []string      `mapstructure:"CORS_ALLOWED_HEADERS"`
+	CORSAllowCredentials bool          `mapstructure:"CORS_ALLOW_CREDENTIALS"`
+	CORSMaxAge           time.Duration `mapstructure:"CORS_MAX_AGE"`
+	HSTSMaxAge           time.Duration `mapstructure:"HSTS_MAX_AGE"`
+	CSP                  string        `mapstructure:"CONTENT_SECURITY_POLICY"`
+	FrameOptions         string        `mapstructure:"FRAME_OPTIONS"`
+	TrustedProxies       []string      `mapstructure:"TRUSTED_PROXIES"`
 	CloudName            string        `mapstructure:"CLOUD_NAME"`
 	CloudApiKey          string        `mapstructure:"CLOUD_API_KEY"`
 	CloudApiSecret       string        `mapstructure:"CLOUD_API_SECRET"`
@@ -24,6 +39,28 @@ type Config struct {
 	EmailSenderName      string        `mapstructure:"EMAIL_SENDER_NAME"`
 	EmailSenderAddress   string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
 	EmailSenderPassword  string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
+	AppBaseURL           string        `mapstructure:"APP_BASE_URL"`
+	LoginMaxAttempts     int           `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LoginMaxIPAttempts   int           `mapstructure:"LOGIN_MAX_IP_ATTEMPTS"`
+	LoginLockoutDuration time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	RateLimitBackend     string        `mapstructure:"RATE_LIMIT_BACKEND"`
+	RateLimitPublic      string        `mapstructure:"RATE_LIMIT_PUBLIC"`
+	RateLimitAuth        string        `mapstructure:"RATE_LIMIT_AUTH"`
+	RateLimitBooking     string        `mapstructure:"RATE_LIMIT_BOOKING"`
+	RevocationBackend    string        `mapstructure:"REVOCATION_BACKEND"`
+	DeletionGracePeriod  time.Duration `mapstructure:"ACCOUNT_DELETION_GRACE_PERIOD"`
+	PasswordHashAlgo     string        `mapstructure:"PASSWORD_HASH_ALGORITHM"`
+	Argon2Memory         uint32        `mapstructure:"ARGON2_MEMORY"`
+	Argon2Iterations     uint32        `mapstructure:"ARGON2_ITERATIONS"`
+	Argon2Threads        uint8         `mapstructure:"ARGON2_THREADS"`
+	BcryptCost           int           `mapstructure:"BCRYPT_COST"`
+	PasswordMinLength    int           `mapstructure:"PASSWORD_MIN_LENGTH"`
+	BreachedPasswords    string        `mapstructure:"BREACHED_PASSWORDS_FILE"`
+	TwoFactorRoles       []string      `mapstructure:"TWO_FACTOR_ROLES"`
+	OIDCIssuerURL        string        `mapstructure:"OIDC_ISSUER_URL"`
+	OIDCClientID         string        `mapstructure:"OIDC_CLIENT_ID"`
+	OIDCClientSecret     string        `mapstructure:"OIDC_CLIENT_SECRET"`
+	OIDCRedirectURL      string        `mapstructure:"OIDC_REDIRECT_URL"`
 }
 
 // loads configuration from file or environment variables
@@ -0,0 +1,8 @@
+package util
+
+// what a person did on a movie
+const (
+	CreditRoleActor    = "actor"
+	CreditRoleDirector = "director"
+	CreditRoleWriter   = "writer"
+)
@@ -1,12 +1,150 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// returns bcrypt hash of the passsword
+// algorithms a PasswordHasher can hash new passwords with
+const (
+	PasswordAlgorithmArgon2id = "argon2id"
+	PasswordAlgorithmBcrypt   = "bcrypt"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+var (
+	// same error whichever algorithm produced the hash
+	ErrMismatchedPassword = bcrypt.ErrMismatchedHashAndPassword
+
+	ErrUnknownPasswordHash = errors.New("unknown password hash format")
+)
+
+// Argon2Params tunes argon2id, memory is in KiB
+type Argon2Params struct {
+	Memory     uint32
+	Iterations uint32
+	Threads    uint8
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// the second recommended option of RFC 9106
+var DefaultArgon2Params = Argon2Params{
+	Memory:     64 * 1024,
+	Iterations: 3,
+	Threads:    4,
+	SaltLength: 16,
+	KeyLength:  32,
+}
+
+// PasswordHasher hashes passwords with one configured algorithm, the
+// encoded hashes record the algorithm and its parameters so older
+// hashes keep verifying after the configuration changes
+type PasswordHasher struct {
+	algorithm  string
+	argon2     Argon2Params
+	bcryptCost int
+}
+
+// zero values in params and bcryptCost fall back to the defaults
+func NewPasswordHasher(algorithm string, params Argon2Params,
+	bcryptCost int) (*PasswordHasher, error) {
+	if algorithm == "" {
+		algorithm = PasswordAlgorithmArgon2id
+	}
+	if algorithm != PasswordAlgorithmArgon2id &&
+		algorithm != PasswordAlgorithmBcrypt {
+		return nil, fmt.Errorf("unknown password hash algorithm %q",
+			algorithm)
+	}
+
+	if params.Memory == 0 {
+		params.Memory = DefaultArgon2Params.Memory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = DefaultArgon2Params.Iterations
+	}
+	if params.Threads == 0 {
+		params.Threads = DefaultArgon2Params.Threads
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultArgon2Params.SaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultArgon2Params.KeyLength
+	}
+
+	if bcryptCost == 0 {
+		bcryptCost = bcrypt.DefaultCost
+	}
+	if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d",
+			bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return &PasswordHasher{
+		algorithm:  algorithm,
+		argon2:     params,
+		bcryptCost: bcryptCost,
+	}, nil
+}
+
+// hashes the password with the configured algorithm
+func (hasher *PasswordHasher) Hash(password string) (string, error) {
+	if hasher.algorithm == PasswordAlgorithmBcrypt {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password),
+			hasher.bcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash password: %w", err)
+		}
+		return string(hashedPassword), nil
+	}
+
+	salt := make([]byte, hasher.argon2.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return encodeArgon2id(hasher.argon2, salt,
+		deriveArgon2id(password, salt, hasher.argon2)), nil
+}
+
+// reports whether the hash was made with another algorithm or other
+// parameters than the hasher uses now
+func (hasher *PasswordHasher) NeedsRehash(hashedPassword string) bool {
+	if strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		if hasher.algorithm != PasswordAlgorithmArgon2id {
+			return true
+		}
+
+		params, salt, key, err := decodeArgon2id(hashedPassword)
+		if err != nil {
+			return true
+		}
+
+		params.SaltLength = uint32(len(salt))
+		params.KeyLength = uint32(len(key))
+		return params != hasher.argon2
+	}
+
+	if hasher.algorithm != PasswordAlgorithmBcrypt {
+		return true
+	}
+
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost != hasher.bcryptCost
+}
+
+// returns bcrypt hash of the passsword, for callers without a configured
+// PasswordHasher
 func HashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -16,7 +154,71 @@ func HashPassword(password string) (string, error) {
 	return string(hashedPassword), nil
 }
 
-// checks if provided password is correct or not
+// checks if provided password is correct or not, whichever algorithm
+// the hash was made with
 func CheckPassword(password, hashPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashPassword), []byte(password))
-}
\ No newline at end of file
+	if !strings.HasPrefix(hashPassword, argon2idPrefix) {
+		return bcrypt.CompareHashAndPassword([]byte(hashPassword), []byte(password))
+	}
+
+	params, salt, key, err := decodeArgon2id(hashPassword)
+	if err != nil {
+		return err
+	}
+
+	params.KeyLength = uint32(len(key))
+	if subtle.ConstantTimeCompare(key,
+		deriveArgon2id(password, salt, params)) != 1 {
+		return ErrMismatchedPassword
+	}
+
+	return nil
+}
+
+func deriveArgon2id(password string, salt []byte,
+	params Argon2Params) []byte {
+	return argon2.IDKey([]byte(password), salt, params.Iterations,
+		params.Memory, params.Threads, params.KeyLength)
+}
+
+// encodes in the PHC string format,
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+func encodeArgon2id(params Argon2Params, salt []byte, key []byte) string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix,
+		argon2.Version, params.Memory, params.Iterations, params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d",
+		&params.Memory, &params.Iterations, &params.Threads)
+	if err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,100 @@
+package util
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	DefaultPasswordMinLength = 8
+
+	// bcrypt ignores anything past 72 bytes
+	passwordMaxLength = 72
+)
+
+var (
+	ErrPasswordBreached = errors.New(
+		"password appears in a list of breached passwords, choose another")
+	ErrPasswordPersonal = errors.New(
+		"password must not be your name, username or email")
+)
+
+// PasswordPolicy decides whether a new password is strong enough
+type PasswordPolicy struct {
+	minLength int
+	breached  map[string]struct{}
+}
+
+// breachedFile is an optional list of known breached passwords, one per
+// line, lines starting with # are skipped
+func NewPasswordPolicy(minLength int,
+	breachedFile string) (*PasswordPolicy, error) {
+	if minLength <= 0 {
+		minLength = DefaultPasswordMinLength
+	}
+	if minLength > passwordMaxLength {
+		return nil, fmt.Errorf("password min length can't be above %d",
+			passwordMaxLength)
+	}
+
+	policy := &PasswordPolicy{
+		minLength: minLength,
+		breached:  map[string]struct{}{},
+	}
+
+	if breachedFile == "" {
+		return policy, nil
+	}
+
+	file, err := os.Open(breachedFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open breached passwords: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		policy.breached[strings.ToLower(line)] = struct{}{}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read breached passwords: %w", err)
+	}
+
+	return policy, nil
+}
+
+// checks the password, personal holds values the password must not be,
+// like the user's email or username
+func (policy *PasswordPolicy) Validate(password string,
+	personal ...string) error {
+	length := utf8.RuneCountInString(password)
+	if length < policy.minLength {
+		return fmt.Errorf("password must be at least %d characters",
+			policy.minLength)
+	}
+	if len(password) > passwordMaxLength {
+		return fmt.Errorf("password must be at most %d bytes",
+			passwordMaxLength)
+	}
+
+	lower := strings.ToLower(password)
+	for _, value := range personal {
+		if value != "" && lower == strings.ToLower(value) {
+			return ErrPasswordPersonal
+		}
+	}
+
+	if _, ok := policy.breached[lower]; ok {
+		return ErrPasswordBreached
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	breachedFile := filepath.Join(t.TempDir(), "breached.txt")
+	err := os.WriteFile(breachedFile,
+		[]byte("# top passwords\npassword123\n\nqwertyuiop\n"), 0o600)
+	require.NoError(t, err)
+
+	policy, err := NewPasswordPolicy(10, breachedFile)
+	require.NoError(t, err)
+
+	require.NoError(t, policy.Validate("correct horse battery"))
+	require.ErrorContains(t, policy.Validate("short"), "at least 10")
+	require.ErrorContains(t, policy.Validate(strings.Repeat("a", 73)),
+		"at most 72")
+	require.ErrorIs(t, policy.Validate("Password123"), ErrPasswordBreached)
+	require.ErrorIs(t, policy.Validate("QwertyUiop"), ErrPasswordBreached)
+	require.ErrorIs(t,
+		policy.Validate("alice@email.com", "alice", "alice@email.com"),
+		ErrPasswordPersonal)
+
+	// counts characters, not bytes
+	require.NoError(t, policy.Validate("ééééééééééé"))
+}
+
+func TestPasswordPolicyDefaults(t *testing.T) {
+	policy, err := NewPasswordPolicy(0, "")
+	require.NoError(t, err)
+	require.Equal(t, DefaultPasswordMinLength, policy.minLength)
+	require.NoError(t, policy.Validate("password123"))
+
+	_, err = NewPasswordPolicy(0, filepath.Join(t.TempDir(), "missing.txt"))
+	require.Error(t, err)
+
+	_, err = NewPasswordPolicy(100, "")
+	require.Error(t, err)
+}
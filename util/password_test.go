@@ -1,6 +1,7 @@
 package util
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -26,3 +27,74 @@ func TestPassword(t *testing.T) {
 	require.NotEmpty(t, hashedPassword2)
 	require.NotEqual(t, hashedPassword1, hashedPassword2)
 }
+
+
+// cheap parameters so the tests stay fast
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Threads: 1}
+
+func TestArgon2idPasswordHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher(PasswordAlgorithmArgon2id,
+		testArgon2Params, 0)
+	require.NoError(t, err)
+
+	password := RandomString(12)
+
+	hashedPassword1, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hashedPassword1,
+		"$argon2id$v=19$m=1024,t=1,p=1$"))
+	require.False(t, hasher.NeedsRehash(hashedPassword1))
+
+	require.NoError(t, CheckPassword(password, hashedPassword1))
+	require.ErrorIs(t, CheckPassword(RandomString(12), hashedPassword1),
+		ErrMismatchedPassword)
+
+	hashedPassword2, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.NotEqual(t, hashedPassword1, hashedPassword2)
+
+	// stronger parameters make the old hash due for an upgrade
+	stronger, err := NewPasswordHasher(PasswordAlgorithmArgon2id,
+		Argon2Params{Memory: 2048, Iterations: 1, Threads: 1}, 0)
+	require.NoError(t, err)
+	require.True(t, stronger.NeedsRehash(hashedPassword1))
+	require.NoError(t, CheckPassword(password, hashedPassword1))
+
+	_, err = NewPasswordHasher("md5", testArgon2Params, 0)
+	require.Error(t, err)
+}
+
+func TestBcryptPasswordHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher(PasswordAlgorithmBcrypt,
+		Argon2Params{}, bcrypt.MinCost)
+	require.NoError(t, err)
+
+	password := RandomString(12)
+
+	hashedPassword, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.False(t, hasher.NeedsRehash(hashedPassword))
+	require.NoError(t, CheckPassword(password, hashedPassword))
+
+	// old bcrypt hashes get moved to argon2id
+	argon, err := NewPasswordHasher("", testArgon2Params, 0)
+	require.NoError(t, err)
+	require.True(t, argon.NeedsRehash(hashedPassword))
+	require.True(t, hasher.NeedsRehash("$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$a2V5"))
+
+	_, err = NewPasswordHasher(PasswordAlgorithmBcrypt, Argon2Params{},
+		bcrypt.MaxCost+1)
+	require.Error(t, err)
+}
+
+func TestCheckPasswordMalformedHash(t *testing.T) {
+	for _, hashedPassword := range []string{
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5",
+	} {
+		err := CheckPassword("password", hashedPassword)
+		require.ErrorIs(t, err, ErrUnknownPasswordHash, hashedPassword)
+	}
+}
@@ -0,0 +1,38 @@
+package util
+
+const (
+	MoviesWritePermission         = "movies:write"
+	ShowtimesWritePermission      = "showtimes:write"
+	ReservationsWritePermission   = "reservations:write"
+	ReservationsReadAllPermission = "reservations:read_all"
+	CheckinScanPermission         = "checkin:scan"
+	RolesManagePermission         = "roles:manage"
+	UsersManagePermission         = "users:manage"
+	APIKeysManagePermission       = "api_keys:manage"
+	AuditReadPermission           = "audit:read"
+	ReviewsModeratePermission     = "reviews:moderate"
+)
+
+// every permission a role can be granted
+var Permissions = []string{
+	MoviesWritePermission,
+	ShowtimesWritePermission,
+	ReservationsWritePermission,
+	ReservationsReadAllPermission,
+	CheckinScanPermission,
+	RolesManagePermission,
+	UsersManagePermission,
+	APIKeysManagePermission,
+	AuditReadPermission,
+	ReviewsModeratePermission,
+}
+
+// returns true if permission is known to the application
+func IsSupportedPermission(permission string) bool {
+	for _, p := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
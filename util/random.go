@@ -1,7 +1,9 @@
 package util
 
 import (
+	crand "crypto/rand"
 	"fmt"
+	"math/big"
 	"math/rand"
 	"strconv"
 	"strings"
@@ -30,6 +32,23 @@ func RandomString(n int) string {
 	return sb.String()
 }
 
+// RandomSecureString generates a string of n characters from a
+// cryptographically secure source, for secrets sent to users
+func RandomSecureString(n int) (string, error) {
+	var sb strings.Builder
+	k := big.NewInt(int64(len(alphabets)))
+
+	for i := 0; i < n; i++ {
+		idx, err := crand.Int(crand.Reader, k)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random string: %w", err)
+		}
+		sb.WriteByte(alphabets[idx.Int64()])
+	}
+
+	return sb.String(), nil
+}
+
 // Generates a random owner name
 func RandomOwner() string {
 	return RandomString(6)
@@ -0,0 +1,107 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpDigits     = 6
+	totpPeriod     = 30 * time.Second
+	totpSecretSize = 20
+	// codes from one period before or after are accepted too,
+	// to make up for clock drift on the user's device
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generates a random base32 encoded TOTP secret
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// returns the RFC 6238 code of secret for the period containing t
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret: %w", err)
+	}
+
+	counter := uint64(t.Unix() / int64(totpPeriod.Seconds()))
+	return hotpCode(key, counter), nil
+}
+
+// checks code against secret at time t
+func ValidateTOTP(code string, secret string, t time.Time) bool {
+	_, ok := TOTPStep(code, secret, t)
+	return ok
+}
+
+// returns the time step code was generated for, ok is false when it
+// doesn't match any of the periods accepted at time t
+func TOTPStep(code string, secret string, t time.Time) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	for i := -totpSkew; i <= totpSkew; i++ {
+		at := t.Add(time.Duration(i) * totpPeriod)
+		expected, err := TOTPCode(secret, at)
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(code), []byte(expected)) == 1 {
+			return at.Unix() / int64(totpPeriod.Seconds()), true
+		}
+	}
+	return 0, false
+}
+
+// returns the otpauth:// URI authenticator apps read from a QR code
+func TOTPProvisioningURI(issuer string, account string, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+// RFC 4226 HOTP with dynamic truncation
+func hotpCode(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
@@ -0,0 +1,80 @@
+package util
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// test vectors from RFC 6238, truncated to 6 digits
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).
+		EncodeToString([]byte("12345678901234567890"))
+
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tc := range testCases {
+		code, err := TOTPCode(secret, time.Unix(tc.unix, 0))
+		require.NoError(t, err)
+		require.Equal(t, tc.code, code)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, 32)
+
+	now := time.Now()
+	code, err := TOTPCode(secret, now)
+	require.NoError(t, err)
+
+	require.True(t, ValidateTOTP(code, secret, now))
+	require.True(t, ValidateTOTP(code, secret, now.Add(30*time.Second)))
+	require.False(t, ValidateTOTP(code, secret, now.Add(2*time.Minute)))
+	require.False(t, ValidateTOTP("12345", secret, now))
+
+	otherSecret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	require.False(t, ValidateTOTP(code, otherSecret, now))
+}
+
+func TestTOTPStep(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	code, err := TOTPCode(secret, now)
+	require.NoError(t, err)
+
+	step, ok := TOTPStep(code, secret, now)
+	require.True(t, ok)
+	require.Equal(t, now.Unix()/30, step)
+
+	// still the step the code was made for when checked a period later
+	step, ok = TOTPStep(code, secret, now.Add(30*time.Second))
+	require.True(t, ok)
+	require.Equal(t, now.Unix()/30, step)
+
+	_, ok = TOTPStep(code, secret, now.Add(2*time.Minute))
+	require.False(t, ok)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("Movie App", "user@email.com", "ABCDEF")
+	require.True(t, strings.HasPrefix(uri,
+		"otpauth://totp/Movie%20App:user@email.com?"))
+	require.Contains(t, uri, "secret=ABCDEF")
+	require.Contains(t, uri, "issuer=Movie+App")
+}
@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	db "github.com/kratos69/movie-app/db/sqlc"
+)
+
+const (
+	// how long a deleted account can still be restored by an admin
+	DefaultDeletionGracePeriod = 30 * 24 * time.Hour
+
+	anonymizeBatchSize = 100
+)
+
+// anonymizes accounts whose deletion grace period has run out
+type AccountAnonymizer struct {
+	store       db.Store
+	gracePeriod time.Duration
+}
+
+func NewAccountAnonymizer(store db.Store,
+	gracePeriod time.Duration) *AccountAnonymizer {
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultDeletionGracePeriod
+	}
+
+	return &AccountAnonymizer{
+		store:       store,
+		gracePeriod: gracePeriod,
+	}
+}
+
+// anonymizes every account that is due and returns how many it handled,
+// one failing account doesn't stop the rest
+func (anonymizer *AccountAnonymizer) RunOnce(ctx context.Context) (int, error) {
+	requestedBefore := time.Now().Add(-anonymizer.gracePeriod)
+	count := 0
+
+	for {
+		userIDs, err := anonymizer.store.ListUsersDueForAnonymization(ctx,
+			db.ListUsersDueForAnonymizationParams{
+				RequestedBefore: requestedBefore,
+				RowLimit:        anonymizeBatchSize,
+			})
+		if err != nil {
+			return count, err
+		}
+
+		var errs []error
+		for _, userID := range userIDs {
+			_, err = anonymizer.store.AnonymizeUserTx(ctx, userID)
+			if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+				errs = append(errs, err)
+				continue
+			}
+			count++
+		}
+
+		// a batch where something failed would come back the same way,
+		// so leave it for the next run instead of looping on it
+		if len(errs) > 0 || len(userIDs) < anonymizeBatchSize {
+			return count, errors.Join(errs...)
+		}
+	}
+}
+
+// runs the anonymizer every interval until ctx is cancelled
+func (anonymizer *AccountAnonymizer) Start(ctx context.Context,
+	interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := anonymizer.RunOnce(ctx)
+		if err != nil {
+			log.Printf("cannot anonymize accounts: %v\n", err)
+		}
+		if count > 0 {
+			log.Printf("anonymized %d deleted accounts\n", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
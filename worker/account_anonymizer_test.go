@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+type anonymizerStore struct {
+	db.Store
+	due        []int64
+	failing    map[int64]bool
+	anonymized []int64
+	cutoff     time.Time
+}
+
+func (store *anonymizerStore) ListUsersDueForAnonymization(
+	_ context.Context,
+	arg db.ListUsersDueForAnonymizationParams) ([]int64, error) {
+	store.cutoff = arg.RequestedBefore
+
+	var due []int64
+	for _, userID := range store.due {
+		if store.failing[userID] || !slices.Contains(store.anonymized, userID) {
+			due = append(due, userID)
+		}
+		if len(due) == int(arg.RowLimit) {
+			break
+		}
+	}
+	return due, nil
+}
+
+func (store *anonymizerStore) AnonymizeUserTx(_ context.Context,
+	userID int64) (db.User, error) {
+	if store.failing[userID] {
+		return db.User{}, errors.New("boom")
+	}
+	store.anonymized = append(store.anonymized, userID)
+	return db.User{UserID: userID}, nil
+}
+
+func TestAccountAnonymizerRunOnce(t *testing.T) {
+	store := &anonymizerStore{}
+	for i := int64(1); i <= anonymizeBatchSize+5; i++ {
+		store.due = append(store.due, i)
+	}
+
+	anonymizer := NewAccountAnonymizer(store, time.Hour)
+
+	count, err := anonymizer.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, anonymizeBatchSize+5, count)
+	require.Len(t, store.anonymized, anonymizeBatchSize+5)
+	require.WithinDuration(t, time.Now().Add(-time.Hour), store.cutoff,
+		time.Second)
+}
+
+func TestAccountAnonymizerKeepsGoingOnFailure(t *testing.T) {
+	store := &anonymizerStore{
+		due:     []int64{1, 2, 3},
+		failing: map[int64]bool{2: true},
+	}
+
+	anonymizer := NewAccountAnonymizer(store, 0)
+	require.Equal(t, DefaultDeletionGracePeriod, anonymizer.gracePeriod)
+
+	count, err := anonymizer.RunOnce(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, []int64{1, 3}, store.anonymized)
+}
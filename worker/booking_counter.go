@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/kratos69/movie-app/db/sqlc"
+)
+
+// recounts the bookings of every movie for the popularity sort, so
+// listing the catalog never has to count reservations
+type BookingCounter struct {
+	store db.Store
+}
+
+func NewBookingCounter(store db.Store) *BookingCounter {
+	return &BookingCounter{store: store}
+}
+
+// recounts the bookings and returns how many movies changed
+func (counter *BookingCounter) RunOnce(ctx context.Context) (int64, error) {
+	return counter.store.RefreshMovieBookingCounts(ctx)
+}
+
+// runs the counter every interval until ctx is cancelled
+func (counter *BookingCounter) Start(ctx context.Context,
+	interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := counter.RunOnce(ctx)
+		if err != nil {
+			log.Printf("cannot count movie bookings: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
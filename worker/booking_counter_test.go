@@ -0,0 +1,31 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+type bookingCountStore struct {
+	db.Store
+	runs int
+}
+
+func (store *bookingCountStore) RefreshMovieBookingCounts(
+	_ context.Context) (int64, error) {
+	store.runs++
+	return 3, nil
+}
+
+func TestBookingCounterRunOnce(t *testing.T) {
+	store := &bookingCountStore{}
+
+	counter := NewBookingCounter(store)
+
+	changed, err := counter.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(3), changed)
+	require.Equal(t, 1, store.runs)
+}
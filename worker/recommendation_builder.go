@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/kratos69/movie-app/db/sqlc"
+)
+
+const (
+	// how many recommendations are kept for each user
+	RecommendationsPerUser = 50
+
+	recommendBatchSize = 100
+)
+
+// scores upcoming movies for every user with booking history, so reading
+// someone's recommendations is a plain lookup
+type RecommendationBuilder struct {
+	store db.Store
+}
+
+func NewRecommendationBuilder(store db.Store) *RecommendationBuilder {
+	return &RecommendationBuilder{store: store}
+}
+
+// refreshes the recommendations of every user with bookings and returns
+// how many users it handled, one failing user doesn't stop the rest
+func (builder *RecommendationBuilder) RunOnce(ctx context.Context) (int, error) {
+	count := 0
+	var errs []error
+	var afterID int64
+
+	for {
+		userIDs, err := builder.store.ListUsersWithBookings(ctx,
+			db.ListUsersWithBookingsParams{
+				AfterID:  afterID,
+				RowLimit: recommendBatchSize,
+			})
+		if err != nil {
+			return count, errors.Join(append(errs, err)...)
+		}
+
+		for _, userID := range userIDs {
+			_, err = builder.store.RefreshUserRecommendationsTx(ctx,
+				db.InsertUserRecommendationsParams{
+					UserID:   userID,
+					RowLimit: RecommendationsPerUser,
+				})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("user %d: %w", userID, err))
+				continue
+			}
+			count++
+		}
+
+		if len(userIDs) < recommendBatchSize {
+			break
+		}
+		afterID = userIDs[len(userIDs)-1]
+	}
+
+	_, err := builder.store.DeleteStaleRecommendations(ctx)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return count, errors.Join(errs...)
+}
+
+// runs the builder every interval until ctx is cancelled
+func (builder *RecommendationBuilder) Start(ctx context.Context,
+	interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := builder.RunOnce(ctx)
+		if err != nil {
+			log.Printf("cannot build recommendations: %v\n", err)
+		}
+		if count > 0 {
+			log.Printf("built recommendations for %d users\n", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+type recommendationStore struct {
+	db.Store
+	users        []int64
+	failing      map[int64]bool
+	refreshed    []int64
+	staleDeleted bool
+}
+
+func (store *recommendationStore) ListUsersWithBookings(_ context.Context,
+	arg db.ListUsersWithBookingsParams) ([]int64, error) {
+	var users []int64
+	for _, userID := range store.users {
+		if userID > arg.AfterID {
+			users = append(users, userID)
+		}
+		if len(users) == int(arg.RowLimit) {
+			break
+		}
+	}
+	return users, nil
+}
+
+func (store *recommendationStore) RefreshUserRecommendationsTx(
+	_ context.Context, arg db.InsertUserRecommendationsParams) (int64, error) {
+	if store.failing[arg.UserID] {
+		return 0, errors.New("boom")
+	}
+	store.refreshed = append(store.refreshed, arg.UserID)
+	return int64(arg.RowLimit), nil
+}
+
+func (store *recommendationStore) DeleteStaleRecommendations(
+	_ context.Context) (int64, error) {
+	store.staleDeleted = true
+	return 0, nil
+}
+
+func TestRecommendationBuilderRunOnce(t *testing.T) {
+	store := &recommendationStore{}
+	for i := int64(1); i <= recommendBatchSize+5; i++ {
+		store.users = append(store.users, i)
+	}
+
+	builder := NewRecommendationBuilder(store)
+
+	count, err := builder.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, recommendBatchSize+5, count)
+	require.Len(t, store.refreshed, recommendBatchSize+5)
+	require.True(t, store.staleDeleted)
+}
+
+func TestRecommendationBuilderKeepsGoingOnFailure(t *testing.T) {
+	store := &recommendationStore{
+		users:   []int64{1, 2, 3},
+		failing: map[int64]bool{2: true},
+	}
+
+	builder := NewRecommendationBuilder(store)
+
+	count, err := builder.RunOnce(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, []int64{1, 3}, store.refreshed)
+}
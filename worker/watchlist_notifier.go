@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"time"
+
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/kratos69/movie-app/mail"
+)
+
+const notifyBatchSize = 100
+
+// emails watchers the alerts queued when a movie's tickets go on sale
+type WatchlistNotifier struct {
+	store   db.Store
+	mailer  mail.EmailSender
+	baseURL string
+}
+
+func NewWatchlistNotifier(store db.Store, mailer mail.EmailSender,
+	baseURL string) *WatchlistNotifier {
+	return &WatchlistNotifier{
+		store:   store,
+		mailer:  mailer,
+		baseURL: baseURL,
+	}
+}
+
+// sends every pending alert and returns how many went out, one failing
+// email doesn't stop the rest
+func (notifier *WatchlistNotifier) RunOnce(ctx context.Context) (int, error) {
+	count := 0
+
+	for {
+		notifications, err := notifier.store.ListPendingWatchlistNotifications(
+			ctx, notifyBatchSize)
+		if err != nil {
+			return count, err
+		}
+
+		var errs []error
+		for _, notification := range notifications {
+			err = notifier.send(notification)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			err = notifier.store.MarkWatchlistNotificationSent(ctx,
+				notification.NotificationID)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			count++
+		}
+
+		// a batch where something failed would come back the same way,
+		// so leave it for the next run instead of looping on it
+		if len(errs) > 0 || len(notifications) < notifyBatchSize {
+			return count, errors.Join(errs...)
+		}
+	}
+}
+
+// runs the notifier every interval until ctx is cancelled
+func (notifier *WatchlistNotifier) Start(ctx context.Context,
+	interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := notifier.RunOnce(ctx)
+		if err != nil {
+			log.Printf("cannot send watchlist alerts: %v\n", err)
+		}
+		if count > 0 {
+			log.Printf("sent %d watchlist alerts\n", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (notifier *WatchlistNotifier) send(
+	notification db.ListPendingWatchlistNotificationsRow) error {
+	movieURL := fmt.Sprintf("%s/movies/%d", notifier.baseURL,
+		notification.MovieID)
+	title := html.EscapeString(notification.Title)
+
+	subject := fmt.Sprintf("Tickets for %s are on sale", notification.Title)
+	content := fmt.Sprintf(`Hello %s,<br/>
+	Tickets for <b>%s</b>, a movie on your watchlist, are now on sale.<br/>
+	<a href="%s">Pick a showtime</a> before the best seats are gone.<br/>
+	`, html.EscapeString(notification.Name), title, movieURL)
+
+	err := notifier.mailer.SendEmail(subject, content,
+		[]string{notification.Email})
+	if err != nil {
+		return fmt.Errorf("notification %d: %w",
+			notification.NotificationID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	db "github.com/kratos69/movie-app/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+type notifierStore struct {
+	db.Store
+	pending []db.ListPendingWatchlistNotificationsRow
+	sent    []int64
+}
+
+func (store *notifierStore) ListPendingWatchlistNotifications(
+	_ context.Context,
+	rowLimit int32) ([]db.ListPendingWatchlistNotificationsRow, error) {
+	var pending []db.ListPendingWatchlistNotificationsRow
+	for _, notification := range store.pending {
+		if !slices.Contains(store.sent, notification.NotificationID) {
+			pending = append(pending, notification)
+		}
+		if len(pending) == int(rowLimit) {
+			break
+		}
+	}
+	return pending, nil
+}
+
+func (store *notifierStore) MarkWatchlistNotificationSent(_ context.Context,
+	notificationID int64) error {
+	store.sent = append(store.sent, notificationID)
+	return nil
+}
+
+type fakeMailer struct {
+	failing map[string]bool
+	to      []string
+}
+
+func (mailer *fakeMailer) SendEmail(subject string, content string,
+	to []string) error {
+	if mailer.failing[to[0]] {
+		return errors.New("boom")
+	}
+	mailer.to = append(mailer.to, to...)
+	return nil
+}
+
+func TestWatchlistNotifierRunOnce(t *testing.T) {
+	store := &notifierStore{}
+	for i := int64(1); i <= notifyBatchSize+5; i++ {
+		store.pending = append(store.pending,
+			db.ListPendingWatchlistNotificationsRow{
+				NotificationID: i,
+				MovieID:        1,
+				Title:          "Dune",
+				Email:          "watcher@email.com",
+			})
+	}
+	mailer := &fakeMailer{}
+
+	notifier := NewWatchlistNotifier(store, mailer, "http://localhost")
+
+	count, err := notifier.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, notifyBatchSize+5, count)
+	require.Len(t, store.sent, notifyBatchSize+5)
+	require.Len(t, mailer.to, notifyBatchSize+5)
+
+	// nothing is sent twice
+	count, err = notifier.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+func TestWatchlistNotifierKeepsGoingOnFailure(t *testing.T) {
+	store := &notifierStore{
+		pending: []db.ListPendingWatchlistNotificationsRow{
+			{NotificationID: 1, Email: "alice@email.com"},
+			{NotificationID: 2, Email: "bob@email.com"},
+			{NotificationID: 3, Email: "carol@email.com"},
+		},
+	}
+	mailer := &fakeMailer{failing: map[string]bool{"bob@email.com": true}}
+
+	notifier := NewWatchlistNotifier(store, mailer, "http://localhost")
+
+	count, err := notifier.RunOnce(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 2, count)
+	// the failed alert stays pending for the next run
+	require.Equal(t, []int64{1, 3}, store.sent)
+}